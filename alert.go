@@ -20,6 +20,8 @@ import (
 	"github.com/influxdata/kapacitor/expvar"
 	"github.com/influxdata/kapacitor/models"
 	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/services/sparkline"
+	"github.com/influxdata/kapacitor/templates"
 	"github.com/influxdata/kapacitor/tick/stateful"
 )
 
@@ -37,8 +39,6 @@ const weightDiff = 1.5
 // Maximum weight applied to newest state change.
 const maxWeight = 1.2
 
-type AlertHandler func(ad *AlertData)
-
 type AlertLevel int
 
 const (
@@ -92,22 +92,30 @@ type AlertData struct {
 	Duration time.Duration   `json:"duration"`
 	Level    AlertLevel      `json:"level"`
 	Data     influxql.Result `json:"data"`
+	// URL of the sparkline graph of the group, if enabled.
+	SparklineURL string `json:"sparkline,omitempty"`
 
 	// Info for custom templates
 	info detailsInfo
+	// Recent values of the sparkline field at the time of the alert.
+	sparkline []float64
 }
 
 type AlertNode struct {
 	node
-	a           *pipeline.AlertNode
-	endpoint    string
-	handlers    []AlertHandler
-	levels      []stateful.Expression
-	scopePools  []stateful.ScopePool
-	states      map[models.GroupID]*alertState
-	idTmpl      *text.Template
-	messageTmpl *text.Template
-	detailsTmpl *html.Template
+	a          *pipeline.AlertNode
+	endpoint   string
+	deliverer  *alertDeliverer
+	levels     []stateful.Expression
+	scopePools []stateful.ScopePool
+	// The level expressions of each group, copied from levels for the first point of the group.
+	levelsByGroup map[models.GroupID][]stateful.Expression
+	states        map[models.GroupID]*alertState
+	idTmpl        *text.Template
+	messageTmpl   *text.Template
+	detailsTmpl   *html.Template
+	// Recent values of the sparkline field per group, nil if not enabled.
+	sparklines *sparklines
 
 	alertsTriggered *expvar.Int
 	oksTriggered    *expvar.Int
@@ -116,6 +124,11 @@ type AlertNode struct {
 	critsTriggered  *expvar.Int
 
 	bufPool sync.Pool
+
+	// Protects states from concurrent access by the alert state API.
+	statesMu sync.RWMutex
+	// Protects levelsByGroup while it is snapshotted.
+	levelsMu sync.Mutex
 }
 
 // Create a new  AlertNode which caches the most recent item and exposes it over the HTTP API.
@@ -126,6 +139,16 @@ func newAlertNode(et *ExecutingTask, n *pipeline.AlertNode, l *log.Logger) (an *
 	}
 	an.node.runF = an.runAlert
 
+	if n.SparklineField != "" {
+		if n.SparklinePoints < 2 {
+			return nil, fmt.Errorf("alert sparklinePoints must be at least 2, got %d", n.SparklinePoints)
+		}
+		if n.SparklinePoints > sparkline.MaxValues {
+			return nil, fmt.Errorf("alert sparklinePoints must be at most %d, got %d", sparkline.MaxValues, n.SparklinePoints)
+		}
+		an.sparklines = newSparklines(n.SparklineField, int(n.SparklinePoints))
+	}
+
 	// Create buffer pool for the templates
 	an.bufPool = sync.Pool{
 		New: func() interface{} {
@@ -134,40 +157,35 @@ func newAlertNode(et *ExecutingTask, n *pipeline.AlertNode, l *log.Logger) (an *
 	}
 
 	// Parse templates
-	an.idTmpl, err = text.New("id").Parse(n.Id)
+	an.idTmpl, err = templates.ParseText("id", n.Id)
 	if err != nil {
 		return nil, err
 	}
 
-	an.messageTmpl, err = text.New("message").Parse(n.Message)
+	an.messageTmpl, err = templates.ParseText("message", n.Message)
 	if err != nil {
 		return nil, err
 	}
 
-	an.detailsTmpl, err = html.New("details").Funcs(html.FuncMap{
-		"json": func(v interface{}) html.JS {
-			a, _ := json.Marshal(v)
-			return html.JS(a)
-		},
-	}).Parse(n.Details)
+	an.detailsTmpl, err = templates.ParseHTML("details", n.Details)
 	if err != nil {
 		return nil, err
 	}
 
 	// Construct alert handlers
-	an.handlers = make([]AlertHandler, 0)
+	an.deliverer = newAlertDeliverer(et, n.Name(), l)
 
 	for _, post := range n.PostHandlers {
 		post := post
-		an.handlers = append(an.handlers, func(ad *AlertData) { an.handlePost(post, ad) })
+		an.deliverer.addHandler("post", func(ad *AlertData) error { return an.handlePost(post, ad) })
 	}
 
 	for _, email := range n.EmailHandlers {
 		email := email
-		an.handlers = append(an.handlers, func(ad *AlertData) { an.handleEmail(email, ad) })
+		an.deliverer.addHandler("email", func(ad *AlertData) error { return an.handleEmail(email, ad) })
 	}
 	if len(n.EmailHandlers) == 0 && (et.tm.SMTPService != nil && et.tm.SMTPService.Global()) {
-		an.handlers = append(an.handlers, func(ad *AlertData) { an.handleEmail(&pipeline.EmailHandler{}, ad) })
+		an.deliverer.addHandler("email", func(ad *AlertData) error { return an.handleEmail(&pipeline.EmailHandler{}, ad) })
 	}
 	// If email has been configured with state changes only set it.
 	if et.tm.SMTPService != nil &&
@@ -178,7 +196,7 @@ func newAlertNode(et *ExecutingTask, n *pipeline.AlertNode, l *log.Logger) (an *
 
 	for _, exec := range n.ExecHandlers {
 		exec := exec
-		an.handlers = append(an.handlers, func(ad *AlertData) { an.handleExec(exec, ad) })
+		an.deliverer.addHandler("exec", func(ad *AlertData) error { return an.handleExec(exec, ad) })
 	}
 
 	for _, log := range n.LogHandlers {
@@ -186,36 +204,84 @@ func newAlertNode(et *ExecutingTask, n *pipeline.AlertNode, l *log.Logger) (an *
 		if !filepath.IsAbs(log.FilePath) {
 			return nil, fmt.Errorf("alert log path must be absolute: %s is not absolute", log.FilePath)
 		}
-		an.handlers = append(an.handlers, func(ad *AlertData) { an.handleLog(log, ad) })
+		an.deliverer.addHandler("log", func(ad *AlertData) error { return an.handleLog(log, ad) })
 	}
 
 	for _, vo := range n.VictorOpsHandlers {
 		vo := vo
-		an.handlers = append(an.handlers, func(ad *AlertData) { an.handleVictorOps(vo, ad) })
+		an.deliverer.addHandler("victorops", func(ad *AlertData) error { return an.handleVictorOps(vo, ad) })
 	}
 	if len(n.VictorOpsHandlers) == 0 && (et.tm.VictorOpsService != nil && et.tm.VictorOpsService.Global()) {
-		an.handlers = append(an.handlers, func(ad *AlertData) { an.handleVictorOps(&pipeline.VictorOpsHandler{}, ad) })
+		an.deliverer.addHandler("victorops", func(ad *AlertData) error { return an.handleVictorOps(&pipeline.VictorOpsHandler{}, ad) })
 	}
 
 	for _, pd := range n.PagerDutyHandlers {
 		pd := pd
-		an.handlers = append(an.handlers, func(ad *AlertData) { an.handlePagerDuty(pd, ad) })
+		an.deliverer.addHandler("pagerduty", func(ad *AlertData) error { return an.handlePagerDuty(pd, ad) })
 	}
 	if len(n.PagerDutyHandlers) == 0 && (et.tm.PagerDutyService != nil && et.tm.PagerDutyService.Global()) {
-		an.handlers = append(an.handlers, func(ad *AlertData) { an.handlePagerDuty(&pipeline.PagerDutyHandler{}, ad) })
+		an.deliverer.addHandler("pagerduty", func(ad *AlertData) error { return an.handlePagerDuty(&pipeline.PagerDutyHandler{}, ad) })
+	}
+
+	for _, pd := range n.PagerDuty2Handlers {
+		pd := pd
+		an.deliverer.addHandler("pagerduty2", func(ad *AlertData) error { return an.handlePagerDuty2(pd, ad) })
+	}
+	if len(n.PagerDuty2Handlers) == 0 && (et.tm.PagerDuty2Service != nil && et.tm.PagerDuty2Service.Global()) {
+		an.deliverer.addHandler("pagerduty2", func(ad *AlertData) error { return an.handlePagerDuty2(&pipeline.PagerDuty2Handler{}, ad) })
 	}
 
 	for _, sensu := range n.SensuHandlers {
 		sensu := sensu
-		an.handlers = append(an.handlers, func(ad *AlertData) { an.handleSensu(sensu, ad) })
+		an.deliverer.addHandler("sensu", func(ad *AlertData) error { return an.handleSensu(sensu, ad) })
+	}
+
+	for _, am := range n.AlertmanagerHandlers {
+		am := am
+		an.deliverer.addHandler("alertmanager", func(ad *AlertData) error { return an.handleAlertmanager(am, ad) })
+	}
+	if len(n.AlertmanagerHandlers) == 0 && (et.tm.AlertmanagerService != nil && et.tm.AlertmanagerService.Global()) {
+		an.deliverer.addHandler("alertmanager", func(ad *AlertData) error { return an.handleAlertmanager(&pipeline.AlertmanagerHandler{}, ad) })
+	}
+
+	for _, jira := range n.JiraHandlers {
+		// Validate jira templates
+		th, err := newTicketHandler(jira.MinLevel, jira.Summary, jira.Description, jira.FieldsMap)
+		if err != nil {
+			return nil, err
+		}
+		jira := jira
+		an.deliverer.addHandler("jira", func(ad *AlertData) error { return an.handleJira(jira, th, ad) })
+	}
+
+	for _, sn := range n.ServiceNowHandlers {
+		// Validate servicenow templates
+		th, err := newTicketHandler(sn.MinLevel, sn.Summary, sn.Description, sn.FieldsMap)
+		if err != nil {
+			return nil, err
+		}
+		an.deliverer.addHandler("servicenow", func(ad *AlertData) error { return an.handleServiceNow(th, ad) })
+	}
+
+	for _, sl := range n.SyslogHandlers {
+		sl := sl
+		an.deliverer.addHandler("syslog", func(ad *AlertData) error { return an.handleSyslog(sl, ad) })
+	}
+	if len(n.SyslogHandlers) == 0 && (et.tm.SyslogService != nil && et.tm.SyslogService.Global()) {
+		an.deliverer.addHandler("syslog", func(ad *AlertData) error { return an.handleSyslog(&pipeline.SyslogHandler{}, ad) })
 	}
 
 	for _, slack := range n.SlackHandlers {
-		slack := slack
-		an.handlers = append(an.handlers, func(ad *AlertData) { an.handleSlack(slack, ad) })
+		// Validate slack templates
+		sh, err := newSlackHandler(slack)
+		if err != nil {
+			return nil, err
+		}
+		an.deliverer.addHandler("slack", func(ad *AlertData) error { return an.handleSlack(sh, ad) })
 	}
 	if len(n.SlackHandlers) == 0 && (et.tm.SlackService != nil && et.tm.SlackService.Global()) {
-		an.handlers = append(an.handlers, func(ad *AlertData) { an.handleSlack(&pipeline.SlackHandler{}, ad) })
+		sh, _ := newSlackHandler(&pipeline.SlackHandler{})
+		an.deliverer.addHandler("slack", func(ad *AlertData) error { return an.handleSlack(sh, ad) })
 	}
 	// If slack has been configured with state changes only set it.
 	if et.tm.SlackService != nil &&
@@ -226,10 +292,10 @@ func newAlertNode(et *ExecutingTask, n *pipeline.AlertNode, l *log.Logger) (an *
 
 	for _, hipchat := range n.HipChatHandlers {
 		hipchat := hipchat
-		an.handlers = append(an.handlers, func(ad *AlertData) { an.handleHipChat(hipchat, ad) })
+		an.deliverer.addHandler("hipchat", func(ad *AlertData) error { return an.handleHipChat(hipchat, ad) })
 	}
 	if len(n.HipChatHandlers) == 0 && (et.tm.HipChatService != nil && et.tm.HipChatService.Global()) {
-		an.handlers = append(an.handlers, func(ad *AlertData) { an.handleHipChat(&pipeline.HipChatHandler{}, ad) })
+		an.deliverer.addHandler("hipchat", func(ad *AlertData) error { return an.handleHipChat(&pipeline.HipChatHandler{}, ad) })
 	}
 	// If HipChat has been configured with state changes only set it.
 	if et.tm.HipChatService != nil &&
@@ -240,23 +306,23 @@ func newAlertNode(et *ExecutingTask, n *pipeline.AlertNode, l *log.Logger) (an *
 
 	for _, alerta := range n.AlertaHandlers {
 		// Validate alerta templates
-		rtmpl, err := text.New("resource").Parse(alerta.Resource)
+		rtmpl, err := templates.ParseText("resource", alerta.Resource)
 		if err != nil {
 			return nil, err
 		}
-		evtmpl, err := text.New("event").Parse(alerta.Event)
+		evtmpl, err := templates.ParseText("event", alerta.Event)
 		if err != nil {
 			return nil, err
 		}
-		etmpl, err := text.New("environment").Parse(alerta.Environment)
+		etmpl, err := templates.ParseText("environment", alerta.Environment)
 		if err != nil {
 			return nil, err
 		}
-		gtmpl, err := text.New("group").Parse(alerta.Group)
+		gtmpl, err := templates.ParseText("group", alerta.Group)
 		if err != nil {
 			return nil, err
 		}
-		vtmpl, err := text.New("value").Parse(alerta.Value)
+		vtmpl, err := templates.ParseText("value", alerta.Value)
 		if err != nil {
 			return nil, err
 		}
@@ -268,25 +334,26 @@ func newAlertNode(et *ExecutingTask, n *pipeline.AlertNode, l *log.Logger) (an *
 			groupTmpl:       gtmpl,
 			valueTmpl:       vtmpl,
 		}
-		an.handlers = append(an.handlers, func(ad *AlertData) { an.handleAlerta(ai, ad) })
+		an.deliverer.addHandler("alerta", func(ad *AlertData) error { return an.handleAlerta(ai, ad) })
 	}
 
 	for _, og := range n.OpsGenieHandlers {
 		og := og
-		an.handlers = append(an.handlers, func(ad *AlertData) { an.handleOpsGenie(og, ad) })
+		an.deliverer.addHandler("opsgenie", func(ad *AlertData) error { return an.handleOpsGenie(og, ad) })
 	}
 	if len(n.OpsGenieHandlers) == 0 && (et.tm.OpsGenieService != nil && et.tm.OpsGenieService.Global()) {
-		an.handlers = append(an.handlers, func(ad *AlertData) { an.handleOpsGenie(&pipeline.OpsGenieHandler{}, ad) })
+		an.deliverer.addHandler("opsgenie", func(ad *AlertData) error { return an.handleOpsGenie(&pipeline.OpsGenieHandler{}, ad) })
 	}
 
 	for _, talk := range n.TalkHandlers {
 		talk := talk
-		an.handlers = append(an.handlers, func(ad *AlertData) { an.handleTalk(talk, ad) })
+		an.deliverer.addHandler("talk", func(ad *AlertData) error { return an.handleTalk(talk, ad) })
 	}
 
 	// Parse level expressions
 	an.levels = make([]stateful.Expression, CritAlert+1)
 	an.scopePools = make([]stateful.ScopePool, CritAlert+1)
+	an.levelsByGroup = make(map[models.GroupID][]stateful.Expression)
 
 	if n.Info != nil {
 		statefulExpression, expressionCompileError := stateful.NewExpression(n.Info)
@@ -332,7 +399,13 @@ func newAlertNode(et *ExecutingTask, n *pipeline.AlertNode, l *log.Logger) (an *
 	return
 }
 
-func (a *AlertNode) runAlert([]byte) error {
+func (a *AlertNode) runAlert(snapshot []byte) error {
+	if snapshot != nil {
+		if err := a.restore(snapshot); err != nil {
+			a.logger.Println("E! failed to restore snapshot:", err)
+		}
+	}
+
 	a.alertsTriggered = &expvar.Int{}
 	a.statMap.Set(statsAlertsTriggered, a.alertsTriggered)
 
@@ -348,11 +421,23 @@ func (a *AlertNode) runAlert([]byte) error {
 	a.critsTriggered = &expvar.Int{}
 	a.statMap.Set(statsCritsTriggered, a.critsTriggered)
 
+	if a.et.tm.AlertmanagerService != nil {
+		// Stop re-sending active alerts once the node has stopped.
+		defer a.et.tm.AlertmanagerService.Clear(a.alertmanagerSource())
+	}
+
+	// Queued alerts get a last attempt before finishing.
+	a.deliverer.open()
+	defer a.deliverer.close()
+
 	switch a.Wants() {
 	case pipeline.StreamEdge:
 		for p, ok := a.ins[0].NextPoint(); ok; p, ok = a.ins[0].NextPoint() {
 			a.timer.Start()
-			l := a.determineLevel(p.Time, p.Fields, p.Tags)
+			if a.sparklines != nil {
+				a.sparklines.add(p.Group, p.Fields)
+			}
+			l := a.determineLevel(p.Time, p.Group, p.Fields, p.Tags)
 			state := a.updateState(p.Time, l, p.Group)
 			if (a.a.UseFlapping && state.flapping) || (a.a.IsStateChangesOnly && !state.changed && !state.expired) {
 				a.timer.Stop()
@@ -372,6 +457,7 @@ func (a *AlertNode) runAlert([]byte) error {
 				if err != nil {
 					return err
 				}
+				a.recordState(state, ad)
 				a.handleAlert(ad)
 				if a.a.LevelTag != "" || a.a.IdTag != "" {
 					p.Tags = p.Tags.Copy()
@@ -419,7 +505,10 @@ func (a *AlertNode) runAlert([]byte) error {
 			var highestPoint *models.BatchPoint
 
 			for i, p := range b.Points {
-				l := a.determineLevel(p.Time, p.Fields, p.Tags)
+				if a.sparklines != nil {
+					a.sparklines.add(b.Group, p.Fields)
+				}
+				l := a.determineLevel(p.Time, b.Group, p.Fields, p.Tags)
 				if l < lowestLevel {
 					lowestLevel = l
 				}
@@ -457,6 +546,7 @@ func (a *AlertNode) runAlert([]byte) error {
 				if err != nil {
 					return err
 				}
+				a.recordState(state, ad)
 				a.handleAlert(ad)
 				// Update tags or fields for Level property
 				if a.a.LevelTag != "" ||
@@ -524,17 +614,17 @@ func (a *AlertNode) handleAlert(ad *AlertData) {
 		a.critsTriggered.Add(1)
 	}
 	a.logger.Printf("D! %v alert triggered id:%s msg:%s data:%v", ad.Level, ad.ID, ad.Message, ad.Data.Series[0])
-	for _, h := range a.handlers {
-		h(ad)
-	}
+	a.deliverer.deliver(ad)
 }
 
-func (a *AlertNode) determineLevel(now time.Time, fields models.Fields, tags map[string]string) (level AlertLevel) {
-	for l, se := range a.levels {
+func (a *AlertNode) determineLevel(now time.Time, group models.GroupID, fields models.Fields, tags map[string]string) (level AlertLevel) {
+	a.levelsMu.Lock()
+	defer a.levelsMu.Unlock()
+	for l, se := range a.groupLevels(group) {
 		if se == nil {
 			continue
 		}
-		if pass, err := EvalPredicate(se, a.scopePools[l], now, fields, tags); pass {
+		if pass, err := EvalPredicate(se, a.scopePools[l], now, a.Location(), fields, tags); pass {
 			level = AlertLevel(l)
 		} else if err != nil {
 			a.logger.Println("E! error evaluating expression:", err)
@@ -546,6 +636,36 @@ func (a *AlertNode) determineLevel(now time.Time, fields models.Fields, tags map
 	return
 }
 
+// The level expressions of the group, copied for the first point of the group.
+// The caller must hold levelsMu.
+func (a *AlertNode) groupLevels(group models.GroupID) []stateful.Expression {
+	levels, ok := a.levelsByGroup[group]
+	if !ok {
+		levels = make([]stateful.Expression, len(a.levels))
+		for l, se := range a.levels {
+			if se != nil {
+				levels[l] = se.CopyReset()
+			}
+		}
+		a.levelsByGroup[group] = levels
+	}
+	return levels
+}
+
+func (a *AlertNode) snapshot() ([]byte, error) {
+	a.levelsMu.Lock()
+	defer a.levelsMu.Unlock()
+	return snapshotExpressions(a.levelsByGroup)
+}
+
+func (a *AlertNode) restore(snapshot []byte) error {
+	a.levelsMu.Lock()
+	defer a.levelsMu.Unlock()
+	return restoreExpressions(snapshot, func(group models.GroupID) ([]stateful.Expression, error) {
+		return a.groupLevels(group), nil
+	})
+}
+
 func (a *AlertNode) batchToResult(b models.Batch) influxql.Result {
 	row := models.BatchToRow(b)
 	r := influxql.Result{
@@ -582,6 +702,12 @@ func (a *AlertNode) alertData(
 		Data:     a.batchToResult(b),
 		info:     info,
 	}
+	if a.sparklines != nil {
+		ad.sparkline = a.sparklines.values(group)
+		if a.et.tm.SparklineService != nil {
+			ad.SparklineURL = a.et.tm.SparklineService.URL(ad.sparkline)
+		}
+	}
 	return ad, nil
 }
 
@@ -596,6 +722,13 @@ type alertState struct {
 	// Note: Alerts are not triggered for every event.
 	lastTriggered time.Time
 	expired       bool
+
+	// The last triggered event, reported by the alert state API.
+	id      string
+	level   AlertLevel
+	since   time.Time
+	message string
+	tags    map[string]string
 }
 
 // Return the duration of the current alert state.
@@ -650,6 +783,8 @@ func (a *alertState) percentChange() float64 {
 }
 
 func (a *AlertNode) updateState(t time.Time, level AlertLevel, group models.GroupID) *alertState {
+	a.statesMu.Lock()
+	defer a.statesMu.Unlock()
 	state, ok := a.states[group]
 	if !ok {
 		state = &alertState{
@@ -671,6 +806,56 @@ func (a *AlertNode) updateState(t time.Time, level AlertLevel, group models.Grou
 	return state
 }
 
+// Record a triggered event as the current state of the alert.
+func (a *AlertNode) recordState(state *alertState, ad *AlertData) {
+	a.statesMu.Lock()
+	defer a.statesMu.Unlock()
+	if state.since.IsZero() || state.level != ad.Level {
+		state.since = ad.Time
+	}
+	state.id = ad.ID
+	state.level = ad.Level
+	state.message = ad.Message
+	state.tags = ad.info.Tags
+}
+
+// The current state of an alert, as reported by the alert state API.
+type AlertState struct {
+	ID   string `json:"id"`
+	Task string `json:"task"`
+	Node string `json:"node"`
+	// The current level of the alert.
+	Level AlertLevel `json:"level"`
+	// The time the alert entered its current level.
+	Since    time.Time         `json:"since"`
+	Message  string            `json:"message"`
+	Tags     map[string]string `json:"tags"`
+	Flapping bool              `json:"flapping"`
+}
+
+// Return the states of all alerts of the node that are not OK.
+func (a *AlertNode) AlertStates() []AlertState {
+	a.statesMu.RLock()
+	defer a.statesMu.RUnlock()
+	states := make([]AlertState, 0, len(a.states))
+	for _, state := range a.states {
+		if state.level == OKAlert {
+			continue
+		}
+		states = append(states, AlertState{
+			ID:       state.id,
+			Task:     a.et.Task.ID,
+			Node:     a.Name(),
+			Level:    state.level,
+			Since:    state.since,
+			Message:  state.message,
+			Tags:     state.tags,
+			Flapping: state.flapping,
+		})
+	}
+	return states
+}
+
 // Type containing information available to ID template.
 type idInfo struct {
 	// Measurement name
@@ -778,39 +963,48 @@ func (a *AlertNode) renderMessageAndDetails(id, name string, t time.Time, group
 //--------------------------------
 // Alert handlers
 
-func (a *AlertNode) handlePost(post *pipeline.PostHandler, ad *AlertData) {
+func (a *AlertNode) handlePost(post *pipeline.PostHandler, ad *AlertData) error {
 	bodyBuffer := a.bufPool.Get().(*bytes.Buffer)
 	defer a.bufPool.Put(bodyBuffer)
 	bodyBuffer.Reset()
 
 	err := json.NewEncoder(bodyBuffer).Encode(ad)
 	if err != nil {
-		a.logger.Println("E! failed to marshal alert data json", err)
-		return
+		return permanentError{fmt.Errorf("failed to marshal alert data json: %s", err)}
 	}
 
-	_, err = http.Post(post.URL, "application/json", bodyBuffer)
+	resp, err := http.Post(post.URL, "application/json", bodyBuffer)
 	if err != nil {
-		a.logger.Println("E! failed to POST batch", err)
+		return fmt.Errorf("failed to POST alert data: %s", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("failed to POST alert data: unexpected response code %d", resp.StatusCode)
 	}
+	return nil
 }
 
-func (a *AlertNode) handleEmail(email *pipeline.EmailHandler, ad *AlertData) {
-	if a.et.tm.SMTPService != nil {
-		err := a.et.tm.SMTPService.SendMail(email.ToList, ad.Message, ad.Details)
+func (a *AlertNode) handleEmail(email *pipeline.EmailHandler, ad *AlertData) error {
+	if a.et.tm.SMTPService == nil {
+		return permanentError{errors.New("smtp service not enabled, cannot send email")}
+	}
+	body := ad.Details
+	var images map[string][]byte
+	if len(ad.sparkline) > 0 {
+		png, err := sparkline.Render(ad.sparkline)
 		if err != nil {
-			a.logger.Println("E!", err)
+			return permanentError{fmt.Errorf("failed to render sparkline: %s", err)}
 		}
-	} else {
-		a.logger.Println("E! smtp service not enabled, cannot send email.")
+		images = map[string][]byte{sparklineImageName: png}
+		body += fmt.Sprintf(`<br><img src="cid:%s" alt="%s">`, sparklineImageName, a.sparklines.field)
 	}
+	return a.et.tm.SMTPService.SendMail(email.ToList, ad.Message, body, images)
 }
 
-func (a *AlertNode) handleExec(ex *pipeline.ExecHandler, ad *AlertData) {
+func (a *AlertNode) handleExec(ex *pipeline.ExecHandler, ad *AlertData) error {
 	b, err := json.Marshal(ad)
 	if err != nil {
-		a.logger.Println("E! failed to marshal alert data json", err)
-		return
+		return permanentError{fmt.Errorf("failed to marshal alert data json: %s", err)}
 	}
 	cmd := exec.Command(ex.Command[0], ex.Command[1:]...)
 	cmd.Stdin = bytes.NewBuffer(b)
@@ -819,37 +1013,32 @@ func (a *AlertNode) handleExec(ex *pipeline.ExecHandler, ad *AlertData) {
 	cmd.Stderr = &out
 	err = cmd.Run()
 	if err != nil {
-		a.logger.Println("E! error running alert command:", err, out.String())
-		return
+		return fmt.Errorf("error running alert command: %s %s", err, out.String())
 	}
+	return nil
 }
 
-func (a *AlertNode) handleLog(l *pipeline.LogHandler, ad *AlertData) {
+func (a *AlertNode) handleLog(l *pipeline.LogHandler, ad *AlertData) error {
 	b, err := json.Marshal(ad)
 	if err != nil {
-		a.logger.Println("E! failed to marshal alert data json", err)
-		return
+		return permanentError{fmt.Errorf("failed to marshal alert data json: %s", err)}
 	}
 	f, err := os.OpenFile(l.FilePath, os.O_WRONLY|os.O_APPEND|os.O_CREATE, os.FileMode(l.Mode))
 	if err != nil {
-		a.logger.Println("E! failed to open file for alert logging", err)
-		return
+		return fmt.Errorf("failed to open file for alert logging: %s", err)
 	}
 	defer f.Close()
-	n, err := f.Write(b)
-	if n != len(b) || err != nil {
-		a.logger.Println("E! failed to write to file", err)
-	}
-	n, err = f.Write([]byte("\n"))
-	if n != 1 || err != nil {
-		a.logger.Println("E! failed to write to file", err)
+	// Write the event and newline in one call so a retry never leaves a partial line.
+	_, err = f.Write(append(b, '\n'))
+	if err != nil {
+		return fmt.Errorf("failed to write to file: %s", err)
 	}
+	return nil
 }
 
-func (a *AlertNode) handleVictorOps(vo *pipeline.VictorOpsHandler, ad *AlertData) {
+func (a *AlertNode) handleVictorOps(vo *pipeline.VictorOpsHandler, ad *AlertData) error {
 	if a.et.tm.VictorOpsService == nil {
-		a.logger.Println("E! failed to send VictorOps alert. VictorOps is not enabled")
-		return
+		return permanentError{errors.New("VictorOps is not enabled")}
 	}
 	var messageType string
 	switch ad.Level {
@@ -858,7 +1047,7 @@ func (a *AlertNode) handleVictorOps(vo *pipeline.VictorOpsHandler, ad *AlertData
 	default:
 		messageType = ad.Level.String()
 	}
-	err := a.et.tm.VictorOpsService.Alert(
+	return a.et.tm.VictorOpsService.Alert(
 		vo.RoutingKey,
 		messageType,
 		ad.Message,
@@ -866,78 +1055,287 @@ func (a *AlertNode) handleVictorOps(vo *pipeline.VictorOpsHandler, ad *AlertData
 		ad.Time,
 		ad.Data,
 	)
-	if err != nil {
-		a.logger.Println("E! failed to send alert data to VictorOps:", err)
-		return
-	}
 }
 
-func (a *AlertNode) handlePagerDuty(pd *pipeline.PagerDutyHandler, ad *AlertData) {
+func (a *AlertNode) handlePagerDuty(pd *pipeline.PagerDutyHandler, ad *AlertData) error {
 	if a.et.tm.PagerDutyService == nil {
-		a.logger.Println("E! failed to send PagerDuty alert. PagerDuty is not enabled")
-		return
+		return permanentError{errors.New("PagerDuty is not enabled")}
 	}
-	err := a.et.tm.PagerDutyService.Alert(
+	return a.et.tm.PagerDutyService.Alert(
 		pd.ServiceKey,
 		ad.ID,
 		ad.Message,
 		ad.Level,
 		ad.Data,
 	)
-	if err != nil {
-		a.logger.Println("E! failed to send alert data to PagerDuty:", err)
-		return
+}
+
+func (a *AlertNode) handlePagerDuty2(pd *pipeline.PagerDuty2Handler, ad *AlertData) error {
+	if a.et.tm.PagerDuty2Service == nil {
+		return permanentError{errors.New("PagerDuty2 is not enabled")}
 	}
+	return a.et.tm.PagerDuty2Service.Alert(
+		pd.RoutingKey,
+		ad.ID,
+		ad.Message,
+		ad.info.Tags["host"],
+		ad.Level,
+		ad.Time,
+		ad.info.Fields,
+	)
 }
 
-func (a *AlertNode) handleSensu(sensu *pipeline.SensuHandler, ad *AlertData) {
+func (a *AlertNode) handleSensu(sensu *pipeline.SensuHandler, ad *AlertData) error {
 	if a.et.tm.SensuService == nil {
-		a.logger.Println("E! failed to send Sensu message. Sensu is not enabled")
-		return
+		return permanentError{errors.New("Sensu is not enabled")}
+	}
+
+	return a.et.tm.SensuService.Alert(
+		ad.ID,
+		ad.Message,
+		ad.Level,
+	)
+}
+
+// Identify the active Alertmanager alerts sent by the node.
+func (a *AlertNode) alertmanagerSource() string {
+	return a.et.Task.ID + "/" + a.Name()
+}
+
+func (a *AlertNode) handleAlertmanager(am *pipeline.AlertmanagerHandler, ad *AlertData) error {
+	if a.et.tm.AlertmanagerService == nil {
+		return permanentError{errors.New("Alertmanager is not enabled")}
+	}
+	if _, ok := a.et.tm.AlertmanagerService.Endpoint(am.Endpoint); !ok {
+		// Retrying cannot succeed until the endpoint is configured and Kapacitor restarted.
+		return permanentError{fmt.Errorf("unknown Alertmanager endpoint %q", am.Endpoint)}
 	}
+	alertName := am.AlertName
+	if alertName == "" {
+		alertName = ad.info.Name
+	}
+	return a.et.tm.AlertmanagerService.Alert(
+		am.Endpoint,
+		a.alertmanagerSource(),
+		ad.ID,
+		alertName,
+		ad.Message,
+		ad.Details,
+		ad.Level,
+		ad.Time,
+		ad.info.Tags,
+	)
+}
+
+// Templates and level of a handler keeping tickets in sync with the alert.
+type ticketHandler struct {
+	minLevel        AlertLevel
+	summaryTmpl     *text.Template
+	descriptionTmpl *text.Template
+	fieldTmpls      map[string]*text.Template
+}
 
-	err := a.et.tm.SensuService.Alert(
+func newTicketHandler(minLevel, summary, description string, fields map[string]string) (ticketHandler, error) {
+	th := ticketHandler{
+		fieldTmpls: make(map[string]*text.Template, len(fields)),
+	}
+	if err := th.minLevel.UnmarshalText([]byte(minLevel)); err != nil {
+		return th, err
+	}
+	var err error
+	th.summaryTmpl, err = templates.ParseText("summary", summary)
+	if err != nil {
+		return th, err
+	}
+	if description != "" {
+		th.descriptionTmpl, err = templates.ParseText("description", description)
+		if err != nil {
+			return th, err
+		}
+	}
+	for name, f := range fields {
+		th.fieldTmpls[name], err = templates.ParseText(name, f)
+		if err != nil {
+			return th, err
+		}
+	}
+	return th, nil
+}
+
+// Render the summary, description and fields of the ticket.
+// Without a description template the details of the alert are used.
+func (th ticketHandler) render(ad *AlertData) (summary, description string, fields map[string]string, err error) {
+	var buf bytes.Buffer
+	if err = th.summaryTmpl.Execute(&buf, ad.info); err != nil {
+		return "", "", nil, fmt.Errorf("failed to evaluate summary template: %s", err)
+	}
+	summary = buf.String()
+	description = ad.Details
+	if th.descriptionTmpl != nil {
+		buf.Reset()
+		if err = th.descriptionTmpl.Execute(&buf, ad.info); err != nil {
+			return "", "", nil, fmt.Errorf("failed to evaluate description template: %s", err)
+		}
+		description = buf.String()
+	}
+	fields = make(map[string]string, len(th.fieldTmpls))
+	for name, tmpl := range th.fieldTmpls {
+		buf.Reset()
+		if err = tmpl.Execute(&buf, ad.info); err != nil {
+			return "", "", nil, fmt.Errorf("failed to evaluate field %q template: %s", name, err)
+		}
+		fields[name] = buf.String()
+	}
+	return summary, description, fields, nil
+}
+
+func (a *AlertNode) handleJira(jira *pipeline.JiraHandler, th ticketHandler, ad *AlertData) error {
+	if a.et.tm.JiraService == nil {
+		return permanentError{errors.New("Jira is not enabled")}
+	}
+	summary, description, fields, err := th.render(ad)
+	if err != nil {
+		return permanentError{err}
+	}
+	return a.et.tm.JiraService.Alert(
+		jira.Project,
+		jira.IssueType,
 		ad.ID,
 		ad.Message,
+		summary,
+		description,
+		fields,
 		ad.Level,
+		th.minLevel,
 	)
+}
+
+func (a *AlertNode) handleServiceNow(th ticketHandler, ad *AlertData) error {
+	if a.et.tm.ServiceNowService == nil {
+		return permanentError{errors.New("ServiceNow is not enabled")}
+	}
+	summary, description, fields, err := th.render(ad)
 	if err != nil {
-		a.logger.Println("E! failed to send alert data to Sensu:", err)
-		return
+		return permanentError{err}
 	}
+	return a.et.tm.ServiceNowService.Alert(
+		ad.ID,
+		ad.Message,
+		summary,
+		description,
+		fields,
+		ad.Level,
+		th.minLevel,
+	)
 }
 
-func (a *AlertNode) handleSlack(slack *pipeline.SlackHandler, ad *AlertData) {
-	if a.et.tm.SlackService == nil {
-		a.logger.Println("E! failed to send Slack message. Slack is not enabled")
-		return
+func (a *AlertNode) handleSyslog(sl *pipeline.SyslogHandler, ad *AlertData) error {
+	if a.et.tm.SyslogService == nil {
+		return permanentError{errors.New("Syslog is not enabled")}
 	}
-	err := a.et.tm.SlackService.Alert(
-		slack.Channel,
+	return a.et.tm.SyslogService.Alert(
+		sl.Facility,
+		ad.ID,
+		a.et.Task.ID,
 		ad.Message,
 		ad.Level,
+		ad.Time,
+		ad.info.Tags,
 	)
+}
+
+// A field of a Slack message attachment.
+type SlackField struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+	Short bool   `json:"short"`
+}
+
+type slackHandler struct {
+	*pipeline.SlackHandler
+
+	titleTmpl  *text.Template
+	fields     []SlackField
+	fieldTmpls []*text.Template
+}
+
+func newSlackHandler(slack *pipeline.SlackHandler) (slackHandler, error) {
+	sh := slackHandler{SlackHandler: slack}
+	var err error
+	sh.titleTmpl, err = templates.ParseText("title", slack.Title)
+	if err != nil {
+		return sh, err
+	}
+	add := func(f pipeline.SlackField, short bool) error {
+		tmpl, err := templates.ParseText("field", f.Value)
+		if err != nil {
+			return err
+		}
+		sh.fields = append(sh.fields, SlackField{Title: f.Title, Short: short})
+		sh.fieldTmpls = append(sh.fieldTmpls, tmpl)
+		return nil
+	}
+	for _, f := range slack.ShortFieldsList {
+		if err := add(f, true); err != nil {
+			return sh, err
+		}
+	}
+	for _, f := range slack.FieldsList {
+		if err := add(f, false); err != nil {
+			return sh, err
+		}
+	}
+	return sh, nil
+}
+
+func (a *AlertNode) handleSlack(slack slackHandler, ad *AlertData) error {
+	if a.et.tm.SlackService == nil {
+		return permanentError{errors.New("Slack is not enabled")}
+	}
+	if err := a.et.tm.SlackService.ValidateWorkspace(slack.Workspace, slack.IsThreaded); err != nil {
+		return permanentError{err}
+	}
+	var buf bytes.Buffer
+	err := slack.titleTmpl.Execute(&buf, ad.info)
 	if err != nil {
-		a.logger.Println("E! failed to send alert data to Slack:", err)
-		return
+		return permanentError{fmt.Errorf("failed to evaluate Slack Title template %s: %s", slack.Title, err)}
 	}
+	title := buf.String()
+
+	fields := make([]SlackField, len(slack.fields))
+	for i, f := range slack.fields {
+		buf.Reset()
+		err := slack.fieldTmpls[i].Execute(&buf, ad.info)
+		if err != nil {
+			return permanentError{fmt.Errorf("failed to evaluate Slack field %q template: %s", f.Title, err)}
+		}
+		f.Value = buf.String()
+		fields[i] = f
+	}
+
+	return a.et.tm.SlackService.Alert(
+		slack.Workspace,
+		slack.Channel,
+		ad.ID,
+		ad.Message,
+		title,
+		fields,
+		ad.SparklineURL,
+		ad.Level,
+		slack.IsThreaded,
+	)
 }
 
-func (a *AlertNode) handleHipChat(hipchat *pipeline.HipChatHandler, ad *AlertData) {
+func (a *AlertNode) handleHipChat(hipchat *pipeline.HipChatHandler, ad *AlertData) error {
 	if a.et.tm.HipChatService == nil {
-		a.logger.Println("E! failed to send HipChat message. HipChat is not enabled")
-		return
+		return permanentError{errors.New("HipChat is not enabled")}
 	}
-	err := a.et.tm.HipChatService.Alert(
+	return a.et.tm.HipChatService.Alert(
 		hipchat.Room,
 		hipchat.Token,
 		ad.Message,
 		ad.Level,
 	)
-	if err != nil {
-		a.logger.Println("E! failed to send alert data to HipChat:", err)
-		return
-	}
 }
 
 type alertaHandler struct {
@@ -950,10 +1348,9 @@ type alertaHandler struct {
 	groupTmpl       *text.Template
 }
 
-func (a *AlertNode) handleAlerta(alerta alertaHandler, ad *AlertData) {
+func (a *AlertNode) handleAlerta(alerta alertaHandler, ad *AlertData) error {
 	if a.et.tm.AlertaService == nil {
-		a.logger.Println("E! failed to send Alerta message. Alerta is not enabled")
-		return
+		return permanentError{errors.New("Alerta is not enabled")}
 	}
 
 	var severity string
@@ -973,8 +1370,7 @@ func (a *AlertNode) handleAlerta(alerta alertaHandler, ad *AlertData) {
 	var buf bytes.Buffer
 	err := alerta.resourceTmpl.Execute(&buf, ad.info)
 	if err != nil {
-		a.logger.Printf("E! failed to evaluate Alerta Resource template %s", alerta.Resource)
-		return
+		return permanentError{fmt.Errorf("failed to evaluate Alerta Resource template %s: %s", alerta.Resource, err)}
 	}
 	resource := buf.String()
 	buf.Reset()
@@ -989,32 +1385,28 @@ func (a *AlertNode) handleAlerta(alerta alertaHandler, ad *AlertData) {
 	}
 	err = alerta.eventTmpl.Execute(&buf, data)
 	if err != nil {
-		a.logger.Printf("E! failed to evaluate Alerta Event template %s", alerta.Event)
-		return
+		return permanentError{fmt.Errorf("failed to evaluate Alerta Event template %s: %s", alerta.Event, err)}
 	}
 	event := buf.String()
 	buf.Reset()
 
 	err = alerta.environmentTmpl.Execute(&buf, ad.info)
 	if err != nil {
-		a.logger.Printf("E! failed to evaluate Alerta Environment template %s", alerta.Environment)
-		return
+		return permanentError{fmt.Errorf("failed to evaluate Alerta Environment template %s: %s", alerta.Environment, err)}
 	}
 	environment := buf.String()
 	buf.Reset()
 
 	err = alerta.groupTmpl.Execute(&buf, ad.info)
 	if err != nil {
-		a.logger.Printf("E! failed to evaluate Alerta Group template %s", alerta.Group)
-		return
+		return permanentError{fmt.Errorf("failed to evaluate Alerta Group template %s: %s", alerta.Group, err)}
 	}
 	group := buf.String()
 	buf.Reset()
 
 	err = alerta.valueTmpl.Execute(&buf, ad.info)
 	if err != nil {
-		a.logger.Printf("E! failed to evaluate Alerta Value template %s", alerta.Value)
-		return
+		return permanentError{fmt.Errorf("failed to evaluate Alerta Value template %s: %s", alerta.Value, err)}
 	}
 	value := buf.String()
 
@@ -1023,7 +1415,7 @@ func (a *AlertNode) handleAlerta(alerta alertaHandler, ad *AlertData) {
 		service = []string{ad.info.Name}
 	}
 
-	err = a.et.tm.AlertaService.Alert(
+	return a.et.tm.AlertaService.Alert(
 		alerta.Token,
 		resource,
 		event,
@@ -1036,16 +1428,11 @@ func (a *AlertNode) handleAlerta(alerta alertaHandler, ad *AlertData) {
 		service,
 		ad.Data,
 	)
-	if err != nil {
-		a.logger.Println("E! failed to send alert data to Alerta:", err)
-		return
-	}
 }
 
-func (a *AlertNode) handleOpsGenie(og *pipeline.OpsGenieHandler, ad *AlertData) {
+func (a *AlertNode) handleOpsGenie(og *pipeline.OpsGenieHandler, ad *AlertData) error {
 	if a.et.tm.OpsGenieService == nil {
-		a.logger.Println("E! failed to send OpsGenie alert. OpsGenie is not enabled")
-		return
+		return permanentError{errors.New("OpsGenie is not enabled")}
 	}
 	var messageType string
 	switch ad.Level {
@@ -1055,7 +1442,7 @@ func (a *AlertNode) handleOpsGenie(og *pipeline.OpsGenieHandler, ad *AlertData)
 		messageType = ad.Level.String()
 	}
 
-	err := a.et.tm.OpsGenieService.Alert(
+	return a.et.tm.OpsGenieService.Alert(
 		og.TeamsList,
 		og.RecipientsList,
 		messageType,
@@ -1064,24 +1451,15 @@ func (a *AlertNode) handleOpsGenie(og *pipeline.OpsGenieHandler, ad *AlertData)
 		ad.Time,
 		ad.Data,
 	)
-	if err != nil {
-		a.logger.Println("E! failed to send alert data to OpsGenie:", err)
-		return
-	}
 }
 
-func (a *AlertNode) handleTalk(talk *pipeline.TalkHandler, ad *AlertData) {
+func (a *AlertNode) handleTalk(talk *pipeline.TalkHandler, ad *AlertData) error {
 	if a.et.tm.TalkService == nil {
-		a.logger.Println("E! failed to send Talk message. Talk is not enabled")
-		return
+		return permanentError{errors.New("Talk is not enabled")}
 	}
 
-	err := a.et.tm.TalkService.Alert(
+	return a.et.tm.TalkService.Alert(
 		ad.ID,
 		ad.Message,
 	)
-	if err != nil {
-		a.logger.Println("E! failed to send alert data to Talk:", err)
-		return
-	}
 }
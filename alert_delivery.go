@@ -0,0 +1,387 @@
+package kapacitor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	kexpvar "github.com/influxdata/kapacitor/expvar"
+	"github.com/influxdata/kapacitor/services/storage"
+)
+
+const (
+	statsAlertHandlerSent    = "sent"
+	statsAlertHandlerFailed  = "failed"
+	statsAlertHandlerRetried = "retried"
+)
+
+// Queue size used when no delivery service is configured.
+const defaultDeliveryQueueSize = 1000
+
+// An AlertHandler delivers alert data to a single destination.
+type AlertHandler func(ad *AlertData) error
+
+// permanentError marks a delivery error that retrying cannot fix,
+// i.e. the handler's service is not enabled.
+type permanentError struct {
+	error
+}
+
+func isPermanent(err error) bool {
+	_, ok := err.(permanentError)
+	return ok
+}
+
+// Counters shared by all handlers of the same kind.
+type alertHandlerStats struct {
+	sent    *kexpvar.Int
+	failed  *kexpvar.Int
+	retried *kexpvar.Int
+}
+
+var (
+	alertHandlerStatsMu     sync.Mutex
+	alertHandlerStatsByKind = make(map[string]*alertHandlerStats)
+)
+
+// Return the stats for a kind of handler, creating them if necessary.
+func getAlertHandlerStats(kind string) *alertHandlerStats {
+	alertHandlerStatsMu.Lock()
+	defer alertHandlerStatsMu.Unlock()
+	if s, ok := alertHandlerStatsByKind[kind]; ok {
+		return s
+	}
+	_, statMap := NewStatistics("alert_handlers", map[string]string{"handler": kind})
+	s := &alertHandlerStats{
+		sent:    &kexpvar.Int{},
+		failed:  &kexpvar.Int{},
+		retried: &kexpvar.Int{},
+	}
+	statMap.Set(statsAlertHandlerSent, s.sent)
+	statMap.Set(statsAlertHandlerFailed, s.failed)
+	statMap.Set(statsAlertHandlerRetried, s.retried)
+	alertHandlerStatsByKind[kind] = s
+	return s
+}
+
+var errDeliveryStopped = errors.New("alert delivery stopped")
+
+// Time allowed for queued alerts to be sent once the deliverer is closed,
+// if no delivery timeout is configured.
+const defaultDeliveryShutdownGrace = 10 * time.Second
+
+// An alert waiting to be sent to a handler.
+type queuedAlert struct {
+	key string
+	ad  *AlertData
+}
+
+// The persisted form of a queued alert.
+// The details info and sparkline values are stored explicitly since they are not part of the AlertData JSON.
+type persistedAlert struct {
+	Alert     *AlertData  `json:"alert"`
+	Info      detailsInfo `json:"info"`
+	Sparkline []float64   `json:"sparkline,omitempty"`
+	Handler   int         `json:"handler"`
+	Kind      string      `json:"kind"`
+}
+
+type alertHandler struct {
+	kind  string
+	send  AlertHandler
+	stats *alertHandlerStats
+}
+
+// The queue of alerts waiting to be sent to a single handler.
+type handlerQueue struct {
+	alertHandler
+	index int
+	queue chan queuedAlert
+	// The result of a send that timed out but has not yet returned.
+	// Sends to a handler never overlap, so the next send waits for it.
+	inflight chan error
+}
+
+// alertDeliverer sends alerts to all handlers of an AlertNode.
+// Each handler has its own bounded queue drained by its own goroutine,
+// retrying failed sends with exponential backoff,
+// so that a failing handler does not delay the other handlers.
+type alertDeliverer struct {
+	queues    []*handlerQueue
+	queueSize int
+
+	retries        int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	timeout        time.Duration
+
+	// Optional store for persisting queued alerts.
+	store  storage.Interface
+	prefix string
+	seq    int64
+
+	// Closed to stop retrying and send the remaining queued alerts once.
+	stopping chan struct{}
+	// Closed to abandon the remaining queued alerts.
+	stopped chan struct{}
+
+	logger *log.Logger
+	wg     sync.WaitGroup
+}
+
+func newAlertDeliverer(et *ExecutingTask, nodeName string, l *log.Logger) *alertDeliverer {
+	d := &alertDeliverer{
+		queueSize: defaultDeliveryQueueSize,
+		prefix:    et.Task.ID + "/" + nodeName + "/",
+		seq:       time.Now().UnixNano(),
+		stopping:  make(chan struct{}),
+		stopped:   make(chan struct{}),
+		logger:    l,
+	}
+	if ds := et.tm.DeliveryService; ds != nil {
+		d.queueSize = ds.QueueSize()
+		d.retries = ds.Retries()
+		d.initialBackoff = ds.InitialBackoff()
+		d.maxBackoff = ds.MaxBackoff()
+		d.timeout = ds.Timeout()
+		d.store = ds.Store()
+	}
+	return d
+}
+
+// Add a handler of the given kind.
+// Must be called before open.
+func (d *alertDeliverer) addHandler(kind string, h AlertHandler) {
+	d.queues = append(d.queues, &handlerQueue{
+		alertHandler: alertHandler{
+			kind:  kind,
+			send:  h,
+			stats: getAlertHandlerStats(kind),
+		},
+		index: len(d.queues),
+		queue: make(chan queuedAlert, d.queueSize),
+	})
+}
+
+// Start delivering alerts, first re-queuing any alerts persisted by a previous run.
+func (d *alertDeliverer) open() {
+	d.restore()
+	for _, q := range d.queues {
+		d.wg.Add(1)
+		go d.run(q)
+	}
+}
+
+// Stop delivering alerts.
+// Queued alerts get a single attempt without retries within a grace period,
+// after which the remaining alerts are dropped, or left in the store if persisted.
+// Must not be called concurrently with deliver.
+func (d *alertDeliverer) close() {
+	close(d.stopping)
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+	grace := d.timeout
+	if grace <= 0 {
+		grace = defaultDeliveryShutdownGrace
+	}
+	timer := time.NewTimer(grace)
+	defer timer.Stop()
+	select {
+	case <-done:
+	case <-timer.C:
+		close(d.stopped)
+		<-done
+	}
+}
+
+// Queue an alert for delivery to all handlers.
+func (d *alertDeliverer) deliver(ad *AlertData) {
+	for _, q := range d.queues {
+		qa := queuedAlert{ad: ad}
+		if d.store != nil {
+			d.seq++
+			qa.key = fmt.Sprintf("%s%020d/%d", d.prefix, d.seq, q.index)
+			d.persist(q, qa)
+		}
+		select {
+		case q.queue <- qa:
+		default:
+			q.stats.failed.Add(1)
+			d.logger.Printf("E! alert queue for %s is full, dropping alert %s", q.kind, ad.ID)
+			d.forget(qa.key)
+		}
+	}
+}
+
+func (d *alertDeliverer) run(q *handlerQueue) {
+	defer d.wg.Done()
+	for {
+		select {
+		case qa := <-q.queue:
+			if d.send(q, qa) {
+				d.forget(qa.key)
+			} else {
+				d.abandon(q, qa)
+			}
+		case <-d.stopping:
+			// Drain the queue without retrying.
+			for {
+				select {
+				case qa := <-q.queue:
+					if d.send(q, qa) {
+						d.forget(qa.key)
+					} else {
+						d.abandon(q, qa)
+					}
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// Send the alert to the handler, retrying failed attempts until the deliverer is stopping.
+// Returns false if the alert was neither sent nor given up on because the deliverer stopped.
+func (d *alertDeliverer) send(q *handlerQueue, qa queuedAlert) bool {
+	backoff := d.initialBackoff
+	for attempt := 0; ; attempt++ {
+		err := d.attempt(q, qa.ad)
+		switch {
+		case err == errDeliveryStopped:
+			return false
+		case err == nil:
+			q.stats.sent.Add(1)
+			return true
+		case isPermanent(err) || attempt >= d.retries:
+			q.stats.failed.Add(1)
+			d.logger.Printf("E! failed to send alert %s to %s after %d attempt(s): %v", qa.ad.ID, q.kind, attempt+1, err)
+			return true
+		}
+		select {
+		case <-d.stopping:
+			return false
+		default:
+		}
+		q.stats.retried.Add(1)
+		d.logger.Printf("W! failed to send alert %s to %s, retrying in %v: %v", qa.ad.ID, q.kind, backoff, err)
+		timer := time.NewTimer(backoff)
+		select {
+		case <-timer.C:
+		case <-d.stopping:
+			timer.Stop()
+			return false
+		}
+		backoff *= 2
+		if backoff > d.maxBackoff {
+			backoff = d.maxBackoff
+		}
+	}
+}
+
+// Make a single delivery attempt, bounded by the configured timeout.
+// A send that timed out earlier is waited for first, so that sends never overlap.
+// Returns errDeliveryStopped if the deliverer stopped before the attempt finished.
+func (d *alertDeliverer) attempt(q *handlerQueue, ad *AlertData) error {
+	if q.inflight != nil {
+		select {
+		case <-q.inflight:
+			q.inflight = nil
+		case <-d.stopped:
+			return errDeliveryStopped
+		}
+	}
+	errC := make(chan error, 1)
+	go func() {
+		errC <- q.send(ad)
+	}()
+	var timeout <-chan time.Time
+	if d.timeout > 0 {
+		timer := time.NewTimer(d.timeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case err := <-errC:
+		return err
+	case <-timeout:
+		q.inflight = errC
+		return fmt.Errorf("timed out after %v", d.timeout)
+	case <-d.stopped:
+		q.inflight = errC
+		return errDeliveryStopped
+	}
+}
+
+// Handle an alert that was not sent because the deliverer stopped.
+// Persisted alerts are kept so that they are sent after a restart, others are dropped.
+func (d *alertDeliverer) abandon(q *handlerQueue, qa queuedAlert) {
+	if qa.key != "" {
+		return
+	}
+	q.stats.failed.Add(1)
+	d.logger.Printf("E! stopped before sending alert %s to %s, dropping alert", qa.ad.ID, q.kind)
+}
+
+// Persist the alert for a handler.
+func (d *alertDeliverer) persist(q *handlerQueue, qa queuedAlert) {
+	pa := persistedAlert{
+		Alert:     qa.ad,
+		Info:      qa.ad.info,
+		Sparkline: qa.ad.sparkline,
+		Handler:   q.index,
+		Kind:      q.kind,
+	}
+	data, err := json.Marshal(pa)
+	if err != nil {
+		d.logger.Println("E! failed to marshal queued alert", err)
+		return
+	}
+	if err := d.store.Put(qa.key, data); err != nil {
+		d.logger.Println("E! failed to persist queued alert", err)
+	}
+}
+
+// Remove a persisted alert once it has been handled.
+func (d *alertDeliverer) forget(key string) {
+	if key == "" || d.store == nil {
+		return
+	}
+	if err := d.store.Delete(key); err != nil {
+		d.logger.Println("E! failed to delete queued alert", err)
+	}
+}
+
+// Re-queue alerts persisted by a previous run of the node.
+// Alerts for handlers that no longer exist are removed.
+func (d *alertDeliverer) restore() {
+	if d.store == nil {
+		return
+	}
+	kvs, err := d.store.List(d.prefix)
+	if err != nil {
+		d.logger.Println("E! failed to load queued alerts", err)
+		return
+	}
+	for _, kv := range kvs {
+		pa := persistedAlert{}
+		if err := json.Unmarshal(kv.Value, &pa); err != nil || pa.Alert == nil ||
+			pa.Handler < 0 || pa.Handler >= len(d.queues) || d.queues[pa.Handler].kind != pa.Kind {
+			d.forget(kv.Key)
+			continue
+		}
+		pa.Alert.info = pa.Info
+		pa.Alert.sparkline = pa.Sparkline
+		select {
+		case d.queues[pa.Handler].queue <- queuedAlert{key: kv.Key, ad: pa.Alert}:
+		default:
+			d.forget(kv.Key)
+		}
+	}
+}
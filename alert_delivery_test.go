@@ -0,0 +1,364 @@
+package kapacitor
+
+import (
+	"errors"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/influxdata/kapacitor/services/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+var deliveryLogger = log.New(os.Stderr, "[delivery] ", log.LstdFlags)
+
+func newTestDeliverer(retries int, timeout time.Duration, store storage.Interface) *alertDeliverer {
+	return &alertDeliverer{
+		queueSize:      10,
+		retries:        retries,
+		initialBackoff: time.Millisecond,
+		maxBackoff:     2 * time.Millisecond,
+		timeout:        timeout,
+		store:          store,
+		prefix:         "task/alert1/",
+		stopping:       make(chan struct{}),
+		stopped:        make(chan struct{}),
+		logger:         deliveryLogger,
+	}
+}
+
+// Returns a handler failing the first n attempts.
+func failingHandler(n int, calls *int) AlertHandler {
+	return func(ad *AlertData) error {
+		*calls++
+		if *calls <= n {
+			return errors.New("service unavailable")
+		}
+		return nil
+	}
+}
+
+// Wait until the handlers of the given kind have handled n alerts.
+func waitForHandled(t *testing.T, kind string, n int64) {
+	stats := getAlertHandlerStats(kind)
+	deadline := time.Now().Add(5 * time.Second)
+	for stats.sent.IntValue()+stats.failed.IntValue() < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %d alerts to be handled by %s", n, kind)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestAlertDeliverer_Retry(t *testing.T) {
+	assert := assert.New(t)
+
+	d := newTestDeliverer(3, 0, nil)
+	var okCalls, flakyCalls, deadCalls int
+	d.addHandler("test_retry_ok", failingHandler(0, &okCalls))
+	d.addHandler("test_retry_flaky", failingHandler(2, &flakyCalls))
+	d.addHandler("test_retry_dead", failingHandler(100, &deadCalls))
+
+	d.open()
+	d.deliver(&AlertData{ID: "id"})
+	waitForHandled(t, "test_retry_ok", 1)
+	waitForHandled(t, "test_retry_flaky", 1)
+	waitForHandled(t, "test_retry_dead", 1)
+	d.close()
+
+	assert.Equal(1, okCalls)
+	assert.Equal(3, flakyCalls)
+	assert.Equal(4, deadCalls)
+
+	ok := getAlertHandlerStats("test_retry_ok")
+	assert.Equal(int64(1), ok.sent.IntValue())
+	assert.Equal(int64(0), ok.retried.IntValue())
+
+	flaky := getAlertHandlerStats("test_retry_flaky")
+	assert.Equal(int64(1), flaky.sent.IntValue())
+	assert.Equal(int64(2), flaky.retried.IntValue())
+	assert.Equal(int64(0), flaky.failed.IntValue())
+
+	dead := getAlertHandlerStats("test_retry_dead")
+	assert.Equal(int64(0), dead.sent.IntValue())
+	assert.Equal(int64(3), dead.retried.IntValue())
+	assert.Equal(int64(1), dead.failed.IntValue())
+}
+
+func TestAlertDeliverer_Permanent(t *testing.T) {
+	assert := assert.New(t)
+
+	d := newTestDeliverer(3, 0, nil)
+	calls := 0
+	d.addHandler("test_permanent", func(ad *AlertData) error {
+		calls++
+		return permanentError{errors.New("not enabled")}
+	})
+
+	d.open()
+	d.deliver(&AlertData{ID: "id"})
+	d.close()
+
+	assert.Equal(1, calls)
+	stats := getAlertHandlerStats("test_permanent")
+	assert.Equal(int64(0), stats.retried.IntValue())
+	assert.Equal(int64(1), stats.failed.IntValue())
+}
+
+func TestAlertDeliverer_Timeout(t *testing.T) {
+	assert := assert.New(t)
+
+	d := newTestDeliverer(0, 10*time.Millisecond, nil)
+	block := make(chan struct{})
+	defer close(block)
+	d.addHandler("test_timeout", func(ad *AlertData) error {
+		<-block
+		return nil
+	})
+
+	d.open()
+	d.deliver(&AlertData{ID: "id"})
+	waitForHandled(t, "test_timeout", 1)
+	d.close()
+
+	stats := getAlertHandlerStats("test_timeout")
+	assert.Equal(int64(0), stats.sent.IntValue())
+	assert.Equal(int64(1), stats.failed.IntValue())
+}
+
+func TestAlertDeliverer_TimeoutNoOverlap(t *testing.T) {
+	assert := assert.New(t)
+
+	d := newTestDeliverer(1, 10*time.Millisecond, nil)
+	release := make(chan struct{})
+	var mu sync.Mutex
+	active, maxActive, calls := 0, 0, 0
+	d.addHandler("test_timeout_overlap", func(ad *AlertData) error {
+		mu.Lock()
+		calls++
+		first := calls == 1
+		active++
+		if active > maxActive {
+			maxActive = active
+		}
+		mu.Unlock()
+		if first {
+			<-release
+		}
+		mu.Lock()
+		active--
+		mu.Unlock()
+		return nil
+	})
+
+	d.open()
+	d.deliver(&AlertData{ID: "id"})
+	// Release the first send well after it timed out.
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	waitForHandled(t, "test_timeout_overlap", 1)
+	d.close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(2, calls)
+	assert.Equal(1, maxActive, "the retry must not overlap the timed out send")
+	stats := getAlertHandlerStats("test_timeout_overlap")
+	assert.Equal(int64(1), stats.sent.IntValue())
+	assert.Equal(int64(1), stats.retried.IntValue())
+}
+
+func TestAlertDeliverer_Independent(t *testing.T) {
+	d := newTestDeliverer(100, 0, nil)
+	d.initialBackoff = time.Hour
+	d.maxBackoff = time.Hour
+	d.addHandler("test_independent_failing", func(ad *AlertData) error {
+		return errors.New("service unavailable")
+	})
+	d.addHandler("test_independent_ok", func(ad *AlertData) error { return nil })
+
+	d.open()
+	d.deliver(&AlertData{ID: "first"})
+	d.deliver(&AlertData{ID: "second"})
+	// The working handler is not delayed by the failing handler waiting to retry.
+	waitForHandled(t, "test_independent_ok", 2)
+
+	// Closing does not wait for the backoff.
+	start := time.Now()
+	d.close()
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("close took %v", elapsed)
+	}
+	stats := getAlertHandlerStats("test_independent_failing")
+	if got, exp := stats.failed.IntValue(), int64(2); got != exp {
+		t.Errorf("unexpected dropped alerts: got %d exp %d", got, exp)
+	}
+}
+
+func TestAlertDeliverer_CloseBlockedHandler(t *testing.T) {
+	d := newTestDeliverer(0, 20*time.Millisecond, nil)
+	block := make(chan struct{})
+	defer close(block)
+	d.addHandler("test_close_blocked", func(ad *AlertData) error {
+		<-block
+		return nil
+	})
+
+	d.open()
+	d.deliver(&AlertData{ID: "first"})
+	d.deliver(&AlertData{ID: "second"})
+
+	// The first send times out and the second waits for it,
+	// so closing gives up on the blocked handler after the grace period.
+	done := make(chan struct{})
+	go func() {
+		d.close()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("close did not return")
+	}
+	stats := getAlertHandlerStats("test_close_blocked")
+	if got, exp := stats.failed.IntValue(), int64(2); got != exp {
+		t.Errorf("unexpected dropped alerts: got %d exp %d", got, exp)
+	}
+}
+
+func TestAlertDeliverer_QueueFull(t *testing.T) {
+	assert := assert.New(t)
+
+	d := newTestDeliverer(0, 0, nil)
+	d.queueSize = 1
+	d.addHandler("test_queue_full", func(ad *AlertData) error { return nil })
+
+	// Not yet opened, so the second alert does not fit.
+	d.deliver(&AlertData{ID: "first"})
+	d.deliver(&AlertData{ID: "second"})
+	d.open()
+	d.close()
+
+	stats := getAlertHandlerStats("test_queue_full")
+	assert.Equal(int64(1), stats.sent.IntValue())
+	assert.Equal(int64(1), stats.failed.IntValue())
+}
+
+func TestAlertDeliverer_Persist(t *testing.T) {
+	assert := assert.New(t)
+	store := newMemStore()
+
+	// Queue alerts without delivering them, as if the process stopped.
+	d := newTestDeliverer(0, 0, store)
+	d.addHandler("test_persist_a", func(ad *AlertData) error { return nil })
+	d.addHandler("test_persist_b", func(ad *AlertData) error { return nil })
+	d.deliver(&AlertData{ID: "id", info: detailsInfo{Message: "msg"}})
+	kvs, _ := store.List(d.prefix)
+	assert.Equal(2, len(kvs))
+
+	// A new deliverer with a changed handler list restores the queued alert.
+	var got []string
+	d = newTestDeliverer(0, 0, store)
+	d.addHandler("test_persist_a", func(ad *AlertData) error {
+		got = append(got, "a:"+ad.ID+":"+ad.info.Message)
+		return nil
+	})
+	d.addHandler("test_persist_c", func(ad *AlertData) error {
+		got = append(got, "c")
+		return nil
+	})
+	d.open()
+	d.close()
+
+	assert.Equal([]string{"a:id:msg"}, got)
+	kvs, _ = store.List(d.prefix)
+	assert.Equal(0, len(kvs))
+}
+
+func TestAlertDeliverer_PersistOnClose(t *testing.T) {
+	assert := assert.New(t)
+	store := newMemStore()
+
+	d := newTestDeliverer(100, 0, store)
+	d.initialBackoff = time.Hour
+	d.maxBackoff = time.Hour
+	d.addHandler("test_persist_close", func(ad *AlertData) error {
+		return errors.New("service unavailable")
+	})
+	d.open()
+	d.deliver(&AlertData{ID: "id"})
+	stats := getAlertHandlerStats("test_persist_close")
+	for stats.retried.IntValue() < 1 {
+		time.Sleep(time.Millisecond)
+	}
+	d.close()
+
+	// The alert is kept to be sent after a restart.
+	kvs, _ := store.List(d.prefix)
+	assert.Equal(1, len(kvs))
+	assert.Equal(int64(0), stats.failed.IntValue())
+}
+
+// In-memory implementation of storage.Interface
+type memStore struct {
+	mu   sync.Mutex
+	data map[string][]byte
+}
+
+func newMemStore() *memStore {
+	return &memStore{data: make(map[string][]byte)}
+}
+
+func (s *memStore) Put(key string, value []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data[key] = value
+	return nil
+}
+
+func (s *memStore) Get(key string) (*storage.KeyValue, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, ok := s.data[key]
+	if !ok {
+		return nil, storage.ErrNoKeyExists
+	}
+	return &storage.KeyValue{Key: key, Value: v}, nil
+}
+
+func (s *memStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.data, key)
+	return nil
+}
+
+func (s *memStore) Exists(key string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.data[key]
+	return ok, nil
+}
+
+func (s *memStore) List(prefix string) ([]*storage.KeyValue, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var kvs []*storage.KeyValue
+	for k, v := range s.data {
+		if strings.HasPrefix(k, prefix) {
+			kvs = append(kvs, &storage.KeyValue{Key: k, Value: v})
+		}
+	}
+	sort.Sort(keyValues(kvs))
+	return kvs, nil
+}
+
+type keyValues []*storage.KeyValue
+
+func (kvs keyValues) Len() int           { return len(kvs) }
+func (kvs keyValues) Less(i, j int) bool { return kvs[i].Key < kvs[j].Key }
+func (kvs keyValues) Swap(i, j int)      { kvs[i], kvs[j] = kvs[j], kvs[i] }
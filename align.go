@@ -0,0 +1,64 @@
+package kapacitor
+
+import "time"
+
+const day = 24 * time.Hour
+
+// Truncate t to a multiple of d in the wall clock time of loc, see time.Time.Truncate.
+// If loc is nil t is truncated in absolute time, i.e. in UTC.
+func truncateIn(t time.Time, d time.Duration, loc *time.Location) time.Time {
+	if loc == nil {
+		return t.Truncate(d)
+	}
+	offset := zoneOffset(t, loc)
+	return wallTime(t.Add(offset).Truncate(d), offset, loc)
+}
+
+// Return the first multiple of d after t in the wall clock time of loc.
+// If loc is nil the multiple is in absolute time.
+func nextIn(t time.Time, d time.Duration, loc *time.Location) time.Time {
+	if loc == nil {
+		return t.Add(d).Truncate(d)
+	}
+	start := truncateIn(t, d, loc)
+	offset := zoneOffset(start, loc)
+	// The next multiple is d later in wall clock time,
+	// unless the wall clock is set back in between, i.e. when daylight saving time ends.
+	next := wallTime(start.Add(offset).Add(d), offset, loc)
+	if n := truncateIn(start.Add(d), d, loc); n.After(start) && n.Before(next) {
+		next = n
+	}
+	return next
+}
+
+// Add d to t, whole days are added to the wall clock time of loc
+// so that a day is 23 or 25 hours long across daylight saving time transitions.
+// Other durations and all durations if loc is nil are added in absolute time.
+func addIn(t time.Time, d time.Duration, loc *time.Location) time.Time {
+	if loc == nil || d%day != 0 {
+		return t.Add(d)
+	}
+	offset := zoneOffset(t, loc)
+	return wallTime(t.Add(offset).Add(d), offset, loc)
+}
+
+func zoneOffset(t time.Time, loc *time.Location) time.Duration {
+	_, offset := t.In(loc).Zone()
+	return time.Duration(offset) * time.Second
+}
+
+// Return the time whose wall clock time in loc is the wall clock time of w in UTC.
+// The offset is preferred for wall clock times that occur twice when daylight saving time ends,
+// and the start of daylight saving time is returned for wall clock times that do not occur.
+func wallTime(w time.Time, offset time.Duration, loc *time.Location) time.Time {
+	t := w.Add(-offset)
+	o := zoneOffset(t, loc)
+	if o == offset {
+		return t
+	}
+	t2 := w.Add(-o)
+	if zoneOffset(t2, loc) == o || t2.After(t) {
+		return t2
+	}
+	return t
+}
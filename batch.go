@@ -171,12 +171,13 @@ func newQueryNode(et *ExecutingTask, n *pipeline.QueryNode, l *log.Logger) (*Que
 	if n.Every != 0 && n.Cron != "" {
 		return nil, errors.New("must not set both 'every' and 'cron' properties")
 	}
+	loc := n.Location()
 	switch {
 	case n.Every != 0:
-		bn.ticker = newTimeTicker(n.Every, n.AlignFlag)
+		bn.ticker = newTimeTicker(n.Every, n.AlignFlag, loc)
 	case n.Cron != "":
 		var err error
-		bn.ticker, err = newCronTicker(n.Cron)
+		bn.ticker, err = newCronTicker(n.Cron, loc)
 		if err != nil {
 			return nil, err
 		}
@@ -212,8 +213,12 @@ func (b *QueryNode) Queries(start, stop time.Time) []string {
 		stop = now
 	}
 	// Crons are sensitive to timezones.
-	// Make sure we are using local time.
-	start = start.Local()
+	// Make sure we are using the time zone of the node or local time.
+	if loc := b.Location(); loc != nil {
+		start = start.In(loc)
+	} else {
+		start = start.Local()
+	}
 	queries := make([]string, 0)
 	for {
 		start = b.ticker.Next(start)
@@ -388,7 +393,9 @@ type ticker interface {
 }
 
 type timeTicker struct {
-	every     time.Duration
+	every time.Duration
+	// The time zone the ticks are aligned in, nil to align them in UTC.
+	loc       *time.Location
 	alignChan chan time.Time
 	stopping  chan struct{}
 	ticker    *time.Ticker
@@ -396,11 +403,12 @@ type timeTicker struct {
 	wg        sync.WaitGroup
 }
 
-func newTimeTicker(every time.Duration, align bool) *timeTicker {
+func newTimeTicker(every time.Duration, align bool, loc *time.Location) *timeTicker {
 	t := &timeTicker{
 		every: every,
 	}
 	if align {
+		t.loc = loc
 		t.alignChan = make(chan time.Time)
 		t.stopping = make(chan struct{})
 	}
@@ -414,27 +422,22 @@ func (t *timeTicker) Start() <-chan time.Time {
 		t.wg.Add(1)
 		go func() {
 			defer t.wg.Done()
-			// Sleep until we are roughly aligned
-			now := time.Now()
-			next := now.Truncate(t.every).Add(t.every)
-			after := time.NewTicker(next.Sub(now))
-			select {
-			case <-after.C:
-				after.Stop()
-			case <-t.stopping:
-				after.Stop()
-				return
-			}
-			t.ticker = time.NewTicker(t.every)
-			// Send first event since we waited for it explicitly
-			t.alignChan <- next
 			for {
+				// Sleep until the next aligned time,
+				// the ticks are not evenly spaced in a time zone with daylight saving time.
+				now := time.Now()
+				next := nextIn(now, t.every, t.loc)
+				timer := time.NewTimer(next.Sub(now))
 				select {
+				case <-timer.C:
+				case <-t.stopping:
+					timer.Stop()
+					return
+				}
+				select {
+				case t.alignChan <- next:
 				case <-t.stopping:
 					return
-				case now := <-t.ticker.C:
-					now = now.Truncate(t.every)
-					t.alignChan <- now
 				}
 			}
 		}()
@@ -458,23 +461,29 @@ func (t *timeTicker) Stop() {
 }
 
 func (t *timeTicker) Next(now time.Time) time.Time {
+	if t.loc != nil {
+		return nextIn(now, t.every, t.loc)
+	}
 	return now.Add(t.every)
 }
 
 type cronTicker struct {
-	expr    *cronexpr.Expression
+	expr *cronexpr.Expression
+	// The time zone of the schedule, nil for the local time zone.
+	loc     *time.Location
 	ticker  chan time.Time
 	closing chan struct{}
 	wg      sync.WaitGroup
 }
 
-func newCronTicker(cronExpr string) (*cronTicker, error) {
+func newCronTicker(cronExpr string, loc *time.Location) (*cronTicker, error) {
 	expr, err := cronexpr.Parse(cronExpr)
 	if err != nil {
 		return nil, err
 	}
 	return &cronTicker{
 		expr:    expr,
+		loc:     loc,
 		ticker:  make(chan time.Time),
 		closing: make(chan struct{}),
 	}, nil
@@ -486,7 +495,7 @@ func (c *cronTicker) Start() <-chan time.Time {
 		defer c.wg.Done()
 		for {
 			now := time.Now()
-			next := c.expr.Next(now)
+			next := c.Next(now)
 			diff := next.Sub(now)
 			select {
 			case <-time.After(diff):
@@ -505,5 +514,8 @@ func (c *cronTicker) Stop() {
 }
 
 func (c *cronTicker) Next(now time.Time) time.Time {
+	if c.loc != nil {
+		now = now.In(c.loc)
+	}
 	return c.expr.Next(now)
 }
@@ -29,6 +29,8 @@ const basePath = "/kapacitor/v1"
 const pingPath = basePath + "/ping"
 const logLevelPath = basePath + "/loglevel"
 const tasksPath = basePath + "/tasks"
+const librariesPath = basePath + "/libraries"
+const convertPipelinePath = basePath + "/pipelines/convert"
 const recordingsPath = basePath + "/recordings"
 const recordStreamPath = basePath + "/recordings/stream"
 const recordBatchPath = basePath + "/recordings/batch"
@@ -36,6 +38,7 @@ const recordQueryPath = basePath + "/recordings/query"
 const replaysPath = basePath + "/replays"
 const replayBatchPath = basePath + "/replays/batch"
 const replayQueryPath = basePath + "/replays/query"
+const alertsStatePath = basePath + "/alerts/state"
 
 // HTTP configuration for connecting to Kapacitor
 type Config struct {
@@ -336,6 +339,36 @@ type Task struct {
 	LastEnabled    time.Time      `json:"last-enabled,omitempty"`
 }
 
+// A TICKscript library that tasks can import.
+type Library struct {
+	Link       Link      `json:"link"`
+	ID         string    `json:"id"`
+	TICKscript string    `json:"script"`
+	Created    time.Time `json:"created"`
+	Modified   time.Time `json:"modified"`
+}
+
+// A pipeline as both its TICKscript and its JSON representation.
+type Pipeline struct {
+	Type       TaskType        `json:"type"`
+	TICKscript string          `json:"script"`
+	Pipeline   json.RawMessage `json:"pipeline"`
+}
+
+// The current state of an alert that is not OK.
+type AlertState struct {
+	ID   string `json:"id"`
+	Task string `json:"task"`
+	Node string `json:"node"`
+	// One of INFO, WARNING or CRITICAL.
+	Level string `json:"level"`
+	// The time the alert entered its current level.
+	Since    time.Time         `json:"since"`
+	Message  string            `json:"message"`
+	Tags     map[string]string `json:"tags"`
+	Flapping bool              `json:"flapping"`
+}
+
 // Information about a recording.
 type Recording struct {
 	Link     Link      `json:"link"`
@@ -365,6 +398,24 @@ type Replay struct {
 // Perform the request.
 // If result is not nil the response body is JSON decoded into result.
 // Codes is a list of valid response codes.
+// A problem found while statically checking a TICKscript.
+type Diagnostic struct {
+	Line    int    `json:"line"`
+	Char    int    `json:"char"`
+	Message string `json:"message"`
+}
+
+// Error returned when a TICKscript is invalid,
+// contains every problem found while statically checking it.
+type DiagnosticsError struct {
+	Message     string
+	Diagnostics []Diagnostic
+}
+
+func (e DiagnosticsError) Error() string {
+	return e.Message
+}
+
 func (c *Client) do(req *http.Request, result interface{}, codes ...int) (*http.Response, error) {
 	req.Header.Set("User-Agent", c.userAgent)
 	resp, err := c.httpClient.Do(req)
@@ -386,11 +437,18 @@ func (c *Client) do(req *http.Request, result interface{}, codes ...int) (*http.
 			return nil, err
 		}
 		type errResp struct {
-			Error string `json:"error"`
+			Error       string       `json:"error"`
+			Diagnostics []Diagnostic `json:"diagnostics"`
 		}
 		d := json.NewDecoder(bytes.NewReader(body))
 		rp := errResp{}
 		d.Decode(&rp)
+		if len(rp.Diagnostics) > 0 {
+			return nil, DiagnosticsError{
+				Message:     rp.Error,
+				Diagnostics: rp.Diagnostics,
+			}
+		}
 		if rp.Error != "" {
 			return nil, errors.New(rp.Error)
 		}
@@ -431,11 +489,14 @@ func (c *Client) TaskLink(id string) Link {
 }
 
 type CreateTaskOptions struct {
-	ID         string     `json:"id,omitempty"`
-	Type       TaskType   `json:"type,omitempty"`
-	DBRPs      []DBRP     `json:"dbrps,omitempty"`
-	TICKscript string     `json:"script,omitempty"`
-	Status     TaskStatus `json:"status,omitempty"`
+	ID         string   `json:"id,omitempty"`
+	Type       TaskType `json:"type,omitempty"`
+	DBRPs      []DBRP   `json:"dbrps,omitempty"`
+	TICKscript string   `json:"script,omitempty"`
+	// The JSON representation of the pipeline, in place of the TICKscript.
+	Pipeline json.RawMessage `json:"pipeline,omitempty"`
+
+	Status TaskStatus `json:"status,omitempty"`
 }
 
 // Create a new task.
@@ -462,10 +523,13 @@ func (c *Client) CreateTask(opt CreateTaskOptions) (Task, error) {
 }
 
 type UpdateTaskOptions struct {
-	Type       TaskType   `json:"type,omitempty"`
-	DBRPs      []DBRP     `json:"dbrps,omitempty"`
-	TICKscript string     `json:"script,omitempty"`
-	Status     TaskStatus `json:"status,omitempty"`
+	Type       TaskType `json:"type,omitempty"`
+	DBRPs      []DBRP   `json:"dbrps,omitempty"`
+	TICKscript string   `json:"script,omitempty"`
+	// The JSON representation of the pipeline, in place of the TICKscript.
+	Pipeline json.RawMessage `json:"pipeline,omitempty"`
+
+	Status TaskStatus `json:"status,omitempty"`
 }
 
 // Update an existing task.
@@ -623,6 +687,235 @@ func (c *Client) ListTasks(opt *ListTasksOptions) ([]Task, error) {
 	return r.Tasks, nil
 }
 
+func (c *Client) LibraryLink(id string) Link {
+	return Link{Relation: Self, Href: path.Join(librariesPath, id)}
+}
+
+type CreateLibraryOptions struct {
+	ID         string `json:"id,omitempty"`
+	TICKscript string `json:"script,omitempty"`
+}
+
+// Create a new library.
+// Errors if the library already exists.
+func (c *Client) CreateLibrary(opt CreateLibraryOptions) (Library, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	err := enc.Encode(opt)
+	if err != nil {
+		return Library{}, err
+	}
+
+	u := *c.url
+	u.Path = librariesPath
+
+	req, err := http.NewRequest("POST", u.String(), &buf)
+	if err != nil {
+		return Library{}, err
+	}
+
+	l := Library{}
+	_, err = c.do(req, &l, http.StatusOK)
+	return l, err
+}
+
+type UpdateLibraryOptions struct {
+	TICKscript string `json:"script,omitempty"`
+}
+
+// Update an existing library.
+// Tasks importing the library use the new version the next time they are defined or enabled.
+func (c *Client) UpdateLibrary(link Link, opt UpdateLibraryOptions) error {
+	if link.Href == "" {
+		return fmt.Errorf("invalid link %v", link)
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	err := enc.Encode(opt)
+	if err != nil {
+		return err
+	}
+
+	u := *c.url
+	u.Path = link.Href
+
+	req, err := http.NewRequest("PATCH", u.String(), &buf)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.do(req, nil, http.StatusNoContent)
+	return err
+}
+
+// Get information about a library.
+func (c *Client) Library(link Link) (Library, error) {
+	library := Library{}
+	if link.Href == "" {
+		return library, fmt.Errorf("invalid link %v", link)
+	}
+
+	u := *c.url
+	u.Path = link.Href
+
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return library, err
+	}
+
+	_, err = c.do(req, &library, http.StatusOK)
+	if err != nil {
+		return library, err
+	}
+	return library, nil
+}
+
+// Delete a library.
+func (c *Client) DeleteLibrary(link Link) error {
+	if link.Href == "" {
+		return fmt.Errorf("invalid link %v", link)
+	}
+
+	u := *c.url
+	u.Path = link.Href
+
+	req, err := http.NewRequest("DELETE", u.String(), nil)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.do(req, nil, http.StatusNoContent)
+	return err
+}
+
+type ConvertPipelineOptions struct {
+	Type TaskType `json:"type,omitempty"`
+	// Either the TICKscript or the JSON representation of the pipeline to convert.
+	TICKscript string          `json:"script,omitempty"`
+	Pipeline   json.RawMessage `json:"pipeline,omitempty"`
+}
+
+// Convert a TICKscript into the JSON representation of its pipeline or vice versa.
+// The pipeline is validated the same way as the pipeline of a task.
+func (c *Client) ConvertPipeline(opt ConvertPipelineOptions) (Pipeline, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	err := enc.Encode(opt)
+	if err != nil {
+		return Pipeline{}, err
+	}
+
+	u := *c.url
+	u.Path = convertPipelinePath
+
+	req, err := http.NewRequest("POST", u.String(), &buf)
+	if err != nil {
+		return Pipeline{}, err
+	}
+
+	p := Pipeline{}
+	_, err = c.do(req, &p, http.StatusOK)
+	return p, err
+}
+
+type ListLibrariesOptions struct {
+	Pattern string
+	Offset  int
+	Limit   int
+}
+
+func (o *ListLibrariesOptions) Default() {
+	if o.Limit == 0 {
+		o.Limit = 100
+	}
+}
+
+func (o *ListLibrariesOptions) Values() *url.Values {
+	v := &url.Values{}
+	v.Set("pattern", o.Pattern)
+	v.Set("offset", strconv.FormatInt(int64(o.Offset), 10))
+	v.Set("limit", strconv.FormatInt(int64(o.Limit), 10))
+	return v
+}
+
+// Get libraries.
+func (c *Client) ListLibraries(opt *ListLibrariesOptions) ([]Library, error) {
+	if opt == nil {
+		opt = new(ListLibrariesOptions)
+	}
+	opt.Default()
+
+	u := *c.url
+	u.Path = librariesPath
+	u.RawQuery = opt.Values().Encode()
+
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Response type
+	type response struct {
+		Libraries []Library `json:"libraries"`
+	}
+
+	r := &response{}
+
+	_, err = c.do(req, r, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	return r.Libraries, nil
+}
+
+type ListAlertStatesOptions struct {
+	// Only list alerts with one of the levels, i.e. CRITICAL.
+	Levels []string
+	// Only list alerts of tasks matching the pattern.
+	TaskPattern string
+}
+
+func (o *ListAlertStatesOptions) Values() *url.Values {
+	v := &url.Values{}
+	for _, level := range o.Levels {
+		v.Add("level", level)
+	}
+	if o.TaskPattern != "" {
+		v.Set("task", o.TaskPattern)
+	}
+	return v
+}
+
+// Get the alerts that are not OK across all executing tasks.
+func (c *Client) ListAlertStates(opt *ListAlertStatesOptions) ([]AlertState, error) {
+	if opt == nil {
+		opt = new(ListAlertStatesOptions)
+	}
+
+	u := *c.url
+	u.Path = alertsStatePath
+	u.RawQuery = opt.Values().Encode()
+
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Response type
+	type response struct {
+		Alerts []AlertState `json:"alerts"`
+	}
+
+	r := &response{}
+
+	_, err = c.do(req, r, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	return r.Alerts, nil
+}
+
 func (c *Client) TaskOutput(link Link, name string) (*influxql.Result, error) {
 	u := *c.url
 	u.Path = path.Join(link.Href, name)
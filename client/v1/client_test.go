@@ -170,6 +170,13 @@ func Test_ReportsErrors(t *testing.T) {
 				return err
 			},
 		},
+		{
+			name: "ListAlertStates",
+			fnc: func(c *client.Client) error {
+				_, err := c.ListAlertStates(nil)
+				return err
+			},
+		},
 	}
 	for _, tc := range testCases {
 		s, c, err := newClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -428,6 +435,38 @@ func Test_CreateTask(t *testing.T) {
 	}
 }
 
+func Test_CreateTask_Diagnostics(t *testing.T) {
+	s, c, err := newClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{
+	"error": "invalid TICKscript: line 1 char 30: cannot compute mean of string field \"value\"",
+	"diagnostics": [{"line": 1, "char": 30, "message": "cannot compute mean of string field \"value\""}]
+}`)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	_, err = c.CreateTask(client.CreateTaskOptions{
+		ID:         "taskname",
+		Type:       client.StreamTask,
+		DBRPs:      []client.DBRP{{Database: "dbname", RetentionPolicy: "rpname"}},
+		TICKscript: "stream|from()|mean('value')",
+	})
+	diagnosticsErr, ok := err.(client.DiagnosticsError)
+	if !ok {
+		t.Fatalf("expected DiagnosticsError got %T: %v", err, err)
+	}
+	if got, exp := diagnosticsErr.Error(), `invalid TICKscript: line 1 char 30: cannot compute mean of string field "value"`; got != exp {
+		t.Errorf("unexpected error got %s exp %s", got, exp)
+	}
+	exp := []client.Diagnostic{{Line: 1, Char: 30, Message: `cannot compute mean of string field "value"`}}
+	if !reflect.DeepEqual(diagnosticsErr.Diagnostics, exp) {
+		t.Errorf("unexpected diagnostics got %v exp %v", diagnosticsErr.Diagnostics, exp)
+	}
+}
+
 func Test_UpdateTask(t *testing.T) {
 	s, c, err := newClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		var task client.UpdateTaskOptions
@@ -717,6 +756,175 @@ func Test_ListTasks_Options(t *testing.T) {
 	}
 }
 
+func Test_CreateLibrary(t *testing.T) {
+	tickScript := "def crit(threshold) = lambda: \"value\" > threshold"
+	s, c, err := newClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var library client.CreateLibraryOptions
+		body, _ := ioutil.ReadAll(r.Body)
+		json.Unmarshal(body, &library)
+
+		if r.URL.Path == "/kapacitor/v1/libraries" && r.Method == "POST" {
+			exp := client.CreateLibraryOptions{
+				ID:         "lib/common.tick",
+				TICKscript: tickScript,
+			}
+			if !reflect.DeepEqual(exp, library) {
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprintf(w, "unexpected CreateLibrary body: got:\n%v\nexp:\n%v\n", library, exp)
+			} else {
+				w.WriteHeader(http.StatusOK)
+				fmt.Fprint(w, `{"link": {"rel":"self", "href":"/kapacitor/v1/libraries/lib/common.tick"}}`)
+			}
+		} else {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "request: %v", r)
+		}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	library, err := c.CreateLibrary(client.CreateLibraryOptions{
+		ID:         "lib/common.tick",
+		TICKscript: tickScript,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, exp := library.Link, c.LibraryLink("lib/common.tick"); got != exp {
+		t.Errorf("unexpected library link got %v exp %v", got, exp)
+	}
+}
+
+func Test_UpdateLibrary(t *testing.T) {
+	s, c, err := newClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var library client.UpdateLibraryOptions
+		body, _ := ioutil.ReadAll(r.Body)
+		json.Unmarshal(body, &library)
+
+		if r.URL.Path == "/kapacitor/v1/libraries/lib/common.tick" && r.Method == "PATCH" &&
+			library.TICKscript == "var x = 1" {
+			w.WriteHeader(http.StatusNoContent)
+		} else {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "request: %v", r)
+		}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	err = c.UpdateLibrary(c.LibraryLink("lib/common.tick"), client.UpdateLibraryOptions{
+		TICKscript: "var x = 1",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_ConvertPipeline(t *testing.T) {
+	s, c, err := newClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var opt client.ConvertPipelineOptions
+		body, _ := ioutil.ReadAll(r.Body)
+		json.Unmarshal(body, &opt)
+
+		if r.URL.Path == "/kapacitor/v1/pipelines/convert" && r.Method == "POST" &&
+			opt.Type == client.StreamTask &&
+			opt.TICKscript == "stream|from()" {
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprint(w, `{
+	"type": "stream",
+	"script": "stream\n    |from()\n",
+	"pipeline": {"nodes":[{"id":0,"name":"stream0","type":"stream","parents":[]}]}
+}`)
+		} else {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "request: %v", r)
+		}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	p, err := c.ConvertPipeline(client.ConvertPipelineOptions{
+		Type:       client.StreamTask,
+		TICKscript: "stream|from()",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, exp := p.TICKscript, "stream\n    |from()\n"; got != exp {
+		t.Errorf("unexpected script got %q exp %q", got, exp)
+	}
+	if got, exp := string(p.Pipeline), `{"nodes":[{"id":0,"name":"stream0","type":"stream","parents":[]}]}`; got != exp {
+		t.Errorf("unexpected pipeline got %s exp %s", got, exp)
+	}
+}
+
+func Test_DeleteLibrary(t *testing.T) {
+	s, c, err := newClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/kapacitor/v1/libraries/lib/common.tick" && r.Method == "DELETE" {
+			w.WriteHeader(http.StatusNoContent)
+		} else {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "request: %v", r)
+		}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	err = c.DeleteLibrary(c.LibraryLink("lib/common.tick"))
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_ListLibraries(t *testing.T) {
+	s, c, err := newClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/kapacitor/v1/libraries" && r.Method == "GET" &&
+			r.URL.Query().Get("pattern") == "lib/*" &&
+			r.URL.Query().Get("offset") == "0" &&
+			r.URL.Query().Get("limit") == "100" {
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprintf(w, `{
+"libraries":[
+	{
+		"link": {"rel":"self", "href":"/kapacitor/v1/libraries/lib/common.tick"},
+		"id": "lib/common.tick",
+		"script": "var x = 1"
+	}
+]}`)
+		} else {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "request: %v", r)
+		}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	libraries, err := c.ListLibraries(&client.ListLibrariesOptions{
+		Pattern: "lib/*",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := []client.Library{{
+		Link:       client.Link{Relation: client.Self, Href: "/kapacitor/v1/libraries/lib/common.tick"},
+		ID:         "lib/common.tick",
+		TICKscript: "var x = 1",
+	}}
+	if !reflect.DeepEqual(exp, libraries) {
+		t.Errorf("unexpected library list: got:\n%v\nexp:\n%v", libraries, exp)
+	}
+}
+
 func Test_TaskOutput(t *testing.T) {
 	s, c, err := newClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.URL.Path == "/kapacitor/v1/tasks/taskname/cpu" && r.Method == "GET" {
@@ -1457,3 +1665,54 @@ func Test_LogLevel(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func Test_ListAlertStates(t *testing.T) {
+	s, c, err := newClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/kapacitor/v1/alerts/state" && r.Method == "GET" &&
+			reflect.DeepEqual(r.URL.Query()["level"], []string{"WARNING", "CRITICAL"}) &&
+			r.URL.Query().Get("task") == "cpu*" {
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprintf(w, `{
+"alerts":[
+	{
+		"id": "cpu/serverA",
+		"task": "cpu_alert",
+		"node": "alert2",
+		"level": "WARNING",
+		"since": "2016-03-31T11:24:55Z",
+		"message": "cpu/serverA is WARNING",
+		"tags": {"host": "serverA"},
+		"flapping": true
+	}
+]}`)
+		} else {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "request: %v", r)
+		}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	alerts, err := c.ListAlertStates(&client.ListAlertStatesOptions{
+		Levels:      []string{"WARNING", "CRITICAL"},
+		TaskPattern: "cpu*",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := []client.AlertState{{
+		ID:       "cpu/serverA",
+		Task:     "cpu_alert",
+		Node:     "alert2",
+		Level:    "WARNING",
+		Since:    time.Date(2016, 3, 31, 11, 24, 55, 0, time.UTC),
+		Message:  "cpu/serverA is WARNING",
+		Tags:     map[string]string{"host": "serverA"},
+		Flapping: true,
+	}}
+	if !reflect.DeepEqual(exp, alerts) {
+		t.Errorf("unexpected alert states:\ngot\n%v\nexp\n%v", alerts, exp)
+	}
+}
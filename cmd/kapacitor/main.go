@@ -2,17 +2,21 @@ package main
 
 import (
 	"bytes"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"os"
+	"sort"
 	"strings"
 	"time"
 
+	"github.com/BurntSushi/toml"
 	"github.com/dustin/go-humanize"
 	"github.com/influxdata/influxdb/influxql"
 	"github.com/influxdata/kapacitor/client/v1"
+	"github.com/influxdata/kapacitor/tick/lint"
 	"github.com/pkg/errors"
 )
 
@@ -39,15 +43,18 @@ Commands:
 
 	record      Record the result of a query or a snapshot of the current stream data.
 	define      Create/update a task.
+	define-library Create/update a TICKscript library that tasks can import.
+	lint        Check TICKscripts for risky but valid constructs.
 	replay      Replay a recording to a task.
 	replay-live Replay data against a task without recording it.
 	enable      Enable and start running a task with live data.
 	disable     Stop running a task.
 	reload      Reload a running task with an updated task definition.
 	push        Publish a task definition to another Kapacitor instance. Not implemented yet.
-	delete      Delete a task or a recording.
-	list        List information about tasks or recordings.
+	delete      Delete a task, a recording or a library.
+	list        List information about tasks, recordings or libraries.
 	show        Display detailed information about a task.
+	alerts      List the current state of alerts.
 	help        Prints help for a command.
 	level       Sets the logging level on the kapacitord server.
 	version     Displays the Kapacitor version info.
@@ -105,6 +112,13 @@ func main() {
 	case "define":
 		commandArgs = args
 		commandF = doDefine
+	case "define-library":
+		commandArgs = args
+		commandF = doDefineLibrary
+	case "lint":
+		lintFlags.Parse(args)
+		commandArgs = lintFlags.Args()
+		commandF = doLint
 	case "replay":
 		replayFlags.Parse(args)
 		commandArgs = replayFlags.Args()
@@ -134,6 +148,13 @@ func main() {
 	case "show":
 		commandArgs = args
 		commandF = doShow
+	case "alerts":
+		if len(args) == 0 {
+			alertsUsage()
+			os.Exit(2)
+		}
+		commandArgs = args
+		commandF = doAlerts
 	case "level":
 		commandArgs = args
 		commandF = doLevel
@@ -155,7 +176,10 @@ func main() {
 // Init flag sets
 func init() {
 	replayFlags.Usage = replayUsage
+	alertsListFlags.Usage = alertsListUsage
 	defineFlags.Usage = defineUsage
+	defineLibraryFlags.Usage = defineLibraryUsage
+	lintFlags.Usage = lintUsage
 
 	recordStreamFlags.Usage = recordStreamUsage
 	recordBatchFlags.Usage = recordBatchUsage
@@ -196,6 +220,10 @@ func doHelp(args []string) error {
 			recordUsage()
 		case "define":
 			defineFlags.Usage()
+		case "define-library":
+			defineLibraryFlags.Usage()
+		case "lint":
+			lintFlags.Usage()
 		case "replay":
 			replayFlags.Usage()
 		case "enable":
@@ -210,6 +238,8 @@ func doHelp(args []string) error {
 			listUsage()
 		case "show":
 			showUsage()
+		case "alerts":
+			alertsUsage()
 		case "level":
 			levelUsage()
 		case "help":
@@ -461,6 +491,7 @@ func doRecord(args []string) error {
 var (
 	defineFlags = flag.NewFlagSet("define", flag.ExitOnError)
 	dtick       = defineFlags.String("tick", "", "Path to the TICKscript")
+	djson       = defineFlags.String("json", "", "Path to the JSON representation of the pipeline, in place of the TICKscript")
 	dtype       = defineFlags.String("type", "", "The task type (stream|batch)")
 	dnoReload   = defineFlags.Bool("no-reload", false, "Do not reload the task even if it is enabled")
 	ddbrp       = make(dbrps, 0)
@@ -556,6 +587,10 @@ For example:
 
 		$ kapacitor define my_task -dbrp mydb.myrp -dbrp otherdb.default
 
+	A task can be defined from the JSON representation of its pipeline instead of a TICKscript.
+
+		$ kapacitor define my_task -json path/to/pipeline.json -type stream -dbrp mydb.myrp
+
 	NOTE: you must specify all 'dbrp' flags you desire if you wish to modify them.
 
 Options:
@@ -574,6 +609,10 @@ func doDefine(args []string) error {
 	defineFlags.Parse(args[1:])
 	id := args[0]
 
+	if *dtick != "" && *djson != "" {
+		return errors.New("must provide either -tick or -json, not both")
+	}
+
 	var script string
 	if *dtick != "" {
 		file, err := os.Open(*dtick)
@@ -588,6 +627,15 @@ func doDefine(args []string) error {
 		script = string(data)
 	}
 
+	var p json.RawMessage
+	if *djson != "" {
+		data, err := ioutil.ReadFile(*djson)
+		if err != nil {
+			return err
+		}
+		p = data
+	}
+
 	var ttype client.TaskType
 	switch *dtype {
 	case "stream":
@@ -605,6 +653,7 @@ func doDefine(args []string) error {
 			Type:       ttype,
 			DBRPs:      ddbrp,
 			TICKscript: script,
+			Pipeline:   p,
 			Status:     client.Disabled,
 		})
 	} else {
@@ -614,6 +663,7 @@ func doDefine(args []string) error {
 				Type:       ttype,
 				DBRPs:      ddbrp,
 				TICKscript: script,
+				Pipeline:   p,
 			},
 		)
 	}
@@ -634,6 +684,175 @@ func doDefine(args []string) error {
 	return nil
 }
 
+// Define Library
+var (
+	defineLibraryFlags = flag.NewFlagSet("define-library", flag.ExitOnError)
+	dltick             = defineLibraryFlags.String("tick", "", "Path to the TICKscript of the library")
+)
+
+func defineLibraryUsage() {
+	var u = `Usage: kapacitor define-library <library ID> -tick <path>
+
+	Create or update a TICKscript library.
+
+	A library contains var and def statements that tasks can import by ID.
+	The ID is a path of segments separated by '/'.
+
+	Tasks importing the library use the updated library the next time they are defined or enabled.
+
+For example:
+
+		$ kapacitor define-library lib/common.tick -tick path/to/common.tick
+
+	Then import it in a task:
+
+		import 'lib/common.tick'
+
+Options:
+
+`
+	fmt.Fprintln(os.Stderr, u)
+	defineLibraryFlags.PrintDefaults()
+}
+
+func doDefineLibrary(args []string) error {
+	if len(args) < 1 {
+		fmt.Fprintln(os.Stderr, "Must provide a library ID.")
+		defineLibraryFlags.Usage()
+		os.Exit(2)
+	}
+	defineLibraryFlags.Parse(args[1:])
+	id := args[0]
+
+	if *dltick == "" {
+		fmt.Fprintln(os.Stderr, "Must provide a TICKscript.")
+		defineLibraryFlags.Usage()
+		os.Exit(2)
+	}
+	data, err := ioutil.ReadFile(*dltick)
+	if err != nil {
+		return err
+	}
+	script := string(data)
+
+	l := cli.LibraryLink(id)
+	library, _ := cli.Library(l)
+	if library.ID == "" {
+		_, err = cli.CreateLibrary(client.CreateLibraryOptions{
+			ID:         id,
+			TICKscript: script,
+		})
+	} else {
+		err = cli.UpdateLibrary(l, client.UpdateLibraryOptions{
+			TICKscript: script,
+		})
+	}
+	return err
+}
+
+// Lint
+var (
+	lintFlags = flag.NewFlagSet("lint", flag.ExitOnError)
+	lconfig   = lintFlags.String("config", "", "Path to a TOML file configuring the rules.")
+	lenable   = lintFlags.String("enable", "", "Rules to enable, separated by commas.")
+	ldisable  = lintFlags.String("disable", "", "Rules to disable, separated by commas.")
+)
+
+func lintUsage() {
+	var u = `Usage: kapacitor lint [options] <path>...
+
+	Check TICKscripts for risky but valid constructs.
+
+	The problems found are printed as a JSON list of objects with the file, rule, line, char and message of each problem.
+	The exit status is non zero if any problem is found.
+
+	All rules are enabled unless disabled by the config file or the -disable option.
+	The config file has the form:
+
+		# Measurements that stream tasks must filter with .where()
+		high-volume-measurements = ["cpu"]
+		# Alert handlers that are deprecated
+		deprecated-handlers = ["hipChat"]
+
+		[rules]
+		unused-var = false
+
+For example:
+
+		$ kapacitor lint -disable unused-var path/to/*.tick
+
+Rules:
+
+`
+	fmt.Fprint(os.Stderr, u)
+	for _, r := range lint.Rules {
+		fmt.Fprintf(os.Stderr, "\t%-24s%s\n", r.Name, r.Description)
+	}
+	fmt.Fprint(os.Stderr, "\nOptions:\n\n")
+	lintFlags.PrintDefaults()
+}
+
+// A problem found by lint in a file.
+type lintProblem struct {
+	File string `json:"file"`
+	lint.Problem
+}
+
+func doLint(args []string) error {
+	if len(args) < 1 {
+		fmt.Fprintln(os.Stderr, "Must provide a path to a TICKscript.")
+		lintFlags.Usage()
+		os.Exit(2)
+	}
+	c := lint.NewConfig()
+	if *lconfig != "" {
+		if _, err := toml.DecodeFile(*lconfig, &c); err != nil {
+			return errors.Wrapf(err, "failed to read lint config %s", *lconfig)
+		}
+		if c.Rules == nil {
+			c.Rules = make(map[string]bool)
+		}
+	}
+	for _, rules := range []struct {
+		names   string
+		enabled bool
+	}{{*lenable, true}, {*ldisable, false}} {
+		if rules.names == "" {
+			continue
+		}
+		for _, name := range strings.Split(rules.names, ",") {
+			c.Rules[strings.TrimSpace(name)] = rules.enabled
+		}
+	}
+	if err := c.Validate(); err != nil {
+		return err
+	}
+
+	problems := []lintProblem{}
+	for _, path := range args {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		ps, err := lint.Lint(string(data), c)
+		if err != nil {
+			return errors.Wrapf(err, "failed to lint %s", path)
+		}
+		for _, p := range ps {
+			problems = append(problems, lintProblem{File: path, Problem: p})
+		}
+	}
+	b, err := json.MarshalIndent(problems, "", "    ")
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(os.Stdout, string(b))
+	if len(problems) > 0 {
+		return fmt.Errorf("found %d problems", len(problems))
+	}
+	return nil
+}
+
 // Replay
 var (
 	replayFlags = flag.NewFlagSet("replay", flag.ExitOnError)
@@ -1081,9 +1300,9 @@ func doShow(args []string) error {
 // List
 
 func listUsage() {
-	var u = `Usage: kapacitor list (tasks|recordings|replays) [(task|recording|replay) ID or pattern]
+	var u = `Usage: kapacitor list (tasks|recordings|replays|libraries) [(task|recording|replay|library) ID or pattern]
 
-List tasks, recordings, or replays and their current state.
+List tasks, recordings, replays or libraries and their current state.
 
 If no ID or pattern is given then all items will be listed.
 `
@@ -1093,7 +1312,7 @@ If no ID or pattern is given then all items will be listed.
 func doList(args []string) error {
 
 	if len(args) == 0 {
-		fmt.Fprintln(os.Stderr, "Must specify 'tasks', 'recordings', or 'replays'")
+		fmt.Fprintln(os.Stderr, "Must specify 'tasks', 'recordings', 'replays' or 'libraries'")
 		listUsage()
 		os.Exit(2)
 	}
@@ -1181,18 +1400,118 @@ func doList(args []string) error {
 			}
 			offset += limit
 		}
+	case "libraries":
+		outFmt := "%-40s%-23s\n"
+		fmt.Fprintf(os.Stdout, outFmt, "ID", "Modified")
+		offset := 0
+		for {
+			libraries, err := cli.ListLibraries(&client.ListLibrariesOptions{
+				Pattern: pattern,
+				Offset:  offset,
+				Limit:   limit,
+			})
+			if err != nil {
+				return err
+			}
+
+			for _, l := range libraries {
+				fmt.Fprintf(os.Stdout, outFmt, l.ID, l.Modified.Local().Format(time.RFC822))
+			}
+			if len(libraries) != limit {
+				break
+			}
+			offset += limit
+		}
 	default:
-		return fmt.Errorf("cannot list '%s' did you mean 'tasks', 'recordings' or 'replays'?", kind)
+		return fmt.Errorf("cannot list '%s' did you mean 'tasks', 'recordings', 'replays' or 'libraries'?", kind)
 	}
 	return nil
 
 }
 
+// Alerts
+var (
+	alertsListFlags = flag.NewFlagSet("alerts-list", flag.ExitOnError)
+	alLevel         = alertsListFlags.String("level", "", "Only list alerts with the level, one of INFO, WARNING or CRITICAL. Multiple levels are separated by commas.")
+	alTask          = alertsListFlags.String("task", "", "Only list alerts of tasks matching the ID or pattern.")
+	alJSON          = alertsListFlags.Bool("json", false, "Print the alerts as JSON.")
+)
+
+func alertsUsage() {
+	var u = `Usage: kapacitor alerts list [options]
+
+List the current state of alerts.
+
+See 'kapacitor alerts list -h' for the options.
+`
+	fmt.Fprintln(os.Stderr, u)
+}
+
+func alertsListUsage() {
+	var u = `Usage: kapacitor alerts list [options]
+
+	List every alert that is not OK across all executing tasks,
+	with its level, the time since it is at that level, its last message,
+	its tags, whether it is flapping and the task and node owning it.
+
+Examples:
+
+	$ kapacitor alerts list -level CRITICAL -task 'cpu_*'
+
+		This lists the critical alerts of tasks with an ID starting with 'cpu_'.
+
+Options:
+`
+	fmt.Fprintln(os.Stderr, u)
+	alertsListFlags.PrintDefaults()
+}
+
+func doAlerts(args []string) error {
+	switch args[0] {
+	case "list":
+		alertsListFlags.Parse(args[1:])
+		opt := &client.ListAlertStatesOptions{
+			TaskPattern: *alTask,
+		}
+		if *alLevel != "" {
+			for _, level := range strings.Split(*alLevel, ",") {
+				opt.Levels = append(opt.Levels, strings.ToUpper(strings.TrimSpace(level)))
+			}
+		}
+		alerts, err := cli.ListAlertStates(opt)
+		if err != nil {
+			return err
+		}
+		if *alJSON {
+			b, err := json.MarshalIndent(alerts, "", "    ")
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(os.Stdout, string(b))
+			return nil
+		}
+		outFmt := "%-30s%-20s%-10s%-10s%-23s%-10v%-30s%s\n"
+		fmt.Fprintf(os.Stdout, outFmt, "ID", "Task", "Node", "Level", "Since", "Flapping", "Tags", "Message")
+		for _, a := range alerts {
+			tags := make([]string, 0, len(a.Tags))
+			for k, v := range a.Tags {
+				tags = append(tags, k+"="+v)
+			}
+			sort.Strings(tags)
+			fmt.Fprintf(os.Stdout, outFmt, a.ID, a.Task, a.Node, a.Level, a.Since.Local().Format(time.RFC822), a.Flapping, strings.Join(tags, ","), a.Message)
+		}
+	default:
+		alertsUsage()
+		return fmt.Errorf("unknown alerts command %s", args[0])
+	}
+	return nil
+}
+
 // Delete
 func deleteUsage() {
-	var u = `Usage: kapacitor delete (tasks|recordings|replays) [task|recording|replay ID]...
+	var u = `Usage: kapacitor delete (tasks|recordings|replays|libraries) [task|recording|replay|library ID]...
 
-	Delete a task or recording.
+	Delete a task, recording, replay or library.
 
 	If a task is enabled it will be disabled and then deleted,
 
@@ -1209,6 +1528,10 @@ For example:
 	You can delete recordings:
 
 		$ kapacitor delete recordings b0a2ba8a-aeeb-45ec-bef9-1a2939963586
+
+	You can delete libraries:
+
+		$ kapacitor delete libraries lib/common.tick
 `
 	fmt.Fprintln(os.Stderr, u)
 }
@@ -1288,8 +1611,29 @@ func doDelete(args []string) error {
 				}
 			}
 		}
+	case "libraries":
+		for _, pattern := range args[1:] {
+			for {
+				libraries, err := cli.ListLibraries(&client.ListLibrariesOptions{
+					Pattern: pattern,
+					Limit:   limit,
+				})
+				if err != nil {
+					return err
+				}
+				for _, library := range libraries {
+					err := cli.DeleteLibrary(library.Link)
+					if err != nil {
+						return err
+					}
+				}
+				if len(libraries) != limit {
+					break
+				}
+			}
+		}
 	default:
-		return fmt.Errorf("cannot delete '%s' did you mean 'tasks', 'recordings' or 'replays'?", kind)
+		return fmt.Errorf("cannot delete '%s' did you mean 'tasks', 'recordings', 'replays' or 'libraries'?", kind)
 	}
 	return nil
 }
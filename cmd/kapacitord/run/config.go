@@ -12,20 +12,26 @@ import (
 	"time"
 
 	"github.com/influxdata/kapacitor/services/alerta"
+	"github.com/influxdata/kapacitor/services/alertmanager"
 	"github.com/influxdata/kapacitor/services/deadman"
+	"github.com/influxdata/kapacitor/services/delivery"
 	"github.com/influxdata/kapacitor/services/hipchat"
 	"github.com/influxdata/kapacitor/services/httpd"
 	"github.com/influxdata/kapacitor/services/influxdb"
+	"github.com/influxdata/kapacitor/services/jira"
 	"github.com/influxdata/kapacitor/services/logging"
 	"github.com/influxdata/kapacitor/services/opsgenie"
 	"github.com/influxdata/kapacitor/services/pagerduty"
+	"github.com/influxdata/kapacitor/services/pagerduty2"
 	"github.com/influxdata/kapacitor/services/replay"
 	"github.com/influxdata/kapacitor/services/reporting"
 	"github.com/influxdata/kapacitor/services/sensu"
+	"github.com/influxdata/kapacitor/services/servicenow"
 	"github.com/influxdata/kapacitor/services/slack"
 	"github.com/influxdata/kapacitor/services/smtp"
 	"github.com/influxdata/kapacitor/services/stats"
 	"github.com/influxdata/kapacitor/services/storage"
+	"github.com/influxdata/kapacitor/services/syslog"
 	"github.com/influxdata/kapacitor/services/talk"
 	"github.com/influxdata/kapacitor/services/task_store"
 	"github.com/influxdata/kapacitor/services/udf"
@@ -46,23 +52,29 @@ type Config struct {
 	InfluxDB []influxdb.Config `toml:"influxdb"`
 	Logging  logging.Config    `toml:"logging"`
 
-	Graphites []graphite.Config `toml:"graphite"`
-	Collectd  collectd.Config   `toml:"collectd"`
-	OpenTSDB  opentsdb.Config   `toml:"opentsdb"`
-	UDPs      []udp.Config      `toml:"udp"`
-	SMTP      smtp.Config       `toml:"smtp"`
-	OpsGenie  opsgenie.Config   `toml:"opsgenie"`
-	VictorOps victorops.Config  `toml:"victorops"`
-	PagerDuty pagerduty.Config  `toml:"pagerduty"`
-	Sensu     sensu.Config      `toml:"sensu"`
-	Slack     slack.Config      `toml:"slack"`
-	HipChat   hipchat.Config    `toml:"hipchat"`
-	Alerta    alerta.Config     `toml:"alerta"`
-	Reporting reporting.Config  `toml:"reporting"`
-	Stats     stats.Config      `toml:"stats"`
-	UDF       udf.Config        `toml:"udf"`
-	Deadman   deadman.Config    `toml:"deadman"`
-	Talk      talk.Config       `toml:"talk"`
+	Graphites    []graphite.Config   `toml:"graphite"`
+	Collectd     collectd.Config     `toml:"collectd"`
+	OpenTSDB     opentsdb.Config     `toml:"opentsdb"`
+	UDPs         []udp.Config        `toml:"udp"`
+	Delivery     delivery.Config     `toml:"delivery"`
+	SMTP         smtp.Config         `toml:"smtp"`
+	OpsGenie     opsgenie.Config     `toml:"opsgenie"`
+	VictorOps    victorops.Config    `toml:"victorops"`
+	PagerDuty    pagerduty.Config    `toml:"pagerduty"`
+	PagerDuty2   pagerduty2.Config   `toml:"pagerduty2"`
+	Sensu        sensu.Config        `toml:"sensu"`
+	Slack        slack.Config        `toml:"slack"`
+	Syslog       syslog.Config       `toml:"syslog"`
+	Alertmanager alertmanager.Config `toml:"alertmanager"`
+	Jira         jira.Config         `toml:"jira"`
+	ServiceNow   servicenow.Config   `toml:"servicenow"`
+	HipChat      hipchat.Config      `toml:"hipchat"`
+	Alerta       alerta.Config       `toml:"alerta"`
+	Reporting    reporting.Config    `toml:"reporting"`
+	Stats        stats.Config        `toml:"stats"`
+	UDF          udf.Config          `toml:"udf"`
+	Deadman      deadman.Config      `toml:"deadman"`
+	Talk         talk.Config         `toml:"talk"`
 
 	Hostname string `toml:"hostname"`
 	DataDir  string `toml:"data_dir"`
@@ -85,12 +97,18 @@ func NewConfig() *Config {
 
 	c.Collectd = collectd.NewConfig()
 	c.OpenTSDB = opentsdb.NewConfig()
+	c.Delivery = delivery.NewConfig()
 	c.SMTP = smtp.NewConfig()
 	c.OpsGenie = opsgenie.NewConfig()
 	c.VictorOps = victorops.NewConfig()
 	c.PagerDuty = pagerduty.NewConfig()
+	c.PagerDuty2 = pagerduty2.NewConfig()
 	c.Sensu = sensu.NewConfig()
 	c.Slack = slack.NewConfig()
+	c.Syslog = syslog.NewConfig()
+	c.Alertmanager = alertmanager.NewConfig()
+	c.Jira = jira.NewConfig()
+	c.ServiceNow = servicenow.NewConfig()
 	c.HipChat = hipchat.NewConfig()
 	c.Alerta = alerta.NewConfig()
 	c.Reporting = reporting.NewConfig()
@@ -192,6 +210,10 @@ func (c *Config) Validate() error {
 	if len(c.InfluxDB) > 0 && c.defaultInfluxDB == -1 {
 		return errors.New("at least one InfluxDB cluster must be marked as default.")
 	}
+	err = c.Delivery.Validate()
+	if err != nil {
+		return err
+	}
 	err = c.UDF.Validate()
 	if err != nil {
 		return err
@@ -204,6 +226,26 @@ func (c *Config) Validate() error {
 	if err != nil {
 		return err
 	}
+	err = c.Slack.Validate()
+	if err != nil {
+		return err
+	}
+	err = c.Syslog.Validate()
+	if err != nil {
+		return err
+	}
+	err = c.Alertmanager.Validate()
+	if err != nil {
+		return err
+	}
+	err = c.Jira.Validate()
+	if err != nil {
+		return err
+	}
+	err = c.ServiceNow.Validate()
+	if err != nil {
+		return err
+	}
 	for _, g := range c.Graphites {
 		if err := g.Validate(); err != nil {
 			return fmt.Errorf("invalid graphite config: %v", err)
@@ -72,3 +72,36 @@ boltdb = "/tmp/kapacitor.db"
 		t.Fatalf("unexpected url 0: %s", c.InfluxDB[0].URLs[0])
 	}
 }
+
+// Ensure the configuration of disabled services is not validated.
+func TestConfig_Validate_DisabledServices(t *testing.T) {
+	c := run.NewConfig()
+	c.Hostname = "localhost"
+	c.DataDir = "/tmp/kapacitor"
+	if _, err := toml.Decode(`
+[syslog]
+enabled = false
+network = "tcp"
+tls = true
+ssl-ca = "/does/not/exist/ca.pem"
+
+[alertmanager]
+enabled = false
+url = ""
+
+[slack]
+enabled = false
+[[slack.workspace]]
+name = ""
+`, c); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Validate(); err != nil {
+		t.Fatalf("unexpected error validating disabled services: %v", err)
+	}
+
+	c.Syslog.Enabled = true
+	if err := c.Validate(); err == nil {
+		t.Error("expected error validating enabled syslog with missing TLS files")
+	}
+}
@@ -19,20 +19,27 @@ import (
 	"github.com/influxdata/influxdb/services/opentsdb"
 	"github.com/influxdata/kapacitor"
 	"github.com/influxdata/kapacitor/services/alerta"
+	"github.com/influxdata/kapacitor/services/alertmanager"
 	"github.com/influxdata/kapacitor/services/deadman"
+	"github.com/influxdata/kapacitor/services/delivery"
 	"github.com/influxdata/kapacitor/services/hipchat"
 	"github.com/influxdata/kapacitor/services/httpd"
 	"github.com/influxdata/kapacitor/services/influxdb"
+	"github.com/influxdata/kapacitor/services/jira"
 	"github.com/influxdata/kapacitor/services/logging"
 	"github.com/influxdata/kapacitor/services/opsgenie"
 	"github.com/influxdata/kapacitor/services/pagerduty"
+	"github.com/influxdata/kapacitor/services/pagerduty2"
 	"github.com/influxdata/kapacitor/services/replay"
 	"github.com/influxdata/kapacitor/services/reporting"
 	"github.com/influxdata/kapacitor/services/sensu"
+	"github.com/influxdata/kapacitor/services/servicenow"
 	"github.com/influxdata/kapacitor/services/slack"
 	"github.com/influxdata/kapacitor/services/smtp"
+	"github.com/influxdata/kapacitor/services/sparkline"
 	"github.com/influxdata/kapacitor/services/stats"
 	"github.com/influxdata/kapacitor/services/storage"
+	"github.com/influxdata/kapacitor/services/syslog"
 	"github.com/influxdata/kapacitor/services/talk"
 	"github.com/influxdata/kapacitor/services/task_store"
 	"github.com/influxdata/kapacitor/services/udf"
@@ -131,14 +138,23 @@ func NewServer(c *Config, buildInfo *BuildInfo, logService logging.Interface) (*
 	s.initHTTPDService(c.HTTP)
 	s.appendInfluxDBService(c.InfluxDB, c.defaultInfluxDB, c.Hostname)
 	s.appendStorageService(c.Storage)
+	s.appendDeliveryService(c.Delivery)
+	s.appendSparklineService()
 	s.appendTaskStoreService(c.Task)
 	s.appendReplayService(c.Replay)
 	s.appendOpsGenieService(c.OpsGenie)
 	s.appendVictorOpsService(c.VictorOps)
 	s.appendPagerDutyService(c.PagerDuty)
+	s.appendPagerDuty2Service(c.PagerDuty2)
 	s.appendHipChatService(c.HipChat)
 	s.appendAlertaService(c.Alerta)
 	s.appendSlackService(c.Slack)
+	if err := s.appendSyslogService(c.Syslog); err != nil {
+		return nil, err
+	}
+	s.appendAlertmanagerService(c.Alertmanager)
+	s.appendJiraService(c.Jira)
+	s.appendServiceNowService(c.ServiceNow)
 	s.appendSensuService(c.Sensu)
 	s.appendTalkService(c.Talk)
 
@@ -175,6 +191,15 @@ func (s *Server) appendStorageService(c storage.Config) {
 	s.Services = append(s.Services, srv)
 }
 
+func (s *Server) appendDeliveryService(c delivery.Config) {
+	l := s.LogService.NewLogger("[delivery] ", log.LstdFlags)
+	srv := delivery.NewService(c, l)
+	srv.StorageService = s.StorageService
+
+	s.TaskMaster.DeliveryService = srv
+	s.Services = append(s.Services, srv)
+}
+
 func (s *Server) appendSMTPService(c smtp.Config) {
 	if c.Enabled {
 		l := s.LogService.NewLogger("[smtp] ", log.LstdFlags)
@@ -214,6 +239,15 @@ func (s *Server) appendHTTPDService() {
 	s.Services = append(s.Services, s.HTTPDService)
 }
 
+func (s *Server) appendSparklineService() {
+	l := s.LogService.NewLogger("[sparkline] ", log.LstdFlags)
+	srv := sparkline.NewService(l)
+	srv.HTTPDService = s.HTTPDService
+
+	s.TaskMaster.SparklineService = srv
+	s.Services = append(s.Services, srv)
+}
+
 func (s *Server) appendTaskStoreService(c task_store.Config) {
 	l := s.LogService.NewLogger("[task_store] ", log.LstdFlags)
 	srv := task_store.NewService(c, l)
@@ -286,6 +320,17 @@ func (s *Server) appendPagerDutyService(c pagerduty.Config) {
 	}
 }
 
+func (s *Server) appendPagerDuty2Service(c pagerduty2.Config) {
+	if c.Enabled {
+		l := s.LogService.NewLogger("[pagerduty2] ", log.LstdFlags)
+		srv := pagerduty2.NewService(c, l)
+		srv.HTTPDService = s.HTTPDService
+		s.TaskMaster.PagerDuty2Service = srv
+
+		s.Services = append(s.Services, srv)
+	}
+}
+
 func (s *Server) appendSensuService(c sensu.Config) {
 	if c.Enabled {
 		l := s.LogService.NewLogger("[sensu] ", log.LstdFlags)
@@ -300,12 +345,60 @@ func (s *Server) appendSlackService(c slack.Config) {
 	if c.Enabled {
 		l := s.LogService.NewLogger("[slack] ", log.LstdFlags)
 		srv := slack.NewService(c, l)
+		srv.StorageService = s.StorageService
 		s.TaskMaster.SlackService = srv
 
 		s.Services = append(s.Services, srv)
 	}
 }
 
+func (s *Server) appendSyslogService(c syslog.Config) error {
+	if c.Enabled {
+		l := s.LogService.NewLogger("[syslog] ", log.LstdFlags)
+		srv, err := syslog.NewService(c, l)
+		if err != nil {
+			return err
+		}
+		s.TaskMaster.SyslogService = srv
+
+		s.Services = append(s.Services, srv)
+	}
+	return nil
+}
+
+func (s *Server) appendAlertmanagerService(c alertmanager.Config) {
+	if c.Enabled {
+		l := s.LogService.NewLogger("[alertmanager] ", log.LstdFlags)
+		srv := alertmanager.NewService(c, l)
+		srv.HTTPDService = s.HTTPDService
+		s.TaskMaster.AlertmanagerService = srv
+
+		s.Services = append(s.Services, srv)
+	}
+}
+
+func (s *Server) appendJiraService(c jira.Config) {
+	if c.Enabled {
+		l := s.LogService.NewLogger("[jira] ", log.LstdFlags)
+		srv := jira.NewService(c, l)
+		srv.StorageService = s.StorageService
+		s.TaskMaster.JiraService = srv
+
+		s.Services = append(s.Services, srv)
+	}
+}
+
+func (s *Server) appendServiceNowService(c servicenow.Config) {
+	if c.Enabled {
+		l := s.LogService.NewLogger("[servicenow] ", log.LstdFlags)
+		srv := servicenow.NewService(c, l)
+		srv.StorageService = s.StorageService
+		s.TaskMaster.ServiceNowService = srv
+
+		s.Services = append(s.Services, srv)
+	}
+}
+
 func (s *Server) appendHipChatService(c hipchat.Config) {
 	if c.Enabled {
 		l := s.LogService.NewLogger("[hipchat] ", log.LstdFlags)
@@ -24,6 +24,8 @@ import (
 	"github.com/influxdata/influxdb/toml"
 	"github.com/influxdata/kapacitor/client/v1"
 	"github.com/influxdata/kapacitor/cmd/kapacitord/run"
+	"github.com/influxdata/kapacitor/services/alertmanager"
+	"github.com/influxdata/kapacitor/services/slack"
 	"github.com/influxdata/kapacitor/services/udf"
 )
 
@@ -100,6 +102,157 @@ func TestServer_CreateTask(t *testing.T) {
 	}
 }
 
+func TestServer_CreateTask_Diagnostics(t *testing.T) {
+	s, cli := OpenDefaultServer()
+	defer s.Close()
+
+	tick := `stream
+    |from()
+        .measurement('test')
+    |eval(lambda: string("value"))
+        .as('value')
+    |where(lambda: "value" > 10)
+    |mean('value')
+`
+	_, err := cli.CreateTask(client.CreateTaskOptions{
+		ID:         "testTaskID",
+		Type:       client.StreamTask,
+		DBRPs:      []client.DBRP{{Database: "mydb", RetentionPolicy: "myrp"}},
+		TICKscript: tick,
+		Status:     client.Disabled,
+	})
+	diagnosticsErr, ok := err.(client.DiagnosticsError)
+	if !ok {
+		t.Fatalf("expected diagnostics got %T: %v", err, err)
+	}
+	exp := []client.Diagnostic{
+		{Line: 6, Char: 28, Message: "mismatched type to binary operator. got string > int64. see bool(), int(), float(), string()"},
+		{Line: 7, Char: 6, Message: `cannot compute mean of string field "value"`},
+	}
+	if !reflect.DeepEqual(diagnosticsErr.Diagnostics, exp) {
+		t.Fatalf("unexpected diagnostics got %v exp %v", diagnosticsErr.Diagnostics, exp)
+	}
+
+	tasks, err := cli.ListTasks(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tasks) != 0 {
+		t.Fatalf("expected no tasks to be defined, got %d", len(tasks))
+	}
+}
+
+func TestServer_CreateTask_SlackWorkspace(t *testing.T) {
+	c := NewConfig()
+	c.Slack.Enabled = true
+	c.Slack.URL = "http://localhost/slack"
+	c.Slack.Workspaces = []slack.WorkspaceConfig{{
+		Name: "ops",
+		URL:  "http://localhost/slack/ops",
+	}}
+	s := OpenServer(c)
+	defer s.Close()
+	cli := Client(s)
+
+	testCases := []struct {
+		handler string
+		expErr  string
+	}{
+		{
+			handler: ".slack().workspace('ops')",
+		},
+		{
+			handler: ".slack().workspace('missing')",
+			expErr:  `invalid TICKscript: alert2: unknown Slack workspace "missing"`,
+		},
+		{
+			handler: ".slack().workspace('ops').thread()",
+			expErr:  `invalid TICKscript: alert2: Slack workspace "ops" has no token, cannot thread alerts`,
+		},
+	}
+	for i, tc := range testCases {
+		tick := `stream
+    |from()
+        .measurement('test')
+    |alert()
+        .crit(lambda: "value" > 10)
+        ` + tc.handler + `
+`
+		id := fmt.Sprintf("testSlackWorkspace%d", i)
+		_, err := cli.CreateTask(client.CreateTaskOptions{
+			ID:         id,
+			Type:       client.StreamTask,
+			DBRPs:      []client.DBRP{{Database: "mydb", RetentionPolicy: "myrp"}},
+			TICKscript: tick,
+			Status:     client.Disabled,
+		})
+		if tc.expErr == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", tc.handler, err)
+			}
+			continue
+		}
+		if err == nil || err.Error() != tc.expErr {
+			t.Errorf("%s: unexpected error got %v exp %s", tc.handler, err, tc.expErr)
+		}
+	}
+}
+
+func TestServer_CreateTask_AlertmanagerEndpoint(t *testing.T) {
+	c := NewConfig()
+	c.Alertmanager.Enabled = true
+	c.Alertmanager.URL = "http://localhost/alertmanager"
+	c.Alertmanager.Endpoints = []alertmanager.EndpointConfig{{
+		Name: "ops",
+		URL:  "http://localhost/alertmanager/ops",
+	}}
+	s := OpenServer(c)
+	defer s.Close()
+	cli := Client(s)
+
+	testCases := []struct {
+		handler string
+		expErr  string
+	}{
+		{
+			handler: ".alertmanager()",
+		},
+		{
+			handler: ".alertmanager().endpoint('ops')",
+		},
+		{
+			handler: ".alertmanager().endpoint('missing')",
+			expErr:  `invalid TICKscript: alert2: unknown Alertmanager endpoint "missing"`,
+		},
+	}
+	for i, tc := range testCases {
+		tick := `stream
+    |from()
+        .measurement('test')
+    |alert()
+        .crit(lambda: "value" > 10)
+        ` + tc.handler + `
+`
+		id := fmt.Sprintf("testAlertmanagerEndpoint%d", i)
+		_, err := cli.CreateTask(client.CreateTaskOptions{
+			ID:         id,
+			Type:       client.StreamTask,
+			DBRPs:      []client.DBRP{{Database: "mydb", RetentionPolicy: "myrp"}},
+			TICKscript: tick,
+			Status:     client.Disabled,
+		})
+		if tc.expErr == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", tc.handler, err)
+			}
+			continue
+		}
+		if err == nil || err.Error() != tc.expErr {
+			t.Errorf("%s: unexpected error got %v exp %s", tc.handler, err, tc.expErr)
+		}
+	}
+}
+
 func TestServer_EnableTask(t *testing.T) {
 	s, cli := OpenDefaultServer()
 	defer s.Close()
@@ -516,6 +669,255 @@ func TestServer_ListTasks_Fields(t *testing.T) {
 
 }
 
+func TestServer_Libraries(t *testing.T) {
+	s, cli := OpenDefaultServer()
+	defer s.Close()
+
+	libraryScript := `def measurement(name) = stream
+    |from()
+        .measurement(name)
+
+def windowed(node) = node
+    |window()
+        .period(10s)
+        .every(10s)
+`
+	library, err := cli.CreateLibrary(client.CreateLibraryOptions{
+		ID:         "lib/common.tick",
+		TICKscript: libraryScript,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp, got := "/kapacitor/v1/libraries/lib/common.tick", library.Link.Href; exp != got {
+		t.Errorf("unexpected library link got %s exp %s", got, exp)
+	}
+
+	// Invalid IDs are rejected
+	_, err = cli.CreateLibrary(client.CreateLibraryOptions{
+		ID:         "lib/../common.tick",
+		TICKscript: libraryScript,
+	})
+	if err == nil {
+		t.Error("expected error creating library with relative path")
+	}
+
+	libraries, err := cli.ListLibraries(&client.ListLibrariesOptions{Pattern: "lib/*"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(libraries) != 1 || libraries[0].ID != "lib/common.tick" || libraries[0].TICKscript != libraryScript {
+		t.Fatalf("unexpected libraries %v", libraries)
+	}
+
+	tick := `import 'lib/common.tick'
+
+measurement('test')
+    |windowed()
+`
+	task, err := cli.CreateTask(client.CreateTaskOptions{
+		ID:         "testTaskID",
+		Type:       client.StreamTask,
+		DBRPs:      []client.DBRP{{Database: "mydb", RetentionPolicy: "myrp"}},
+		TICKscript: tick,
+		Status:     client.Disabled,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ti, err := cli.Task(task.Link, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dot := "digraph testTaskID {\nstream0 -> from1;\nfrom1 -> window2;\n}"
+	if ti.Dot != dot {
+		t.Fatalf("unexpected dot\ngot\n%s\nexp\n%s\n", ti.Dot, dot)
+	}
+
+	// Update the library
+	err = cli.UpdateLibrary(library.Link, client.UpdateLibraryOptions{
+		TICKscript: libraryScript + "\nvar unused = 1\n",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err := cli.Library(library.Link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(l.TICKscript, "var unused = 1") {
+		t.Fatalf("library was not updated got %s", l.TICKscript)
+	}
+
+	// Imported libraries cannot be deleted
+	err = cli.DeleteLibrary(library.Link)
+	if exp := "cannot delete library lib/common.tick, it is imported by task testTaskID"; err == nil || err.Error() != exp {
+		t.Fatalf("unexpected error deleting imported library got %v exp %q", err, exp)
+	}
+	err = cli.DeleteTask(task.Link)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = cli.DeleteLibrary(library.Link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = cli.CreateTask(client.CreateTaskOptions{
+		ID:         "otherTaskID",
+		Type:       client.StreamTask,
+		DBRPs:      []client.DBRP{{Database: "mydb", RetentionPolicy: "myrp"}},
+		TICKscript: tick,
+		Status:     client.Disabled,
+	})
+	if err == nil {
+		t.Fatal("expected error creating task importing a deleted library")
+	}
+	if exp := `failed to import "lib/common.tick"`; !strings.Contains(err.Error(), exp) {
+		t.Errorf("unexpected error got %q exp to contain %q", err.Error(), exp)
+	}
+}
+
+// Updates of libraries breaking the tasks importing them are rejected.
+func TestServer_UpdateLibraryImported(t *testing.T) {
+	s, cli := OpenDefaultServer()
+	defer s.Close()
+
+	libraryScript := `def windowed(node) = node
+    |window()
+        .period(10s)
+        .every(10s)
+`
+	library, err := cli.CreateLibrary(client.CreateLibraryOptions{
+		ID:         "lib/common.tick",
+		TICKscript: libraryScript,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The task imports the library through another library.
+	_, err = cli.CreateLibrary(client.CreateLibraryOptions{
+		ID: "lib/cpu.tick",
+		TICKscript: `import 'lib/common.tick'
+
+def cpu() = stream
+    |from()
+        .measurement('cpu')
+    |windowed()
+`,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = cli.CreateTask(client.CreateTaskOptions{
+		ID:         "testTaskID",
+		Type:       client.StreamTask,
+		DBRPs:      []client.DBRP{{Database: "mydb", RetentionPolicy: "myrp"}},
+		TICKscript: "import 'lib/cpu.tick'\n\ncpu()\n    |count('value')\n",
+		Status:     client.Disabled,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Removing the function used by the task is rejected.
+	err = cli.UpdateLibrary(library.Link, client.UpdateLibraryOptions{
+		TICKscript: "var unused = 1\n",
+	})
+	if exp := "cannot update library lib/common.tick, task testTaskID fails to evaluate"; err == nil || !strings.Contains(err.Error(), exp) {
+		t.Fatalf("unexpected error updating imported library got %v exp to contain %q", err, exp)
+	}
+	l, err := cli.Library(library.Link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.TICKscript != libraryScript {
+		t.Fatalf("library was updated got %s", l.TICKscript)
+	}
+
+	err = cli.UpdateLibrary(library.Link, client.UpdateLibraryOptions{
+		TICKscript: libraryScript + "\nvar unused = 1\n",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestServer_PipelineJSON(t *testing.T) {
+	s, cli := OpenDefaultServer()
+	defer s.Close()
+
+	tick := `stream
+    |from()
+        .measurement('test')
+    |window()
+        .period(10s)
+        .every(10s)
+    |count('value')
+`
+	p, err := cli.ConvertPipeline(client.ConvertPipelineOptions{
+		Type:       client.StreamTask,
+		TICKscript: tick,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Convert the JSON back to the TICKscript
+	back, err := cli.ConvertPipeline(client.ConvertPipelineOptions{
+		Type:     client.StreamTask,
+		Pipeline: p.Pipeline,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if back.TICKscript != tick {
+		t.Errorf("unexpected TICKscript\ngot\n%s\nexp\n%s\n", back.TICKscript, tick)
+	}
+
+	_, err = cli.ConvertPipeline(client.ConvertPipelineOptions{
+		Type:       client.StreamTask,
+		TICKscript: tick,
+		Pipeline:   p.Pipeline,
+	})
+	if err == nil {
+		t.Error("expected error converting both a TICKscript and a pipeline")
+	}
+
+	// Create a task from the JSON
+	task, err := cli.CreateTask(client.CreateTaskOptions{
+		ID:       "testTaskID",
+		Type:     client.StreamTask,
+		DBRPs:    []client.DBRP{{Database: "mydb", RetentionPolicy: "myrp"}},
+		Pipeline: p.Pipeline,
+		Status:   client.Disabled,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ti, err := cli.Task(task.Link, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ti.TICKscript != tick {
+		t.Errorf("unexpected TICKscript\ngot\n%s\nexp\n%s\n", ti.TICKscript, tick)
+	}
+	dot := "digraph testTaskID {\nstream0 -> from1;\nfrom1 -> window2;\nwindow2 -> count3;\n}"
+	if ti.Dot != dot {
+		t.Errorf("unexpected dot\ngot\n%s\nexp\n%s\n", ti.Dot, dot)
+	}
+
+	_, err = cli.CreateTask(client.CreateTaskOptions{
+		ID:       "invalidTaskID",
+		Type:     client.StreamTask,
+		DBRPs:    []client.DBRP{{Database: "mydb", RetentionPolicy: "myrp"}},
+		Pipeline: []byte(`{"nodes":[{"id":0,"name":"stream","type":"stream","parents":[]},{"id":1,"name":"from","type":"from","parents":[5]}]}`),
+	})
+	if err == nil {
+		t.Error("expected error creating task from an invalid pipeline")
+	}
+}
+
 func TestServer_StreamTask(t *testing.T) {
 	s, cli := OpenDefaultServer()
 	defer s.Close()
@@ -667,6 +1069,103 @@ test0 value=1 0000000011
 	}
 }
 
+func TestServer_AlertsState(t *testing.T) {
+	s, cli := OpenDefaultServer()
+	defer s.Close()
+
+	id := "testAlertsState"
+	tick := `stream
+    |from()
+        .measurement('test')
+        .groupBy('host')
+    |alert()
+        .id('{{ .Name }}/{{ index .Tags "host" }}')
+        .warn(lambda: "value" > 5)
+        .crit(lambda: "value" > 10)
+`
+
+	_, err := cli.CreateTask(client.CreateTaskOptions{
+		ID:   id,
+		Type: client.StreamTask,
+		DBRPs: []client.DBRP{{
+			Database:        "mydb",
+			RetentionPolicy: "myrp",
+		}},
+		TICKscript: tick,
+		Status:     client.Enabled,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	points := `test,host=serverA value=20 0000000000
+test,host=serverB value=1 0000000000
+test,host=serverC value=20 0000000000
+test,host=serverA value=7 0000000001
+test,host=serverB value=1 0000000001
+test,host=serverC value=1 0000000001
+test,host=serverA value=8 0000000002
+`
+	v := url.Values{}
+	v.Add("precision", "s")
+	s.MustWrite("mydb", "myrp", points, v)
+
+	exp := []client.AlertState{{
+		ID:      "test/serverA",
+		Task:    id,
+		Node:    "alert2",
+		Level:   "WARNING",
+		Since:   time.Unix(1, 0).UTC(),
+		Message: "test/serverA is WARNING",
+		Tags:    map[string]string{"host": "serverA"},
+	}}
+	var alerts []client.AlertState
+	for i := 0; i < 100; i++ {
+		alerts, err = cli.ListAlertStates(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if reflect.DeepEqual(alerts, exp) {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if !reflect.DeepEqual(alerts, exp) {
+		t.Fatalf("unexpected alert states:\ngot\n%v\nexp\n%v", alerts, exp)
+	}
+
+	testCases := []struct {
+		opt *client.ListAlertStatesOptions
+		exp []client.AlertState
+	}{
+		{
+			opt: &client.ListAlertStatesOptions{Levels: []string{"WARNING", "CRITICAL"}},
+			exp: exp,
+		},
+		{
+			opt: &client.ListAlertStatesOptions{Levels: []string{"CRITICAL"}},
+			exp: []client.AlertState{},
+		},
+		{
+			opt: &client.ListAlertStatesOptions{TaskPattern: "testAlerts*"},
+			exp: exp,
+		},
+		{
+			opt: &client.ListAlertStatesOptions{TaskPattern: "other*"},
+			exp: []client.AlertState{},
+		},
+	}
+	for _, tc := range testCases {
+		alerts, err := cli.ListAlertStates(tc.opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(alerts, tc.exp) {
+			t.Errorf("unexpected alert states for %+v:\ngot\n%v\nexp\n%v", tc.opt, alerts, tc.exp)
+		}
+	}
+}
+
 func TestServer_BatchTask(t *testing.T) {
 	c := NewConfig()
 	c.InfluxDB[0].Enabled = true
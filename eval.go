@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"sync"
 	"time"
 
 	"github.com/influxdata/kapacitor/expvar"
@@ -18,8 +19,10 @@ const (
 
 type EvalNode struct {
 	node
-	e                  *pipeline.EvalNode
-	expressions        []stateful.Expression
+	e           *pipeline.EvalNode
+	expressions []stateful.Expression
+	// Protects the expressions by group while they are snapshotted.
+	mu                 sync.Mutex
 	expressionsByGroup map[models.GroupID][]stateful.Expression
 	scopePool          stateful.ScopePool
 
@@ -53,6 +56,11 @@ func newEvalNode(et *ExecutingTask, n *pipeline.EvalNode, l *log.Logger) (*EvalN
 }
 
 func (e *EvalNode) runEval(snapshot []byte) error {
+	if snapshot != nil {
+		if err := e.restore(snapshot); err != nil {
+			e.logger.Println("E! failed to restore snapshot:", err)
+		}
+	}
 	e.evalErrors = &expvar.Int{}
 	e.statMap.Set(statsEvalErrors, e.evalErrors)
 	switch e.Provides() {
@@ -111,18 +119,13 @@ func (e *EvalNode) runEval(snapshot []byte) error {
 func (e *EvalNode) eval(now time.Time, group models.GroupID, fields models.Fields, tags map[string]string) (models.Fields, error) {
 	vars := e.scopePool.Get()
 	defer e.scopePool.Put(vars)
-	err := fillScope(vars, e.scopePool.ReferenceVariables(), now, fields, tags)
+	err := fillScope(vars, e.scopePool.ReferenceVariables(), now, e.Location(), fields, tags)
 	if err != nil {
 		return nil, err
 	}
-	expressions, ok := e.expressionsByGroup[group]
-	if !ok {
-		expressions = make([]stateful.Expression, len(e.expressions))
-		for i, exp := range e.expressions {
-			expressions[i] = exp.CopyReset()
-		}
-		e.expressionsByGroup[group] = expressions
-	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	expressions := e.groupExpressions(group)
 	for i, expr := range expressions {
 		v, err := expr.Eval(vars)
 		if err != nil {
@@ -167,3 +170,31 @@ func (e *EvalNode) eval(now time.Time, group models.GroupID, fields models.Field
 	}
 	return newFields, nil
 }
+
+// The expressions of the group, copied for the first point of the group.
+// The caller must hold mu.
+func (e *EvalNode) groupExpressions(group models.GroupID) []stateful.Expression {
+	expressions, ok := e.expressionsByGroup[group]
+	if !ok {
+		expressions = make([]stateful.Expression, len(e.expressions))
+		for i, exp := range e.expressions {
+			expressions[i] = exp.CopyReset()
+		}
+		e.expressionsByGroup[group] = expressions
+	}
+	return expressions
+}
+
+func (e *EvalNode) snapshot() ([]byte, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return snapshotExpressions(e.expressionsByGroup)
+}
+
+func (e *EvalNode) restore(snapshot []byte) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return restoreExpressions(snapshot, func(group models.GroupID) ([]stateful.Expression, error) {
+		return e.groupExpressions(group), nil
+	})
+}
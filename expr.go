@@ -1,6 +1,8 @@
 package kapacitor
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
@@ -10,10 +12,10 @@ import (
 )
 
 // EvalPredicate - Evaluate a given expression as a boolean predicate against a set of fields and tags
-func EvalPredicate(se stateful.Expression, scopePool stateful.ScopePool, now time.Time, fields models.Fields, tags models.Tags) (bool, error) {
+func EvalPredicate(se stateful.Expression, scopePool stateful.ScopePool, now time.Time, loc *time.Location, fields models.Fields, tags models.Tags) (bool, error) {
 	vars := scopePool.Get()
 	defer scopePool.Put(vars)
-	err := fillScope(vars, scopePool.ReferenceVariables(), now, fields, tags)
+	err := fillScope(vars, scopePool.ReferenceVariables(), now, loc, fields, tags)
 	if err != nil {
 		return false, err
 	}
@@ -26,10 +28,14 @@ func EvalPredicate(se stateful.Expression, scopePool stateful.ScopePool, now tim
 }
 
 // fillScope - given a scope and reference variables, we fill the exact variables from the now, fields and tags.
-func fillScope(vars *tick.Scope, referenceVariables []string, now time.Time, fields models.Fields, tags models.Tags) error {
+// The time is in the time zone loc, or the local time zone if loc is nil.
+func fillScope(vars *tick.Scope, referenceVariables []string, now time.Time, loc *time.Location, fields models.Fields, tags models.Tags) error {
+	if loc == nil {
+		loc = time.Local
+	}
 	for _, refVariableName := range referenceVariables {
 		if refVariableName == "time" {
-			vars.Set("time", now.Local())
+			vars.Set("time", now.In(loc))
 			continue
 		}
 
@@ -50,9 +56,68 @@ func fillScope(vars *tick.Scope, referenceVariables []string, now time.Time, fie
 			vars.Set(refVariableName, tagValue)
 		}
 		if !isFieldExists && !isTagExists {
-			return fmt.Errorf("no field or tag exists for %s", refVariableName)
+			// Missing values are nil so they can be checked with isPresent or '??'.
+			vars.Set(refVariableName, nil)
+		}
+	}
+
+	return nil
+}
+
+// The state of the stateful expressions of a node by group, see stateful.Expression.Snapshot.
+type expressionsSnapshot map[models.GroupID][][]byte
+
+// Snapshot the expressions of each group.
+// Returns nil if none of the expressions has state.
+func snapshotExpressions(byGroup map[models.GroupID][]stateful.Expression) ([]byte, error) {
+	snapshot := make(expressionsSnapshot)
+	for group, expressions := range byGroup {
+		states := make([][]byte, len(expressions))
+		hasState := false
+		for i, se := range expressions {
+			if se == nil {
+				continue
+			}
+			data, err := se.Snapshot()
+			if err != nil {
+				return nil, err
+			}
+			states[i] = data
+			hasState = hasState || data != nil
 		}
+		if hasState {
+			snapshot[group] = states
+		}
+	}
+	if len(snapshot) == 0 {
+		return nil, nil
 	}
+	return json.Marshal(snapshot)
+}
 
+// Restore the expressions of each group from a snapshot,
+// groupExpressions returns the expressions of a group, creating them if needed.
+func restoreExpressions(data []byte, groupExpressions func(group models.GroupID) ([]stateful.Expression, error)) error {
+	var snapshot expressionsSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return fmt.Errorf("invalid expressions snapshot: %v", err)
+	}
+	for group, states := range snapshot {
+		expressions, err := groupExpressions(group)
+		if err != nil {
+			return err
+		}
+		if len(expressions) != len(states) {
+			return errors.New("expressions changed, not using snapshot")
+		}
+		for i, state := range states {
+			if state == nil || expressions[i] == nil {
+				continue
+			}
+			if err := expressions[i].Restore(state); err != nil {
+				return err
+			}
+		}
+	}
 	return nil
 }
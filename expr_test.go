@@ -0,0 +1,136 @@
+package kapacitor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick"
+	"github.com/influxdata/kapacitor/tick/stateful"
+)
+
+func newTestWhereNode(expression tick.Node) *WhereNode {
+	return &WhereNode{
+		w:           &pipeline.WhereNode{Expression: expression},
+		expressions: make(map[models.GroupID]stateful.Expression),
+		scopePools:  make(map[models.GroupID]stateful.ScopePool),
+	}
+}
+
+func TestWhereNode_SnapshotRestore(t *testing.T) {
+	// Pass points whose value is above the running mean of their group.
+	expression := &tick.BinaryNode{
+		Operator: tick.TokenGreater,
+		Left:     &tick.ReferenceNode{Reference: "value"},
+		Right: &tick.FunctionNode{
+			Func: "mean",
+			Args: []tick.Node{&tick.ReferenceNode{Reference: "value"}},
+		},
+	}
+	now := time.Date(2016, 3, 27, 0, 0, 0, 0, time.UTC)
+	eval := func(w *WhereNode, group models.GroupID, value float64) bool {
+		expr, scopePool, err := w.groupExpression(group)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pass, err := EvalPredicate(expr, scopePool, now, nil, models.Fields{"value": value}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return pass
+	}
+
+	w := newTestWhereNode(expression)
+	eval(w, "a", 10)
+	eval(w, "a", 20)
+	eval(w, "b", 100)
+	snapshot, err := w.snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	restored := newTestWhereNode(expression)
+	if err := restored.restore(snapshot); err != nil {
+		t.Fatal(err)
+	}
+	// The mean of group a is 15 and of group b is 100 after restoring.
+	if got := eval(restored, "a", 18); !got {
+		t.Error("expected 18 to be above the restored mean of group a")
+	}
+	if got := eval(restored, "b", 90); got {
+		t.Error("expected 90 to be below the restored mean of group b")
+	}
+	// New groups start without state.
+	if got := eval(restored, "c", 1); got {
+		t.Error("expected the first value of group c to equal its mean")
+	}
+}
+
+func TestSnapshotExpressions_Stateless(t *testing.T) {
+	w := newTestWhereNode(&tick.BinaryNode{
+		Operator: tick.TokenGreater,
+		Left:     &tick.ReferenceNode{Reference: "value"},
+		Right:    &tick.NumberNode{IsFloat: true, Float64: 10},
+	})
+	if _, _, err := w.groupExpression("a"); err != nil {
+		t.Fatal(err)
+	}
+	snapshot, err := w.snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Nodes without state do not cause task snapshots to be saved.
+	if snapshot != nil {
+		t.Errorf("unexpected snapshot: %s", snapshot)
+	}
+}
+
+func TestAlertNode_SnapshotRestoreGroups(t *testing.T) {
+	// Critical on the third point of each group.
+	crit := &tick.BinaryNode{
+		Operator: tick.TokenEqual,
+		Left:     &tick.FunctionNode{Func: "count"},
+		Right:    &tick.NumberNode{IsInt: true, Int64: 3},
+	}
+	newAlertNode := func() *AlertNode {
+		se, err := stateful.NewExpression(crit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		a := &AlertNode{
+			node:          node{Node: &pipeline.AlertNode{}},
+			levels:        make([]stateful.Expression, CritAlert+1),
+			scopePools:    make([]stateful.ScopePool, CritAlert+1),
+			levelsByGroup: make(map[models.GroupID][]stateful.Expression),
+		}
+		a.levels[CritAlert] = se
+		a.scopePools[CritAlert] = stateful.NewScopePool(stateful.FindReferenceVariables(crit))
+		return a
+	}
+	now := time.Date(2016, 3, 27, 0, 0, 0, 0, time.UTC)
+	level := func(a *AlertNode, group models.GroupID) AlertLevel {
+		return a.determineLevel(now, group, models.Fields{"value": 1.0}, nil)
+	}
+
+	a := newAlertNode()
+	level(a, "a")
+	level(a, "a")
+	level(a, "b")
+	snapshot, err := a.snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	restored := newAlertNode()
+	if err := restored.restore(snapshot); err != nil {
+		t.Fatal(err)
+	}
+	// The count is kept for each group, only group a reaches 3.
+	if got := level(restored, "a"); got != CritAlert {
+		t.Errorf("unexpected level of group a: got %v exp %v", got, CritAlert)
+	}
+	if got := level(restored, "b"); got != OKAlert {
+		t.Errorf("unexpected level of group b: got %v exp %v", got, OKAlert)
+	}
+}
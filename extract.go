@@ -0,0 +1,190 @@
+package kapacitor
+
+import (
+	"log"
+	"sort"
+
+	"github.com/influxdata/kapacitor/expvar"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+const (
+	statsPointsUnmatched = "points_unmatched"
+)
+
+type ExtractNode struct {
+	node
+	e *pipeline.ExtractNode
+
+	// The named capture groups of the pattern.
+	captures []capture
+	// The sorted names of the capture groups written as tags.
+	tags []string
+
+	pointsUnmatched *expvar.Int
+}
+
+type capture struct {
+	// The index of the capture group in the pattern.
+	index int
+	name  string
+	tag   bool
+}
+
+// Create a new ExtractNode which extracts the named capture groups of a regular expression into tags or fields.
+func newExtractNode(et *ExecutingTask, n *pipeline.ExtractNode, l *log.Logger) (*ExtractNode, error) {
+	en := &ExtractNode{
+		node: node{Node: n, et: et, logger: l},
+		e:    n,
+	}
+	isTag := make(map[string]bool, len(n.TagsList))
+	for _, tag := range n.TagsList {
+		isTag[tag] = true
+	}
+	for i, name := range n.Pattern.SubexpNames() {
+		if name == "" {
+			continue
+		}
+		en.captures = append(en.captures, capture{index: i, name: name, tag: isTag[name]})
+	}
+	for tag := range isTag {
+		en.tags = append(en.tags, tag)
+	}
+	sort.Strings(en.tags)
+	en.node.runF = en.runExtract
+	return en, nil
+}
+
+func (e *ExtractNode) runExtract([]byte) error {
+	e.pointsUnmatched = &expvar.Int{}
+	e.statMap.Set(statsPointsUnmatched, e.pointsUnmatched)
+
+	switch e.Provides() {
+	case pipeline.StreamEdge:
+		for p, ok := e.ins[0].NextPoint(); ok; p, ok = e.ins[0].NextPoint() {
+			e.timer.Start()
+			p.Fields, p.Tags = e.extract(p.Fields, p.Tags)
+			if e.e.RegroupFlag {
+				dims := e.dimensions(p.Dimensions)
+				p.Group = models.TagsToGroupID(dims, p.Tags)
+				p.Dimensions = dims
+			}
+			e.timer.Stop()
+			for _, child := range e.outs {
+				err := child.CollectPoint(p)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	case pipeline.BatchEdge:
+		for b, ok := e.ins[0].NextBatch(); ok; b, ok = e.ins[0].NextBatch() {
+			e.timer.Start()
+			for i := range b.Points {
+				b.Points[i].Fields, b.Points[i].Tags = e.extract(b.Points[i].Fields, b.Points[i].Tags)
+			}
+			batches := []models.Batch{b}
+			if e.e.RegroupFlag && len(b.Points) > 0 {
+				batches = e.regroupBatch(b)
+			}
+			e.timer.Stop()
+			for _, batch := range batches {
+				for _, child := range e.outs {
+					err := child.CollectBatch(batch)
+					if err != nil {
+						return err
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Extract the capture groups from the source tag or field.
+// The fields and tags are returned unchanged if the source is missing or does not match.
+func (e *ExtractNode) extract(fields models.Fields, tags models.Tags) (models.Fields, models.Tags) {
+	value, ok := tags[e.e.Source]
+	if !ok {
+		value, ok = fields[e.e.Source].(string)
+	}
+	var matches []int
+	if ok {
+		matches = e.e.Pattern.FindStringSubmatchIndex(value)
+	}
+	if matches == nil {
+		e.pointsUnmatched.Add(1)
+		return fields, tags
+	}
+	newFields := fields
+	fieldsCopied := false
+	newTags := tags
+	tagsCopied := false
+	for _, c := range e.captures {
+		start, end := matches[2*c.index], matches[2*c.index+1]
+		// The capture group did not participate in the match.
+		if start < 0 {
+			continue
+		}
+		v := value[start:end]
+		if c.tag {
+			if !tagsCopied {
+				newTags = newTags.Copy()
+				tagsCopied = true
+			}
+			newTags[c.name] = v
+		} else {
+			if !fieldsCopied {
+				newFields = newFields.Copy()
+				fieldsCopied = true
+			}
+			newFields[c.name] = v
+		}
+	}
+	return newFields, newTags
+}
+
+// The sorted dimensions with the tags written added.
+func (e *ExtractNode) dimensions(dims []string) []string {
+	newDims := make([]string, len(dims), len(dims)+len(e.tags))
+	copy(newDims, dims)
+	for _, tag := range e.tags {
+		i := sort.SearchStrings(newDims, tag)
+		if i < len(newDims) && newDims[i] == tag {
+			continue
+		}
+		newDims = append(newDims, "")
+		copy(newDims[i+1:], newDims[i:])
+		newDims[i] = tag
+	}
+	return newDims
+}
+
+// Split the batch into a batch for each of the new groups of its points,
+// in the order the groups first occur.
+func (e *ExtractNode) regroupBatch(b models.Batch) []models.Batch {
+	dims := e.dimensions(models.SortedKeys(b.Tags))
+	var batches []models.Batch
+	index := make(map[models.GroupID]int)
+	for _, p := range b.Points {
+		groupID := models.TagsToGroupID(dims, p.Tags)
+		i, ok := index[groupID]
+		if !ok {
+			tags := make(models.Tags, len(dims))
+			for _, dim := range dims {
+				tags[dim] = p.Tags[dim]
+			}
+			i = len(batches)
+			index[groupID] = i
+			batches = append(batches, models.Batch{
+				Name:  b.Name,
+				Group: groupID,
+				TMax:  b.TMax,
+				Tags:  tags,
+			})
+		}
+		batches[i].Points = append(batches[i].Points, p)
+	}
+	return batches
+}
@@ -0,0 +1,165 @@
+package kapacitor
+
+import (
+	"log"
+	"math"
+	"time"
+
+	"github.com/influxdata/kapacitor/expvar"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+const (
+	statsPointsFilled  = "points_filled"
+	statsPointsSkipped = "points_skipped"
+)
+
+type FillNode struct {
+	node
+	f *pipeline.FillNode
+
+	pointsFilled  *expvar.Int
+	pointsSkipped *expvar.Int
+}
+
+// Create a new FillNode which generates points for gaps in the data.
+func newFillNode(et *ExecutingTask, n *pipeline.FillNode, l *log.Logger) (*FillNode, error) {
+	fn := &FillNode{
+		node: node{Node: n, et: et, logger: l},
+		f:    n,
+	}
+	fn.node.runF = fn.runFill
+	return fn, nil
+}
+
+func (f *FillNode) runFill([]byte) error {
+	f.pointsFilled = &expvar.Int{}
+	f.statMap.Set(statsPointsFilled, f.pointsFilled)
+	f.pointsSkipped = &expvar.Int{}
+	f.statMap.Set(statsPointsSkipped, f.pointsSkipped)
+
+	switch f.Provides() {
+	case pipeline.StreamEdge:
+		previous := make(map[models.GroupID]models.Point)
+		for p, ok := f.ins[0].NextPoint(); ok; p, ok = f.ins[0].NextPoint() {
+			f.timer.Start()
+			var filled []models.Point
+			if prev, ok := previous[p.Group]; ok {
+				for _, t := range f.gapTimes(prev.Time, p.Time) {
+					fp := prev
+					fp.Time = t
+					fp.Fields = f.fillFields(prev.Time, prev.Fields, p.Time, p.Fields, t)
+					filled = append(filled, fp)
+				}
+			}
+			previous[p.Group] = p
+			f.timer.Stop()
+			for _, fp := range append(filled, p) {
+				for _, child := range f.outs {
+					err := child.CollectPoint(fp)
+					if err != nil {
+						return err
+					}
+				}
+			}
+		}
+	case pipeline.BatchEdge:
+		for b, ok := f.ins[0].NextBatch(); ok; b, ok = f.ins[0].NextBatch() {
+			f.timer.Start()
+			b.Points = f.fillBatchPoints(b.Points)
+			f.timer.Stop()
+			for _, child := range f.outs {
+				err := child.CollectBatch(b)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Return the points with generated points for the gaps between them.
+func (f *FillNode) fillBatchPoints(points []models.BatchPoint) []models.BatchPoint {
+	if len(points) < 2 {
+		return points
+	}
+	filled := make([]models.BatchPoint, 1, len(points))
+	filled[0] = points[0]
+	for i := 1; i < len(points); i++ {
+		prev, next := points[i-1], points[i]
+		for _, t := range f.gapTimes(prev.Time, next.Time) {
+			filled = append(filled, models.BatchPoint{
+				Time:   t,
+				Fields: f.fillFields(prev.Time, prev.Fields, next.Time, next.Fields, t),
+				Tags:   prev.Tags,
+			})
+		}
+		filled = append(filled, next)
+	}
+	return filled
+}
+
+// Return the times of the points missing between two points.
+// A point is missing for every full interval without data after the previous point.
+// No points are returned for gaps missing more than the maximum number of points.
+func (f *FillNode) gapTimes(prev, next time.Time) []time.Time {
+	missing := int64(next.Sub(prev)/f.f.Every) - 1
+	if missing <= 0 {
+		return nil
+	}
+	if missing > f.f.MaxPoints {
+		f.pointsSkipped.Add(missing)
+		return nil
+	}
+	times := make([]time.Time, missing)
+	for i := range times {
+		times[i] = prev.Add(time.Duration(i+1) * f.f.Every)
+	}
+	f.pointsFilled.Add(missing)
+	return times
+}
+
+// Return the fields of a point generated at time t between the previous and next point.
+func (f *FillNode) fillFields(prevTime time.Time, prev models.Fields, nextTime time.Time, next models.Fields, t time.Time) models.Fields {
+	fields := make(models.Fields, len(prev))
+	for name, value := range prev {
+		switch v := f.f.Value.(type) {
+		case int64, float64:
+			fields[name] = v
+		case string:
+			switch v {
+			case "null":
+				fields[name] = nil
+			case "previous":
+				fields[name] = value
+			case "linear":
+				fields[name] = interpolate(value, next[name], float64(t.Sub(prevTime))/float64(nextTime.Sub(prevTime)))
+			}
+		}
+	}
+	return fields
+}
+
+// Interpolate linearly between two numeric values by the fraction.
+// If either value is not numeric the previous value is returned.
+func interpolate(prev, next interface{}, fraction float64) interface{} {
+	switch p := prev.(type) {
+	case float64:
+		switch n := next.(type) {
+		case float64:
+			return p + (n-p)*fraction
+		case int64:
+			return p + (float64(n)-p)*fraction
+		}
+	case int64:
+		switch n := next.(type) {
+		case int64:
+			return p + int64(math.Floor(float64(n-p)*fraction+0.5))
+		case float64:
+			return float64(p) + (n-float64(p))*fraction
+		}
+	}
+	return prev
+}
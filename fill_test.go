@@ -0,0 +1,104 @@
+package kapacitor
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/influxdata/kapacitor/expvar"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+func TestFillNode_BatchPoints(t *testing.T) {
+	point := func(s int, value interface{}) models.BatchPoint {
+		return models.BatchPoint{
+			Time:   time.Unix(int64(s), 0),
+			Fields: models.Fields{"value": value},
+			Tags:   models.Tags{"host": "A"},
+		}
+	}
+	points := []models.BatchPoint{
+		point(0, int64(0)),
+		point(10, int64(10)),
+		// Jitter smaller than the interval is not a gap.
+		point(25, int64(25)),
+		point(55, int64(40)),
+	}
+	testCases := []struct {
+		value interface{}
+		exp   []models.BatchPoint
+	}{
+		{
+			value: "null",
+			exp: []models.BatchPoint{
+				points[0], points[1], points[2],
+				point(35, nil),
+				point(45, nil),
+				points[3],
+			},
+		},
+		{
+			value: "previous",
+			exp: []models.BatchPoint{
+				points[0], points[1], points[2],
+				point(35, int64(25)),
+				point(45, int64(25)),
+				points[3],
+			},
+		},
+		{
+			value: "linear",
+			exp: []models.BatchPoint{
+				points[0], points[1], points[2],
+				point(35, int64(30)),
+				point(45, int64(35)),
+				points[3],
+			},
+		},
+		{
+			value: -1.0,
+			exp: []models.BatchPoint{
+				points[0], points[1], points[2],
+				point(35, -1.0),
+				point(45, -1.0),
+				points[3],
+			},
+		},
+	}
+	for _, tc := range testCases {
+		f := &FillNode{
+			f:             &pipeline.FillNode{Every: 10 * time.Second, Value: tc.value, MaxPoints: 1000},
+			pointsFilled:  &expvar.Int{},
+			pointsSkipped: &expvar.Int{},
+		}
+		if got := f.fillBatchPoints(points); !reflect.DeepEqual(got, tc.exp) {
+			t.Errorf("unexpected points for fill %v:\ngot %v\nexp %v", tc.value, got, tc.exp)
+		}
+		if got := f.pointsFilled.IntValue(); got != 2 {
+			t.Errorf("unexpected points filled for fill %v: got %d exp 2", tc.value, got)
+		}
+	}
+}
+
+func TestFillNode_MaxPoints(t *testing.T) {
+	f := &FillNode{
+		f:             &pipeline.FillNode{Every: time.Second, Value: "null", MaxPoints: 60},
+		pointsFilled:  &expvar.Int{},
+		pointsSkipped: &expvar.Int{},
+	}
+	start := time.Unix(0, 0)
+	if got := len(f.gapTimes(start, start.Add(61*time.Second))); got != 60 {
+		t.Errorf("unexpected points for a gap of 60 points: got %d exp 60", got)
+	}
+	// A gap of a day is not filled.
+	if got := f.gapTimes(start, start.Add(24*time.Hour)); got != nil {
+		t.Errorf("unexpected points for a gap of a day: got %d exp 0", len(got))
+	}
+	if got := f.pointsFilled.IntValue(); got != 60 {
+		t.Errorf("unexpected points filled: got %d exp 60", got)
+	}
+	if got, exp := f.pointsSkipped.IntValue(), int64(24*60*60-1); got != exp {
+		t.Errorf("unexpected points skipped: got %d exp %d", got, exp)
+	}
+}
@@ -326,6 +326,58 @@ batch
 	testBatcherWithOutput(t, "TestBatch_Default", script, 30*time.Second, er)
 }
 
+func TestBatch_Extract(t *testing.T) {
+
+	var script = `
+batch
+	|query('''
+		SELECT value
+		FROM "telegraf"."default".cpu
+''')
+		.period(10s)
+		.every(10s)
+	|extract('host', /^(?P<role>[a-z]+)-(?P<number>[0-9]+)\.(?P<dc>[a-z0-9]+)$/)
+		.tags('dc')
+		.regroup()
+	|sum('value')
+	|httpOut('TestBatch_Extract')
+`
+
+	er := kapacitor.Result{
+		Series: imodels.Rows{
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"dc": "dc3"},
+				Columns: []string{"time", "sum"},
+				Values: [][]interface{}{[]interface{}{
+					time.Date(1971, 1, 1, 0, 0, 6, 0, time.UTC),
+					4.0,
+				}},
+			},
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"dc": "dc1"},
+				Columns: []string{"time", "sum"},
+				Values: [][]interface{}{[]interface{}{
+					time.Date(1971, 1, 1, 0, 0, 6, 0, time.UTC),
+					2.0,
+				}},
+			},
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"dc": ""},
+				Columns: []string{"time", "sum"},
+				Values: [][]interface{}{[]interface{}{
+					time.Date(1971, 1, 1, 0, 0, 6, 0, time.UTC),
+					4.0,
+				}},
+			},
+		},
+	}
+
+	testBatcherWithOutput(t, "TestBatch_Extract", script, 30*time.Second, er)
+}
+
 func TestBatch_DoubleGroupBy(t *testing.T) {
 
 	var script = `
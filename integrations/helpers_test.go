@@ -8,6 +8,9 @@ import (
 	"net/http/httptest"
 	"os"
 	"reflect"
+	"sort"
+	"sync"
+	"testing"
 	"time"
 
 	client "github.com/influxdata/influxdb/client/v2"
@@ -154,6 +157,9 @@ func (ts taskStore) HasSnapshot(name string) bool
 func (ts taskStore) LoadSnapshot(name string) (*kapacitor.TaskSnapshot, error) {
 	return nil, errors.New("not implemented")
 }
+func (ts taskStore) LoadLibrary(id string) (string, error) {
+	return "", errors.New("not implemented")
+}
 
 type deadman struct {
 	interval  time.Duration
@@ -192,3 +198,30 @@ func (m *metaclient) Authenticate(username, password string) (ui *meta.UserInfo,
 func (m *metaclient) Users() ([]meta.UserInfo, error) {
 	return nil, errors.New("no user")
 }
+
+// Records values seen by a test server.
+// Alerts are sent to each handler independently,
+// so requests from different handlers arrive in no particular order.
+type seenValues struct {
+	mu     sync.Mutex
+	values []string
+}
+
+func (s *seenValues) add(v string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values = append(s.values, v)
+}
+
+// Check the values seen in any order.
+func (s *seenValues) check(t *testing.T, name string, exp ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	got := append([]string(nil), s.values...)
+	sort.Strings(got)
+	exp = append([]string(nil), exp...)
+	sort.Strings(exp)
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("unexpected %s got %v exp %v", name, got, exp)
+	}
+}
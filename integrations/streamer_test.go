@@ -1,17 +1,23 @@
 package integrations
 
 import (
+	"bufio"
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"image/png"
 	"io/ioutil"
 	"log"
 	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
 	"path"
 	"reflect"
+	"strings"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"text/template"
@@ -20,15 +26,23 @@ import (
 	"github.com/influxdata/influxdb/client"
 	"github.com/influxdata/influxdb/influxql"
 	imodels "github.com/influxdata/influxdb/models"
+	"github.com/influxdata/influxdb/toml"
 	"github.com/influxdata/kapacitor"
 	"github.com/influxdata/kapacitor/clock"
 	"github.com/influxdata/kapacitor/services/alerta"
+	"github.com/influxdata/kapacitor/services/alertmanager"
 	"github.com/influxdata/kapacitor/services/hipchat"
 	"github.com/influxdata/kapacitor/services/httpd"
+	"github.com/influxdata/kapacitor/services/jira"
 	"github.com/influxdata/kapacitor/services/opsgenie"
 	"github.com/influxdata/kapacitor/services/pagerduty"
+	"github.com/influxdata/kapacitor/services/pagerduty2"
 	"github.com/influxdata/kapacitor/services/sensu"
+	"github.com/influxdata/kapacitor/services/servicenow"
 	"github.com/influxdata/kapacitor/services/slack"
+	"github.com/influxdata/kapacitor/services/sparkline"
+	"github.com/influxdata/kapacitor/services/storage"
+	"github.com/influxdata/kapacitor/services/syslog"
 	"github.com/influxdata/kapacitor/services/talk"
 	"github.com/influxdata/kapacitor/services/victorops"
 	"github.com/influxdata/kapacitor/udf"
@@ -306,6 +320,206 @@ stream
 	testStreamerWithOutput(t, "TestStream_WindowMissing", script, 13*time.Second, er, nil, false)
 }
 
+func TestStream_WindowCount(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+		.groupBy('host')
+	|window()
+		.periodCount(4)
+		.everyCount(4)
+	|sum('value')
+	|httpOut('TestStream_WindowCount')
+`
+	er := kapacitor.Result{
+		Series: imodels.Rows{
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"host": "serverA"},
+				Columns: []string{"time", "sum"},
+				Values: [][]interface{}{[]interface{}{
+					time.Date(1971, 1, 1, 0, 0, 11, 0, time.UTC),
+					22.0,
+				}},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_WindowCount", script, 13*time.Second, er, nil, false)
+}
+
+func TestStream_WindowSession(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('requests')
+		.groupBy('host')
+	|window()
+		.session(5s)
+	|count('value')
+	|httpOut('TestStream_WindowSession')
+`
+	er := kapacitor.Result{
+		Series: imodels.Rows{
+			{
+				Name:    "requests",
+				Tags:    map[string]string{"host": "serverA"},
+				Columns: []string{"time", "count"},
+				Values: [][]interface{}{[]interface{}{
+					time.Date(1971, 1, 1, 0, 0, 11, 0, time.UTC),
+					2.0,
+				}},
+			},
+			{
+				Name:    "requests",
+				Tags:    map[string]string{"host": "serverB"},
+				Columns: []string{"time", "count"},
+				Values: [][]interface{}{[]interface{}{
+					time.Date(1971, 1, 1, 0, 0, 3, 0, time.UTC),
+					1.0,
+				}},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_WindowSession", script, 21*time.Second, er, nil, true)
+}
+
+func TestStream_WindowLateness(t *testing.T) {
+	var script = `
+var win = stream
+	|from()
+		.measurement('cpu')
+	|window()
+		.period(10s)
+		.every(10s)
+		.align()
+		.allowedLateness(5s)
+win
+	|late()
+	|httpOut('late')
+win
+	|sum('value')
+	|httpOut('TestStream_WindowLateness')
+`
+	// The point at 12s arrives late but within the allowed lateness.
+	er := kapacitor.Result{
+		Series: imodels.Rows{
+			{
+				Name:    "cpu",
+				Tags:    nil,
+				Columns: []string{"time", "sum"},
+				Values: [][]interface{}{[]interface{}{
+					time.Date(1971, 1, 1, 0, 0, 20, 0, time.UTC),
+					12.0,
+				}},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_WindowLateness", script, 27*time.Second, er, nil, false)
+}
+
+func TestStream_Fill(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+		.groupBy('host')
+	|fill(10s)
+		.value('linear')
+	|window()
+		.period(50s)
+		.every(50s)
+	|httpOut('TestStream_Fill')
+`
+	er := kapacitor.Result{
+		Series: imodels.Rows{
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"host": "serverA"},
+				Columns: []string{"time", "value"},
+				Values: [][]interface{}{
+					{time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC), 1.0},
+					{time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC), 2.0},
+					{time.Date(1971, 1, 1, 0, 0, 20, 0, time.UTC), 3.0},
+					{time.Date(1971, 1, 1, 0, 0, 30, 0, time.UTC), 4.0},
+					{time.Date(1971, 1, 1, 0, 0, 40, 0, time.UTC), 5.0},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_Fill", script, 51*time.Second, er, nil, false)
+}
+
+// Gaps missing more than maxPoints points are not filled.
+func TestStream_FillMaxPoints(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+		.groupBy('host')
+	|fill(10s)
+		.value('linear')
+		.maxPoints(1)
+	|window()
+		.period(90s)
+		.every(90s)
+	|httpOut('TestStream_FillMaxPoints')
+`
+	er := kapacitor.Result{
+		Series: imodels.Rows{
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"host": "serverA"},
+				Columns: []string{"time", "value"},
+				Values: [][]interface{}{
+					{time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC), 1.0},
+					{time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC), 2.0},
+					{time.Date(1971, 1, 1, 0, 0, 40, 0, time.UTC), 5.0},
+					{time.Date(1971, 1, 1, 0, 0, 50, 0, time.UTC), 6.0},
+					{time.Date(1971, 1, 1, 0, 1, 0, 0, time.UTC), 7.0},
+					{time.Date(1971, 1, 1, 0, 1, 10, 0, time.UTC), 8.0},
+					{time.Date(1971, 1, 1, 0, 1, 20, 0, time.UTC), 9.0},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_FillMaxPoints", script, 91*time.Second, er, nil, false)
+}
+
+func TestStream_FromRegex(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement(/^cpu_/)
+		.groupBy('host')
+	|window()
+		.period(10s)
+		.every(10s)
+	|count('value')
+	|httpOut('TestStream_FromRegex')
+`
+	er := kapacitor.Result{
+		Series: imodels.Rows{
+			{
+				Name:    "cpu_user",
+				Tags:    map[string]string{"host": "serverA"},
+				Columns: []string{"time", "count"},
+				Values: [][]interface{}{[]interface{}{
+					time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC),
+					2.0,
+				}},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_FromRegex", script, 11*time.Second, er, nil, false)
+}
+
 func TestStream_Window(t *testing.T) {
 
 	var script = `
@@ -627,6 +841,33 @@ stream
 	testStreamerWithOutput(t, "TestStream_EvalAllTypes", script, 2*time.Second, er, nil, false)
 }
 
+func TestStream_EvalMissing(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('types')
+	|eval(lambda: if(isPresent("value"), "value", -1.0), lambda: "region" ?? 'unknown')
+		.as('value', 'region')
+	|httpOut('TestStream_EvalMissing')
+`
+	er := kapacitor.Result{
+		Series: imodels.Rows{
+			{
+				Name:    "types",
+				Tags:    nil,
+				Columns: []string{"time", "region", "value"},
+				Values: [][]interface{}{[]interface{}{
+					time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC),
+					"unknown",
+					-1.0,
+				}},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_EvalMissing", script, 3*time.Second, er, nil, false)
+}
+
 func TestStream_EvalGroups(t *testing.T) {
 	var script = `
 stream
@@ -667,6 +908,108 @@ stream
 	testStreamerWithOutput(t, "TestStream_EvalGroups", script, 3*time.Second, er, nil, false)
 }
 
+func TestStream_EvalStatefulFunctions(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+		.groupBy('host')
+	|eval(
+		lambda: rate("value"),
+		lambda: delta("value"),
+		lambda: mean("value"),
+		lambda: spread("value"),
+		lambda: ewma("value", 0.5),
+		lambda: elapsedSince("value" > 50.0)
+	)
+		.as('rate', 'delta', 'mean', 'spread', 'ewma', 'elapsed')
+	|httpOut('TestStream_EvalStatefulFunctions')
+`
+	er := kapacitor.Result{
+		Series: imodels.Rows{
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"host": "serverA"},
+				Columns: []string{"time", "delta", "elapsed", "ewma", "mean", "rate", "spread"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC),
+						20.0,
+						2.0,
+						27.5,
+						23.333333333333336,
+						20.0,
+						30.0,
+					},
+				},
+			},
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"host": "serverB"},
+				Columns: []string{"time", "delta", "elapsed", "ewma", "mean", "rate", "spread"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC),
+						-30.0,
+						2.0,
+						77.5,
+						250.0 / 3,
+						-30.0,
+						40.0,
+					},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_EvalStatefulFunctions", script, 4*time.Second, er, nil, false)
+}
+
+func TestStream_AlertStatefulGroups(t *testing.T) {
+	// The state of count() is kept for each group,
+	// so both groups are critical on their third point.
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+		.groupBy('host')
+	|alert()
+		.crit(lambda: count() == 3)
+		.levelField('level')
+	|httpOut('TestStream_AlertStatefulGroups')
+`
+	er := kapacitor.Result{
+		Series: imodels.Rows{
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"host": "serverA"},
+				Columns: []string{"time", "level", "value"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC),
+						"CRITICAL",
+						40.0,
+					},
+				},
+			},
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"host": "serverB"},
+				Columns: []string{"time", "level", "value"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC),
+						"CRITICAL",
+						60.0,
+					},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_AlertStatefulGroups", script, 4*time.Second, er, nil, false)
+}
+
 func TestStream_Eval_Time(t *testing.T) {
 	var script = `
 stream
@@ -714,6 +1057,49 @@ stream
 	time.Local = local
 }
 
+func TestStream_Eval_Timezone(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('types')
+		.groupBy('group')
+		.timezone('America/New_York')
+	|eval(lambda: hour("time"), lambda: hour("time", 'Asia/Tokyo'))
+		.as('hour', 'tokyo_hour')
+	|httpOut('TestStream_Eval_Timezone')
+`
+	er := kapacitor.Result{
+		Series: imodels.Rows{
+			{
+				Name:    "types",
+				Tags:    map[string]string{"group": "A"},
+				Columns: []string{"time", "hour", "tokyo_hour"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 1, 0, 0, 0, time.UTC),
+						20.0,
+						10.0,
+					},
+				},
+			},
+			{
+				Name:    "types",
+				Tags:    map[string]string{"group": "B"},
+				Columns: []string{"time", "hour", "tokyo_hour"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 1, 0, 0, 0, time.UTC),
+						20.0,
+						10.0,
+					},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_Eval_Timezone", script, 2*time.Hour, er, nil, false)
+}
+
 func TestStream_Default(t *testing.T) {
 	var script = `
 stream
@@ -746,8 +1132,58 @@ stream
 	testStreamerWithOutput(t, "TestStream_Default", script, 15*time.Second, er, nil, false)
 }
 
-func TestStream_AllMeasurements(t *testing.T) {
-
+func TestStream_Extract(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+	|extract('host', /^(?P<role>[a-z]+)-(?P<number>[0-9]+)\.(?P<dc>[a-z0-9]+)$/)
+		.tags('role', 'dc')
+		.regroup()
+	|where(lambda: ("number" ?? '') != '13')
+	|window()
+		.period(10s)
+		.every(10s)
+	|sum('value')
+	|httpOut('TestStream_Extract')
+`
+	er := kapacitor.Result{
+		Series: imodels.Rows{
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"dc": "", "role": ""},
+				Columns: []string{"time", "sum"},
+				Values: [][]interface{}{[]interface{}{
+					time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC),
+					18.0,
+				}},
+			},
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"dc": "dc1", "role": "db"},
+				Columns: []string{"time", "sum"},
+				Values: [][]interface{}{[]interface{}{
+					time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC),
+					14.0,
+				}},
+			},
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"dc": "dc3", "role": "web"},
+				Columns: []string{"time", "sum"},
+				Values: [][]interface{}{[]interface{}{
+					time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC),
+					12.0,
+				}},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_Extract", script, 15*time.Second, er, nil, true)
+}
+
+func TestStream_AllMeasurements(t *testing.T) {
+
 	var script = `
 stream
 	|from()
@@ -996,6 +1432,38 @@ stream
 	testStreamerWithOutput(t, "TestStream_SimpleMR", script, 15*time.Second, er, nil, false)
 }
 
+func TestStream_VarWhereList(t *testing.T) {
+
+	var script = `
+var servers = ['serverA', 'serverC']
+stream
+	|from()
+		.measurement('cpu')
+		.where(lambda: "host" in servers)
+		.where(lambda: "host" not in ['serverB', 'serverC'])
+	|window()
+		.period(10s)
+		.every(10s)
+	|count('value')
+	|httpOut('TestStream_SimpleMR')
+`
+	er := kapacitor.Result{
+		Series: imodels.Rows{
+			{
+				Name:    "cpu",
+				Tags:    nil,
+				Columns: []string{"time", "count"},
+				Values: [][]interface{}{[]interface{}{
+					time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC),
+					10.0,
+				}},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_SimpleMR", script, 15*time.Second, er, nil, false)
+}
+
 func TestStream_GroupBy(t *testing.T) {
 
 	var script = `
@@ -1436,6 +1904,62 @@ cpu
 	testStreamerWithOutput(t, "TestStream_JoinN", script, 15*time.Second, er, nil, false)
 }
 
+func TestStream_LookupJoin(t *testing.T) {
+	var script = `
+var deploys = stream
+	|from()
+		.measurement('deploys')
+
+stream
+	|from()
+		.measurement('errors')
+		.groupBy('host')
+	|lookupJoin(deploys)
+		.on('host')
+		.as('deploy')
+		.ttl(10s)
+		.default('version', 'unknown')
+	|httpOut('TestStream_LookupJoin')
+`
+
+	er := kapacitor.Result{
+		Series: imodels.Rows{
+			{
+				Name:    "errors",
+				Tags:    map[string]string{"host": "serverA"},
+				Columns: []string{"time", "deploy.version", "value"},
+				Values: [][]interface{}{[]interface{}{
+					time.Date(1971, 1, 1, 0, 0, 12, 0, time.UTC),
+					"1.1",
+					8.0,
+				}},
+			},
+			{
+				Name:    "errors",
+				Tags:    map[string]string{"host": "serverB"},
+				Columns: []string{"time", "deploy.version", "value"},
+				Values: [][]interface{}{[]interface{}{
+					time.Date(1971, 1, 1, 0, 0, 11, 0, time.UTC),
+					"unknown",
+					7.0,
+				}},
+			},
+			{
+				Name:    "errors",
+				Tags:    map[string]string{"host": "serverC"},
+				Columns: []string{"time", "deploy.version", "value"},
+				Values: [][]interface{}{[]interface{}{
+					time.Date(1971, 1, 1, 0, 0, 7, 0, time.UTC),
+					"unknown",
+					6.0,
+				}},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_LookupJoin", script, 13*time.Second, er, nil, true)
+}
+
 func TestStream_JoinOn(t *testing.T) {
 	var script = `
 var errorsByServiceDC = stream
@@ -3433,49 +3957,1161 @@ func TestStream_AlertSensu(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	listen, err := net.ListenTCP("tcp", addr)
+	listen, err := net.ListenTCP("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listen.Close()
+	go func() {
+		for {
+			conn, err := listen.Accept()
+			if err != nil {
+				return
+			}
+			func() {
+				defer conn.Close()
+
+				atomic.AddInt32(&requestCount, 1)
+				type postData struct {
+					Name   string `json:"name"`
+					Source string `json:"source"`
+					Output string `json:"output"`
+					Status int    `json:"status"`
+				}
+				pd := postData{}
+				dec := json.NewDecoder(conn)
+				dec.Decode(&pd)
+
+				if exp := "Kapacitor"; pd.Source != exp {
+					t.Errorf("unexpected source got %s exp %s", pd.Source, exp)
+				}
+
+				if exp := "kapacitor.cpu.serverA is CRITICAL"; pd.Output != exp {
+					t.Errorf("unexpected text got %s exp %s", pd.Output, exp)
+				}
+
+				if exp := "kapacitor.cpu.serverA"; pd.Name != exp {
+					t.Errorf("unexpected text got %s exp %s", pd.Name, exp)
+				}
+
+				if exp := 2; pd.Status != exp {
+					t.Errorf("unexpected status got %v exp %v", pd.Status, exp)
+				}
+			}()
+		}
+	}()
+
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+		.where(lambda: "host" == 'serverA')
+		.groupBy('host')
+	|window()
+		.period(10s)
+		.every(10s)
+	|count('value')
+	|alert()
+		.id('kapacitor.{{ .Name }}.{{ index .Tags "host" }}')
+		.info(lambda: "count" > 6.0)
+		.warn(lambda: "count" > 7.0)
+		.crit(lambda: "count" > 8.0)
+		.sensu()
+`
+
+	clock, et, replayErr, tm := testStreamer(t, "TestStream_Alert", script, nil)
+	defer tm.Close()
+
+	c := sensu.NewConfig()
+	c.Addr = listen.Addr().String()
+	c.Source = "Kapacitor"
+	sl := sensu.NewService(c, logService.NewLogger("[test_sensu] ", log.LstdFlags))
+	tm.SensuService = sl
+
+	err = fastForwardTask(clock, et, replayErr, tm, 13*time.Second)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if rc := atomic.LoadInt32(&requestCount); rc != 1 {
+		t.Errorf("unexpected requestCount got %d exp 1", rc)
+	}
+}
+
+func TestStream_AlertSlack(t *testing.T) {
+	requestCount := int32(0)
+	channels := &seenValues{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requestCount, 1)
+		type postData struct {
+			Channel     string `json:"channel"`
+			Username    string `json:"username"`
+			Text        string `json:"text"`
+			Attachments []struct {
+				Fallback string `json:"fallback"`
+				Color    string `json:"color"`
+				Text     string `json:"text"`
+			} `json:"attachments"`
+		}
+		pd := postData{}
+		dec := json.NewDecoder(r.Body)
+		dec.Decode(&pd)
+		if exp := "/test/slack/url"; r.URL.String() != exp {
+			t.Errorf("unexpected url got %s exp %s", r.URL.String(), exp)
+		}
+		channels.add(pd.Channel)
+		if exp := "kapacitor"; pd.Username != exp {
+			t.Errorf("unexpected username got %s exp %s", pd.Username, exp)
+		}
+		if exp := ""; pd.Text != exp {
+			t.Errorf("unexpected text got %s exp %s", pd.Text, exp)
+		}
+		if len(pd.Attachments) != 1 {
+			t.Errorf("unexpected attachments got %v", pd.Attachments)
+		} else {
+			exp := "kapacitor/cpu/serverA is CRITICAL"
+			if pd.Attachments[0].Fallback != exp {
+				t.Errorf("unexpected fallback got %s exp %s", pd.Attachments[0].Fallback, exp)
+			}
+			if pd.Attachments[0].Text != exp {
+				t.Errorf("unexpected text got %s exp %s", pd.Attachments[0].Text, exp)
+			}
+			if exp := "danger"; pd.Attachments[0].Color != exp {
+				t.Errorf("unexpected color got %s exp %s", pd.Attachments[0].Color, exp)
+			}
+		}
+	}))
+	defer ts.Close()
+
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+		.where(lambda: "host" == 'serverA')
+		.groupBy('host')
+	|window()
+		.period(10s)
+		.every(10s)
+	|count('value')
+	|alert()
+		.id('kapacitor/{{ .Name }}/{{ index .Tags "host" }}')
+		.info(lambda: "count" > 6.0)
+		.warn(lambda: "count" > 7.0)
+		.crit(lambda: "count" > 8.0)
+		.slack()
+		.channel('#alerts')
+		.slack()
+		.channel('@jim')
+`
+
+	clock, et, replayErr, tm := testStreamer(t, "TestStream_Alert", script, nil)
+	defer tm.Close()
+
+	c := slack.NewConfig()
+	c.URL = ts.URL + "/test/slack/url"
+	c.Channel = "#channel"
+	sl := slack.NewService(c, logService.NewLogger("[test_slack] ", log.LstdFlags))
+	tm.SlackService = sl
+
+	err := fastForwardTask(clock, et, replayErr, tm, 13*time.Second)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if rc := atomic.LoadInt32(&requestCount); rc != 2 {
+		t.Errorf("unexpected requestCount got %d exp 2", rc)
+	}
+	channels.check(t, "channels", "#alerts", "@jim")
+}
+
+func TestStream_AlertSlackThread(t *testing.T) {
+	type field struct {
+		Title string `json:"title"`
+		Value string `json:"value"`
+		Short bool   `json:"short"`
+	}
+	type postData struct {
+		Channel     string `json:"channel"`
+		Username    string `json:"username"`
+		IconEmoji   string `json:"icon_emoji"`
+		ThreadTS    string `json:"thread_ts"`
+		Attachments []struct {
+			Color  string  `json:"color"`
+			Title  string  `json:"title"`
+			Text   string  `json:"text"`
+			Fields []field `json:"fields"`
+		} `json:"attachments"`
+	}
+	// Recoveries reply to the message that started the thread.
+	expPosts := []struct {
+		threadTS string
+		level    string
+		color    string
+		emoji    string
+	}{
+		{threadTS: "", level: "CRITICAL", color: "#ff0000", emoji: ":fire:"},
+		{threadTS: "1", level: "WARNING", color: "warning"},
+		{threadTS: "1", level: "OK", color: "good"},
+		{threadTS: "", level: "WARNING", color: "warning"},
+		{threadTS: "4", level: "OK", color: "good"},
+	}
+	requestCount := int32(0)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rc := atomic.AddInt32(&requestCount, 1)
+		pd := postData{}
+		dec := json.NewDecoder(r.Body)
+		dec.Decode(&pd)
+		fmt.Fprintf(w, `{"ok":true,"ts":"%d"}`, rc)
+
+		if exp, got := "Bearer test_token", r.Header.Get("Authorization"); got != exp {
+			t.Errorf("unexpected authorization got %s exp %s", got, exp)
+		}
+		if exp := "#ops"; pd.Channel != exp {
+			t.Errorf("unexpected channel got %s exp %s", pd.Channel, exp)
+		}
+		i := int(rc - 1)
+		if i >= len(expPosts) {
+			t.Errorf("unexpected request %d", rc)
+			return
+		}
+		exp := expPosts[i]
+		if pd.ThreadTS != exp.threadTS {
+			t.Errorf("unexpected thread_ts for request %d got %q exp %q", rc, pd.ThreadTS, exp.threadTS)
+		}
+		if pd.IconEmoji != exp.emoji {
+			t.Errorf("unexpected icon_emoji for request %d got %q exp %q", rc, pd.IconEmoji, exp.emoji)
+		}
+		if len(pd.Attachments) != 1 {
+			t.Errorf("unexpected attachments got %v", pd.Attachments)
+			return
+		}
+		a := pd.Attachments[0]
+		if a.Color != exp.color {
+			t.Errorf("unexpected color for request %d got %s exp %s", rc, a.Color, exp.color)
+		}
+		if exp := "cpu is " + exp.level; a.Title != exp {
+			t.Errorf("unexpected title got %s exp %s", a.Title, exp)
+		}
+		if exp := "kapacitor/cpu/serverA is " + exp.level; a.Text != exp {
+			t.Errorf("unexpected text got %s exp %s", a.Text, exp)
+		}
+		expFields := []field{
+			{Title: "Host", Value: "serverA", Short: true},
+			{Title: "Type", Value: "idle"},
+		}
+		if !reflect.DeepEqual(a.Fields, expFields) {
+			t.Errorf("unexpected fields got %v exp %v", a.Fields, expFields)
+		}
+	}))
+	defer ts.Close()
+
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+		.where(lambda: "host" == 'serverA')
+		.groupBy('host')
+	|alert()
+		.id('kapacitor/{{ .Name }}/{{ index .Tags "host" }}')
+		.warn(lambda: "value" > 7.0)
+		.crit(lambda: "value" > 8.0)
+		.stateChangesOnly()
+		.slack()
+		.workspace('ops')
+		.title('{{ .Name }} is {{ .Level }}')
+		.field('Type', '{{ index .Tags "type" }}')
+		.shortField('Host', '{{ index .Tags "host" }}')
+		.thread()
+`
+
+	clock, et, replayErr, tm := testStreamer(t, "TestStream_AlertDuration", script, nil)
+	defer tm.Close()
+
+	c := slack.NewConfig()
+	c.URL = ts.URL + "/test/slack/url"
+	c.Workspaces = []slack.WorkspaceConfig{{
+		Name:      "ops",
+		Channel:   "#ops",
+		Token:     "test_token",
+		WebAPIURL: ts.URL,
+		Colors:    map[string]string{"CRITICAL": "#ff0000"},
+		Emoji:     map[string]string{"CRITICAL": ":fire:"},
+	}}
+	if err := c.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	sl := slack.NewService(c, logService.NewLogger("[test_slack] ", log.LstdFlags))
+	tm.SlackService = sl
+
+	err := fastForwardTask(clock, et, replayErr, tm, 13*time.Second)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if exp, rc := len(expPosts), int(atomic.LoadInt32(&requestCount)); rc != exp {
+		t.Errorf("unexpected requestCount got %d exp %d", rc, exp)
+	}
+}
+
+type alertmanagerAlert struct {
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations"`
+	StartsAt    time.Time         `json:"startsAt"`
+	EndsAt      *time.Time        `json:"endsAt"`
+}
+
+func TestStream_AlertStates(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+		.where(lambda: "host" == 'serverA')
+		.groupBy('host')
+	|alert()
+		.id('kapacitor/{{ .Name }}/{{ index .Tags "host" }}')
+		.message('{{ .ID }} is {{ .Level }} value: {{ index .Fields "value" }}')
+		.warn(lambda: "value" > 6.0)
+		.crit(lambda: "value" > 8.0)
+`
+
+	clock, et, replayErr, tm := testStreamer(t, "TestStream_AlertDuration", script, nil)
+	defer tm.Close()
+
+	err := fastForwardTask(clock, et, replayErr, tm, 13*time.Second)
+	if err != nil {
+		t.Error(err)
+	}
+
+	exp := []kapacitor.AlertState{{
+		ID:      "kapacitor/cpu/serverA",
+		Task:    "TestStream_AlertDuration",
+		Node:    "alert2",
+		Level:   kapacitor.WarnAlert,
+		Since:   time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC),
+		Message: "kapacitor/cpu/serverA is WARNING value: 7",
+		Tags:    map[string]string{"host": "serverA", "type": "idle"},
+	}}
+	if got := tm.AlertStates(); !reflect.DeepEqual(got, exp) {
+		t.Errorf("unexpected alert states:\ngot\n%v\nexp\n%v", got, exp)
+	}
+}
+
+func TestStream_AlertSparkline(t *testing.T) {
+	checkSparkline := func(u string) {
+		resp, err := http.Get(u)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer resp.Body.Close()
+		if exp, got := http.StatusOK, resp.StatusCode; got != exp {
+			t.Errorf("unexpected status code got %d exp %d", got, exp)
+			return
+		}
+		if exp, got := "image/png", resp.Header.Get("Content-Type"); got != exp {
+			t.Errorf("unexpected content type got %s exp %s", got, exp)
+		}
+		img, err := png.Decode(resp.Body)
+		if err != nil {
+			t.Errorf("invalid sparkline image: %v", err)
+			return
+		}
+		if b := img.Bounds(); b.Dx() != 120 || b.Dy() != 30 {
+			t.Errorf("unexpected sparkline size %dx%d", b.Dx(), b.Dy())
+		}
+	}
+	var mu sync.Mutex
+	var urls []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ad := kapacitor.AlertData{}
+		dec := json.NewDecoder(r.Body)
+		if err := dec.Decode(&ad); err != nil {
+			t.Error(err)
+			return
+		}
+		mu.Lock()
+		urls = append(urls, ad.SparklineURL)
+		mu.Unlock()
+		checkSparkline(ad.SparklineURL)
+	}))
+	defer ts.Close()
+
+	ss := sparkline.NewService(logService.NewLogger("[test_sparkline] ", log.LstdFlags))
+	ss.HTTPDService = httpService
+	if err := ss.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer ss.Close()
+
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+		.where(lambda: "host" == 'serverA')
+		.groupBy('host')
+	|alert()
+		.id('kapacitor/{{ .Name }}/{{ index .Tags "host" }}')
+		.warn(lambda: "value" > 7.0)
+		.crit(lambda: "value" > 8.0)
+		.stateChangesOnly()
+		.sparkline('value')
+		.sparklinePoints(5)
+		.post('` + ts.URL + `')
+`
+
+	clock, et, replayErr, tm := testStreamer(t, "TestStream_AlertDuration", script, nil)
+	tm.SparklineService = ss
+
+	err := fastForwardTask(clock, et, replayErr, tm, 13*time.Second)
+	if err != nil {
+		t.Error(err)
+	}
+	tm.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if exp, got := 5, len(urls); got != exp {
+		t.Fatalf("got %v exp %v", got, exp)
+	}
+	// The URLs contain the values at the time of each alert
+	// and remain available after the task is stopped.
+	exp := httpService.URL() + "/sparkline.png?values=" + url.QueryEscape("9")
+	if urls[0] != exp {
+		t.Errorf("unexpected sparkline URL got %q exp %q", urls[0], exp)
+	}
+	for _, u := range urls {
+		checkSparkline(u)
+	}
+}
+
+func TestStream_AlertAlertmanager(t *testing.T) {
+	date := func(s int) time.Time {
+		return time.Date(1971, 1, 1, 0, 0, s, 0, time.UTC)
+	}
+	end := func(s int) *time.Time {
+		t := date(s)
+		return &t
+	}
+	// Each request resolves the previous alert if the severity changed.
+	expPosts := [][]struct {
+		severity string
+		startsAt time.Time
+		endsAt   *time.Time
+	}{
+		{{severity: "critical", startsAt: date(0)}},
+		{{severity: "critical", startsAt: date(0), endsAt: end(2)}, {severity: "warning", startsAt: date(2)}},
+		{{severity: "warning", startsAt: date(2), endsAt: end(4)}},
+		{{severity: "warning", startsAt: date(5)}},
+		{{severity: "warning", startsAt: date(5), endsAt: end(8)}},
+	}
+	requestCount := int32(0)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rc := atomic.AddInt32(&requestCount, 1)
+		if exp := "/test/am/api/v1/alerts"; r.URL.String() != exp {
+			t.Errorf("unexpected url got %s exp %s", r.URL.String(), exp)
+		}
+		var alerts []alertmanagerAlert
+		dec := json.NewDecoder(r.Body)
+		dec.Decode(&alerts)
+
+		i := int(rc - 1)
+		if i >= len(expPosts) {
+			t.Errorf("unexpected request %d", rc)
+			return
+		}
+		if len(alerts) != len(expPosts[i]) {
+			t.Errorf("unexpected number of alerts for request %d got %d exp %d", rc, len(alerts), len(expPosts[i]))
+			return
+		}
+		for j, exp := range expPosts[i] {
+			a := alerts[j]
+			expLabels := map[string]string{
+				"alertname": "HighCPU",
+				"severity":  exp.severity,
+				"host":      "serverA",
+				"type":      "idle",
+			}
+			if !reflect.DeepEqual(a.Labels, expLabels) {
+				t.Errorf("unexpected labels for request %d got %v exp %v", rc, a.Labels, expLabels)
+			}
+			if exp := "kapacitor/cpu/serverA"; a.Annotations["alert_id"] != exp {
+				t.Errorf("unexpected alert_id annotation got %s exp %s", a.Annotations["alert_id"], exp)
+			}
+			if exp := "kapacitor/cpu/serverA is " + strings.ToUpper(exp.severity); a.Annotations["summary"] != exp {
+				t.Errorf("unexpected summary annotation got %s exp %s", a.Annotations["summary"], exp)
+			}
+			if exp := "details"; a.Annotations["description"] != exp {
+				t.Errorf("unexpected description annotation got %s exp %s", a.Annotations["description"], exp)
+			}
+			if !a.StartsAt.Equal(exp.startsAt) {
+				t.Errorf("unexpected startsAt for request %d got %v exp %v", rc, a.StartsAt, exp.startsAt)
+			}
+			if !reflect.DeepEqual(a.EndsAt, exp.endsAt) {
+				t.Errorf("unexpected endsAt for request %d got %v exp %v", rc, a.EndsAt, exp.endsAt)
+			}
+		}
+	}))
+	defer ts.Close()
+
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+		.where(lambda: "host" == 'serverA')
+		.groupBy('host')
+	|alert()
+		.id('kapacitor/{{ .Name }}/{{ index .Tags "host" }}')
+		.details('details')
+		.warn(lambda: "value" > 7.0)
+		.crit(lambda: "value" > 8.0)
+		.stateChangesOnly()
+		.alertmanager()
+			.endpoint('test')
+			.alertName('HighCPU')
+`
+
+	clock, et, replayErr, tm := testStreamer(t, "TestStream_AlertDuration", script, nil)
+	defer tm.Close()
+
+	c := alertmanager.NewConfig()
+	c.ResendInterval = toml.Duration(time.Hour)
+	c.Endpoints = []alertmanager.EndpointConfig{{
+		Name: "test",
+		URL:  ts.URL + "/test/am/",
+	}}
+	if err := c.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	am := alertmanager.NewService(c, logService.NewLogger("[test_am] ", log.LstdFlags))
+	tm.AlertmanagerService = am
+
+	err := fastForwardTask(clock, et, replayErr, tm, 13*time.Second)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if exp, rc := len(expPosts), int(atomic.LoadInt32(&requestCount)); rc != exp {
+		t.Errorf("unexpected requestCount got %d exp %d", rc, exp)
+	}
+}
+
+func TestStream_AlertAlertmanagerResend(t *testing.T) {
+	posts := make(chan []alertmanagerAlert, 100)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var alerts []alertmanagerAlert
+		dec := json.NewDecoder(r.Body)
+		dec.Decode(&alerts)
+		posts <- alerts
+	}))
+	defer ts.Close()
+
+	c := alertmanager.NewConfig()
+	c.URL = ts.URL
+	c.ResendInterval = toml.Duration(10 * time.Millisecond)
+	am := alertmanager.NewService(c, logService.NewLogger("[test_am] ", log.LstdFlags))
+	am.Open()
+	defer am.Close()
+
+	start := time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC)
+	tags := map[string]string{"host": "serverA"}
+	if err := am.Alert("", "task/alert1", "id", "cpu", "cpu is CRITICAL", "", kapacitor.CritAlert, start, tags); err != nil {
+		t.Fatal(err)
+	}
+	// The initial post and two re-sends of the active alert.
+	for i := 0; i < 3; i++ {
+		select {
+		case alerts := <-posts:
+			if len(alerts) != 1 {
+				t.Fatalf("unexpected alerts for post %d got %v", i, alerts)
+			}
+			if !alerts[0].StartsAt.Equal(start) {
+				t.Errorf("unexpected startsAt for post %d got %v exp %v", i, alerts[0].StartsAt, start)
+			}
+			if alerts[0].EndsAt != nil {
+				t.Errorf("unexpected endsAt for post %d got %v", i, alerts[0].EndsAt)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for post %d", i)
+		}
+	}
+
+	if err := am.Alert("", "task/alert1", "id", "cpu", "cpu is OK", "", kapacitor.OKAlert, start.Add(time.Minute), tags); err != nil {
+		t.Fatal(err)
+	}
+	// Resolved alerts are no longer re-sent,
+	// skip the resolution and any re-send that raced with it.
+	drain := time.After(50 * time.Millisecond)
+	for drained := false; !drained; {
+		select {
+		case <-posts:
+		case <-drain:
+			drained = true
+		}
+	}
+	select {
+	case alerts := <-posts:
+		t.Errorf("unexpected post after resolution %v", alerts)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestStream_AlertAlertmanagerRetryResolve(t *testing.T) {
+	var mu sync.Mutex
+	fail := false
+	var posts [][]alertmanagerAlert
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if fail {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"error":"unavailable"}`))
+			return
+		}
+		var alerts []alertmanagerAlert
+		dec := json.NewDecoder(r.Body)
+		dec.Decode(&alerts)
+		posts = append(posts, alerts)
+	}))
+	defer ts.Close()
+	setFail := func(f bool) {
+		mu.Lock()
+		fail = f
+		mu.Unlock()
+	}
+
+	c := alertmanager.NewConfig()
+	c.URL = ts.URL
+	c.ResendInterval = toml.Duration(time.Hour)
+	am := alertmanager.NewService(c, logService.NewLogger("[test_am] ", log.LstdFlags))
+
+	start := time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC)
+	tags := map[string]string{"host": "serverA"}
+	if err := am.Alert("", "task/alert1", "id", "cpu", "cpu is CRITICAL", "", kapacitor.CritAlert, start, tags); err != nil {
+		t.Fatal(err)
+	}
+	// The resolution fails, so the retry must resolve the alert again.
+	setFail(true)
+	if err := am.Alert("", "task/alert1", "id", "cpu", "cpu is OK", "", kapacitor.OKAlert, start.Add(time.Minute), tags); err == nil {
+		t.Fatal("expected error posting resolution")
+	}
+	setFail(false)
+	if err := am.Alert("", "task/alert1", "id", "cpu", "cpu is OK", "", kapacitor.OKAlert, start.Add(time.Minute), tags); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(posts) != 2 {
+		t.Fatalf("unexpected posts got %v", posts)
+	}
+	if resolved := posts[1]; len(resolved) != 1 || resolved[0].EndsAt == nil || !resolved[0].EndsAt.Equal(start.Add(time.Minute)) {
+		t.Errorf("unexpected resolution got %v", resolved)
+	}
+}
+
+func TestStream_AlertAlertmanagerClear(t *testing.T) {
+	posts := make(chan []alertmanagerAlert, 100)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var alerts []alertmanagerAlert
+		dec := json.NewDecoder(r.Body)
+		dec.Decode(&alerts)
+		posts <- alerts
+	}))
+	defer ts.Close()
+
+	c := alertmanager.NewConfig()
+	c.URL = ts.URL
+	c.ResendInterval = toml.Duration(10 * time.Millisecond)
+	am := alertmanager.NewService(c, logService.NewLogger("[test_am] ", log.LstdFlags))
+
+	start := time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC)
+	if err := am.Alert("", "task/alert1", "id", "cpu", "cpu is CRITICAL", "", kapacitor.CritAlert, start, nil); err != nil {
+		t.Fatal(err)
+	}
+	<-posts
+	// The alerts of a stopped task are no longer re-sent.
+	am.Clear("task/alert1")
+	am.Open()
+	defer am.Close()
+	select {
+	case alerts := <-posts:
+		t.Errorf("unexpected post after clearing %v", alerts)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+// A request received by a ticketing system stub.
+type ticketRequest struct {
+	Method string
+	Path   string
+	Body   map[string]interface{}
+}
+
+// Return a stub server recording requests,
+// the reply function returns the response body for a request.
+func newTicketServer(t *testing.T, reply func(r ticketRequest) string) (*httptest.Server, *[]ticketRequest) {
+	var requests []ticketRequest
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tr := ticketRequest{
+			Method: r.Method,
+			Path:   r.URL.Path,
+		}
+		dec := json.NewDecoder(r.Body)
+		dec.Decode(&tr.Body)
+		requests = append(requests, tr)
+		if u, p, _ := r.BasicAuth(); u != "user" || p != "secret" {
+			t.Errorf("unexpected basic auth got %s:%s", u, p)
+		}
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(reply(tr)))
+	}))
+	return ts, &requests
+}
+
+const jiraTestScript = `
+stream
+	|from()
+		.measurement('cpu')
+		.where(lambda: "host" == 'serverA')
+		.groupBy('host')
+	|alert()
+		.id('kapacitor/{{ .Name }}/{{ index .Tags "host" }}')
+		.warn(lambda: "value" > 7.0)
+		.crit(lambda: "value" > 8.0)
+		.stateChangesOnly()
+		.jira()
+			.minLevel('CRITICAL')
+			.issueType('Incident')
+			.summary('{{ .Name }} on {{ index .Tags "host" }} is {{ .Level }}')
+			.description('value is {{ index .Fields "value" }}')
+			.field('labels', '{{ index .Tags "type" }}')
+`
+
+func newJiraTestConfig(url string) jira.Config {
+	c := jira.NewConfig()
+	c.Enabled = true
+	c.URL = url
+	c.Username = "user"
+	c.Token = "secret"
+	c.Project = "OPS"
+	c.ResolveTransitionID = "31"
+	return c
+}
+
+func TestStream_AlertJira(t *testing.T) {
+	ts, requests := newTicketServer(t, func(r ticketRequest) string {
+		if r.Path == "/rest/api/2/issue" {
+			return `{"id":"10000","key":"OPS-1"}`
+		}
+		return "{}"
+	})
+	defer ts.Close()
+
+	clock, et, replayErr, tm := testStreamer(t, "TestStream_AlertDuration", jiraTestScript, nil)
+	defer tm.Close()
+
+	c := newJiraTestConfig(ts.URL)
+	if err := c.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	js := jira.NewService(c, logService.NewLogger("[test_jira] ", log.LstdFlags))
+	tm.JiraService = js
+
+	err := fastForwardTask(clock, et, replayErr, tm, 13*time.Second)
+	if err != nil {
+		t.Error(err)
+	}
+
+	// The issue is opened once CRITICAL, commented on when WARNING and resolved when OK.
+	// The following WARNING is below the minimum level and does not open an issue.
+	exp := []ticketRequest{
+		{
+			Method: "POST",
+			Path:   "/rest/api/2/issue",
+			Body: map[string]interface{}{
+				"fields": map[string]interface{}{
+					"project":     map[string]interface{}{"key": "OPS"},
+					"issuetype":   map[string]interface{}{"name": "Incident"},
+					"summary":     "cpu on serverA is CRITICAL",
+					"description": "value is 9",
+					"labels":      "idle",
+				},
+			},
+		},
+		{
+			Method: "POST",
+			Path:   "/rest/api/2/issue/OPS-1/comment",
+			Body:   map[string]interface{}{"body": "Alert kapacitor/cpu/serverA changed from CRITICAL to WARNING: kapacitor/cpu/serverA is WARNING"},
+		},
+		{
+			Method: "POST",
+			Path:   "/rest/api/2/issue/OPS-1/transitions",
+			Body: map[string]interface{}{
+				"transition": map[string]interface{}{"id": "31"},
+				"update": map[string]interface{}{
+					"comment": []interface{}{
+						map[string]interface{}{
+							"add": map[string]interface{}{"body": "Alert kapacitor/cpu/serverA recovered: kapacitor/cpu/serverA is OK"},
+						},
+					},
+				},
+			},
+		},
+	}
+	if !reflect.DeepEqual(*requests, exp) {
+		t.Errorf("unexpected requests:\ngot %v\nexp %v", *requests, exp)
+	}
+}
+
+func TestStream_AlertJiraRestart(t *testing.T) {
+	ts, requests := newTicketServer(t, func(r ticketRequest) string {
+		if r.Path == "/rest/api/2/issue" {
+			return `{"id":"10000","key":"OPS-1"}`
+		}
+		return "{}"
+	})
+	defer ts.Close()
+
+	dir, err := ioutil.TempDir("", "kapacitor_jira")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	sc := storage.NewConfig()
+	sc.BoltDBPath = path.Join(dir, "kapacitor.db")
+	store := storage.NewService(sc, logService.NewLogger("[test_storage] ", log.LstdFlags))
+	if err := store.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	// Open the issue before the restart.
+	c := newJiraTestConfig(ts.URL)
+	js := jira.NewService(c, logService.NewLogger("[test_jira] ", log.LstdFlags))
+	js.StorageService = store
+	js.Open()
+	err = js.Alert("", "Incident", "kapacitor/cpu/serverA", "kapacitor/cpu/serverA is CRITICAL", "summary", "", nil, kapacitor.CritAlert, kapacitor.CritAlert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	js.Close()
+	if exp, got := 1, len(*requests); got != exp {
+		t.Fatalf("unexpected number of requests got %d exp %d", got, exp)
+	}
+
+	clock, et, replayErr, tm := testStreamer(t, "TestStream_AlertDuration", jiraTestScript, nil)
+	defer tm.Close()
+
+	js = jira.NewService(c, logService.NewLogger("[test_jira] ", log.LstdFlags))
+	js.StorageService = store
+	js.Open()
+	tm.JiraService = js
+
+	err = fastForwardTask(clock, et, replayErr, tm, 13*time.Second)
+	if err != nil {
+		t.Error(err)
+	}
+
+	// The stored issue is reused instead of opening a duplicate.
+	var paths []string
+	for _, r := range (*requests)[1:] {
+		paths = append(paths, r.Path)
+	}
+	exp := []string{
+		"/rest/api/2/issue/OPS-1/comment",
+		"/rest/api/2/issue/OPS-1/transitions",
+	}
+	if !reflect.DeepEqual(paths, exp) {
+		t.Errorf("unexpected request paths:\ngot %v\nexp %v", paths, exp)
+	}
+}
+
+func TestStream_AlertJiraProjects(t *testing.T) {
+	issues := 0
+	ts, requests := newTicketServer(t, func(r ticketRequest) string {
+		if r.Path == "/rest/api/2/issue" {
+			issues++
+			return fmt.Sprintf(`{"id":"1000%d","key":"ISSUE-%d"}`, issues, issues)
+		}
+		return "{}"
+	})
+	defer ts.Close()
+
+	js := jira.NewService(newJiraTestConfig(ts.URL), logService.NewLogger("[test_jira] ", log.LstdFlags))
+	js.Open()
+	defer js.Close()
+
+	// Each project has its own issue for the alert.
+	for _, project := range []string{"OPS", "DEV"} {
+		err := js.Alert(project, "", "kapacitor/cpu/serverA", "kapacitor/cpu/serverA is CRITICAL", "summary", "", nil, kapacitor.CritAlert, kapacitor.CritAlert)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, project := range []string{"OPS", "DEV"} {
+		err := js.Alert(project, "", "kapacitor/cpu/serverA", "kapacitor/cpu/serverA is OK", "summary", "", nil, kapacitor.OKAlert, kapacitor.CritAlert)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var paths []string
+	for _, r := range *requests {
+		paths = append(paths, r.Path)
+	}
+	exp := []string{
+		"/rest/api/2/issue",
+		"/rest/api/2/issue",
+		"/rest/api/2/issue/ISSUE-1/transitions",
+		"/rest/api/2/issue/ISSUE-2/transitions",
+	}
+	if !reflect.DeepEqual(paths, exp) {
+		t.Errorf("unexpected request paths:\ngot %v\nexp %v", paths, exp)
+	}
+}
+
+func TestStream_AlertJiraBlockedAlert(t *testing.T) {
+	hanging := make(chan struct{})
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if strings.Contains(string(body), "SLOW") {
+			// Hang until the test is done.
+			close(hanging)
+			<-release
+		}
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":"10000","key":"ISSUE-1"}`))
+	}))
+	defer ts.Close()
+	defer close(release)
+
+	js := jira.NewService(newJiraTestConfig(ts.URL), logService.NewLogger("[test_jira] ", log.LstdFlags))
+	js.Open()
+	defer js.Close()
+
+	go js.Alert("SLOW", "", "kapacitor/cpu/serverA", "kapacitor/cpu/serverA is CRITICAL", "summary", "", nil, kapacitor.CritAlert, kapacitor.CritAlert)
+	<-hanging
+
+	// A hanging request for one alert does not hold up the other alerts.
+	done := make(chan error, 1)
+	go func() {
+		done <- js.Alert("OPS", "", "kapacitor/cpu/serverB", "kapacitor/cpu/serverB is CRITICAL", "summary", "", nil, kapacitor.CritAlert, kapacitor.CritAlert)
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("alert blocked by the hanging request of another alert")
+	}
+}
+
+func TestStream_AlertJiraRetryResolve(t *testing.T) {
+	failResolve := true
+	var requests []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.Path)
+		switch {
+		case r.URL.Path == "/rest/api/2/issue":
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id":"10000","key":"OPS-1"}`))
+		case failResolve:
+			failResolve = false
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"errorMessages":["unavailable"]}`))
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer ts.Close()
+
+	js := jira.NewService(newJiraTestConfig(ts.URL), logService.NewLogger("[test_jira] ", log.LstdFlags))
+	js.Open()
+	defer js.Close()
+
+	err := js.Alert("", "", "kapacitor/cpu/serverA", "kapacitor/cpu/serverA is CRITICAL", "summary", "", nil, kapacitor.CritAlert, kapacitor.CritAlert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The failed resolution is retried without commenting twice.
+	err = js.Alert("", "", "kapacitor/cpu/serverA", "kapacitor/cpu/serverA is OK", "summary", "", nil, kapacitor.OKAlert, kapacitor.CritAlert)
+	if err == nil {
+		t.Fatal("expected error resolving issue")
+	}
+	err = js.Alert("", "", "kapacitor/cpu/serverA", "kapacitor/cpu/serverA is OK", "summary", "", nil, kapacitor.OKAlert, kapacitor.CritAlert)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp := []string{
+		"/rest/api/2/issue",
+		"/rest/api/2/issue/OPS-1/transitions",
+		"/rest/api/2/issue/OPS-1/transitions",
+	}
+	if !reflect.DeepEqual(requests, exp) {
+		t.Errorf("unexpected request paths:\ngot %v\nexp %v", requests, exp)
+	}
+}
+
+// A store failing to store values after a number of successful puts.
+type failingPutStore struct {
+	storage.Interface
+	puts int
+}
+
+func (s *failingPutStore) Put(key string, value []byte) error {
+	if s.puts == 0 {
+		return errors.New("put failed")
+	}
+	s.puts--
+	return s.Interface.Put(key, value)
+}
+
+type failingPutStorageService struct {
+	store *failingPutStore
+}
+
+func (s failingPutStorageService) Store(namespace string) storage.Interface {
+	return s.store
+}
+
+func TestStream_AlertJiraPendingOpen(t *testing.T) {
+	ts, requests := newTicketServer(t, func(r ticketRequest) string {
+		if r.Path == "/rest/api/2/issue" {
+			return `{"id":"10000","key":"OPS-1"}`
+		}
+		return "{}"
+	})
+	defer ts.Close()
+
+	dir, err := ioutil.TempDir("", "kapacitor_jira")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	sc := storage.NewConfig()
+	sc.BoltDBPath = path.Join(dir, "kapacitor.db")
+	ss := storage.NewService(sc, logService.NewLogger("[test_storage] ", log.LstdFlags))
+	if err := ss.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer ss.Close()
+
+	// The pending state is stored but the opened issue is not.
+	store := &failingPutStore{Interface: ss.Store("jira_tickets"), puts: 1}
+	js := jira.NewService(newJiraTestConfig(ts.URL), logService.NewLogger("[test_jira] ", log.LstdFlags))
+	js.StorageService = failingPutStorageService{store: store}
+	js.Open()
+	defer js.Close()
+
+	err = js.Alert("", "", "kapacitor/cpu/serverA", "kapacitor/cpu/serverA is CRITICAL", "summary", "", nil, kapacitor.CritAlert, kapacitor.CritAlert)
+	if err == nil {
+		t.Fatal("expected error storing issue")
+	}
+	// A retry does not open a duplicate issue.
+	err = js.Alert("", "", "kapacitor/cpu/serverA", "kapacitor/cpu/serverA is CRITICAL", "summary", "", nil, kapacitor.CritAlert, kapacitor.CritAlert)
 	if err != nil {
 		t.Fatal(err)
 	}
-	defer listen.Close()
-	go func() {
-		for {
-			conn, err := listen.Accept()
-			if err != nil {
-				return
-			}
-			func() {
-				defer conn.Close()
+	if exp, got := 1, len(*requests); got != exp {
+		t.Errorf("unexpected number of requests got %d exp %d", got, exp)
+	}
+}
 
-				atomic.AddInt32(&requestCount, 1)
-				type postData struct {
-					Name   string `json:"name"`
-					Source string `json:"source"`
-					Output string `json:"output"`
-					Status int    `json:"status"`
-				}
-				pd := postData{}
-				dec := json.NewDecoder(conn)
-				dec.Decode(&pd)
+func TestStream_AlertServiceNow(t *testing.T) {
+	incidents := 0
+	ts, requests := newTicketServer(t, func(r ticketRequest) string {
+		if r.Method == "POST" {
+			incidents++
+			return fmt.Sprintf(`{"result":{"sys_id":"sys%d","number":"INC%d"}}`, incidents, incidents)
+		}
+		return `{"result":{}}`
+	})
+	defer ts.Close()
 
-				if exp := "Kapacitor"; pd.Source != exp {
-					t.Errorf("unexpected source got %s exp %s", pd.Source, exp)
-				}
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+		.where(lambda: "host" == 'serverA')
+		.groupBy('host')
+	|alert()
+		.id('kapacitor/{{ .Name }}/{{ index .Tags "host" }}')
+		.details('details')
+		.warn(lambda: "value" > 7.0)
+		.crit(lambda: "value" > 8.0)
+		.stateChangesOnly()
+		.serviceNow()
+			.field('cmdb_ci', '{{ index .Tags "host" }}')
+`
 
-				if exp := "kapacitor.cpu.serverA is CRITICAL"; pd.Output != exp {
-					t.Errorf("unexpected text got %s exp %s", pd.Output, exp)
-				}
+	clock, et, replayErr, tm := testStreamer(t, "TestStream_AlertDuration", script, nil)
+	defer tm.Close()
 
-				if exp := "kapacitor.cpu.serverA"; pd.Name != exp {
-					t.Errorf("unexpected text got %s exp %s", pd.Name, exp)
-				}
+	c := servicenow.NewConfig()
+	c.Enabled = true
+	c.URL = ts.URL
+	c.Username = "user"
+	c.Password = "secret"
+	if err := c.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	sn := servicenow.NewService(c, logService.NewLogger("[test_servicenow] ", log.LstdFlags))
+	tm.ServiceNowService = sn
 
-				if exp := 2; pd.Status != exp {
-					t.Errorf("unexpected status got %v exp %v", pd.Status, exp)
-				}
-			}()
+	err := fastForwardTask(clock, et, replayErr, tm, 13*time.Second)
+	if err != nil {
+		t.Error(err)
+	}
+
+	incident := func(summary, urgency string) ticketRequest {
+		return ticketRequest{
+			Method: "POST",
+			Path:   "/api/now/table/incident",
+			Body: map[string]interface{}{
+				"short_description": summary,
+				"description":       "details",
+				"urgency":           urgency,
+				"impact":            urgency,
+				"cmdb_ci":           "serverA",
+			},
 		}
-	}()
+	}
+	resolve := func(sysID string) ticketRequest {
+		return ticketRequest{
+			Method: "PATCH",
+			Path:   "/api/now/table/incident/" + sysID,
+			Body: map[string]interface{}{
+				"state":       "6",
+				"close_code":  "Solved (Permanently)",
+				"close_notes": "Alert kapacitor/cpu/serverA recovered: kapacitor/cpu/serverA is OK",
+			},
+		}
+	}
+	exp := []ticketRequest{
+		incident("kapacitor/cpu/serverA is CRITICAL", "1"),
+		{
+			Method: "PATCH",
+			Path:   "/api/now/table/incident/sys1",
+			Body:   map[string]interface{}{"work_notes": "Alert kapacitor/cpu/serverA changed from CRITICAL to WARNING: kapacitor/cpu/serverA is WARNING"},
+		},
+		resolve("sys1"),
+		incident("kapacitor/cpu/serverA is WARNING", "2"),
+		resolve("sys2"),
+	}
+	if !reflect.DeepEqual(*requests, exp) {
+		t.Errorf("unexpected requests:\ngot %v\nexp %v", *requests, exp)
+	}
+}
+
+func TestStream_AlertSyslog(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
 
 	var script = `
 stream
@@ -3483,88 +5119,74 @@ stream
 		.measurement('cpu')
 		.where(lambda: "host" == 'serverA')
 		.groupBy('host')
-	|window()
-		.period(10s)
-		.every(10s)
-	|count('value')
 	|alert()
-		.id('kapacitor.{{ .Name }}.{{ index .Tags "host" }}')
-		.info(lambda: "count" > 6.0)
-		.warn(lambda: "count" > 7.0)
-		.crit(lambda: "count" > 8.0)
-		.sensu()
+		.id('kapacitor/{{ .Name }}/{{ index .Tags "host" }}')
+		.warn(lambda: "value" > 7.0)
+		.crit(lambda: "value" > 8.0)
+		.stateChangesOnly()
+		.syslog()
+			.facility('auth')
 `
 
-	clock, et, replayErr, tm := testStreamer(t, "TestStream_Alert", script, nil)
+	clock, et, replayErr, tm := testStreamer(t, "TestStream_AlertDuration", script, nil)
 	defer tm.Close()
 
-	c := sensu.NewConfig()
-	c.Addr = listen.Addr().String()
-	c.Source = "Kapacitor"
-	sl := sensu.NewService(c, logService.NewLogger("[test_sensu] ", log.LstdFlags))
-	tm.SensuService = sl
+	c := syslog.NewConfig()
+	c.Address = conn.LocalAddr().String()
+	c.Hostname = "kapacitor.example.com"
+	sl, err := syslog.NewService(c, logService.NewLogger("[test_syslog] ", log.LstdFlags))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sl.Close()
+	tm.SyslogService = sl
 
 	err = fastForwardTask(clock, et, replayErr, tm, 13*time.Second)
 	if err != nil {
 		t.Error(err)
 	}
 
-	if rc := atomic.LoadInt32(&requestCount); rc != 1 {
-		t.Errorf("unexpected requestCount got %d exp 1", rc)
+	pid := os.Getpid()
+	// Facility auth is 4, i.e. PRI is 32 plus the severity.
+	sd := `[alert@32473 id="kapacitor/cpu/serverA" task="TestStream_AlertDuration" level="%s"][tags@32473 host="serverA" type="idle"]`
+	expMsgs := []string{
+		fmt.Sprintf("<34>1 1971-01-01T00:00:00.000000Z kapacitor.example.com kapacitor %d alert "+sd+" kapacitor/cpu/serverA is CRITICAL", pid, "CRITICAL"),
+		fmt.Sprintf("<36>1 1971-01-01T00:00:02.000000Z kapacitor.example.com kapacitor %d alert "+sd+" kapacitor/cpu/serverA is WARNING", pid, "WARNING"),
+		fmt.Sprintf("<37>1 1971-01-01T00:00:04.000000Z kapacitor.example.com kapacitor %d alert "+sd+" kapacitor/cpu/serverA is OK", pid, "OK"),
+		fmt.Sprintf("<36>1 1971-01-01T00:00:05.000000Z kapacitor.example.com kapacitor %d alert "+sd+" kapacitor/cpu/serverA is WARNING", pid, "WARNING"),
+		fmt.Sprintf("<37>1 1971-01-01T00:00:08.000000Z kapacitor.example.com kapacitor %d alert "+sd+" kapacitor/cpu/serverA is OK", pid, "OK"),
 	}
-}
-
-func TestStream_AlertSlack(t *testing.T) {
-	requestCount := int32(0)
-	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		atomic.AddInt32(&requestCount, 1)
-		type postData struct {
-			Channel     string `json:"channel"`
-			Username    string `json:"username"`
-			Text        string `json:"text"`
-			Attachments []struct {
-				Fallback string `json:"fallback"`
-				Color    string `json:"color"`
-				Text     string `json:"text"`
-			} `json:"attachments"`
-		}
-		pd := postData{}
-		dec := json.NewDecoder(r.Body)
-		dec.Decode(&pd)
-		if exp := "/test/slack/url"; r.URL.String() != exp {
-			t.Errorf("unexpected url got %s exp %s", r.URL.String(), exp)
-		}
-		if rc := atomic.LoadInt32(&requestCount); rc == 1 {
-			if exp := "#alerts"; pd.Channel != exp {
-				t.Errorf("unexpected channel got %s exp %s", pd.Channel, exp)
-			}
-		} else if rc := atomic.LoadInt32(&requestCount); rc == 2 {
-			if exp := "@jim"; pd.Channel != exp {
-				t.Errorf("unexpected channel got %s exp %s", pd.Channel, exp)
-			}
+	buf := make([]byte, 1024)
+	for i, exp := range expMsgs {
+		conn.SetReadDeadline(time.Now().Add(time.Second))
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			t.Fatalf("failed to read message %d: %v", i, err)
 		}
-		if exp := "kapacitor"; pd.Username != exp {
-			t.Errorf("unexpected username got %s exp %s", pd.Username, exp)
+		if got := string(buf[:n]); got != exp {
+			t.Errorf("unexpected message %d:\ngot %s\nexp %s", i, got, exp)
 		}
-		if exp := ""; pd.Text != exp {
-			t.Errorf("unexpected text got %s exp %s", pd.Text, exp)
+	}
+}
+
+func TestStream_AlertSyslogRFC3164(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	lines := make(chan string, 10)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
 		}
-		if len(pd.Attachments) != 1 {
-			t.Errorf("unexpected attachments got %v", pd.Attachments)
-		} else {
-			exp := "kapacitor/cpu/serverA is CRITICAL"
-			if pd.Attachments[0].Fallback != exp {
-				t.Errorf("unexpected fallback got %s exp %s", pd.Attachments[0].Fallback, exp)
-			}
-			if pd.Attachments[0].Text != exp {
-				t.Errorf("unexpected text got %s exp %s", pd.Attachments[0].Text, exp)
-			}
-			if exp := "danger"; pd.Attachments[0].Color != exp {
-				t.Errorf("unexpected color got %s exp %s", pd.Attachments[0].Color, exp)
-			}
+		defer conn.Close()
+		scanner := bufio.NewScanner(conn)
+		for scanner.Scan() {
+			lines <- scanner.Text()
 		}
-	}))
-	defer ts.Close()
+	}()
 
 	var script = `
 stream
@@ -3572,42 +5194,63 @@ stream
 		.measurement('cpu')
 		.where(lambda: "host" == 'serverA')
 		.groupBy('host')
-	|window()
-		.period(10s)
-		.every(10s)
-	|count('value')
 	|alert()
 		.id('kapacitor/{{ .Name }}/{{ index .Tags "host" }}')
-		.info(lambda: "count" > 6.0)
-		.warn(lambda: "count" > 7.0)
-		.crit(lambda: "count" > 8.0)
-		.slack()
-		.channel('#alerts')
-		.slack()
-		.channel('@jim')
+		.warn(lambda: "value" > 7.0)
+		.crit(lambda: "value" > 8.0)
+		.message('{{ .ID }} is{{ "\n" }}{{ .Level }}')
+		.stateChangesOnly()
+		.syslog()
 `
 
-	clock, et, replayErr, tm := testStreamer(t, "TestStream_Alert", script, nil)
+	clock, et, replayErr, tm := testStreamer(t, "TestStream_AlertDuration", script, nil)
 	defer tm.Close()
 
-	c := slack.NewConfig()
-	c.URL = ts.URL + "/test/slack/url"
-	c.Channel = "#channel"
-	sl := slack.NewService(c, logService.NewLogger("[test_slack] ", log.LstdFlags))
-	tm.SlackService = sl
+	c := syslog.NewConfig()
+	c.Network = "tcp"
+	c.Address = l.Addr().String()
+	c.Format = "rfc3164"
+	c.Hostname = "kapacitor.example.com"
+	if err := c.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	sl, err := syslog.NewService(c, logService.NewLogger("[test_syslog] ", log.LstdFlags))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sl.Close()
+	tm.SyslogService = sl
 
-	err := fastForwardTask(clock, et, replayErr, tm, 13*time.Second)
+	err = fastForwardTask(clock, et, replayErr, tm, 13*time.Second)
 	if err != nil {
 		t.Error(err)
 	}
 
-	if rc := atomic.LoadInt32(&requestCount); rc != 2 {
-		t.Errorf("unexpected requestCount got %d exp 2", rc)
+	pid := os.Getpid()
+	// Facility local0 is 16, i.e. PRI is 128 plus the severity.
+	// The newlines within the messages are escaped.
+	expLines := []string{
+		fmt.Sprintf("<130>Jan  1 00:00:00 kapacitor.example.com kapacitor[%d]: kapacitor/cpu/serverA is\\nCRITICAL", pid),
+		fmt.Sprintf("<132>Jan  1 00:00:02 kapacitor.example.com kapacitor[%d]: kapacitor/cpu/serverA is\\nWARNING", pid),
+		fmt.Sprintf("<133>Jan  1 00:00:04 kapacitor.example.com kapacitor[%d]: kapacitor/cpu/serverA is\\nOK", pid),
+		fmt.Sprintf("<132>Jan  1 00:00:05 kapacitor.example.com kapacitor[%d]: kapacitor/cpu/serverA is\\nWARNING", pid),
+		fmt.Sprintf("<133>Jan  1 00:00:08 kapacitor.example.com kapacitor[%d]: kapacitor/cpu/serverA is\\nOK", pid),
+	}
+	for i, exp := range expLines {
+		select {
+		case got := <-lines:
+			if got != exp {
+				t.Errorf("unexpected line %d:\ngot %s\nexp %s", i, got, exp)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for line %d", i)
+		}
 	}
 }
 
 func TestStream_AlertHipChat(t *testing.T) {
 	requestCount := int32(0)
+	urls := &seenValues{}
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		atomic.AddInt32(&requestCount, 1)
 		type postData struct {
@@ -3620,15 +5263,7 @@ func TestStream_AlertHipChat(t *testing.T) {
 		dec := json.NewDecoder(r.Body)
 		dec.Decode(&pd)
 
-		if rc := atomic.LoadInt32(&requestCount); rc == 1 {
-			if exp := "/1234567/notification?auth_token=testtoken1234567"; r.URL.String() != exp {
-				t.Errorf("unexpected url got %s exp %s", r.URL.String(), exp)
-			}
-		} else if rc := atomic.LoadInt32(&requestCount); rc == 2 {
-			if exp := "/Test%20Room/notification?auth_token=testtokenTestRoom"; r.URL.String() != exp {
-				t.Errorf("unexpected url got %s exp %s", r.URL.String(), exp)
-			}
-		}
+		urls.add(r.URL.String())
 		if exp := "kapacitor"; pd.From != exp {
 			t.Errorf("unexpected username got %s exp %s", pd.From, exp)
 		}
@@ -3685,10 +5320,12 @@ stream
 	if rc := atomic.LoadInt32(&requestCount); rc != 2 {
 		t.Errorf("unexpected requestCount got %d exp 2", rc)
 	}
+	urls.check(t, "urls", "/1234567/notification?auth_token=testtoken1234567", "/Test%20Room/notification?auth_token=testtokenTestRoom")
 }
 
 func TestStream_AlertAlerta(t *testing.T) {
 	requestCount := int32(0)
+	urls := &seenValues{}
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		atomic.AddInt32(&requestCount, 1)
 		type postData struct {
@@ -3705,10 +5342,8 @@ func TestStream_AlertAlerta(t *testing.T) {
 		dec := json.NewDecoder(r.Body)
 		dec.Decode(&pd)
 
-		if rc := atomic.LoadInt32(&requestCount); rc == 1 {
-			if exp := "/alert?api-key=testtoken1234567"; r.URL.String() != exp {
-				t.Errorf("unexpected url got %s exp %s", r.URL.String(), exp)
-			}
+		urls.add(r.URL.String())
+		if r.URL.String() == "/alert?api-key=testtoken1234567" {
 			if exp := "cpu"; pd.Resource != exp {
 				t.Errorf("unexpected resource got %s exp %s", pd.Resource, exp)
 			}
@@ -3809,10 +5444,12 @@ stream
 	if rc := atomic.LoadInt32(&requestCount); rc != 2 {
 		t.Errorf("unexpected requestCount got %d exp 2", rc)
 	}
+	urls.check(t, "urls", "/alert?api-key=testtoken1234567", "/alert?api-key=anothertesttoken")
 }
 
 func TestStream_AlertOpsGenie(t *testing.T) {
 	requestCount := int32(0)
+	teams := &seenValues{}
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		atomic.AddInt32(&requestCount, 1)
 
@@ -3850,7 +5487,8 @@ func TestStream_AlertOpsGenie(t *testing.T) {
 		if pd.Description == nil {
 			t.Error("unexpected description got nil")
 		}
-		if rc := atomic.LoadInt32(&requestCount); rc == 1 {
+		teams.add(strings.Join(pd.Teams, ","))
+		if len(pd.Teams) > 0 && pd.Teams[0] == "test_team" {
 			if exp, l := 2, len(pd.Teams); l != exp {
 				t.Errorf("unexpected teams count got %d exp %d", l, exp)
 			}
@@ -3869,7 +5507,7 @@ func TestStream_AlertOpsGenie(t *testing.T) {
 			if exp := "another_recipient"; pd.Recipients[1] != exp {
 				t.Errorf("unexpected recipients[1] got %s exp %s", pd.Recipients[1], exp)
 			}
-		} else if rc := atomic.LoadInt32(&requestCount); rc == 2 {
+		} else {
 			if exp, l := 1, len(pd.Teams); l != exp {
 				t.Errorf("unexpected teams count got %d exp %d", l, exp)
 			}
@@ -3928,10 +5566,12 @@ stream
 	if rc := atomic.LoadInt32(&requestCount); rc != 2 {
 		t.Errorf("unexpected requestCount got %d exp 1", rc)
 	}
+	teams.check(t, "teams", "test_team,another_team", "test_team2")
 }
 
 func TestStream_AlertPagerDuty(t *testing.T) {
 	requestCount := int32(0)
+	serviceKeys := &seenValues{}
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		atomic.AddInt32(&requestCount, 1)
 		type postData struct {
@@ -3945,15 +5585,7 @@ func TestStream_AlertPagerDuty(t *testing.T) {
 		pd := postData{}
 		dec := json.NewDecoder(r.Body)
 		dec.Decode(&pd)
-		if rc := atomic.LoadInt32(&requestCount); rc == 1 {
-			if exp := "service_key"; pd.ServiceKey != exp {
-				t.Errorf("unexpected service key got %s exp %s", pd.ServiceKey, exp)
-			}
-		} else if rc := atomic.LoadInt32(&requestCount); rc == 2 {
-			if exp := "test_override_key"; pd.ServiceKey != exp {
-				t.Errorf("unexpected service key got %s exp %s", pd.ServiceKey, exp)
-			}
-		}
+		serviceKeys.add(pd.ServiceKey)
 		if exp := "trigger"; pd.EventType != exp {
 			t.Errorf("unexpected event type got %s exp %s", pd.EventType, exp)
 		}
@@ -4010,21 +5642,142 @@ stream
 	if rc := atomic.LoadInt32(&requestCount); rc != 2 {
 		t.Errorf("unexpected requestCount got %d exp 1", rc)
 	}
+	serviceKeys.check(t, "service keys", "service_key", "test_override_key")
 }
 
-func TestStream_AlertVictorOps(t *testing.T) {
+func TestStream_AlertPagerDuty2(t *testing.T) {
+	type payload struct {
+		Summary       string                 `json:"summary"`
+		Source        string                 `json:"source"`
+		Severity      string                 `json:"severity"`
+		Timestamp     string                 `json:"timestamp"`
+		CustomDetails map[string]interface{} `json:"custom_details"`
+	}
+	type postData struct {
+		RoutingKey  string   `json:"routing_key"`
+		EventAction string   `json:"event_action"`
+		DedupKey    string   `json:"dedup_key"`
+		Payload     *payload `json:"payload"`
+		Client      string   `json:"client"`
+		ClientURL   string   `json:"client_url"`
+	}
+	// Each alert is sent to both handlers, in order for each handler.
+	expEvents := []struct {
+		action    string
+		severity  string
+		timestamp string
+		value     float64
+	}{
+		{action: "trigger", severity: "critical", timestamp: "1971-01-01T00:00:00Z", value: 9},
+		{action: "trigger", severity: "warning", timestamp: "1971-01-01T00:00:02Z", value: 8},
+		{action: "resolve"},
+		{action: "trigger", severity: "warning", timestamp: "1971-01-01T00:00:05Z", value: 8},
+		{action: "resolve"},
+	}
 	requestCount := int32(0)
+	var mu sync.Mutex
+	requestsByKey := make(map[string]int)
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		atomic.AddInt32(&requestCount, 1)
-		if rc := atomic.LoadInt32(&requestCount); rc == 1 {
-			if exp, got := "/api_key/test_key", r.URL.String(); got != exp {
-				t.Errorf("unexpected VO url got %s exp %s", got, exp)
-			}
-		} else if rc := atomic.LoadInt32(&requestCount); rc == 2 {
-			if exp, got := "/api_key/test_key2", r.URL.String(); got != exp {
-				t.Errorf("unexpected VO url got %s exp %s", got, exp)
+		pd := postData{}
+		dec := json.NewDecoder(r.Body)
+		dec.Decode(&pd)
+		w.WriteHeader(http.StatusAccepted)
+
+		if pd.RoutingKey != "routing_key" && pd.RoutingKey != "test_override_key" {
+			t.Errorf("unexpected routing key got %s", pd.RoutingKey)
+		}
+		mu.Lock()
+		requestsByKey[pd.RoutingKey]++
+		rc := requestsByKey[pd.RoutingKey]
+		mu.Unlock()
+		if exp := "kapacitor/cpu/serverA"; pd.DedupKey != exp {
+			t.Errorf("unexpected dedup key got %s exp %s", pd.DedupKey, exp)
+		}
+		if exp := "kapacitor"; pd.Client != exp {
+			t.Errorf("unexpected client got %s exp %s", pd.Client, exp)
+		}
+		if len(pd.ClientURL) == 0 {
+			t.Errorf("unexpected client url got empty string")
+		}
+		i := rc - 1
+		if i >= len(expEvents) {
+			t.Errorf("unexpected request %d", rc)
+			return
+		}
+		exp := expEvents[i]
+		if pd.EventAction != exp.action {
+			t.Errorf("unexpected event action for request %d got %s exp %s", rc, pd.EventAction, exp.action)
+		}
+		if exp.action == "resolve" {
+			if pd.Payload != nil {
+				t.Errorf("unexpected payload for resolve got %v", pd.Payload)
 			}
+			return
+		}
+		if pd.Payload == nil {
+			t.Errorf("unexpected payload got nil")
+			return
+		}
+		if got := pd.Payload.Severity; got != exp.severity {
+			t.Errorf("unexpected severity for request %d got %s exp %s", rc, got, exp.severity)
+		}
+		if got := pd.Payload.Timestamp; got != exp.timestamp {
+			t.Errorf("unexpected timestamp for request %d got %s exp %s", rc, got, exp.timestamp)
+		}
+		if exp := "serverA"; pd.Payload.Source != exp {
+			t.Errorf("unexpected source got %s exp %s", pd.Payload.Source, exp)
+		}
+		if exp := "kapacitor/cpu/serverA is " + strings.ToUpper(exp.severity); pd.Payload.Summary != exp {
+			t.Errorf("unexpected summary got %s exp %s", pd.Payload.Summary, exp)
 		}
+		if got := pd.Payload.CustomDetails["value"]; got != exp.value {
+			t.Errorf("unexpected custom details value for request %d got %v exp %v", rc, got, exp.value)
+		}
+	}))
+	defer ts.Close()
+
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+		.where(lambda: "host" == 'serverA')
+		.groupBy('host')
+	|alert()
+		.id('kapacitor/{{ .Name }}/{{ index .Tags "host" }}')
+		.warn(lambda: "value" > 7.0)
+		.crit(lambda: "value" > 8.0)
+		.stateChangesOnly()
+		.pagerDuty2()
+		.pagerDuty2()
+			.routingKey('test_override_key')
+`
+
+	clock, et, replayErr, tm := testStreamer(t, "TestStream_AlertDuration", script, nil)
+	defer tm.Close()
+	c := pagerduty2.NewConfig()
+	c.URL = ts.URL
+	c.RoutingKey = "routing_key"
+	pd := pagerduty2.NewService(c, logService.NewLogger("[test_pd2] ", log.LstdFlags))
+	pd.HTTPDService = tm.HTTPDService
+	tm.PagerDuty2Service = pd
+
+	err := fastForwardTask(clock, et, replayErr, tm, 13*time.Second)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if exp, rc := 2*len(expEvents), int(atomic.LoadInt32(&requestCount)); rc != exp {
+		t.Errorf("unexpected requestCount got %d exp %d", rc, exp)
+	}
+}
+
+func TestStream_AlertVictorOps(t *testing.T) {
+	requestCount := int32(0)
+	urls := &seenValues{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requestCount, 1)
+		urls.add(r.URL.String())
 		type postData struct {
 			MessageType    string      `json:"message_type"`
 			EntityID       string      `json:"entity_id"`
@@ -4095,6 +5848,7 @@ stream
 	if rc := atomic.LoadInt32(&requestCount); rc != 2 {
 		t.Errorf("unexpected requestCount got %d exp 1", rc)
 	}
+	urls.check(t, "urls", "/api_key/test_key", "/api_key/test_key2")
 }
 
 func TestStream_AlertTalk(t *testing.T) {
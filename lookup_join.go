@@ -0,0 +1,167 @@
+package kapacitor
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/influxdata/kapacitor/expvar"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+const (
+	statsLookupsMatched = "lookups_matched"
+	statsLookupsMissed  = "lookups_missed"
+)
+
+type LookupJoinNode struct {
+	node
+	l *pipeline.LookupJoinNode
+
+	// Table of the latest reference point per key.
+	mu    sync.RWMutex
+	table map[models.GroupID]lookupEntry
+	// Time of the data at which expired entries are dropped next.
+	nextSweep time.Time
+
+	lookupsMatched *expvar.Int
+	lookupsMissed  *expvar.Int
+}
+
+type lookupEntry struct {
+	time   time.Time
+	fields models.Fields
+}
+
+// Create a new LookupJoinNode which enriches data with the latest reference data.
+func newLookupJoinNode(et *ExecutingTask, n *pipeline.LookupJoinNode, l *log.Logger) (*LookupJoinNode, error) {
+	ln := &LookupJoinNode{
+		l:     n,
+		node:  node{Node: n, et: et, logger: l},
+		table: make(map[models.GroupID]lookupEntry),
+	}
+	ln.node.runF = ln.runLookupJoin
+	return ln, nil
+}
+
+func (l *LookupJoinNode) runLookupJoin([]byte) error {
+	l.lookupsMatched = &expvar.Int{}
+	l.lookupsMissed = &expvar.Int{}
+	l.statMap.Set(statsLookupsMatched, l.lookupsMatched)
+	l.statMap.Set(statsLookupsMissed, l.lookupsMissed)
+
+	// The first parent is the data, the second the reference data.
+	// Consume the reference data concurrently so that the data is never held back.
+	refDone := make(chan struct{})
+	go func() {
+		defer close(refDone)
+		ref := l.ins[1]
+		for p, ok := ref.Next(); ok; p, ok = ref.Next() {
+			switch v := p.(type) {
+			case models.Point:
+				l.update(v.Time, v.Tags, v.Fields)
+			case models.Batch:
+				for _, bp := range v.Points {
+					l.update(bp.Time, bp.Tags, bp.Fields)
+				}
+			}
+		}
+	}()
+
+	switch l.Provides() {
+	case pipeline.StreamEdge:
+		for p, ok := l.ins[0].NextPoint(); ok; p, ok = l.ins[0].NextPoint() {
+			l.timer.Start()
+			p.Fields = l.enrich(p.Time, p.Tags, p.Fields)
+			l.timer.Stop()
+			for _, child := range l.outs {
+				err := child.CollectPoint(p)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	case pipeline.BatchEdge:
+		for b, ok := l.ins[0].NextBatch(); ok; b, ok = l.ins[0].NextBatch() {
+			l.timer.Start()
+			for i := range b.Points {
+				b.Points[i].Fields = l.enrich(b.Points[i].Time, b.Points[i].Tags, b.Points[i].Fields)
+			}
+			l.timer.Stop()
+			for _, child := range l.outs {
+				err := child.CollectBatch(b)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+	<-refDone
+	return nil
+}
+
+// Update the table entry of the reference point, unless the entry is newer.
+func (l *LookupJoinNode) update(t time.Time, tags models.Tags, fields models.Fields) {
+	key := models.TagsToGroupID(l.l.Dimensions, tags)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if e, ok := l.table[key]; ok && e.time.After(t) {
+		return
+	}
+	l.table[key] = lookupEntry{
+		time:   t,
+		fields: fields,
+	}
+}
+
+// Drop the entries that expired for data at time t.
+// The data is mostly in time order, so the entries cannot be used by later data.
+// The table is swept once per ttl so that the cost per point stays constant.
+func (l *LookupJoinNode) sweep(t time.Time) {
+	if l.l.Ttl <= 0 || t.Before(l.nextSweep) {
+		return
+	}
+	l.nextSweep = t.Add(l.l.Ttl)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for key, e := range l.table {
+		if t.Sub(e.time) > l.l.Ttl {
+			delete(l.table, key)
+		}
+	}
+}
+
+// Return the fields enriched with the fields of the matching table entry,
+// or the defaults if no entry exists.
+func (l *LookupJoinNode) enrich(t time.Time, tags models.Tags, fields models.Fields) models.Fields {
+	l.sweep(t)
+	key := models.TagsToGroupID(l.l.Dimensions, tags)
+	l.mu.RLock()
+	e, ok := l.table[key]
+	l.mu.RUnlock()
+	if ok && l.l.Ttl > 0 && t.Sub(e.time) > l.l.Ttl {
+		ok = false
+	}
+	ref := e.fields
+	if ok {
+		l.lookupsMatched.Add(1)
+	} else {
+		l.lookupsMissed.Add(1)
+		ref = l.l.Defaults
+	}
+	if len(ref) == 0 {
+		return fields
+	}
+	newFields := fields.Copy()
+	for name, value := range ref {
+		if l.l.Prefix != "" {
+			name = l.l.Prefix + "." + name
+		}
+		// Never overwrite existing fields of the data.
+		if _, exists := newFields[name]; !exists {
+			newFields[name] = value
+		}
+	}
+	return newFields
+}
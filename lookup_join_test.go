@@ -0,0 +1,33 @@
+package kapacitor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/kapacitor/expvar"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+func TestLookupJoin_DropExpired(t *testing.T) {
+	l := &LookupJoinNode{
+		l:              &pipeline.LookupJoinNode{Dimensions: []string{"host"}, Ttl: 10 * time.Second},
+		table:          make(map[models.GroupID]lookupEntry),
+		lookupsMatched: &expvar.Int{},
+		lookupsMissed:  &expvar.Int{},
+	}
+	start := time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC)
+	l.update(start, models.Tags{"host": "serverA"}, models.Fields{"version": "1.0"})
+	l.update(start.Add(5*time.Second), models.Tags{"host": "serverB"}, models.Fields{"version": "1.1"})
+
+	fields := l.enrich(start.Add(12*time.Second), models.Tags{"host": "serverB"}, models.Fields{})
+	if exp, got := "1.1", fields["version"]; got != exp {
+		t.Errorf("unexpected version got %v exp %v", got, exp)
+	}
+	if _, ok := l.table[models.TagsToGroupID([]string{"host"}, models.Tags{"host": "serverA"})]; ok {
+		t.Error("expected expired entry of serverA to be dropped")
+	}
+	if exp, got := 1, len(l.table); got != exp {
+		t.Errorf("unexpected number of entries got %d exp %d", got, exp)
+	}
+}
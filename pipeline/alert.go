@@ -1,9 +1,12 @@
 package pipeline
 
 import (
+	"fmt"
 	"reflect"
+	"strings"
 	"time"
 
+	"github.com/influxdata/kapacitor/templates"
 	"github.com/influxdata/kapacitor/tick"
 )
 
@@ -19,6 +22,15 @@ const defaultMessageTmpl = "{{ .ID }} is {{ .Level }}"
 // Default template for constructing a details message.
 const defaultDetailsTmpl = "{{ json . }}"
 
+// Default number of values in sparkline graphs.
+const defaultSparklinePoints = 30
+
+// Default lowest level for opening tickets.
+const defaultTicketMinLevel = "WARNING"
+
+// Default template for the summary of tickets.
+const defaultTicketSummaryTmpl = "{{ .Message }}"
+
 // Default log mode for file
 const defaultLogFileMode = 0600
 
@@ -28,7 +40,7 @@ const defaultLogFileMode = 0600
 // See AlertNode.Info, AlertNode.Warn, and AlertNode.Crit below.
 //
 // Different event handlers can be configured for each AlertNode.
-// Some handlers like Email, HipChat, Sensu, Slack, OpsGenie, VictorOps, PagerDuty and Talk have a configuration
+// Some handlers like Email, HipChat, Sensu, Slack, OpsGenie, VictorOps, PagerDuty, PagerDuty2, Alertmanager, Syslog and Talk have a configuration
 // option 'global' that indicates that all alerts implicitly use the handler.
 //
 // Available event handlers:
@@ -44,6 +56,11 @@ const defaultLogFileMode = 0600
 //    * OpsGenie -- Send alert to OpsGenie.
 //    * VictorOps -- Send alert to VictorOps.
 //    * PagerDuty -- Send alert to PagerDuty.
+//    * PagerDuty2 -- Send alert to PagerDuty using the Events API v2.
+//    * Jira -- Open and track a Jira issue for the alert.
+//    * ServiceNow -- Open and track a ServiceNow incident for the alert.
+//    * Alertmanager -- Send alert to Prometheus Alertmanager.
+//    * Syslog -- Send alert message to a syslog server.
 //    * Talk -- Post alert message to Talk client.
 //
 // See below for more details on configuring each handler.
@@ -65,6 +82,11 @@ const defaultLogFileMode = 0600
 //
 // It is valid to configure multiple alert handlers, even with the same type.
 //
+// Events are queued and sent to the handlers in the background.
+// Failed deliveries are retried with exponential backoff according to the 'delivery'
+// section of the configuration. The number of sent, failed and retried deliveries
+// per handler type are reported in the 'alert_handlers' statistics.
+//
 // Example:
 //   stream
 //           .groupBy('service')
@@ -155,6 +177,19 @@ type AlertNode struct {
 	//    * Fields -- Map of fields. Use '{{ index .Fields "key" }}' to get a specific field value.
 	//    * Time -- The time of the point that triggered the event.
 	//
+	// All alert templates can use the following functions:
+	//
+	//    * humanize, humanizeBytes, humanizeDuration -- Format numbers, bytes and durations in seconds.
+	//    * toUpper, toLower -- Change the case of a string.
+	//    * join -- Join a list with a separator.
+	//    * default -- Replace an empty value, i.e. '{{ .Value | default "n/a" }}'.
+	//    * round -- Round a number to a number of decimal places, i.e. '{{ .Value | round 2 }}'.
+	//    * timeFormat -- Format a time in a timezone, i.e. '{{ .Time | timeFormat "15:04" "Europe/Paris" }}'.
+	//    * tag, field -- Lookup a tag or field with a fallback, i.e. '{{ tag .Tags "host" "unknown" }}'.
+	//    * printf, urlquery -- The builtin Go template functions.
+	//
+	// Templates are validated when the task is defined.
+	//
 	// Example:
 	//   stream
 	//       |from()
@@ -162,9 +197,9 @@ type AlertNode struct {
 	//           .groupBy('service', 'host')
 	//       |alert()
 	//           .id('{{ index .Tags "service" }}/{{ index .Tags "host" }}')
-	//           .message('{{ .ID }} is {{ .Level}} value: {{ index .Fields "value" }}')
+	//           .message('{{ .ID }} is {{ .Level}} value: {{ field .Fields "value" 0 | round 2 }}')
 	//
-	// Message: authentication/auth001.example.com is CRITICAL value:42
+	// Message: authentication/auth001.example.com is CRITICAL value:42.12
 	//
 	// Default: {{ .ID }} is {{ .Level }}
 	Message string
@@ -234,6 +269,16 @@ type AlertNode struct {
 	// Optional field key to add to the data, containing the alert ID as a string.
 	IdField string
 
+	// Field of which the recent values are rendered as a sparkline graph.
+	// tick:ignore
+	SparklineField string `tick:"Sparkline"`
+
+	// Number of recent values per group rendered in the sparkline graph.
+	// Minimum value is 2, maximum value is 120, the width of the graph in pixels.
+	//
+	// Default: 30
+	SparklinePoints int64
+
 	// Indicates an alert should trigger only if all points in a batch match the criteria
 	// tick:ignore
 	AllFlag bool `tick:"All"`
@@ -270,10 +315,30 @@ type AlertNode struct {
 	// tick:ignore
 	PagerDutyHandlers []*PagerDutyHandler `tick:"PagerDuty"`
 
+	// Send alert to PagerDuty using the Events API v2.
+	// tick:ignore
+	PagerDuty2Handlers []*PagerDuty2Handler `tick:"PagerDuty2"`
+
 	// Send alert to Sensu.
 	// tick:ignore
 	SensuHandlers []*SensuHandler `tick:"Sensu"`
 
+	// Open Jira issues for the alert.
+	// tick:ignore
+	JiraHandlers []*JiraHandler `tick:"Jira"`
+
+	// Open ServiceNow incidents for the alert.
+	// tick:ignore
+	ServiceNowHandlers []*ServiceNowHandler `tick:"ServiceNow"`
+
+	// Send alert to Alertmanager.
+	// tick:ignore
+	AlertmanagerHandlers []*AlertmanagerHandler `tick:"Alertmanager"`
+
+	// Send alert to syslog.
+	// tick:ignore
+	SyslogHandlers []*SyslogHandler `tick:"Syslog"`
+
 	// Send alert to Slack.
 	// tick:ignore
 	SlackHandlers []*SlackHandler `tick:"Slack"`
@@ -302,10 +367,86 @@ func newAlertNode(wants EdgeType) *AlertNode {
 		Id:        defaultIDTmpl,
 		Message:   defaultMessageTmpl,
 		Details:   defaultDetailsTmpl,
+
+		SparklinePoints: defaultSparklinePoints,
 	}
 	return a
 }
 
+// Validate the templates of the alert and its handlers.
+func (n *AlertNode) validate() error {
+	parse := func(name, tmpl string) error {
+		if _, err := templates.ParseText(name, tmpl); err != nil {
+			return fmt.Errorf("invalid %s template: %s", name, err)
+		}
+		return nil
+	}
+	if err := parse("id", n.Id); err != nil {
+		return err
+	}
+	if err := parse("message", n.Message); err != nil {
+		return err
+	}
+	if _, err := templates.ParseHTML("details", n.Details); err != nil {
+		return fmt.Errorf("invalid details template: %s", err)
+	}
+	for _, a := range n.AlertaHandlers {
+		for name, tmpl := range map[string]string{
+			"alerta resource":    a.Resource,
+			"alerta event":       a.Event,
+			"alerta environment": a.Environment,
+			"alerta group":       a.Group,
+			"alerta value":       a.Value,
+		} {
+			if err := parse(name, tmpl); err != nil {
+				return err
+			}
+		}
+	}
+	for _, s := range n.SyslogHandlers {
+		if s.Facility != "" && !syslogFacilities[strings.ToLower(s.Facility)] {
+			return fmt.Errorf("unknown syslog facility %q", s.Facility)
+		}
+	}
+	for _, s := range n.SlackHandlers {
+		if err := parse("slack title", s.Title); err != nil {
+			return err
+		}
+		for _, fields := range [][]SlackField{s.ShortFieldsList, s.FieldsList} {
+			for _, f := range fields {
+				if err := parse("slack field "+f.Title, f.Value); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	ticket := func(kind, summary, description string, fields map[string]string) error {
+		if err := parse(kind+" summary", summary); err != nil {
+			return err
+		}
+		if err := parse(kind+" description", description); err != nil {
+			return err
+		}
+		for name, f := range fields {
+			if err := parse(kind+" field "+name, f); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, j := range n.JiraHandlers {
+		if err := ticket("jira", j.Summary, j.Description, j.FieldsMap); err != nil {
+			return err
+		}
+	}
+	for _, s := range n.ServiceNowHandlers {
+		if err := ticket("servicenow", s.Summary, s.Description, s.FieldsMap); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 //tick:ignore
 func (n *AlertNode) ChainMethods() map[string]reflect.Value {
 	return map[string]reflect.Value{
@@ -391,6 +532,33 @@ func (a *AlertNode) Flapping(low, high float64) *AlertNode {
 	return a
 }
 
+// Keep the recent values of a field per group and render them as a sparkline graph.
+// The graphs are PNG images served by the HTTP API at
+// '/kapacitor/v1/sparkline.png', the values at the time of the alert are part of the URL
+// so the graph remains available after the task is stopped.
+// The URL of the graph of the group is added to the alert data as 'sparkline',
+// shown as the image of Slack attachments
+// and the graph is embedded as an inline image in emails.
+//
+// Example:
+//   stream
+//       |from()
+//           .measurement('cpu')
+//           .groupBy('host')
+//       |alert()
+//           .crit(lambda: "usage_idle" < 10)
+//           .sparkline('usage_idle')
+//           .sparklinePoints(60)
+//           .slack()
+//
+// Show the last 60 values of 'usage_idle' of the host in Slack.
+//
+// tick:property
+func (a *AlertNode) Sparkline(field string) *AlertNode {
+	a.SparklineField = field
+	return a
+}
+
 // HTTP POST JSON alert data to a specified URL.
 // tick:property
 func (a *AlertNode) Post(url string) *PostHandler {
@@ -652,6 +820,70 @@ type PagerDutyHandler struct {
 	ServiceKey string
 }
 
+// Send the alert to PagerDuty using the Events API v2.
+// To use the Events API v2 you must first add an integration of type
+// 'Use our API directly' with 'Events API v2' to a PagerDuty service.
+// Place the integration key into the 'pagerduty2' section of the Kapacitor configuration as the option 'routing-key'.
+//
+// Example:
+//    [pagerduty2]
+//      enabled = true
+//      routing-key = "xxxxxxxxx"
+//
+// With the correct configuration you can now use PagerDuty2 in TICKscripts.
+//
+// Example:
+//    stream
+//         |alert()
+//             .pagerDuty2()
+//
+// The alert ID is used as the dedup key of the PagerDuty incident.
+// Events for the OK level resolve the incident, all other levels trigger it.
+// The incident severity is 'critical', 'warning' or 'info' depending on the alert level
+// and the fields of the alert data are sent as the custom details.
+// The 'host' tag is used as the source of the event, if present.
+//
+// Example:
+//    stream
+//         |alert()
+//             .pagerDuty2()
+//                 .routingKey('team_rocket')
+//
+// Send alerts to the PagerDuty service with the integration key 'team_rocket'.
+//
+// If the 'pagerduty2' section in the configuration has the option: global = true
+// then all alerts are sent to PagerDuty without the need to explicitly state it
+// in the TICKscript.
+//
+// Example:
+//    [pagerduty2]
+//      enabled = true
+//      routing-key = "xxxxxxxxx"
+//      global = true
+//
+// Example:
+//    stream
+//         |alert()
+//
+// Send alert to PagerDuty using the Events API v2.
+// tick:property
+func (a *AlertNode) PagerDuty2() *PagerDuty2Handler {
+	pd := &PagerDuty2Handler{
+		AlertNode: a,
+	}
+	a.PagerDuty2Handlers = append(a.PagerDuty2Handlers, pd)
+	return pd
+}
+
+// tick:embedded:AlertNode.PagerDuty2
+type PagerDuty2Handler struct {
+	*AlertNode
+
+	// The integration key to use for the alert.
+	// Defaults to the value in the configuration if empty.
+	RoutingKey string
+}
+
 // Send the alert to HipChat.
 // To allow Kapacitor to post to HipChat,
 // go to the URL https://www.hipchat.com/docs/apiv2 for
@@ -853,6 +1085,293 @@ type SensuHandler struct {
 	*AlertNode
 }
 
+// Open a Jira issue when the alert reaches a level and keep it in sync with the alert.
+// Changes of the alert level are added as comments to the issue and
+// once the alert recovers the issue is commented on and resolved.
+// The issue keys are stored per alert ID so that restarting Kapacitor does not open duplicate issues.
+//
+// Example:
+//    [jira]
+//      enabled = true
+//      url = "https://example.atlassian.net"
+//      username = "kapacitor"
+//      token = "xxxxxxxxx"
+//      project = "OPS"
+//      issue-type = "Task"
+//      resolve-transition-id = "31"
+//
+// Example:
+//    stream
+//         |alert()
+//             .jira()
+//                 .minLevel('CRITICAL')
+//                 .summary('{{ .Name }} on {{ index .Tags "host" }} is {{ .Level }}')
+//                 .field('labels', 'kapacitor')
+//
+// Open an issue in the 'OPS' project once the alert is CRITICAL.
+//
+// tick:property
+func (a *AlertNode) Jira() *JiraHandler {
+	jira := &JiraHandler{
+		AlertNode: a,
+		MinLevel:  defaultTicketMinLevel,
+		Summary:   defaultTicketSummaryTmpl,
+		FieldsMap: make(map[string]string),
+	}
+	a.JiraHandlers = append(a.JiraHandlers, jira)
+	return jira
+}
+
+// tick:embedded:AlertNode.Jira
+type JiraHandler struct {
+	*AlertNode
+
+	// Jira project key.
+	// If empty uses the project from the configuration.
+	Project string
+
+	// Jira issue type.
+	// If empty uses the issue type from the configuration.
+	IssueType string
+
+	// Lowest level for which an issue is opened, one of INFO, WARNING or CRITICAL.
+	// Default is WARNING.
+	MinLevel string
+
+	// Summary of the issue.
+	// Can be a template and has access to the same data as the AlertNode.Details property.
+	// Default is the message of the alert.
+	Summary string
+
+	// Description of the issue.
+	// Can be a template and has access to the same data as the AlertNode.Details property.
+	// Default is the details of the alert.
+	Description string
+
+	// Additional issue fields.
+	// tick:ignore
+	FieldsMap map[string]string `tick:"Field"`
+}
+
+// Set an additional field of the issue, i.e. a custom field.
+// The value can be a template and has access to the same data as the AlertNode.Details property.
+// tick:property
+func (j *JiraHandler) Field(name, value string) *JiraHandler {
+	j.FieldsMap[name] = value
+	return j
+}
+
+// Open a ServiceNow incident when the alert reaches a level and keep it in sync with the alert.
+// Changes of the alert level are added as work notes to the incident and
+// once the alert recovers the incident is resolved.
+// The urgency and impact of the incident are set from the alert level.
+// The incident IDs are stored per alert ID so that restarting Kapacitor does not open duplicate incidents.
+//
+// Example:
+//    [servicenow]
+//      enabled = true
+//      url = "https://example.service-now.com"
+//      username = "kapacitor"
+//      password = "xxxxxxxxx"
+//
+// Example:
+//    stream
+//         |alert()
+//             .serviceNow()
+//                 .field('assignment_group', 'Operations')
+//                 .field('cmdb_ci', '{{ index .Tags "host" }}')
+//
+// Open an incident assigned to the 'Operations' group once the alert is WARNING or CRITICAL.
+//
+// tick:property
+func (a *AlertNode) ServiceNow() *ServiceNowHandler {
+	sn := &ServiceNowHandler{
+		AlertNode: a,
+		MinLevel:  defaultTicketMinLevel,
+		Summary:   defaultTicketSummaryTmpl,
+		FieldsMap: make(map[string]string),
+	}
+	a.ServiceNowHandlers = append(a.ServiceNowHandlers, sn)
+	return sn
+}
+
+// tick:embedded:AlertNode.ServiceNow
+type ServiceNowHandler struct {
+	*AlertNode
+
+	// Lowest level for which an incident is opened, one of INFO, WARNING or CRITICAL.
+	// Default is WARNING.
+	MinLevel string
+
+	// Short description of the incident.
+	// Can be a template and has access to the same data as the AlertNode.Details property.
+	// Default is the message of the alert.
+	Summary string
+
+	// Description of the incident.
+	// Can be a template and has access to the same data as the AlertNode.Details property.
+	// Default is the details of the alert.
+	Description string
+
+	// Additional incident fields.
+	// tick:ignore
+	FieldsMap map[string]string `tick:"Field"`
+}
+
+// Set an additional field of the incident, i.e. 'assignment_group'.
+// The value can be a template and has access to the same data as the AlertNode.Details property.
+// tick:property
+func (s *ServiceNowHandler) Field(name, value string) *ServiceNowHandler {
+	s.FieldsMap[name] = value
+	return s
+}
+
+// Send the alert to Prometheus Alertmanager.
+// Alerts are posted to the '/api/v1/alerts' endpoint of Alertmanager.
+//
+// Example:
+//    [alertmanager]
+//      enabled = true
+//      url = "http://localhost:9093"
+//
+// Example:
+//    stream
+//         |alert()
+//             .alertmanager()
+//
+// The labels of the Alertmanager alert are the tags of the alert data
+// plus the 'alertname' and 'severity' labels.
+// The alert name defaults to the name of the alert data, i.e. the measurement.
+// The severity is 'critical', 'warning' or 'info' depending on the alert level.
+// The message and the details of the alert are sent as the 'summary' and
+// 'description' annotations and the alert ID as the 'alert_id' annotation.
+//
+// Alertmanager alerts start when the alert leaves the OK level
+// and end when it returns to the OK level.
+// Since the severity is a label, changing the level starts a new Alertmanager alert
+// and ends the one with the previous severity.
+// Active alerts are re-sent periodically, see the 'resend-interval' option,
+// so that Alertmanager does not consider them resolved.
+//
+// Additional named endpoints can be configured.
+//
+// Example:
+//    [[alertmanager.endpoint]]
+//      name = "ops"
+//      url = "http://alertmanager.ops.example.com:9093"
+//
+// Example:
+//    stream
+//         |alert()
+//             .alertmanager()
+//                 .endpoint('ops')
+//                 .alertName('HighCPU')
+//
+// Send alerts named 'HighCPU' to the Alertmanager of the 'ops' endpoint.
+//
+// If the 'alertmanager' section in the configuration has the option: global = true
+// then all alerts are sent to Alertmanager without the need to explicitly state it
+// in the TICKscript.
+//
+// tick:property
+func (a *AlertNode) Alertmanager() *AlertmanagerHandler {
+	am := &AlertmanagerHandler{
+		AlertNode: a,
+	}
+	a.AlertmanagerHandlers = append(a.AlertmanagerHandlers, am)
+	return am
+}
+
+// tick:embedded:AlertNode.Alertmanager
+type AlertmanagerHandler struct {
+	*AlertNode
+
+	// Name of the configured Alertmanager endpoint.
+	// If empty uses the top level URL from the configuration.
+	Endpoint string
+
+	// Value of the 'alertname' label.
+	// If empty uses the name of the alert data.
+	AlertName string
+}
+
+// Send the alert to a syslog server.
+// Messages are sent over UDP, TCP, optionally using TLS, or a local unix socket
+// in either the RFC 5424 or the RFC 3164 format.
+//
+// Example:
+//    [syslog]
+//      enabled = true
+//      network = "tcp"
+//      address = "siem.example.com:6514"
+//      tls = true
+//      format = "rfc5424"
+//      facility = "local0"
+//
+// Example:
+//    stream
+//         |alert()
+//             .syslog()
+//
+// The severity of the messages is 'critical', 'warning', 'informational' or 'notice'
+// for the CRITICAL, WARNING, INFO and OK levels respectively.
+// RFC 5424 messages carry the alert ID, task name and level as well as the tags
+// of the alert data as structured data.
+//
+// Example:
+//    stream
+//         |alert()
+//             .syslog()
+//                 .facility('auth')
+//
+// Send alerts to syslog using the 'auth' facility.
+//
+// If the 'syslog' section in the configuration has the option: global = true
+// then all alerts are sent to syslog without the need to explicitly state it
+// in the TICKscript.
+//
+// tick:property
+func (a *AlertNode) Syslog() *SyslogHandler {
+	sl := &SyslogHandler{
+		AlertNode: a,
+	}
+	a.SyslogHandlers = append(a.SyslogHandlers, sl)
+	return sl
+}
+
+// The names of the syslog facilities.
+var syslogFacilities = map[string]bool{
+	"kern":     true,
+	"user":     true,
+	"mail":     true,
+	"daemon":   true,
+	"auth":     true,
+	"syslog":   true,
+	"lpr":      true,
+	"news":     true,
+	"uucp":     true,
+	"cron":     true,
+	"authpriv": true,
+	"ftp":      true,
+	"local0":   true,
+	"local1":   true,
+	"local2":   true,
+	"local3":   true,
+	"local4":   true,
+	"local5":   true,
+	"local6":   true,
+	"local7":   true,
+}
+
+// tick:embedded:AlertNode.Syslog
+type SyslogHandler struct {
+	*AlertNode
+
+	// The syslog facility of the messages, i.e. 'daemon', 'auth' or 'local0' to 'local7'.
+	// If empty uses the facility from the configuration.
+	Facility string
+}
+
 // Send the alert to Slack.
 // To allow Kapacitor to post to Slack,
 // go to the URL https://slack.com/services/new/incoming-webhook
@@ -909,6 +1428,48 @@ type SensuHandler struct {
 //         |alert()
 //
 // Send alert to Slack using default channel '#general'.
+//
+// Additional named workspaces can be configured,
+// each with its own webhook URL or Web API token.
+// Alerts are sent to the top level workspace unless a workspace is selected.
+//
+// Example:
+//    [[slack.workspace]]
+//      name = "ops"
+//      url = "https://hooks.slack.com/services/yyyyyyyyy/yyyyyyyyy/yyyyyyyyyyyyyyyyyyyyyyyy"
+//      channel = "#ops"
+//
+// Example:
+//    stream
+//         |alert()
+//             .slack()
+//             .workspace('ops')
+//
+// Send alerts to the '#ops' channel of the 'ops' workspace.
+//
+// Messages are sent as attachments colored by the alert level.
+// The colors and an icon emoji per level can be set in the configuration.
+// The attachment can have a title and a list of fields,
+// the title and field values are templates with access to the same data as the AlertNode.Details property.
+//
+// Example:
+//    [slack]
+//      enabled = true
+//      url = "https://hooks.slack.com/services/xxxxxxxxx/xxxxxxxxx/xxxxxxxxxxxxxxxxxxxxxxxx"
+//      [slack.colors]
+//        CRITICAL = "#ff0000"
+//      [slack.emoji]
+//        CRITICAL = ":fire:"
+//
+// Example:
+//    stream
+//         |alert()
+//             .slack()
+//             .title('{{ .Name }} is {{ .Level }}')
+//             .shortField('Host', '{{ index .Tags "host" }}')
+//             .shortField('Value', '{{ index .Fields "value" }}')
+//             .field('Message', '{{ .Message }}')
+//
 // tick:property
 func (a *AlertNode) Slack() *SlackHandler {
 	slack := &SlackHandler{
@@ -925,6 +1486,70 @@ type SlackHandler struct {
 	// Slack channel in which to post messages.
 	// If empty uses the channel from the configuration.
 	Channel string
+
+	// Name of the configured Slack workspace to post messages to.
+	// If empty uses the top level workspace from the configuration.
+	// The workspace must exist when the task is defined.
+	Workspace string
+
+	// Title of the message attachment.
+	// Can be a template and has access to the same data as the AlertNode.Details property.
+	// Default is no title.
+	Title string
+
+	// Fields of the message attachment.
+	// tick:ignore
+	FieldsList []SlackField `tick:"Field"`
+
+	// Short fields of the message attachment.
+	// tick:ignore
+	ShortFieldsList []SlackField `tick:"ShortField"`
+
+	// Whether to thread alert messages.
+	// tick:ignore
+	IsThreaded bool `tick:"Thread"`
+}
+
+// A field of a Slack message attachment.
+type SlackField struct {
+	Title string
+	// Can be a template and has access to the same data as the AlertNode.Details property.
+	Value string
+}
+
+// Add a field to the message attachment.
+// The value can be a template and has access to the same data as the AlertNode.Details property.
+// tick:property
+func (s *SlackHandler) Field(title, value string) *SlackHandler {
+	s.FieldsList = append(s.FieldsList, SlackField{Title: title, Value: value})
+	return s
+}
+
+// Add a short field to the message attachment.
+// Short fields are displayed side by side and before all other fields.
+// The value can be a template and has access to the same data as the AlertNode.Details property.
+// tick:property
+func (s *SlackHandler) ShortField(title, value string) *SlackHandler {
+	s.ShortFieldsList = append(s.ShortFieldsList, SlackField{Title: title, Value: value})
+	return s
+}
+
+// Thread the alert messages.
+// The first message of an alert starts a new thread,
+// all following messages, including the recovery, are posted as replies to it.
+// Once the alert recovers the next message starts a new thread.
+// Threading requires a Web API token in the configuration of the workspace.
+//
+// Example:
+//    stream
+//         |alert()
+//             .slack()
+//             .thread()
+//
+// tick:property
+func (s *SlackHandler) Thread() *SlackHandler {
+	s.IsThreaded = true
+	return s
 }
 
 // Send alert to OpsGenie.
@@ -102,6 +102,8 @@ type QueryNode struct {
 	// The specific cron implementation is documented here:
 	// https://github.com/gorhill/cronexpr#implementation
 	//
+	// The schedule is in the timezone of the node, or the local time zone of the server.
+	//
 	// The Cron property is mutually exclusive with the Every property.
 	Cron string
 
@@ -143,6 +145,7 @@ func newQueryNode() *QueryNode {
 func (n *QueryNode) ChainMethods() map[string]reflect.Value {
 	return map[string]reflect.Value{
 		"GroupBy": reflect.ValueOf(n.chainnode.GroupBy),
+		"Fill":    reflect.ValueOf(n.chainnode.Fill),
 	}
 }
 
@@ -166,6 +169,7 @@ func (b *QueryNode) GroupBy(d ...interface{}) *QueryNode {
 
 // Align start and stop times for quiries with even boundaries of the QueryNode.Every property.
 // Does not apply if using the QueryNode.Cron property.
+// If the node has a timezone the boundaries are aligned in the wall clock time of the time zone.
 // tick:property
 func (b *QueryNode) Align() *QueryNode {
 	b.AlignFlag = true
@@ -0,0 +1,270 @@
+package pipeline
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/influxdata/kapacitor/tick"
+	"github.com/influxdata/kapacitor/tick/stateful"
+)
+
+// Statically check the pipeline without any data.
+// The edges of the nodes must match and expressions must type check
+// against the field types that are known from the nodes before them.
+//
+// The types of fields are only known when they are created by the pipeline,
+// i.e. by eval, default and InfluxQL nodes, other fields are not checked.
+//
+// The checks are not part of CreatePipeline, so that they only run
+// when a task is defined and not each time it is loaded.
+// tick:ignore
+func (p *Pipeline) Check() tick.Diagnostics {
+	c := &checker{
+		fieldTypes: make(map[ID]map[string]stateful.ValueType),
+	}
+	p.Walk(func(n Node) error {
+		c.checkEdges(n)
+		c.fieldTypes[n.ID()] = c.checkNode(n, c.inputTypes(n))
+		return nil
+	})
+	return c.diagnostics
+}
+
+type checker struct {
+	// Known field types of the data each node provides.
+	fieldTypes  map[ID]map[string]stateful.ValueType
+	diagnostics tick.Diagnostics
+}
+
+func (c *checker) errorf(p tick.Position, fmtStr string, args ...interface{}) {
+	c.diagnostics = append(c.diagnostics, tick.NewDiagnostic(p, fmtStr, args...))
+}
+
+func (c *checker) checkEdges(n Node) {
+	if n.Wants() == NoEdge {
+		return
+	}
+	parents := n.Parents()
+	if _, ok := n.(*LookupJoinNode); ok && len(parents) > 0 {
+		// The reference data may be either a stream or a batch.
+		parents = parents[:1]
+	}
+	for _, parent := range parents {
+		if parent.Provides() != n.Wants() {
+			c.errorf(n.position(), "cannot link %s to %s, mismatched edges: %s -> %s", parent.Name(), n.Name(), parent.Provides(), n.Wants())
+		}
+	}
+}
+
+// The known field types of the data arriving at the node.
+func (c *checker) inputTypes(n Node) map[string]stateful.ValueType {
+	types := make(map[string]stateful.ValueType)
+	parents := n.Parents()
+	switch node := n.(type) {
+	case *JoinNode:
+		for i, parent := range parents {
+			if i >= len(node.Names) {
+				break
+			}
+			for name, t := range c.fieldTypes[parent.ID()] {
+				types[node.Names[i]+"."+name] = t
+			}
+		}
+		return types
+	case *LookupJoinNode:
+		// The reference fields never overwrite the fields of the data.
+		parents = parents[:1]
+	}
+	for i, parent := range parents {
+		if i == 0 {
+			for name, t := range c.fieldTypes[parent.ID()] {
+				types[name] = t
+			}
+			continue
+		}
+		// Only the types all the parents agree on are known.
+		for name, t := range types {
+			if c.fieldTypes[parent.ID()][name] != t {
+				delete(types, name)
+			}
+		}
+	}
+	return types
+}
+
+// Check the node and return the known field types of the data it provides.
+func (c *checker) checkNode(n Node, types map[string]stateful.ValueType) map[string]stateful.ValueType {
+	switch node := n.(type) {
+	case *FromNode:
+		c.checkExpression(node.Expression, stateful.TBool, types)
+	case *WhereNode:
+		c.checkExpression(node.Expression, stateful.TBool, types)
+	case *AlertNode:
+		c.checkExpression(node.Info, stateful.TBool, types)
+		c.checkExpression(node.Warn, stateful.TBool, types)
+		c.checkExpression(node.Crit, stateful.TBool, types)
+	case *EvalNode:
+		return c.checkEval(node, types)
+	case *DefaultNode:
+		for name, value := range node.Fields {
+			switch value.(type) {
+			case float64:
+				types[name] = stateful.TFloat64
+			case int64:
+				types[name] = stateful.TInt64
+			case string:
+				types[name] = stateful.TString
+			case bool:
+				types[name] = stateful.TBool
+			}
+		}
+	case *DerivativeNode:
+		if t, ok := types[node.Field]; ok && t != stateful.TFloat64 && t != stateful.TInt64 {
+			c.errorf(node.position(), "cannot compute derivative of %s field %q", t, node.Field)
+		}
+		types[node.As] = stateful.TFloat64
+	case *ExtractNode:
+		if t, ok := types[node.Source]; ok && t != stateful.TString {
+			c.errorf(node.position(), "cannot extract from %s field %q, must be a string", t, node.Source)
+		}
+		tags := make(map[string]bool, len(node.TagsList))
+		for _, tag := range node.TagsList {
+			tags[tag] = true
+		}
+		for _, name := range node.captureNames() {
+			if !tags[name] {
+				types[name] = stateful.TString
+			}
+		}
+	case *InfluxQLNode:
+		return c.checkInfluxQL(node, types)
+	case *UDFNode, *StatsNode:
+		// The fields are created outside of the pipeline.
+		return make(map[string]stateful.ValueType)
+	}
+	return types
+}
+
+func (c *checker) checkEval(n *EvalNode, types map[string]stateful.ValueType) map[string]stateful.ValueType {
+	// The results of previous expressions are available to later expressions.
+	scope := make(map[string]stateful.ValueType, len(types))
+	for name, t := range types {
+		scope[name] = t
+	}
+	for i, expr := range n.Expressions {
+		if i >= len(n.AsList) {
+			break
+		}
+		c.checkExpression(expr, stateful.InvalidType, scope)
+		if t := stateful.InferType(expr, scope); t != stateful.InvalidType {
+			scope[n.AsList[i]] = t
+		} else {
+			delete(scope, n.AsList[i])
+		}
+	}
+	provided := make(map[string]stateful.ValueType)
+	switch {
+	case n.KeepFlag && len(n.KeepList) > 0:
+		for _, name := range n.KeepList {
+			if t, ok := scope[name]; ok {
+				provided[name] = t
+			}
+		}
+	case n.KeepFlag:
+		provided = scope
+	default:
+		for _, name := range n.AsList {
+			if t, ok := scope[name]; ok {
+				provided[name] = t
+			}
+		}
+	}
+	return provided
+}
+
+// Check the expression and that it compiles, an expression that is not set is ignored.
+func (c *checker) checkExpression(expr tick.Node, want stateful.ValueType, types map[string]stateful.ValueType) {
+	if expr == nil {
+		return
+	}
+	if diagnostics := stateful.Check(expr, want, types); len(diagnostics) > 0 {
+		c.diagnostics = append(c.diagnostics, diagnostics...)
+		return
+	}
+	if _, err := stateful.NewExpression(expr); err != nil {
+		c.errorf(expr, "%s", err)
+	}
+}
+
+func (c *checker) checkInfluxQL(n *InfluxQLNode, types map[string]stateful.ValueType) map[string]stateful.ValueType {
+	reducers := n.ReduceCreater.reducers()
+	provided := make(map[string]stateful.ValueType)
+	if len(reducers) == 0 {
+		return provided
+	}
+	var outputs []stateful.ValueType
+	if t, ok := types[n.Field]; ok {
+		for _, r := range reducers {
+			if r.in == t {
+				outputs = append(outputs, r.out)
+			}
+		}
+		if len(outputs) == 0 {
+			c.errorf(n.position(), "cannot compute %s of %s field %q", n.Method, t, n.Field)
+			return provided
+		}
+	} else {
+		for _, r := range reducers {
+			outputs = append(outputs, r.out)
+		}
+	}
+	// The output type is only known if all the possible reducers agree.
+	for _, t := range outputs[1:] {
+		if t != outputs[0] {
+			return provided
+		}
+	}
+	provided[n.As] = outputs[0]
+	return provided
+}
+
+// The input and output types of a reducer.
+type reducerTypes struct {
+	in, out stateful.ValueType
+}
+
+var reducerValueTypes = map[string]stateful.ValueType{
+	"Float":   stateful.TFloat64,
+	"Integer": stateful.TInt64,
+	"String":  stateful.TString,
+	"Boolean": stateful.TBool,
+}
+
+// The types of the reducers that are set,
+// derived from the names of the fields, i.e. CreateFloatIntegerReducer or CreateFloatBulkIntegerReducer.
+func (r ReduceCreater) reducers() []reducerTypes {
+	var reducers []reducerTypes
+	v := reflect.ValueOf(r)
+	for i := 0; i < v.NumField(); i++ {
+		name := v.Type().Field(i).Name
+		if !strings.HasPrefix(name, "Create") || !strings.HasSuffix(name, "Reducer") || v.Field(i).IsNil() {
+			continue
+		}
+		name = strings.TrimSuffix(strings.TrimPrefix(name, "Create"), "Reducer")
+		name = strings.Replace(name, "Bulk", "", 1)
+		var types reducerTypes
+		for typeName, t := range reducerValueTypes {
+			if strings.HasPrefix(name, typeName) {
+				types.in = t
+				types.out = t
+				if out, ok := reducerValueTypes[strings.TrimPrefix(name, typeName)]; ok {
+					types.out = out
+				}
+			}
+		}
+		if types.in != stateful.InvalidType {
+			reducers = append(reducers, types)
+		}
+	}
+	return reducers
+}
@@ -18,6 +18,17 @@ import (
 // data point with the result of `error_count / total_count` where
 // `error_count` and `total_count` are existing fields on the data point.
 //
+// A data point is dropped if an expression references a missing field or tag.
+// Use the `if`, `isPresent` functions and the `??` operator to handle missing values instead.
+//
+// Example:
+//    stream
+//        |eval(lambda: if(isPresent("total_count"), "error_count" / "total_count", 0.0), lambda: "region" ?? 'unknown')
+//          .as('error_percent', 'region')
+//
+// The `??` operator returns the referenced value, or the right operand if the reference is missing.
+// The `if` function only evaluates the branch selected by the condition.
+//
 // Available Statistics:
 //
 //    * eval_errors -- number of errors evaluating any expressions.
@@ -0,0 +1,115 @@
+package pipeline
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// Extract parts of a tag or string field into new tags or fields
+// using the named capture groups of a regular expression.
+//
+// Example:
+//    stream
+//        |from()
+//            .measurement('cpu')
+//            .groupBy('host')
+//        |extract('host', /^(?P<role>[a-z]+)-(?P<number>[0-9]+)\.(?P<dc>[a-z0-9]+)$/)
+//            .tags('role', 'dc')
+//            .regroup()
+//        |window()
+//            .period(1m)
+//            .every(1m)
+//        |count('value')
+//
+// The above example splits the host `web-12.dc3` into the tags `role` and `dc`
+// with the values `web` and `dc3`, and the string field `number` with the value `12`.
+// Since the data is regrouped the points are counted per host, role and dc.
+//
+// Named capture groups are written as string fields, unless they are written as tags with the tags property.
+// Unnamed capture groups are ignored.
+// Data whose tag or field is missing or does not match the regular expression is passed on unchanged.
+//
+// Available Statistics:
+//
+//    * points_unmatched -- number of points whose tag or field was missing or did not match
+//
+type ExtractNode struct {
+	chainnode
+
+	// The name of the tag or string field to extract from.
+	// tick:ignore
+	Source string
+
+	// The regular expression whose named capture groups are extracted.
+	// tick:ignore
+	Pattern *regexp.Regexp
+
+	// The names of the capture groups written as tags.
+	// tick:ignore
+	TagsList []string `tick:"Tags"`
+
+	// Whether to group by the tags written, in addition to the current dimensions.
+	// tick:ignore
+	RegroupFlag bool `tick:"Regroup"`
+}
+
+func newExtractNode(e EdgeType, source string, pattern *regexp.Regexp) *ExtractNode {
+	return &ExtractNode{
+		chainnode: newBasicChainNode("extract", e, e),
+		Source:    source,
+		Pattern:   pattern,
+	}
+}
+
+// Write the named capture groups as tags instead of fields.
+// tick:property
+func (n *ExtractNode) Tags(names ...string) *ExtractNode {
+	n.TagsList = names
+	return n
+}
+
+// Group the data by the tags written in addition to the current dimensions.
+// Batches are split into a batch for each of the new groups.
+// tick:property
+func (n *ExtractNode) Regroup() *ExtractNode {
+	n.RegroupFlag = true
+	return n
+}
+
+// The names of the named capture groups of the pattern.
+func (n *ExtractNode) captureNames() []string {
+	var names []string
+	for _, name := range n.Pattern.SubexpNames() {
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func (n *ExtractNode) validate() error {
+	if n.Source == "" {
+		return errors.New("must provide the tag or field to extract from")
+	}
+	if n.Pattern == nil {
+		return errors.New("must provide a regular expression to extract with")
+	}
+	names := n.captureNames()
+	if len(names) == 0 {
+		return fmt.Errorf("regular expression %s has no named capture groups, i.e. (?P<name>...)", n.Pattern)
+	}
+TAGS:
+	for _, tag := range n.TagsList {
+		for _, name := range names {
+			if tag == name {
+				continue TAGS
+			}
+		}
+		return fmt.Errorf("tag %q is not a named capture group of %s", tag, n.Pattern)
+	}
+	if n.RegroupFlag && len(n.TagsList) == 0 {
+		return errors.New("cannot regroup without tags, see the tags property")
+	}
+	return nil
+}
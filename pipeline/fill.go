@@ -0,0 +1,92 @@
+package pipeline
+
+import (
+	"fmt"
+	"time"
+)
+
+// Default maximum number of points generated for a gap.
+const defaultFillMaxPoints = 1000
+
+// Fill gaps in the data of each group with generated points.
+// A point is generated for each expected interval without data,
+// so that nodes like derivative or dashboards fed by httpOut do not misbehave on gaps.
+// Stream points are filled per group as the next point arrives,
+// batch points are filled between the points of each batch.
+//
+// Example:
+//    stream
+//        |from()
+//            .measurement('requests')
+//            .groupBy('host')
+//        |fill(10s)
+//            .value('previous')
+//        |derivative('count')
+//
+// The above example expects a point every `10 seconds` for each host.
+// If a host misses a point, a point with the field values of the previous point is generated.
+//
+// Unlike the JoinNode fill property, which only applies within joins,
+// the fill node applies to any stream or batch data.
+//
+// Gaps missing more than maxPoints points, e.g. after an outage of the source, are not filled.
+//
+// Available Statistics:
+//
+//    * points_filled -- number of points generated
+//    * points_skipped -- number of points not generated for gaps missing more than maxPoints points
+//
+type FillNode struct {
+	chainnode
+
+	// The expected interval between points.
+	// tick:ignore
+	Every time.Duration
+
+	// The value of the fields of generated points.
+	// Options are:
+	//
+	//   - null - (default) fill fields with null.
+	//     Lambda expressions treat null fields as missing,
+	//     i.e. isPresent() is false and the ?? operator uses its default.
+	//   - previous - fill fields with the values of the previous point.
+	//   - linear - interpolate numeric fields linearly between the previous and next point,
+	//     other fields are filled with the values of the previous point.
+	//   - Any numerical value - fill fields with the given value.
+	Value interface{}
+
+	// The maximum number of points generated for a gap.
+	// Gaps missing more points are not filled.
+	// Default: 1000
+	MaxPoints int64
+}
+
+func newFillNode(e EdgeType, every time.Duration) *FillNode {
+	return &FillNode{
+		chainnode: newBasicChainNode("fill", e, e),
+		Every:     every,
+		Value:     "null",
+		MaxPoints: defaultFillMaxPoints,
+	}
+}
+
+func (n *FillNode) validate() error {
+	if n.Every <= 0 {
+		return fmt.Errorf("fill interval must be positive, got %v", n.Every)
+	}
+	if n.MaxPoints <= 0 {
+		return fmt.Errorf("fill max points must be positive, got %d", n.MaxPoints)
+	}
+	switch v := n.Value.(type) {
+	case int64, float64:
+	case string:
+		switch v {
+		case "null", "previous", "linear":
+		default:
+			return fmt.Errorf("unexpected fill value %s, must be null, previous, linear or a number", v)
+		}
+	default:
+		return fmt.Errorf("unexpected fill value of type %T, must be null, previous, linear or a number", n.Value)
+	}
+	return nil
+}
@@ -154,12 +154,12 @@ func (n *chainnode) Median(field string) *InfluxQLNode {
 // Compute the difference between min and max points.
 func (n *chainnode) Spread(field string) *InfluxQLNode {
 	i := newInfluxQLNode("spread", field, n.Provides(), StreamEdge, ReduceCreater{
-		CreateFloatBulkReducer: func() (FloatBulkPointAggregator, influxql.FloatPointEmitter) {
-			fn := influxql.NewFloatSliceFuncReducer(influxql.FloatSpreadReduceSlice)
+		CreateFloatReducer: func() (influxql.FloatPointAggregator, influxql.FloatPointEmitter) {
+			fn := newFloatSpreadReducer()
 			return fn, fn
 		},
-		CreateIntegerBulkReducer: func() (IntegerBulkPointAggregator, influxql.IntegerPointEmitter) {
-			fn := influxql.NewIntegerSliceFuncReducer(influxql.IntegerSpreadReduceSlice)
+		CreateIntegerReducer: func() (influxql.IntegerPointAggregator, influxql.IntegerPointEmitter) {
+			fn := newIntegerSpreadReducer()
 			return fn, fn
 		},
 	})
@@ -362,12 +362,12 @@ func (n *chainnode) Bottom(num int64, field string, fieldsAndTags ...string) *In
 // Compute the standard deviation.
 func (n *chainnode) Stddev(field string) *InfluxQLNode {
 	i := newInfluxQLNode("stddev", field, n.Provides(), StreamEdge, ReduceCreater{
-		CreateFloatBulkReducer: func() (FloatBulkPointAggregator, influxql.FloatPointEmitter) {
-			fn := influxql.NewFloatSliceFuncReducer(influxql.FloatStddevReduceSlice)
+		CreateFloatReducer: func() (influxql.FloatPointAggregator, influxql.FloatPointEmitter) {
+			fn := newStddevReducer()
 			return fn, fn
 		},
-		CreateIntegerBulkFloatReducer: func() (IntegerBulkPointAggregator, influxql.FloatPointEmitter) {
-			fn := influxql.NewIntegerSliceFuncFloatReducer(influxql.IntegerStddevReduceSlice)
+		CreateIntegerFloatReducer: func() (influxql.IntegerPointAggregator, influxql.FloatPointEmitter) {
+			fn := newStddevReducer()
 			return fn, fn
 		},
 	})
@@ -0,0 +1,109 @@
+package pipeline
+
+import (
+	"math"
+
+	"github.com/influxdata/influxdb/influxql"
+)
+
+// Reducers keeping only a running state instead of all points,
+// so that windows can be reduced one point at a time.
+
+// Computes the difference between the min and max values of float points.
+type floatSpreadReducer struct {
+	min, max float64
+	count    int
+}
+
+func newFloatSpreadReducer() *floatSpreadReducer {
+	return &floatSpreadReducer{}
+}
+
+func (r *floatSpreadReducer) AggregateFloat(p *influxql.FloatPoint) {
+	if r.count == 0 {
+		r.min, r.max = p.Value, p.Value
+	} else {
+		r.min = math.Min(r.min, p.Value)
+		r.max = math.Max(r.max, p.Value)
+	}
+	r.count++
+}
+
+func (r *floatSpreadReducer) Emit() []influxql.FloatPoint {
+	if r.count == 0 {
+		return nil
+	}
+	return []influxql.FloatPoint{{Time: influxql.ZeroTime, Value: r.max - r.min}}
+}
+
+// Computes the difference between the min and max values of integer points.
+type integerSpreadReducer struct {
+	min, max int64
+	count    int
+}
+
+func newIntegerSpreadReducer() *integerSpreadReducer {
+	return &integerSpreadReducer{}
+}
+
+func (r *integerSpreadReducer) AggregateInteger(p *influxql.IntegerPoint) {
+	if r.count == 0 || p.Value < r.min {
+		r.min = p.Value
+	}
+	if r.count == 0 || p.Value > r.max {
+		r.max = p.Value
+	}
+	r.count++
+}
+
+func (r *integerSpreadReducer) Emit() []influxql.IntegerPoint {
+	if r.count == 0 {
+		return nil
+	}
+	return []influxql.IntegerPoint{{Time: influxql.ZeroTime, Value: r.max - r.min}}
+}
+
+// Computes the sample standard deviation using Welford's online algorithm.
+// Like the InfluxQL stddev, NaN values are ignored
+// and fewer than two points produce a nil point.
+type stddevReducer struct {
+	// Number of points including NaN values.
+	n int
+	// Number of values, their mean and sum of squared differences from the mean.
+	count int
+	mean  float64
+	m2    float64
+}
+
+func newStddevReducer() *stddevReducer {
+	return &stddevReducer{}
+}
+
+func (r *stddevReducer) add(v float64) {
+	r.n++
+	if math.IsNaN(v) {
+		return
+	}
+	r.count++
+	delta := v - r.mean
+	r.mean += delta / float64(r.count)
+	r.m2 += delta * (v - r.mean)
+}
+
+func (r *stddevReducer) AggregateFloat(p *influxql.FloatPoint) {
+	r.add(p.Value)
+}
+
+func (r *stddevReducer) AggregateInteger(p *influxql.IntegerPoint) {
+	r.add(float64(p.Value))
+}
+
+func (r *stddevReducer) Emit() []influxql.FloatPoint {
+	if r.n < 2 {
+		return []influxql.FloatPoint{{Time: influxql.ZeroTime, Nil: true}}
+	}
+	return []influxql.FloatPoint{{
+		Time:  influxql.ZeroTime,
+		Value: math.Sqrt(r.m2 / float64(r.count-1)),
+	}}
+}
@@ -2,6 +2,7 @@ package pipeline
 
 import (
 	"fmt"
+	"reflect"
 	"strings"
 	"time"
 )
@@ -89,6 +90,13 @@ func newJoinNode(e EdgeType, parents []Node) *JoinNode {
 	return j
 }
 
+//tick:ignore
+func (j *JoinNode) ChainMethods() map[string]reflect.Value {
+	return map[string]reflect.Value{
+		"Fill": reflect.ValueOf(j.chainnode.Fill),
+	}
+}
+
 // Prefix names for all fields from the respective nodes.
 // Each field from the parent nodes will be prefixed with the provided name and a '.'.
 // See the example above.
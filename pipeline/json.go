@@ -0,0 +1,495 @@
+package pipeline
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdata/influxdb/influxql"
+	"github.com/influxdata/kapacitor/tick"
+)
+
+// A chaining, dynamic or property method called on a node while evaluating a TICKscript.
+type call struct {
+	// The node the chaining or dynamic method was called on, nil for property methods.
+	receiver Node
+	method   string
+	dynamic  bool
+	args     []interface{}
+}
+
+// JSON representation of a pipeline.
+//
+// Each node records the chaining method that created it and the property methods called on it,
+// so that the pipeline can be recreated by replaying the calls.
+// Nodes created implicitly, like the stream or batch source of the pipeline or the nodes of a deadman switch,
+// have no chaining method.
+type pipelineJSON struct {
+	Nodes []nodeJSON `json:"nodes"`
+}
+
+type nodeJSON struct {
+	ID          ID         `json:"id"`
+	Name        string     `json:"name"`
+	Type        string     `json:"type"`
+	Parents     []ID       `json:"parents"`
+	ChainMethod *callJSON  `json:"chainMethod,omitempty"`
+	Properties  []callJSON `json:"properties,omitempty"`
+}
+
+type nodesByID []Node
+
+func (n nodesByID) Len() int           { return len(n) }
+func (n nodesByID) Less(i, j int) bool { return n[i].ID() < n[j].ID() }
+func (n nodesByID) Swap(i, j int)      { n[i], n[j] = n[j], n[i] }
+
+type nodeJSONsByID []*nodeJSON
+
+func (n nodeJSONsByID) Len() int           { return len(n) }
+func (n nodeJSONsByID) Less(i, j int) bool { return n[i].ID < n[j].ID }
+func (n nodeJSONsByID) Swap(i, j int)      { n[i], n[j] = n[j], n[i] }
+
+type callJSON struct {
+	// The ID of the node the chaining method is called on.
+	Receiver *ID       `json:"receiver,omitempty"`
+	Method   string    `json:"method"`
+	Dynamic  bool      `json:"dynamic,omitempty"`
+	Args     []argJSON `json:"args"`
+}
+
+// An argument of a call, the type determines the JSON type of the value:
+//
+//    * string, regex, lambda and reference -- string
+//    * int and float -- number
+//    * bool -- boolean
+//    * duration -- string, i.e. "10s"
+//    * node -- number, the ID of the node
+//    * star -- no value
+type argJSON struct {
+	Type  string          `json:"type"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+const (
+	argString    = "string"
+	argInt       = "int"
+	argFloat     = "float"
+	argBool      = "bool"
+	argDuration  = "duration"
+	argRegex     = "regex"
+	argStar      = "star"
+	argLambda    = "lambda"
+	argReference = "reference"
+	argNode      = "node"
+)
+
+// Marshal the pipeline to JSON.
+// Only the calls recorded while evaluating the TICKscript of the pipeline are marshaled,
+// nodes created or modified directly in Go are not.
+// tick:ignore
+func (p *Pipeline) MarshalJSON() ([]byte, error) {
+	var nodes []Node
+	p.Walk(func(n Node) error {
+		nodes = append(nodes, n)
+		return nil
+	})
+	sort.Sort(nodesByID(nodes))
+
+	pj := pipelineJSON{Nodes: make([]nodeJSON, len(nodes))}
+	for i, n := range nodes {
+		nj := nodeJSON{
+			ID:      n.ID(),
+			Name:    n.Name(),
+			Type:    n.Desc(),
+			Parents: make([]ID, len(n.Parents())),
+		}
+		for j, parent := range n.Parents() {
+			nj.Parents[j] = parent.ID()
+		}
+		creation, properties := n.calls()
+		if creation != nil {
+			c, err := newCallJSON(*creation)
+			if err != nil {
+				return nil, fmt.Errorf("node %s: %v", n.Name(), err)
+			}
+			nj.ChainMethod = &c
+		}
+		for _, property := range properties {
+			c, err := newCallJSON(property)
+			if err != nil {
+				return nil, fmt.Errorf("node %s: %v", n.Name(), err)
+			}
+			nj.Properties = append(nj.Properties, c)
+		}
+		pj.Nodes[i] = nj
+	}
+	return json.Marshal(pj)
+}
+
+// Unmarshal the pipeline from JSON by evaluating the TICKscript of its calls.
+// UDFs and the global deadman switch are not available, use CreatePipelineFromJSON instead if they are needed.
+// tick:ignore
+func (p *Pipeline) UnmarshalJSON(data []byte) error {
+	created, err := CreatePipelineFromJSON(data, tick.NewScope(), noDeadman{})
+	if err != nil {
+		return err
+	}
+	*p = *created
+	for _, n := range p.sorted {
+		n.setPipeline(p)
+	}
+	return nil
+}
+
+// Create a pipeline from its JSON representation, see Pipeline.MarshalJSON.
+// tick:ignore
+func CreatePipelineFromJSON(data []byte, scope *tick.Scope, deadman DeadmanService) (*Pipeline, error) {
+	script, edge, err := tickscriptFromJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	return CreatePipeline(script, edge, scope, deadman)
+}
+
+// Convert the JSON representation of a pipeline into a formatted TICKscript.
+// tick:ignore
+func JSONToTICKscript(data []byte) (string, error) {
+	script, _, err := tickscriptFromJSON(data)
+	return script, err
+}
+
+// Deadman service without a global deadman switch.
+type noDeadman struct{}
+
+func (noDeadman) Interval() time.Duration { return 0 }
+func (noDeadman) Threshold() float64      { return 0 }
+func (noDeadman) Id() string              { return "" }
+func (noDeadman) Message() string         { return "" }
+func (noDeadman) Global() bool            { return false }
+
+func newCallJSON(c call) (callJSON, error) {
+	cj := callJSON{
+		Method:  c.method,
+		Dynamic: c.dynamic,
+		Args:    make([]argJSON, len(c.args)),
+	}
+	if c.receiver != nil {
+		id := c.receiver.ID()
+		cj.Receiver = &id
+	}
+	for i, arg := range c.args {
+		a, err := newArgJSON(arg)
+		if err != nil {
+			return callJSON{}, fmt.Errorf("argument %d of %s: %v", i, c.method, err)
+		}
+		cj.Args[i] = a
+	}
+	return cj, nil
+}
+
+func newArgJSON(arg interface{}) (argJSON, error) {
+	var typ string
+	var value interface{}
+	switch a := arg.(type) {
+	case string:
+		typ, value = argString, a
+	case int64:
+		typ, value = argInt, a
+	case float64:
+		typ, value = argFloat, a
+	case bool:
+		typ, value = argBool, a
+	case time.Duration:
+		typ, value = argDuration, influxql.FormatDuration(a)
+	case *regexp.Regexp:
+		typ, value = argRegex, a.String()
+	case *tick.StarNode:
+		return argJSON{Type: argStar}, nil
+	case *tick.ReferenceNode:
+		typ, value = argReference, a.Reference
+	case tick.Node:
+		var buf bytes.Buffer
+		a.Format(&buf, "", false)
+		typ, value = argLambda, buf.String()
+	case Node:
+		typ, value = argNode, a.ID()
+	default:
+		return argJSON{}, fmt.Errorf("cannot marshal value of type %T", arg)
+	}
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return argJSON{}, err
+	}
+	return argJSON{Type: typ, Value: raw}, nil
+}
+
+// Matches the identifiers that can be used as var names.
+var identifier = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// Identifiers that cannot be used as var names.
+var reservedNames = map[string]bool{
+	"stream": true,
+	"batch":  true,
+	"var":    true,
+	"lambda": true,
+	"import": true,
+	"def":    true,
+	"TRUE":   true,
+	"FALSE":  true,
+	"AND":    true,
+	"OR":     true,
+}
+
+// Generate a TICKscript replaying the calls of the nodes.
+//
+// The nodes are created in the order of their IDs, so that the nodes of the evaluated TICKscript get the same IDs.
+// A node is chained onto the statement of the previous node if it is its only child,
+// otherwise the node starts a new statement and the nodes it is called on or passed to are declared as vars.
+func tickscriptFromJSON(data []byte) (string, EdgeType, error) {
+	var pj pipelineJSON
+	if err := json.Unmarshal(data, &pj); err != nil {
+		return "", NoEdge, fmt.Errorf("invalid pipeline JSON: %v", err)
+	}
+	nodes := make(map[ID]*nodeJSON, len(pj.Nodes))
+	for i := range pj.Nodes {
+		n := &pj.Nodes[i]
+		if _, ok := nodes[n.ID]; ok {
+			return "", NoEdge, fmt.Errorf("duplicate node ID %d", n.ID)
+		}
+		nodes[n.ID] = n
+	}
+
+	// Find the source and the nodes created by chaining methods in the order of their IDs.
+	edge := NoEdge
+	var source ID
+	var created []*nodeJSON
+	for i := range pj.Nodes {
+		n := &pj.Nodes[i]
+		if n.ChainMethod != nil {
+			created = append(created, n)
+			continue
+		}
+		if len(n.Parents) != 0 {
+			// Created implicitly by the chaining method of another node.
+			continue
+		}
+		var e EdgeType
+		switch n.Type {
+		case "stream":
+			e = StreamEdge
+		case "batch":
+			e = BatchEdge
+		default:
+			continue
+		}
+		if edge != NoEdge {
+			return "", NoEdge, fmt.Errorf("pipeline must have a single stream or batch source, found nodes %d and %d", source, n.ID)
+		}
+		edge, source = e, n.ID
+	}
+	if edge == NoEdge {
+		return "", NoEdge, fmt.Errorf("pipeline must have a stream or batch source")
+	}
+	sort.Sort(nodeJSONsByID(created))
+
+	// Count how the nodes are used, to decide which are declared as vars.
+	children := make(map[ID]int)
+	referenced := make(map[ID]bool)
+	for _, n := range created {
+		if n.ChainMethod.Receiver == nil {
+			return "", NoEdge, fmt.Errorf("node %d: chaining method %s has no receiver", n.ID, n.ChainMethod.Method)
+		}
+		r := *n.ChainMethod.Receiver
+		if receiver, ok := nodes[r]; !ok || r >= n.ID || (receiver.ChainMethod == nil && r != source) {
+			return "", NoEdge, fmt.Errorf("node %d: invalid receiver %d", n.ID, r)
+		}
+		children[r]++
+		for _, c := range append([]callJSON{*n.ChainMethod}, n.Properties...) {
+			for _, arg := range c.Args {
+				if arg.Type != argNode {
+					continue
+				}
+				var id ID
+				if err := json.Unmarshal(arg.Value, &id); err != nil {
+					return "", NoEdge, fmt.Errorf("node %d: invalid node argument of %s: %v", n.ID, c.Method, err)
+				}
+				if a, ok := nodes[id]; !ok || id >= n.ID || (a.ChainMethod == nil && id != source) {
+					return "", NoEdge, fmt.Errorf("node %d: invalid node argument %d of %s", n.ID, id, c.Method)
+				}
+				referenced[id] = true
+			}
+		}
+	}
+	inline := make(map[ID]bool)
+	needsVar := make(map[ID]bool)
+	for i, n := range created {
+		r := *n.ChainMethod.Receiver
+		if i > 0 && created[i-1].ID == r && children[r] == 1 && !referenced[r] {
+			inline[n.ID] = true
+		} else {
+			needsVar[r] = true
+		}
+	}
+	for id := range referenced {
+		needsVar[id] = true
+	}
+
+	// Name the vars
+	names := map[ID]string{source: nodes[source].Type}
+	used := make(map[string]bool)
+	for _, n := range created {
+		if !needsVar[n.ID] {
+			continue
+		}
+		name := n.Name
+		if !identifier.MatchString(name) || reservedNames[name] || used[name] {
+			name = fmt.Sprintf("node%d", n.ID)
+		}
+		used[name] = true
+		names[n.ID] = name
+	}
+
+	var buf bytes.Buffer
+	for i, n := range created {
+		if !inline[n.ID] {
+			if i > 0 {
+				buf.WriteString("\n\n")
+			}
+			// The var is assigned the last node of the statement.
+			last := n
+			for j := i + 1; j < len(created) && inline[created[j].ID]; j++ {
+				last = created[j]
+			}
+			if needsVar[last.ID] {
+				buf.WriteString("var ")
+				buf.WriteString(names[last.ID])
+				buf.WriteString(" = ")
+			}
+			buf.WriteString(names[*n.ChainMethod.Receiver])
+		}
+		op := "|"
+		if n.ChainMethod.Dynamic {
+			op = "@"
+		}
+		if err := writeCall(&buf, op, *n.ChainMethod, names); err != nil {
+			return "", NoEdge, fmt.Errorf("node %d: %v", n.ID, err)
+		}
+		for _, p := range n.Properties {
+			if err := writeCall(&buf, ".", p, names); err != nil {
+				return "", NoEdge, fmt.Errorf("node %d: %v", n.ID, err)
+			}
+		}
+	}
+	buf.WriteByte('\n')
+
+	script, err := tick.Format(buf.String())
+	if err != nil {
+		return "", NoEdge, fmt.Errorf("invalid pipeline JSON: %v", err)
+	}
+	return script, edge, nil
+}
+
+func writeCall(buf *bytes.Buffer, op string, c callJSON, names map[ID]string) error {
+	if !identifier.MatchString(c.Method) {
+		return fmt.Errorf("invalid method name %q", c.Method)
+	}
+	buf.WriteString(op)
+	buf.WriteString(c.Method)
+	buf.WriteByte('(')
+	for i, arg := range c.Args {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		if err := writeArg(buf, arg, names); err != nil {
+			return fmt.Errorf("argument %d of %s: %v", i, c.Method, err)
+		}
+	}
+	buf.WriteByte(')')
+	return nil
+}
+
+func writeArg(buf *bytes.Buffer, arg argJSON, names map[ID]string) error {
+	var n tick.Node
+	switch arg.Type {
+	case argString:
+		var s string
+		if err := json.Unmarshal(arg.Value, &s); err != nil {
+			return err
+		}
+		n = &tick.StringNode{
+			Literal:      s,
+			TripleQuotes: strings.Contains(s, "\n") && !strings.Contains(s, "'''"),
+		}
+	case argInt:
+		var i int64
+		if err := json.Unmarshal(arg.Value, &i); err != nil {
+			return err
+		}
+		n = &tick.NumberNode{IsInt: true, Int64: i}
+	case argFloat:
+		var f float64
+		if err := json.Unmarshal(arg.Value, &f); err != nil {
+			return err
+		}
+		n = &tick.NumberNode{IsFloat: true, Float64: f}
+	case argBool:
+		var b bool
+		if err := json.Unmarshal(arg.Value, &b); err != nil {
+			return err
+		}
+		n = &tick.BoolNode{Bool: b}
+	case argDuration:
+		var s string
+		if err := json.Unmarshal(arg.Value, &s); err != nil {
+			return err
+		}
+		d, err := influxql.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		n = &tick.DurationNode{Dur: d}
+	case argRegex:
+		var s string
+		if err := json.Unmarshal(arg.Value, &s); err != nil {
+			return err
+		}
+		r, err := regexp.Compile(s)
+		if err != nil {
+			return err
+		}
+		n = &tick.RegexNode{Regex: r}
+	case argStar:
+		buf.WriteByte('*')
+		return nil
+	case argLambda:
+		var s string
+		if err := json.Unmarshal(arg.Value, &s); err != nil {
+			return err
+		}
+		buf.WriteString("lambda: ")
+		buf.WriteString(s)
+		return nil
+	case argReference:
+		var s string
+		if err := json.Unmarshal(arg.Value, &s); err != nil {
+			return err
+		}
+		n = &tick.ReferenceNode{Reference: s}
+	case argNode:
+		var id ID
+		if err := json.Unmarshal(arg.Value, &id); err != nil {
+			return err
+		}
+		buf.WriteString(names[id])
+		return nil
+	default:
+		return fmt.Errorf("unknown argument type %s", strconv.Quote(arg.Type))
+	}
+	n.Format(buf, "", false)
+	return nil
+}
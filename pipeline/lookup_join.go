@@ -0,0 +1,126 @@
+package pipeline
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Enriches the data with the fields of slowly changing reference data.
+// Unlike the JoinNode, points are not paired by time.
+// Instead the reference parent is kept as a table containing the latest point
+// for each combination of the dimensions of the lookup.
+// Each point of the data is enriched with the fields of the matching table entry.
+// The reference data is applied as it arrives,
+// the data is never held back waiting for reference data.
+// As a consequence the order of data and reference points with close times is not defined:
+// a data point may or may not be enriched with a reference point
+// that has the same or a slightly earlier time.
+// Send the reference data ahead of the data if the order matters.
+//
+// Example:
+//    var deploys = stream
+//        |from()
+//            .measurement('deploys')
+//    stream
+//        |from()
+//            .measurement('errors')
+//        |lookupJoin(deploys)
+//            .on('host')
+//            .as('deploy')
+//            // Forget deploys older than a day.
+//            .ttl(24h)
+//            // Use a default version for hosts without a known deploy.
+//            .default('version', 'unknown')
+//        |groupBy('deploy.version')
+//        ...
+//
+// In the above example each errors point is enriched with the fields of the latest
+// deploys point with the same host tag, i.e. the 'deploy.version' field.
+type LookupJoinNode struct {
+	chainnode
+
+	// The dimensions on which to lookup the reference data.
+	// If empty the latest reference point is used for all data.
+	// tick:ignore
+	Dimensions []string `tick:"On"`
+
+	// Optional prefix for the names of the reference fields.
+	// tick:ignore
+	Prefix string `tick:"As"`
+
+	// The maximum age of a table entry relative to the time of the data point.
+	// Older entries are treated as missing and are dropped from the table.
+	// If zero the entries are kept indefinitely.
+	Ttl time.Duration
+
+	// Default reference field values used when no table entry exists.
+	// tick:ignore
+	Defaults map[string]interface{} `tick:"Default"`
+}
+
+func newLookupJoinNode(e EdgeType, main, ref Node) *LookupJoinNode {
+	l := &LookupJoinNode{
+		chainnode: newBasicChainNode("lookup_join", e, e),
+		Defaults:  make(map[string]interface{}),
+	}
+	main.linkChild(l)
+	ref.linkChild(l)
+	return l
+}
+
+//tick:ignore
+func (l *LookupJoinNode) ChainMethods() map[string]reflect.Value {
+	return map[string]reflect.Value{
+		"Default": reflect.ValueOf(l.chainnode.Default),
+	}
+}
+
+// Lookup the reference data on specific tags.
+// Both the data and the reference data need to have the tags.
+// tick:property
+func (l *LookupJoinNode) On(dims ...string) *LookupJoinNode {
+	l.Dimensions = dims
+	return l
+}
+
+// Prefix the names of the reference fields with the name and a '.'.
+// Without a prefix the reference fields are added as is,
+// existing fields of the data are never overwritten.
+//
+// The name cannot have a dot '.' character.
+//
+// tick:property
+func (l *LookupJoinNode) As(name string) *LookupJoinNode {
+	l.Prefix = name
+	return l
+}
+
+// Set the default value of a reference field when no table entry exists.
+// The name is the name of the reference field without the prefix.
+// tick:property
+func (l *LookupJoinNode) Default(name string, value interface{}) *LookupJoinNode {
+	l.Defaults[name] = value
+	return l
+}
+
+func (l *LookupJoinNode) validate() error {
+	if strings.ContainsRune(l.Prefix, '.') {
+		return fmt.Errorf("cannot use name %s as field prefix, it contains a '.' character", l.Prefix)
+	}
+	if l.Ttl < 0 {
+		return fmt.Errorf("lookupJoin ttl must not be negative, got %v", l.Ttl)
+	}
+	for field, value := range l.Defaults {
+		switch value.(type) {
+		case float64:
+		case int64:
+		case bool:
+		case string:
+		default:
+			return fmt.Errorf("unsupported type %T for field %q, lookupJoin default values must be float,int,string or bool", value, field)
+		}
+	}
+	return nil
+}
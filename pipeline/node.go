@@ -3,6 +3,7 @@ package pipeline
 import (
 	"bytes"
 	"fmt"
+	"regexp"
 	"strings"
 	"time"
 
@@ -58,11 +59,22 @@ type Node interface {
 	ID() ID
 	setID(ID)
 
+	// Position in the TICKscript where the node was created, nil if not created by a TICKscript.
+	position() tick.Position
+	// The recorded calls creating and configuring the node, see MarshalJSON.
+	calls() (creation *call, properties []call)
+
 	// The type of input the node wants.
 	Wants() EdgeType
 	// The type of output the node provides.
 	Provides() EdgeType
 
+	// The time zone of the node, set by the timezone property or inherited from its first parent.
+	// Nil if no time zone is set.
+	Location() *time.Location
+	// Load the time zone of the timezone property.
+	loadLocation() error
+
 	// Check that the definition of the node is consistent
 	validate() error
 
@@ -89,6 +101,37 @@ type node struct {
 	provides EdgeType
 	tm       bool
 	pm       bool
+	pos      tick.Position
+
+	// The calls creating and configuring the node in a TICKscript,
+	// nil if the node was created implicitly by another call.
+	creation   *call
+	properties []call
+
+	// The time zone of the node, i.e. 'Europe/Berlin'.
+	//
+	// Lambda expressions, the alignment of windows and the schedule of batch queries
+	// use the wall clock time of the time zone, including its daylight saving time transitions.
+	// Nodes without a time zone use the time zone of their first parent,
+	// so the time zone of a whole task is set on its from or query node.
+	//
+	// Without a time zone lambda expressions and cron schedules use the local time zone of the server
+	// and windows and batch queries are aligned in UTC.
+	//
+	// Example:
+	//    stream
+	//        |from()
+	//            .measurement('requests')
+	//            .timezone('Europe/Berlin')
+	//        |where(lambda: hour("time") >= 9 AND hour("time") < 17)
+	//        |window()
+	//            .period(1d)
+	//            .every(1d)
+	//            .align()
+	//
+	// The window emits the requests of each day from midnight to midnight in Berlin.
+	Timezone string
+	loc      *time.Location
 }
 
 // tick:ignore
@@ -118,6 +161,38 @@ func (n *node) SetName(name string) {
 	n.name = name
 }
 
+// tick:ignore
+func (n *node) SetPosition(p tick.Position) {
+	n.pos = p
+}
+
+func (n *node) position() tick.Position {
+	return n.pos
+}
+
+// tick:ignore
+func (n *node) RecordChainMethod(receiver interface{}, method string, dynamic bool, args []interface{}) {
+	r, _ := receiver.(Node)
+	n.creation = &call{
+		receiver: r,
+		method:   method,
+		dynamic:  dynamic,
+		args:     append([]interface{}(nil), args...),
+	}
+}
+
+// tick:ignore
+func (n *node) RecordProperty(name string, args []interface{}) {
+	n.properties = append(n.properties, call{
+		method: name,
+		args:   append([]interface{}(nil), args...),
+	})
+}
+
+func (n *node) calls() (*call, []call) {
+	return n.creation, n.properties
+}
+
 // tick:ignore
 func (n *node) Parents() []Node {
 	return n.parents
@@ -172,6 +247,32 @@ func (n *node) Provides() EdgeType {
 	return n.provides
 }
 
+// tick:ignore
+func (n *node) Location() *time.Location {
+	switch {
+	case n.loc != nil:
+		return n.loc
+	case n.Timezone != "":
+		loc, _ := time.LoadLocation(n.Timezone)
+		return loc
+	case len(n.parents) > 0:
+		return n.parents[0].Location()
+	}
+	return nil
+}
+
+func (n *node) loadLocation() error {
+	if n.Timezone == "" {
+		return nil
+	}
+	loc, err := time.LoadLocation(n.Timezone)
+	if err != nil {
+		return fmt.Errorf("invalid timezone %q: %v", n.Timezone, err)
+	}
+	n.loc = loc
+	return nil
+}
+
 func (n *node) validate() error {
 	return nil
 }
@@ -353,6 +454,11 @@ func (n *chainnode) Join(others ...Node) *JoinNode {
 	return j
 }
 
+// Enrich this node with the latest data of a reference node, see LookupJoinNode.
+func (n *chainnode) LookupJoin(ref Node) *LookupJoinNode {
+	return newLookupJoinNode(n.provides, n, ref)
+}
+
 // Create an eval node that will evaluate the given transformation function to each data point.
 //  A list of expressions may be provided and will be evaluated in the order they are given
 // and results of previous expressions are made available to later expressions.
@@ -409,6 +515,21 @@ func (n *chainnode) Shift(shift time.Duration) *ShiftNode {
 	return s
 }
 
+// Create a new node that fills gaps in the data with generated points.
+func (n *chainnode) Fill(every time.Duration) *FillNode {
+	f := newFillNode(n.Provides(), every)
+	n.linkChild(f)
+	return f
+}
+
+// Create a node that extracts the named capture groups of a regular expression,
+// matched against a tag or string field, into new tags or fields.
+func (n *chainnode) Extract(source string, pattern *regexp.Regexp) *ExtractNode {
+	e := newExtractNode(n.Provides(), source, pattern)
+	n.linkChild(e)
+	return e
+}
+
 // Create a node that logs all data it receives.
 func (n *chainnode) Log() *LogNode {
 	s := newLogNode(n.Provides())
@@ -62,6 +62,9 @@ func CreatePipeline(script string, sourceEdge EdgeType, scope *tick.Scope, deadm
 	}
 	if err = p.Walk(
 		func(n Node) error {
+			if err := n.loadLocation(); err != nil {
+				return err
+			}
 			return n.validate()
 		}); err != nil {
 		return nil, err
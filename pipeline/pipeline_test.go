@@ -1,9 +1,12 @@
 package pipeline
 
 import (
+	"encoding/json"
+	"math"
 	"testing"
 	"time"
 
+	"github.com/influxdata/influxdb/influxql"
 	"github.com/influxdata/kapacitor/tick"
 	"github.com/stretchr/testify/assert"
 )
@@ -96,3 +99,574 @@ func TestPipelineSort(t *testing.T) {
 
 	assert.Equal(sorted, p.sorted)
 }
+
+func TestTICK_To_Pipeline_InvalidAlertTemplate(t *testing.T) {
+	testCases := []struct {
+		script string
+		err    string
+	}{
+		{
+			script: `stream|from()|alert().message('{{ .ID | unknownFunc }}')`,
+			err:    `invalid message template: template: message:1: function "unknownFunc" not defined`,
+		},
+		{
+			script: `stream|from()|alert().details('{{ .ID ')`,
+			err:    `invalid details template: template: details:1: unclosed action`,
+		},
+		{
+			script: `stream|from()|alert().slack().field('Value', '{{ field .Fields "value" ')`,
+			err:    `invalid slack field Value template: template: slack field Value:1: unclosed action`,
+		},
+	}
+	for _, tc := range testCases {
+		_, err := CreatePipeline(tc.script, StreamEdge, tick.NewScope(), deadman{})
+		if err == nil {
+			t.Errorf("expected error for %q", tc.script)
+			continue
+		}
+		if got := err.Error(); got != tc.err {
+			t.Errorf("unexpected error for %q:\ngot %s\nexp %s", tc.script, got, tc.err)
+		}
+	}
+
+	script := `stream|from()|alert().message('{{ .ID }} {{ field .Fields "value" 0 | round 2 | humanize }} {{ .Time | timeFormat "15:04" "UTC" }}')`
+	if _, err := CreatePipeline(script, StreamEdge, tick.NewScope(), deadman{}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTICK_To_Pipeline_SyslogFacility(t *testing.T) {
+	script := `stream|from()|alert().syslog().facility('LOCAL3')`
+	if _, err := CreatePipeline(script, StreamEdge, tick.NewScope(), deadman{}); err != nil {
+		t.Fatal(err)
+	}
+
+	script = `stream|from()|alert().syslog().facility('local8')`
+	_, err := CreatePipeline(script, StreamEdge, tick.NewScope(), deadman{})
+	if exp := `unknown syslog facility "local8"`; err == nil || err.Error() != exp {
+		t.Errorf("unexpected error got %v exp %s", err, exp)
+	}
+}
+
+func TestTICK_To_Pipeline_LookupJoin(t *testing.T) {
+	var tickScript = `
+var ref = stream
+	|from()
+		.measurement('deploys')
+
+stream
+	|from()
+		.measurement('errors')
+	|lookupJoin(ref)
+		.on('host')
+		.ttl(1h)
+		.default('version', 'unknown')
+	|default()
+		.field('value', 0.0)
+`
+
+	p, err := CreatePipeline(tickScript, StreamEdge, tick.NewScope(), deadman{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var l *LookupJoinNode
+	p.Walk(func(n Node) error {
+		if ln, ok := n.(*LookupJoinNode); ok {
+			l = ln
+		}
+		return nil
+	})
+	if l == nil {
+		t.Fatal("expected a LookupJoinNode")
+	}
+	if exp, got := 2, len(l.Parents()); exp != got {
+		t.Errorf("unexpected number of parents: exp %d got %d", exp, got)
+	}
+	assert.Equal(t, []string{"host"}, l.Dimensions)
+	if exp, got := time.Hour, l.Ttl; exp != got {
+		t.Errorf("unexpected ttl: exp %v got %v", exp, got)
+	}
+	assert.Equal(t, map[string]interface{}{"version": "unknown"}, l.Defaults)
+	if _, ok := l.Children()[0].(*DefaultNode); !ok {
+		t.Errorf("unexpected child: exp DefaultNode got %T", l.Children()[0])
+	}
+}
+
+func TestTICK_To_Pipeline_InvalidWindow(t *testing.T) {
+	testCases := []struct {
+		script string
+		err    string
+	}{
+		{
+			script: `stream|from()|window().period(10s).every(10s).periodCount(10).everyCount(1)`,
+			err:    "window can be defined by either period and every, periodCount and everyCount or session, not a combination",
+		},
+		{
+			script: `stream|from()|window().periodCount(10).session(1m)`,
+			err:    "window can be defined by either period and every, periodCount and everyCount or session, not a combination",
+		},
+		{
+			script: `stream|from()|window().periodCount(10)`,
+			err:    "window periodCount and everyCount must both be positive, got 10 and 0",
+		},
+		{
+			script: `stream|from()|window().periodCount(10).everyCount(1).allowedLateness(10s)`,
+			err:    "window allowedLateness and reemit can only be used with period and every",
+		},
+		{
+			script: `stream|from()|window().period(10s).every(10s).reemit()`,
+			err:    "window reemit requires an allowedLateness",
+		},
+		{
+			script: `stream|from()|window().period(10s).every(10s)|late()`,
+			err:    "window late requires an allowedLateness",
+		},
+	}
+	for _, tc := range testCases {
+		_, err := CreatePipeline(tc.script, StreamEdge, tick.NewScope(), deadman{})
+		if err == nil {
+			t.Errorf("expected error for %q", tc.script)
+			continue
+		}
+		if got := err.Error(); got != tc.err {
+			t.Errorf("unexpected error for %q:\ngot %s\nexp %s", tc.script, got, tc.err)
+		}
+	}
+}
+
+func TestTICK_To_Pipeline_Fill(t *testing.T) {
+	assert := assert.New(t)
+	p, err := CreatePipeline(`stream|from()|fill(10s).value(0)`, StreamEdge, tick.NewScope(), deadman{})
+	if !assert.NoError(err) {
+		return
+	}
+	f, ok := p.sources[0].Children()[0].Children()[0].(*FillNode)
+	if assert.True(ok) {
+		assert.Equal(10*time.Second, f.Every)
+		assert.Equal(int64(0), f.Value)
+		assert.Equal(int64(1000), f.MaxPoints)
+	}
+
+	for script, exp := range map[string]string{
+		`stream|from()|fill(0s)`:                "fill interval must be positive, got 0s",
+		`stream|from()|fill(10s).value('next')`: "unexpected fill value next, must be null, previous, linear or a number",
+		`stream|from()|fill(10s).value(TRUE)`:   "unexpected fill value of type bool, must be null, previous, linear or a number",
+		`stream|from()|fill(10s).maxPoints(0)`:  "fill max points must be positive, got 0",
+	} {
+		_, err := CreatePipeline(script, StreamEdge, tick.NewScope(), deadman{})
+		if assert.Error(err, script) {
+			assert.Equal(exp, err.Error())
+		}
+	}
+}
+
+// The fill node can be chained after nodes with a fill property.
+func TestTICK_To_Pipeline_FillChained(t *testing.T) {
+	assert := assert.New(t)
+	p, err := CreatePipeline(`
+var a = stream|from().measurement('a')
+var b = stream|from().measurement('b')
+a
+	|join(b)
+		.as('a', 'b')
+		.fill(0)
+	|fill(10s)
+`, StreamEdge, tick.NewScope(), deadman{})
+	if assert.NoError(err) {
+		j, ok := p.sources[0].Children()[0].Children()[0].(*JoinNode)
+		if assert.True(ok) {
+			assert.Equal(int64(0), j.Fill)
+			_, ok := j.Children()[0].(*FillNode)
+			assert.True(ok)
+		}
+	}
+
+	p, err = CreatePipeline(`
+batch
+	|query('SELECT mean("value") FROM "db"."rp"."cpu"')
+		.period(1m)
+		.every(1m)
+		.fill('previous')
+	|fill(1m)
+`, BatchEdge, tick.NewScope(), deadman{})
+	if assert.NoError(err) {
+		q, ok := p.sources[0].Children()[0].(*QueryNode)
+		if assert.True(ok) {
+			assert.Equal("previous", q.Fill)
+			_, ok := q.Children()[0].(*FillNode)
+			assert.True(ok)
+		}
+	}
+}
+
+func TestTICK_To_Pipeline_Extract(t *testing.T) {
+	assert := assert.New(t)
+	p, err := CreatePipeline(`stream
+	|from()
+	|extract('host', /^(?P<role>[a-z]+)-(?P<number>[0-9]+)\.(?P<dc>[a-z0-9]+)$/)
+		.tags('role', 'dc')
+		.regroup()
+	|where(lambda: "number" == '12')
+`, StreamEdge, tick.NewScope(), deadman{})
+	if !assert.NoError(err) {
+		return
+	}
+	e, ok := p.sources[0].Children()[0].Children()[0].(*ExtractNode)
+	if assert.True(ok) {
+		assert.Equal("host", e.Source)
+		assert.Equal(`^(?P<role>[a-z]+)-(?P<number>[0-9]+)\.(?P<dc>[a-z0-9]+)$`, e.Pattern.String())
+		assert.Equal([]string{"role", "dc"}, e.TagsList)
+		assert.True(e.RegroupFlag)
+	}
+
+	for script, exp := range map[string]string{
+		`stream|from()|extract('', /(?P<a>.)/)`:               "must provide the tag or field to extract from",
+		`stream|from()|extract('host', /(.)/)`:                "regular expression (.) has no named capture groups, i.e. (?P<name>...)",
+		`stream|from()|extract('host', /(?P<a>.)/).tags('b')`: `tag "b" is not a named capture group of (?P<a>.)`,
+		`stream|from()|extract('host', /(?P<a>.)/).regroup()`: "cannot regroup without tags, see the tags property",
+		`stream|from()|eval(lambda: 1).as('host')|extract('host', /(?P<a>.)/)|where(lambda: "a" > 1)`: "line 1 char 42: cannot extract from int64 field \"host\", must be a string\n" +
+			"line 1 char 88: mismatched type to binary operator. got string > int64. see bool(), int(), float(), string()",
+	} {
+		p, err := CreatePipeline(script, StreamEdge, tick.NewScope(), deadman{})
+		if err == nil {
+			if diagnostics := p.Check(); len(diagnostics) > 0 {
+				err = diagnostics
+			}
+		}
+		if assert.Error(err, script) {
+			assert.Equal(exp, err.Error(), script)
+		}
+	}
+}
+
+func TestTICK_To_Pipeline_Timezone(t *testing.T) {
+	assert := assert.New(t)
+	p, err := CreatePipeline(`stream
+	|from()
+		.timezone('Europe/Berlin')
+	|window()
+		.period(1d)
+		.every(1d)
+		.align()
+	|where(lambda: hour("time") >= 9)
+		.timezone('America/New_York')
+	|count('value')
+`, StreamEdge, tick.NewScope(), deadman{})
+	if !assert.NoError(err) {
+		return
+	}
+	assert.Nil(p.sources[0].Location())
+	from := p.sources[0].Children()[0]
+	window := from.Children()[0]
+	where := window.Children()[0]
+	count := where.Children()[0]
+	assert.Equal("Europe/Berlin", from.Location().String())
+	assert.Equal("Europe/Berlin", window.Location().String())
+	assert.Equal("America/New_York", where.Location().String())
+	assert.Equal("America/New_York", count.Location().String())
+
+	_, err = CreatePipeline(`stream|from().timezone('Europe/Nowhere')`, StreamEdge, tick.NewScope(), deadman{})
+	if assert.Error(err) {
+		assert.Equal(`invalid timezone "Europe/Nowhere": unknown time zone Europe/Nowhere`, err.Error())
+	}
+}
+
+func TestTICK_To_Pipeline_FromSelection(t *testing.T) {
+	assert := assert.New(t)
+	p, err := CreatePipeline(`stream
+	|from()
+		.database('db0', 'db1')
+		.retentionPolicy('autogen')
+		.measurement(/^cpu_/)
+`, StreamEdge, tick.NewScope(), deadman{})
+	if !assert.NoError(err) {
+		return
+	}
+	f := p.sources[0].Children()[0].(*FromNode)
+	assert.Equal([]string{"db0", "db1"}, f.Databases.Names)
+	assert.True(f.Databases.Matches("db1"))
+	assert.False(f.Databases.Matches("db2"))
+	assert.Equal([]string{"autogen"}, f.RetentionPolicies.Names)
+	if assert.NotNil(f.Measurements.Regex) {
+		assert.Equal("^cpu_", f.Measurements.Regex.String())
+	}
+	assert.True(f.Measurements.Matches("cpu_user"))
+	assert.False(f.Measurements.Matches("mem"))
+
+	for script, exp := range map[string]string{
+		`stream|from().measurement(/^cpu/, 'mem')`: "measurement must be one or more names or a single regex, got *regexp.Regexp",
+		`stream|from().database('db0', '')`:        `from cannot select an empty name together with other names: ["db0" ""]`,
+	} {
+		_, err := CreatePipeline(script, StreamEdge, tick.NewScope(), deadman{})
+		if assert.Error(err, script) {
+			assert.Contains(err.Error(), exp)
+		}
+	}
+}
+
+func TestIncrementalReducers(t *testing.T) {
+	for _, values := range [][]float64{
+		{1},
+		{4, 2},
+		{3, -1, 7.5, 2, 2, 10},
+		{1, math.NaN(), 5},
+	} {
+		points := make([]influxql.FloatPoint, len(values))
+		spread := newFloatSpreadReducer()
+		stddev := newStddevReducer()
+		hasNaN := false
+		for i, v := range values {
+			points[i] = influxql.FloatPoint{Value: v}
+			spread.AggregateFloat(&points[i])
+			stddev.AggregateFloat(&points[i])
+			hasNaN = hasNaN || math.IsNaN(v)
+		}
+		// The spread of NaN values is NaN, which never compares equal.
+		if !hasNaN {
+			exp := influxql.FloatSpreadReduceSlice(points)[0]
+			if got := spread.Emit()[0]; got.Value != exp.Value {
+				t.Errorf("unexpected spread of %v: got %v exp %v", values, got.Value, exp.Value)
+			}
+		}
+		exp := influxql.FloatStddevReduceSlice(points)[0]
+		got := stddev.Emit()[0]
+		if got.Nil != exp.Nil || math.Abs(got.Value-exp.Value) > 1e-12 {
+			t.Errorf("unexpected stddev of %v: got %v exp %v", values, got, exp)
+		}
+	}
+
+	spread := newIntegerSpreadReducer()
+	for _, v := range []int64{3, -2, 8} {
+		spread.AggregateInteger(&influxql.IntegerPoint{Value: v})
+	}
+	if got := spread.Emit()[0].Value; got != 10 {
+		t.Errorf("unexpected integer spread: got %d exp 10", got)
+	}
+}
+
+func TestTICK_To_Pipeline_Check(t *testing.T) {
+	assert := assert.New(t)
+	script := `var data = stream
+	|from()
+	|eval(lambda: string("value"), lambda: "count" + 1)
+		.as('value', 'count')
+	|where(lambda: "value" > 10)
+
+data
+	|mean('value')
+
+data
+	|alert()
+		.crit(lambda: "value")
+
+var windowed = stream
+	|from()
+	|window()
+		.period(10s)
+		.every(10s)
+
+data
+	|union(windowed)
+`
+	p, err := CreatePipeline(script, StreamEdge, tick.NewScope(), deadman{})
+	if !assert.NoError(err) {
+		return
+	}
+	diagnostics := p.Check()
+	assert.Equal(tick.Diagnostics{
+		{Line: 5, Char: 25, Message: "mismatched type to binary operator. got string > int64. see bool(), int(), float(), string()"},
+		{Line: 21, Char: 3, Message: "cannot link window7 to union9, mismatched edges: batch -> stream"},
+		{Line: 12, Char: 17, Message: "expression must evaluate to boolean, got string"},
+		{Line: 8, Char: 3, Message: `cannot compute mean of string field "value"`},
+	}, diagnostics)
+
+	// Fields of unknown types are not checked.
+	p, err = CreatePipeline(`stream|from()|where(lambda: "value" > 10)|mean('value')`, StreamEdge, tick.NewScope(), deadman{})
+	if assert.NoError(err) {
+		assert.Empty(p.Check())
+	}
+}
+
+func TestPipeline_JSON(t *testing.T) {
+	assert := assert.New(t)
+	script := `var cpu = stream
+    |from()
+        .measurement('cpu')
+        .where(lambda: "host" =~ /^server\/[0-9]+$/ AND "cpu" != 'cpu-total')
+        .groupBy(*)
+    |window()
+        .period(10s)
+        .every(5s)
+        .align()
+
+var mem = stream
+    |from()
+        .measurement('mem')
+        .groupBy('host')
+    |window()
+        .period(10s)
+        .every(5s)
+        .align()
+    |mean('used')
+        .as('used')
+
+cpu
+    |mean('usage_idle')
+        .as('idle')
+    |join(mem)
+        .as('cpu', 'mem')
+        .tolerance(1ms)
+    |eval(lambda: 100.0 - "cpu.idle", lambda: -1)
+        .as('used', 'ones')
+    |alert()
+        .id('{{ .Name }}:{{ index .Tags "host" }}')
+        .message('''It's
+high''')
+        .crit(lambda: "used" > 90)
+        .email('oncall@example.com')
+        .post('http://example.com/alert')
+
+mem
+    |deadman(100.0, 10s)
+        .log('/tmp/dead.log')
+
+mem
+    |stats(1m)
+    |log()
+`
+	p, err := CreatePipeline(script, StreamEdge, tick.NewScope(), deadman{})
+	if !assert.NoError(err) {
+		return
+	}
+	data, err := json.Marshal(p)
+	if !assert.NoError(err) {
+		return
+	}
+
+	// The nodes created by the chaining methods have the same IDs after a round trip.
+	var unmarshaled Pipeline
+	if !assert.NoError(json.Unmarshal(data, &unmarshaled)) {
+		return
+	}
+	roundTrip, err := json.Marshal(&unmarshaled)
+	if !assert.NoError(err) {
+		return
+	}
+	assert.JSONEq(string(data), string(roundTrip))
+	assert.Equal(string(p.Dot("test")), string(unmarshaled.Dot("test")))
+
+	got, err := JSONToTICKscript(data)
+	if !assert.NoError(err) {
+		return
+	}
+	exp := `var window2 = stream
+    |from()
+        .measurement('cpu')
+        .where(lambda: "host" =~ /^server\/[0-9]+$/ AND "cpu" != 'cpu-total')
+        .groupBy(*)
+    |window()
+        .period(10s)
+        .every(5s)
+        .align()
+
+var mean5 = stream
+    |from()
+        .measurement('mem')
+        .groupBy('host')
+    |window()
+        .period(10s)
+        .every(5s)
+        .align()
+    |mean('used')
+        .as('used')
+
+window2
+    |mean('usage_idle')
+        .as('idle')
+    |join(mean5)
+        .as('cpu', 'mem')
+        .tolerance(1ms)
+    |eval(lambda: 100.0 - "cpu.idle", lambda: -1)
+        .as('used', 'ones')
+    |alert()
+        .id('{{ .Name }}:{{ index .Tags "host" }}')
+        .message('''It's
+high''')
+        .crit(lambda: "used" > 90)
+        .email('oncall@example.com')
+        .post('http://example.com/alert')
+
+mean5
+    |deadman(100.0, 10s)
+        .log('/tmp/dead.log')
+
+mean5
+    |stats(1m)
+    |log()
+`
+	assert.Equal(exp, got)
+}
+
+func TestPipeline_JSON_Invalid(t *testing.T) {
+	testCases := []struct {
+		json string
+		err  string
+	}{
+		{
+			json: `{"nodes":[]}`,
+			err:  "pipeline must have a stream or batch source",
+		},
+		{
+			json: `{"nodes":[{"id":0,"type":"stream"},{"id":1,"type":"from","chainMethod":{"receiver":2,"method":"from","args":[]}}]}`,
+			err:  "node 1: invalid receiver 2",
+		},
+		{
+			json: `{"nodes":[{"id":0,"type":"stream"},{"id":1,"type":"from","chainMethod":{"receiver":0,"method":"from","args":[{"type":"complex","value":1}]}}]}`,
+			err:  `node 1: argument 0 of from: unknown argument type "complex"`,
+		},
+		{
+			json: `{"nodes":[{"id":0,"type":"stream"},{"id":1,"type":"from","chainMethod":{"receiver":0,"method":"from","args":[]},"properties":[{"method":"measurement","args":[{"type":"int","value":"cpu"}]}]}]}`,
+			err:  "node 1: argument 0 of measurement: json: cannot unmarshal string into Go value of type int64",
+		},
+	}
+	for _, tc := range testCases {
+		_, err := JSONToTICKscript([]byte(tc.json))
+		if err == nil || err.Error() != tc.err {
+			t.Errorf("unexpected error for %s: got %v exp %s", tc.json, err, tc.err)
+		}
+	}
+}
+
+func TestPipeline_JSON_BatchTask(t *testing.T) {
+	script := `batch
+    |query('SELECT mean("value") FROM "telegraf"."default"."cpu"')
+        .period(1m)
+        .every(1m)
+        .groupBy(time(10s), 'host')
+    |httpOut('cpu')
+`
+	scope := tick.NewScope()
+	scope.Set("time", func(d time.Duration) time.Duration { return d })
+	p, err := CreatePipeline(script, BatchEdge, scope, deadman{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := JSONToTICKscript(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := `batch
+    |query('SELECT mean("value") FROM "telegraf"."default"."cpu"')
+        .period(1m)
+        .every(1m)
+        .groupBy(10s, 'host')
+    |httpOut('cpu')
+`
+	if got != exp {
+		t.Errorf("unexpected TICKscript:\ngot\n%s\nexp\n%s", got, exp)
+	}
+}
@@ -1,7 +1,9 @@
 package pipeline
 
 import (
+	"fmt"
 	"reflect"
+	"regexp"
 	"time"
 
 	"github.com/influxdata/kapacitor/tick"
@@ -71,6 +73,19 @@ func (s *StreamNode) From() *FromNode {
 // The above example selects only data points from the database `mydb`
 // and retention policy `myrp` and measurement `mymeasurement` where
 // the tag `host` matches the regex `logger\d+`
+//
+// The database, retention policy and measurement can also be selected
+// by a list of names or by a regex.
+//
+// Example:
+//    stream
+//        |from()
+//           .database('mydb', 'otherdb')
+//           .measurement(/^cpu_/)
+//        ...
+//
+// The above example selects the data points from the databases `mydb` and `otherdb`
+// of all measurements starting with `cpu_`.
 type FromNode struct {
 	chainnode
 
@@ -82,17 +97,20 @@ type FromNode struct {
 	// tick:ignore
 	Dimensions []interface{} `tick:"GroupBy"`
 
-	// The database name.
+	// The database names.
 	// If empty any database will be used.
-	Database string
+	// tick:ignore
+	Databases NameSelection `tick:"Database"`
 
-	// The retention policy name
+	// The retention policy names.
 	// If empty any retention policy will be used.
-	RetentionPolicy string
+	// tick:ignore
+	RetentionPolicies NameSelection `tick:"RetentionPolicy"`
 
-	// The measurement name
+	// The measurement names.
 	// If empty any measurement will be used.
-	Measurement string
+	// tick:ignore
+	Measurements NameSelection `tick:"Measurement"`
 
 	// Optional duration for truncating timestamps.
 	// Helpful to ensure data points land on specific boundaries
@@ -246,3 +264,115 @@ func (s *FromNode) GroupBy(tag ...interface{}) *FromNode {
 	s.Dimensions = tag
 	return s
 }
+
+// Select the data of one or more databases by name,
+// or of the databases matching a regex.
+// The selection only filters the data of the dbrps of the task,
+// a regex does not add databases to the task.
+//
+// Example:
+//    stream
+//        |from()
+//            .database(/^prod_/)
+// tick:property
+func (s *FromNode) Database(names ...interface{}) (*FromNode, error) {
+	sel, err := newNameSelection("database", names)
+	if err != nil {
+		return nil, err
+	}
+	s.Databases = sel
+	return s, nil
+}
+
+// Select the data of one or more retention policies by name,
+// or of the retention policies matching a regex.
+// The selection only filters the data of the dbrps of the task,
+// a regex does not add retention policies to the task.
+// tick:property
+func (s *FromNode) RetentionPolicy(names ...interface{}) (*FromNode, error) {
+	sel, err := newNameSelection("retentionPolicy", names)
+	if err != nil {
+		return nil, err
+	}
+	s.RetentionPolicies = sel
+	return s, nil
+}
+
+// Select the data of one or more measurements by name,
+// or of the measurements matching a regex.
+//
+// Example:
+//    stream
+//        |from()
+//            .measurement('cpu', 'mem')
+// tick:property
+func (s *FromNode) Measurement(names ...interface{}) (*FromNode, error) {
+	sel, err := newNameSelection("measurement", names)
+	if err != nil {
+		return nil, err
+	}
+	s.Measurements = sel
+	return s, nil
+}
+
+// A selection of names, either a list of names or a regex.
+// An empty selection selects any name.
+type NameSelection struct {
+	Names []string
+	Regex *regexp.Regexp
+}
+
+func newNameSelection(property string, values []interface{}) (NameSelection, error) {
+	if len(values) == 1 {
+		if r, ok := values[0].(*regexp.Regexp); ok {
+			return NameSelection{Regex: r}, nil
+		}
+	}
+	names := make([]string, len(values))
+	for i, v := range values {
+		name, ok := v.(string)
+		if !ok {
+			return NameSelection{}, fmt.Errorf("%s must be one or more names or a single regex, got %T", property, v)
+		}
+		names[i] = name
+	}
+	// An empty name selects any name, as it always has.
+	if len(names) == 1 && names[0] == "" {
+		names = nil
+	}
+	return NameSelection{Names: names}, nil
+}
+
+// Whether the selection selects any name.
+func (n NameSelection) IsEmpty() bool {
+	return len(n.Names) == 0 && n.Regex == nil
+}
+
+// Whether the selection selects the name.
+func (n NameSelection) Matches(name string) bool {
+	if n.Regex != nil {
+		return n.Regex.MatchString(name)
+	}
+	if len(n.Names) == 0 {
+		return true
+	}
+	for _, sel := range n.Names {
+		if sel == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (n *FromNode) validate() error {
+	for _, sel := range []NameSelection{n.Databases, n.RetentionPolicies, n.Measurements} {
+		if len(sel.Names) > 1 {
+			for _, name := range sel.Names {
+				if name == "" {
+					return fmt.Errorf("from cannot select an empty name together with other names: %q", sel.Names)
+				}
+			}
+		}
+	}
+	return nil
+}
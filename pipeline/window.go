@@ -1,6 +1,8 @@
 package pipeline
 
 import (
+	"errors"
+	"fmt"
 	"time"
 )
 
@@ -19,6 +21,58 @@ import (
 // and the window contains the last `10 minutes` worth of data.
 // As a result each time the window is emitted it contains half new data and half old data.
 //
+// Windows can also be defined by a number of points or by sessions of activity.
+//
+// Example:
+//    stream
+//        |window()
+//            .periodCount(100)
+//            .everyCount(10)
+//        |mean('value')
+//
+// The above windowing example emits a window of the last `100` points of each group
+// every `10` points.
+//
+// Example:
+//    stream
+//        |from()
+//            .measurement('requests')
+//            .groupBy('user')
+//        |window()
+//            .session(30m)
+//        |count('value')
+//
+// The above windowing example emits a window for each session of requests of a user,
+// a session ends when no request has been received from the user for `30 minutes`.
+//
+// Windows can wait for points arriving out of order, see allowedLateness.
+//
+// Example:
+//    var win = stream
+//        |window()
+//            .period(1m)
+//            .every(1m)
+//            .allowedLateness(30s)
+//    win
+//        |sum('value')
+//        |influxDBOutput()
+//            .database('mydb')
+//            .measurement('sums')
+//    win
+//        |late()
+//        |influxDBOutput()
+//            .database('mydb')
+//            .measurement('late')
+//
+// The above windowing example emits each window once points `30 seconds` past the end of the window
+// have been received, so that points arriving up to `30 seconds` late are included in their window.
+// Points arriving later than that are written to the 'late' measurement.
+//
+// NOTE: A window with equal period and every whose only child is one of the
+// count, sum, mean, min, max, first, last, spread or stddev functions
+// does not keep the points of the window in memory.
+// Instead each point is reduced into the function as it arrives, the results are the same.
+//
 // NOTE: Time for a window (or any node) is implemented by inspecting the times on the incoming data points.
 // As a result if the incoming data stream stops then no more windows will be emitted because time is no longer
 // increasing for the window node.
@@ -31,6 +85,28 @@ type WindowNode struct {
 	// Wether to align the window edges with the zero time
 	// tick:ignore
 	AlignFlag bool `tick:"Align"`
+
+	// The number of points per group in the window.
+	// Must be used together with EveryCount instead of Period and Every.
+	PeriodCount int64
+	// How often, in number of points per group, the window is emitted into the pipeline.
+	// The first window is emitted once PeriodCount points have been received.
+	EveryCount int64
+
+	// The inactivity gap closing a session window.
+	// tick:ignore
+	SessionGap time.Duration `tick:"Session"`
+
+	// How long after the end of a window points for the window are accepted.
+	// The window is held open until a point past the end of the window plus the allowed lateness,
+	// the watermark, has been received for the group.
+	// Points older than the open windows are dropped, unless sent to a late node.
+	// Only applies to windows defined by period and every.
+	AllowedLateness time.Duration
+
+	// Wether to emit windows at their end and emit them again for each late point.
+	// tick:ignore
+	ReemitFlag bool `tick:"Reemit"`
 }
 
 func newWindowNode() *WindowNode {
@@ -42,8 +118,103 @@ func newWindowNode() *WindowNode {
 // Wether to align the window edges with the zero time.
 // If not aligned the window starts and ends relative to the
 // first data point it receives.
+//
+// If the node has a timezone the edges are aligned in the wall clock time of the time zone,
+// i.e. a window with an every of 1d ends at midnight.
+// Windows of whole days are 23 or 25 hours long on the days daylight saving time starts or ends.
 // tick:property
 func (w *WindowNode) Align() *WindowNode {
 	w.AlignFlag = true
 	return w
 }
+
+// Window the data of each group by sessions of activity.
+// A session window is emitted once no data has been received
+// for the group for longer than the gap.
+// The session ends when data for any group arrives after the gap has passed,
+// since time is based on the incoming data points.
+// tick:property
+func (w *WindowNode) Session(gap time.Duration) *WindowNode {
+	w.SessionGap = gap
+	return w
+}
+
+// Emit each window as soon as it ends, without waiting for the allowed lateness.
+// Each late point arriving within the allowed lateness causes the windows containing it
+// to be emitted again, corrected with the late point.
+// tick:property
+func (w *WindowNode) Reemit() *WindowNode {
+	w.ReemitFlag = true
+	return w
+}
+
+// Create a stream of the points arriving too late for the window.
+// Without a late node such points are dropped.
+// The window must have an allowedLateness.
+func (w *WindowNode) Late() *LateNode {
+	n := newLateNode()
+	w.linkChild(n)
+	return n
+}
+
+func (w *WindowNode) validate() error {
+	timeBased := w.Period != 0 || w.Every != 0 || w.AlignFlag
+	countBased := w.PeriodCount != 0 || w.EveryCount != 0
+	sessionBased := w.SessionGap != 0
+	modes := 0
+	for _, m := range []bool{timeBased, countBased, sessionBased} {
+		if m {
+			modes++
+		}
+	}
+	if modes > 1 {
+		return errors.New("window can be defined by either period and every, periodCount and everyCount or session, not a combination")
+	}
+	if countBased && (w.PeriodCount <= 0 || w.EveryCount <= 0) {
+		return fmt.Errorf("window periodCount and everyCount must both be positive, got %d and %d", w.PeriodCount, w.EveryCount)
+	}
+	if w.AllowedLateness < 0 {
+		return fmt.Errorf("window allowedLateness must not be negative, got %v", w.AllowedLateness)
+	}
+	if (w.AllowedLateness != 0 || w.ReemitFlag) && (countBased || sessionBased) {
+		return errors.New("window allowedLateness and reemit can only be used with period and every")
+	}
+	if w.ReemitFlag && w.AllowedLateness == 0 {
+		return errors.New("window reemit requires an allowedLateness")
+	}
+	if w.AllowedLateness == 0 {
+		for _, c := range w.Children() {
+			if _, ok := c.(*LateNode); ok {
+				return errors.New("window late requires an allowedLateness")
+			}
+		}
+	}
+	if w.SessionGap < 0 {
+		return fmt.Errorf("window session gap must be positive, got %v", w.SessionGap)
+	}
+	return nil
+}
+
+// A stream of the points dropped by a WindowNode because they arrived after the allowed lateness.
+// The points are passed on unmodified.
+//
+// Example:
+//    var win = stream
+//        |window()
+//            .period(1m)
+//            .every(1m)
+//            .allowedLateness(10s)
+//    win
+//        |late()
+//        |log()
+//
+// The above example logs all points arriving later than `10 seconds` after the end of their window.
+type LateNode struct {
+	chainnode
+}
+
+func newLateNode() *LateNode {
+	return &LateNode{
+		chainnode: newBasicChainNode("late", BatchEdge, StreamEdge),
+	}
+}
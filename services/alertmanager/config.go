@@ -0,0 +1,72 @@
+package alertmanager
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/influxdata/influxdb/toml"
+)
+
+// Default interval for re-sending active alerts,
+// must be shorter than the resolve_timeout of Alertmanager.
+const DefaultResendInterval = toml.Duration(time.Minute)
+
+type Config struct {
+	// Whether Alertmanager integration is enabled.
+	Enabled bool `toml:"enabled"`
+	// The URL of the default Alertmanager, i.e. http://localhost:9093.
+	URL string `toml:"url"`
+	// Interval at which active alerts are re-sent so that Alertmanager does not expire them.
+	ResendInterval toml.Duration `toml:"resend-interval"`
+	// Whether every alert should automatically go to Alertmanager.
+	Global bool `toml:"global"`
+
+	// Additional named Alertmanager endpoints.
+	Endpoints []EndpointConfig `toml:"endpoint"`
+}
+
+// Configuration of a named Alertmanager endpoint.
+// Alerts select an endpoint by name, the top level URL is the default endpoint.
+type EndpointConfig struct {
+	Name string `toml:"name"`
+	URL  string `toml:"url"`
+}
+
+func NewConfig() Config {
+	return Config{
+		ResendInterval: DefaultResendInterval,
+	}
+}
+
+func (c Config) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.URL == "" {
+		return fmt.Errorf("must specify alertmanager url")
+	}
+	if c.ResendInterval <= 0 {
+		return fmt.Errorf("alertmanager resend-interval must be positive")
+	}
+	if _, err := url.Parse(c.URL); err != nil {
+		return err
+	}
+	names := make(map[string]bool, len(c.Endpoints))
+	for _, e := range c.Endpoints {
+		if e.Name == "" {
+			return fmt.Errorf("alertmanager endpoint must have a name")
+		}
+		if names[e.Name] {
+			return fmt.Errorf("duplicate name %q for alertmanager endpoints", e.Name)
+		}
+		names[e.Name] = true
+		if e.URL == "" {
+			return fmt.Errorf("alertmanager endpoint %q must have a url", e.Name)
+		}
+		if _, err := url.Parse(e.URL); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,267 @@
+package alertmanager
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/kapacitor"
+)
+
+const alertsPath = "/api/v1/alerts"
+
+// An alert as accepted by the Alertmanager API.
+type alert struct {
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations"`
+	StartsAt     time.Time         `json:"startsAt"`
+	EndsAt       *time.Time        `json:"endsAt,omitempty"`
+	GeneratorURL string            `json:"generatorURL,omitempty"`
+}
+
+// Active alerts are identified by the source sending them and their ID.
+type activeKey struct {
+	source  string
+	alertID string
+}
+
+type Service struct {
+	HTTPDService interface {
+		URL() string
+	}
+
+	endpoints      map[string]string
+	resendInterval time.Duration
+	global         bool
+
+	// Active alerts per endpoint.
+	mu     sync.Mutex
+	active map[string]map[activeKey]*alert
+
+	closing chan struct{}
+	wg      sync.WaitGroup
+
+	logger *log.Logger
+}
+
+func NewService(c Config, l *log.Logger) *Service {
+	s := &Service{
+		endpoints:      make(map[string]string, len(c.Endpoints)+1),
+		resendInterval: time.Duration(c.ResendInterval),
+		global:         c.Global,
+		active:         make(map[string]map[activeKey]*alert),
+		logger:         l,
+	}
+	s.endpoints[""] = c.URL
+	for _, e := range c.Endpoints {
+		s.endpoints[e.Name] = e.URL
+	}
+	return s
+}
+
+func (s *Service) Open() error {
+	s.closing = make(chan struct{})
+	s.wg.Add(1)
+	go s.resend()
+	return nil
+}
+
+func (s *Service) Close() error {
+	if s.closing != nil {
+		close(s.closing)
+		s.wg.Wait()
+	}
+	return nil
+}
+
+func (s *Service) Global() bool {
+	return s.global
+}
+
+// Return the URL of the named endpoint, the default endpoint has the empty name.
+// Reports whether the endpoint exists.
+func (s *Service) Endpoint(name string) (string, bool) {
+	u, ok := s.endpoints[name]
+	return u, ok
+}
+
+// Send an alert to the Alertmanager endpoint.
+//
+// The labels of the alert are the tags plus the alertname and severity.
+// The first non OK event of an alert sets startsAt,
+// an OK event sets endsAt and resolves the alert.
+// Since the severity is a label, a change of severity resolves the alert
+// with the previous severity and starts a new one.
+// Active alerts are re-sent periodically until resolved,
+// or until the source sending them is cleared.
+// The active alerts only change once they have been posted,
+// so that a failed post can be retried by calling Alert again.
+func (s *Service) Alert(endpoint, source, alertID, alertName, message, details string, level kapacitor.AlertLevel, t time.Time, tags map[string]string) error {
+	u, ok := s.Endpoint(endpoint)
+	if !ok {
+		return fmt.Errorf("unknown Alertmanager endpoint %q", endpoint)
+	}
+	key := activeKey{source: source, alertID: alertID}
+
+	s.mu.Lock()
+	prev := s.active[endpoint][key]
+	s.mu.Unlock()
+
+	var alerts []*alert
+	resolve := prev != nil && (level == kapacitor.OKAlert || prev.Labels["severity"] != Severity(level))
+	if resolve {
+		// Resolve the previous alert
+		resolved := *prev
+		endsAt := t
+		resolved.EndsAt = &endsAt
+		alerts = append(alerts, &resolved)
+	}
+	var a *alert
+	if level != kapacitor.OKAlert {
+		a = &alert{
+			Labels:       make(map[string]string, len(tags)+2),
+			Annotations:  make(map[string]string, 3),
+			StartsAt:     t,
+			GeneratorURL: s.generatorURL(),
+		}
+		for k, v := range tags {
+			a.Labels[k] = v
+		}
+		a.Labels["alertname"] = alertName
+		a.Labels["severity"] = Severity(level)
+		a.Annotations["alert_id"] = alertID
+		a.Annotations["summary"] = message
+		if details != "" {
+			a.Annotations["description"] = details
+		}
+		if prev != nil && !resolve {
+			a.StartsAt = prev.StartsAt
+		}
+		alerts = append(alerts, a)
+	}
+
+	if len(alerts) == 0 {
+		// Nothing is known about the alert, i.e. the first event is OK.
+		return nil
+	}
+	if err := s.post(u, alerts); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	active := s.active[endpoint]
+	if active == nil {
+		active = make(map[activeKey]*alert)
+		s.active[endpoint] = active
+	}
+	if a != nil {
+		active[key] = a
+	} else {
+		delete(active, key)
+	}
+	return nil
+}
+
+// Stop re-sending the active alerts of the source,
+// i.e. once the task sending them has stopped.
+func (s *Service) Clear(source string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, active := range s.active {
+		for key := range active {
+			if key.source == source {
+				delete(active, key)
+			}
+		}
+	}
+}
+
+// Return the Alertmanager severity label for an alert level.
+func Severity(level kapacitor.AlertLevel) string {
+	switch level {
+	case kapacitor.CritAlert:
+		return "critical"
+	case kapacitor.WarnAlert:
+		return "warning"
+	case kapacitor.InfoAlert:
+		return "info"
+	default:
+		return "ok"
+	}
+}
+
+func (s *Service) generatorURL() string {
+	if s.HTTPDService == nil {
+		return ""
+	}
+	return s.HTTPDService.URL()
+}
+
+// Periodically re-send all active alerts.
+func (s *Service) resend() {
+	defer s.wg.Done()
+	ticker := time.NewTicker(s.resendInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.closing:
+			return
+		case <-ticker.C:
+			s.resendActive()
+		}
+	}
+}
+
+func (s *Service) resendActive() {
+	s.mu.Lock()
+	pending := make(map[string][]*alert, len(s.active))
+	for endpoint, active := range s.active {
+		for _, a := range active {
+			pending[endpoint] = append(pending[endpoint], a)
+		}
+	}
+	s.mu.Unlock()
+	for endpoint, alerts := range pending {
+		if err := s.post(s.endpoints[endpoint], alerts); err != nil {
+			s.logger.Printf("E! failed to re-send active alerts to Alertmanager endpoint %q: %v", endpoint, err)
+		}
+	}
+}
+
+func (s *Service) post(u string, alerts []*alert) error {
+	var post bytes.Buffer
+	enc := json.NewEncoder(&post)
+	err := enc.Encode(alerts)
+	if err != nil {
+		return err
+	}
+
+	resp, err := http.Post(strings.TrimSuffix(u, "/")+alertsPath, "application/json", &post)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return err
+		}
+		type response struct {
+			Error string `json:"error"`
+		}
+		r := &response{Error: fmt.Sprintf("failed to understand Alertmanager response. code: %d content: %s", resp.StatusCode, string(body))}
+		b := bytes.NewReader(body)
+		dec := json.NewDecoder(b)
+		dec.Decode(r)
+		return errors.New(r.Error)
+	}
+	return nil
+}
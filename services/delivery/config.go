@@ -0,0 +1,65 @@
+package delivery
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/influxdata/influxdb/toml"
+)
+
+const (
+	// Default number of alerts that can be queued per handler.
+	DefaultQueueSize = 1000
+	// Default number of retries after the first failed attempt.
+	DefaultRetries = 3
+	// Default wait before the first retry.
+	DefaultInitialBackoff = toml.Duration(time.Second)
+	// Default upper bound for the wait between retries.
+	DefaultMaxBackoff = toml.Duration(time.Minute)
+	// Default time allowed for a single delivery attempt.
+	DefaultTimeout = toml.Duration(10 * time.Second)
+)
+
+type Config struct {
+	// Maximum number of alerts queued per handler.
+	// Alerts arriving while the queue is full are dropped and counted as failed.
+	QueueSize int `toml:"queue-size"`
+	// Number of times a failed delivery is retried.
+	Retries int `toml:"retries"`
+	// Wait before the first retry, doubled on every subsequent retry.
+	InitialBackoff toml.Duration `toml:"initial-backoff"`
+	// Upper bound for the wait between retries.
+	MaxBackoff toml.Duration `toml:"max-backoff"`
+	// Time allowed for a single delivery attempt.
+	// A value of 0 means no timeout.
+	Timeout toml.Duration `toml:"timeout"`
+	// Whether queued alerts should be persisted to the storage service,
+	// so that they survive a restart.
+	Persist bool `toml:"persist"`
+}
+
+func NewConfig() Config {
+	return Config{
+		QueueSize:      DefaultQueueSize,
+		Retries:        DefaultRetries,
+		InitialBackoff: DefaultInitialBackoff,
+		MaxBackoff:     DefaultMaxBackoff,
+		Timeout:        DefaultTimeout,
+	}
+}
+
+func (c Config) Validate() error {
+	if c.QueueSize < 1 {
+		return fmt.Errorf("delivery queue-size must be positive, got %d", c.QueueSize)
+	}
+	if c.Retries < 0 {
+		return fmt.Errorf("delivery retries must not be negative, got %d", c.Retries)
+	}
+	if c.InitialBackoff < 0 || c.MaxBackoff < 0 || c.Timeout < 0 {
+		return fmt.Errorf("delivery durations must not be negative")
+	}
+	if c.MaxBackoff < c.InitialBackoff {
+		return fmt.Errorf("delivery max-backoff %v must be greater than or equal to initial-backoff %v", time.Duration(c.MaxBackoff), time.Duration(c.InitialBackoff))
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package delivery
+
+import (
+	"log"
+	"time"
+
+	"github.com/influxdata/kapacitor/services/storage"
+)
+
+const deliveryNamespace = "alert_delivery"
+
+// Service provides the configuration for delivering alerts to handlers
+// and access to the store used to persist queued alerts.
+type Service struct {
+	StorageService interface {
+		Store(namespace string) storage.Interface
+	}
+	c      Config
+	logger *log.Logger
+}
+
+func NewService(c Config, l *log.Logger) *Service {
+	return &Service{
+		c:      c,
+		logger: l,
+	}
+}
+
+func (s *Service) Open() error {
+	if s.c.Persist {
+		s.logger.Println("I! persisting queued alerts")
+	}
+	return nil
+}
+
+func (s *Service) Close() error {
+	return nil
+}
+
+func (s *Service) QueueSize() int {
+	return s.c.QueueSize
+}
+
+func (s *Service) Retries() int {
+	return s.c.Retries
+}
+
+func (s *Service) InitialBackoff() time.Duration {
+	return time.Duration(s.c.InitialBackoff)
+}
+
+func (s *Service) MaxBackoff() time.Duration {
+	return time.Duration(s.c.MaxBackoff)
+}
+
+func (s *Service) Timeout() time.Duration {
+	return time.Duration(s.c.Timeout)
+}
+
+// Return the store for queued alerts.
+// Returns nil if persistence is disabled.
+func (s *Service) Store() storage.Interface {
+	if !s.c.Persist || s.StorageService == nil {
+		return nil
+	}
+	return s.StorageService.Store(deliveryNamespace)
+}
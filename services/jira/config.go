@@ -0,0 +1,45 @@
+package jira
+
+import (
+	"fmt"
+	"net/url"
+)
+
+const DefaultIssueType = "Task"
+
+type Config struct {
+	// Whether Jira integration is enabled.
+	Enabled bool `toml:"enabled"`
+	// The base URL of Jira, i.e. https://example.atlassian.net.
+	URL string `toml:"url"`
+	// The Jira username.
+	Username string `toml:"username"`
+	// The Jira API token or password of the user.
+	Token string `toml:"token"`
+	// The default project key, can be overridden per alert.
+	Project string `toml:"project"`
+	// The default issue type, can be overridden per alert.
+	IssueType string `toml:"issue-type"`
+	// The ID of the workflow transition that resolves an issue.
+	// If empty issues are only commented on when the alert recovers.
+	ResolveTransitionID string `toml:"resolve-transition-id"`
+}
+
+func NewConfig() Config {
+	return Config{
+		IssueType: DefaultIssueType,
+	}
+}
+
+func (c Config) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.URL == "" {
+		return fmt.Errorf("must specify jira url")
+	}
+	if _, err := url.Parse(c.URL); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,194 @@
+package jira
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/influxdata/kapacitor"
+	"github.com/influxdata/kapacitor/services/storage"
+	"github.com/influxdata/kapacitor/services/ticket"
+)
+
+const (
+	ticketsNamespace = "jira_tickets"
+	// Timeout of the requests to Jira, so that an unresponsive server does not hold up alerts.
+	requestTimeout = 30 * time.Second
+)
+
+type Service struct {
+	// Optional storage for the issue keys of open alerts.
+	// If not set the issue keys are only kept in memory.
+	StorageService interface {
+		Store(namespace string) storage.Interface
+	}
+
+	url                 string
+	username            string
+	token               string
+	project             string
+	issueType           string
+	resolveTransitionID string
+
+	client  *http.Client
+	manager *ticket.Manager
+	logger  *log.Logger
+}
+
+func NewService(c Config, l *log.Logger) *Service {
+	s := &Service{
+		url:                 strings.TrimSuffix(c.URL, "/"),
+		username:            c.Username,
+		token:               c.Token,
+		project:             c.Project,
+		issueType:           c.IssueType,
+		resolveTransitionID: c.ResolveTransitionID,
+		client:              &http.Client{Timeout: requestTimeout},
+		logger:              l,
+	}
+	s.manager = ticket.NewManager("Jira", (*tracker)(s), l)
+	return s
+}
+
+func (s *Service) Open() error {
+	if s.StorageService != nil {
+		s.manager.SetStore(s.StorageService.Store(ticketsNamespace))
+	}
+	return nil
+}
+
+func (s *Service) Close() error {
+	return nil
+}
+
+// Open, comment on or resolve the Jira issue of the alert.
+// Empty project and issue type use the values from the configuration.
+// Each project and issue type has its own issue for an alert.
+// The fields are set on the issue in addition to the summary and description.
+func (s *Service) Alert(project, issueType, alertID, message, summary, description string, fields map[string]string, level, minLevel kapacitor.AlertLevel) error {
+	if project == "" {
+		project = s.project
+	}
+	if issueType == "" {
+		issueType = s.issueType
+	}
+	issueFields := make(map[string]string, len(fields)+2)
+	for k, v := range fields {
+		issueFields[k] = v
+	}
+	issueFields["project"] = project
+	issueFields["issuetype"] = issueType
+	return s.manager.Alert(project+"/"+issueType, alertID, message, level, minLevel, ticket.Ticket{
+		Summary:     summary,
+		Description: description,
+		Fields:      issueFields,
+	})
+}
+
+// tracker implements ticket.Tracker using the Jira REST API.
+type tracker Service
+
+func (t *tracker) Open(tk ticket.Ticket) (string, error) {
+	fields := make(map[string]interface{}, len(tk.Fields)+2)
+	for k, v := range tk.Fields {
+		switch k {
+		case "project":
+			fields[k] = map[string]string{"key": v}
+		case "issuetype":
+			fields[k] = map[string]string{"name": v}
+		default:
+			fields[k] = v
+		}
+	}
+	fields["summary"] = tk.Summary
+	fields["description"] = tk.Description
+	r := struct {
+		Key string `json:"key"`
+	}{}
+	err := t.do("POST", "/rest/api/2/issue", map[string]interface{}{"fields": fields}, &r)
+	if err != nil {
+		return "", err
+	}
+	if r.Key == "" {
+		return "", errors.New("no issue key in Jira response")
+	}
+	return r.Key, nil
+}
+
+func (t *tracker) Comment(key, comment string) error {
+	return t.do("POST", "/rest/api/2/issue/"+key+"/comment", map[string]string{"body": comment}, nil)
+}
+
+// Comment on the issue while transitioning it,
+// or only comment if no resolve transition is configured.
+func (t *tracker) Resolve(key, comment string) error {
+	if t.resolveTransitionID == "" {
+		return t.Comment(key, comment)
+	}
+	transition := map[string]interface{}{
+		"transition": map[string]string{"id": t.resolveTransitionID},
+		"update": map[string]interface{}{
+			"comment": []interface{}{
+				map[string]interface{}{
+					"add": map[string]string{"body": comment},
+				},
+			},
+		},
+	}
+	return t.do("POST", "/rest/api/2/issue/"+key+"/transitions", transition, nil)
+}
+
+// Send a request to the Jira API, decoding the response into r if not nil.
+func (t *tracker) do(method, path string, body, r interface{}) error {
+	var post bytes.Buffer
+	enc := json.NewEncoder(&post)
+	err := enc.Encode(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(method, t.url+path, &post)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if t.username != "" {
+		req.SetBasicAuth(t.username, t.token)
+	}
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return err
+		}
+		type response struct {
+			ErrorMessages []string          `json:"errorMessages"`
+			Errors        map[string]string `json:"errors"`
+		}
+		res := &response{}
+		dec := json.NewDecoder(bytes.NewReader(body))
+		if dec.Decode(res) != nil || (len(res.ErrorMessages) == 0 && len(res.Errors) == 0) {
+			return fmt.Errorf("failed to understand Jira response. code: %d content: %s", resp.StatusCode, string(body))
+		}
+		return fmt.Errorf("Jira error: %v %v", res.ErrorMessages, res.Errors)
+	}
+	if r == nil {
+		return nil
+	}
+	err = json.NewDecoder(resp.Body).Decode(r)
+	if err == io.EOF {
+		return nil
+	}
+	return err
+}
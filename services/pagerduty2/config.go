@@ -0,0 +1,20 @@
+package pagerduty2
+
+const DefaultPagerDuty2APIURL = "https://events.pagerduty.com/v2/enqueue"
+
+type Config struct {
+	// Whether PagerDuty Events API v2 integration is enabled.
+	Enabled bool `toml:"enabled"`
+	// The PagerDuty Events API v2 URL, should not need to be changed.
+	URL string `toml:"url"`
+	// The PagerDuty integration routing key.
+	RoutingKey string `toml:"routing-key"`
+	// Whether every alert should automatically go to PagerDuty
+	Global bool `toml:"global"`
+}
+
+func NewConfig() Config {
+	return Config{
+		URL: DefaultPagerDuty2APIURL,
+	}
+}
@@ -0,0 +1,153 @@
+package pagerduty2
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/influxdata/kapacitor"
+)
+
+// PagerDuty rejects dedup keys longer than this.
+const maxDedupKeyLength = 255
+
+type Service struct {
+	HTTPDService interface {
+		URL() string
+	}
+	routingKey string
+	url        string
+	global     bool
+	logger     *log.Logger
+}
+
+func NewService(c Config, l *log.Logger) *Service {
+	return &Service{
+		routingKey: c.RoutingKey,
+		url:        c.URL,
+		global:     c.Global,
+		logger:     l,
+	}
+}
+
+func (s *Service) Open() error {
+	return nil
+}
+
+func (s *Service) Close() error {
+	return nil
+}
+
+func (s *Service) Global() bool {
+	return s.global
+}
+
+type payload struct {
+	Summary       string                 `json:"summary"`
+	Source        string                 `json:"source"`
+	Severity      string                 `json:"severity"`
+	Timestamp     string                 `json:"timestamp"`
+	Class         string                 `json:"class,omitempty"`
+	CustomDetails map[string]interface{} `json:"custom_details,omitempty"`
+}
+
+type event struct {
+	RoutingKey  string   `json:"routing_key"`
+	EventAction string   `json:"event_action"`
+	DedupKey    string   `json:"dedup_key"`
+	Payload     *payload `json:"payload,omitempty"`
+	Client      string   `json:"client"`
+	ClientURL   string   `json:"client_url"`
+}
+
+// Send an event to PagerDuty.
+// Alerts with an OK level resolve the incident with the same dedup key,
+// all other levels trigger it.
+func (s *Service) Alert(routingKey, alertID, desc, source string, level kapacitor.AlertLevel, t time.Time, details map[string]interface{}) error {
+	if routingKey == "" {
+		routingKey = s.routingKey
+	}
+	ev := event{
+		RoutingKey: routingKey,
+		DedupKey:   DedupKey(alertID),
+		Client:     kapacitor.Product,
+		ClientURL:  s.HTTPDService.URL(),
+	}
+	if level == kapacitor.OKAlert {
+		ev.EventAction = "resolve"
+	} else {
+		ev.EventAction = "trigger"
+		if source == "" {
+			source = kapacitor.Product
+		}
+		ev.Payload = &payload{
+			Summary:       desc,
+			Source:        source,
+			Severity:      Severity(level),
+			Timestamp:     t.UTC().Format(time.RFC3339Nano),
+			Class:         alertID,
+			CustomDetails: details,
+		}
+	}
+
+	var post bytes.Buffer
+	enc := json.NewEncoder(&post)
+	err := enc.Encode(ev)
+	if err != nil {
+		return err
+	}
+
+	resp, err := http.Post(s.url, "application/json", &post)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted && resp.StatusCode != http.StatusOK {
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return err
+		}
+		type response struct {
+			Message string   `json:"message"`
+			Errors  []string `json:"errors"`
+		}
+		r := &response{Message: fmt.Sprintf("failed to understand PagerDuty response. code: %d content: %s", resp.StatusCode, string(body))}
+		b := bytes.NewReader(body)
+		dec := json.NewDecoder(b)
+		dec.Decode(r)
+		if len(r.Errors) > 0 {
+			return fmt.Errorf("%s: %v", r.Message, r.Errors)
+		}
+		return errors.New(r.Message)
+	}
+	return nil
+}
+
+// Return the PagerDuty severity for an alert level.
+func Severity(level kapacitor.AlertLevel) string {
+	switch level {
+	case kapacitor.CritAlert:
+		return "critical"
+	case kapacitor.WarnAlert:
+		return "warning"
+	default:
+		return "info"
+	}
+}
+
+// Return the dedup key for an alert ID.
+// IDs that are too long for PagerDuty are replaced by their SHA-256 hash.
+func DedupKey(alertID string) string {
+	if len(alertID) <= maxDedupKeyLength {
+		return alertID
+	}
+	sum := sha256.Sum256([]byte(alertID))
+	return hex.EncodeToString(sum[:])
+}
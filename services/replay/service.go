@@ -73,7 +73,7 @@ type Service struct {
 		NewNamedClient(name string) (client.Client, error)
 	}
 	TaskMaster interface {
-		NewFork(name string, dbrps []kapacitor.DBRP, measurements []string) (*kapacitor.Edge, error)
+		NewFork(name string, dbrps []kapacitor.DBRP, measurements []string, patterns []*regexp.Regexp) (*kapacitor.Edge, error)
 		DelFork(name string)
 		New() *kapacitor.TaskMaster
 		Stream(name string) (kapacitor.StreamCollector, error)
@@ -583,7 +583,7 @@ func (s *Service) handleRecordStream(w http.ResponseWriter, r *http.Request) {
 	// Spawn routine to perform actual recording.
 	go func(recording Recording) {
 		ds, _ := parseDataSourceURL(dataUrl.String())
-		err := s.doRecordStream(opt.ID, ds, opt.Stop, t.DBRPs, t.Measurements())
+		err := s.doRecordStream(opt.ID, ds, opt.Stop, t.DBRPs, t.Measurements(), t.MeasurementPatterns())
 		s.updateRecordingResult(recording, ds, err)
 	}(recording)
 
@@ -1193,8 +1193,8 @@ func (s streamWriter) Close() error {
 }
 
 // Record the stream for a duration
-func (s *Service) doRecordStream(id string, dataSource DataSource, stop time.Time, dbrps []kapacitor.DBRP, measurements []string) error {
-	e, err := s.TaskMaster.NewFork(id, dbrps, measurements)
+func (s *Service) doRecordStream(id string, dataSource DataSource, stop time.Time, dbrps []kapacitor.DBRP, measurements []string, patterns []*regexp.Regexp) error {
+	e, err := s.TaskMaster.NewFork(id, dbrps, measurements, patterns)
 	if err != nil {
 		return err
 	}
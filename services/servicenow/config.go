@@ -0,0 +1,48 @@
+package servicenow
+
+import (
+	"fmt"
+	"net/url"
+)
+
+const (
+	// Default state of resolved incidents.
+	DefaultResolvedState = "6"
+	// Default close code of resolved incidents.
+	DefaultCloseCode = "Solved (Permanently)"
+)
+
+type Config struct {
+	// Whether ServiceNow integration is enabled.
+	Enabled bool `toml:"enabled"`
+	// The URL of the ServiceNow instance, i.e. https://example.service-now.com.
+	URL string `toml:"url"`
+	// The ServiceNow username.
+	Username string `toml:"username"`
+	// The ServiceNow password.
+	Password string `toml:"password"`
+	// The value of the state field of resolved incidents.
+	ResolvedState string `toml:"resolved-state"`
+	// The value of the close_code field of resolved incidents.
+	CloseCode string `toml:"close-code"`
+}
+
+func NewConfig() Config {
+	return Config{
+		ResolvedState: DefaultResolvedState,
+		CloseCode:     DefaultCloseCode,
+	}
+}
+
+func (c Config) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.URL == "" {
+		return fmt.Errorf("must specify servicenow url")
+	}
+	if _, err := url.Parse(c.URL); err != nil {
+		return err
+	}
+	return nil
+}
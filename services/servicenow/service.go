@@ -0,0 +1,175 @@
+package servicenow
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/influxdata/kapacitor"
+	"github.com/influxdata/kapacitor/services/storage"
+	"github.com/influxdata/kapacitor/services/ticket"
+)
+
+const (
+	ticketsNamespace = "servicenow_tickets"
+	incidentPath     = "/api/now/table/incident"
+	// Timeout of the requests to ServiceNow, so that an unresponsive server does not hold up alerts.
+	requestTimeout = 30 * time.Second
+)
+
+type Service struct {
+	// Optional storage for the incident IDs of open alerts.
+	// If not set the incident IDs are only kept in memory.
+	StorageService interface {
+		Store(namespace string) storage.Interface
+	}
+
+	url           string
+	username      string
+	password      string
+	resolvedState string
+	closeCode     string
+
+	client  *http.Client
+	manager *ticket.Manager
+	logger  *log.Logger
+}
+
+func NewService(c Config, l *log.Logger) *Service {
+	s := &Service{
+		url:           strings.TrimSuffix(c.URL, "/"),
+		username:      c.Username,
+		password:      c.Password,
+		resolvedState: c.ResolvedState,
+		closeCode:     c.CloseCode,
+		client:        &http.Client{Timeout: requestTimeout},
+		logger:        l,
+	}
+	s.manager = ticket.NewManager("ServiceNow", (*tracker)(s), l)
+	return s
+}
+
+func (s *Service) Open() error {
+	if s.StorageService != nil {
+		s.manager.SetStore(s.StorageService.Store(ticketsNamespace))
+	}
+	return nil
+}
+
+func (s *Service) Close() error {
+	return nil
+}
+
+// Open, comment on or resolve the ServiceNow incident of the alert.
+// The fields are set on the incident in addition to the
+// short_description, description, urgency and impact.
+// All ServiceNow handlers of an alert share its incident.
+func (s *Service) Alert(alertID, message, summary, description string, fields map[string]string, level, minLevel kapacitor.AlertLevel) error {
+	return s.manager.Alert("", alertID, message, level, minLevel, ticket.Ticket{
+		Summary:     summary,
+		Description: description,
+		Fields:      fields,
+	})
+}
+
+// Return the ServiceNow urgency and impact of an alert level.
+func Urgency(level kapacitor.AlertLevel) string {
+	switch level {
+	case kapacitor.CritAlert:
+		return "1"
+	case kapacitor.WarnAlert:
+		return "2"
+	default:
+		return "3"
+	}
+}
+
+// tracker implements ticket.Tracker using the ServiceNow Table API.
+type tracker Service
+
+func (t *tracker) Open(tk ticket.Ticket) (string, error) {
+	incident := make(map[string]string, len(tk.Fields)+4)
+	incident["short_description"] = tk.Summary
+	incident["description"] = tk.Description
+	incident["urgency"] = Urgency(tk.Level)
+	incident["impact"] = Urgency(tk.Level)
+	for k, v := range tk.Fields {
+		incident[k] = v
+	}
+	r := struct {
+		Result struct {
+			SysID string `json:"sys_id"`
+		} `json:"result"`
+	}{}
+	if err := t.do("POST", incidentPath, incident, &r); err != nil {
+		return "", err
+	}
+	if r.Result.SysID == "" {
+		return "", errors.New("no sys_id in ServiceNow response")
+	}
+	return r.Result.SysID, nil
+}
+
+func (t *tracker) Comment(sysID, comment string) error {
+	return t.do("PATCH", incidentPath+"/"+sysID, map[string]string{"work_notes": comment}, nil)
+}
+
+func (t *tracker) Resolve(sysID, comment string) error {
+	incident := map[string]string{
+		"state":       t.resolvedState,
+		"close_code":  t.closeCode,
+		"close_notes": comment,
+	}
+	return t.do("PATCH", incidentPath+"/"+sysID, incident, nil)
+}
+
+// Send a request to the ServiceNow API, decoding the response into r if not nil.
+func (t *tracker) do(method, path string, body, r interface{}) error {
+	var post bytes.Buffer
+	enc := json.NewEncoder(&post)
+	err := enc.Encode(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(method, t.url+path, &post)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(t.username, t.password)
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return err
+		}
+		type response struct {
+			Error struct {
+				Message string `json:"message"`
+				Detail  string `json:"detail"`
+			} `json:"error"`
+		}
+		res := &response{}
+		dec := json.NewDecoder(bytes.NewReader(body))
+		if dec.Decode(res) != nil || res.Error.Message == "" {
+			return fmt.Errorf("failed to understand ServiceNow response. code: %d content: %s", resp.StatusCode, string(body))
+		}
+		return fmt.Errorf("ServiceNow error: %s: %s", res.Error.Message, res.Error.Detail)
+	}
+	if r == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(r)
+}
@@ -1,5 +1,13 @@
 package slack
 
+import (
+	"fmt"
+
+	"github.com/influxdata/kapacitor"
+)
+
+const DefaultWebAPIURL = "https://slack.com/api/chat.postMessage"
+
 type Config struct {
 	// Whether Slack integration is enabled.
 	Enabled bool `toml:"enabled"`
@@ -12,8 +20,74 @@ type Config struct {
 	// Whether all alerts should automatically use stateChangesOnly mode.
 	// Only applies if global is also set.
 	StateChangesOnly bool `toml:"state-changes-only"`
+
+	// Slack Web API token, required for threading alerts.
+	// If set, messages are posted using the Web API instead of the webhook URL.
+	Token string `toml:"token"`
+	// The Slack Web API chat.postMessage URL, should not need to be changed.
+	WebAPIURL string `toml:"web-api-url"`
+	// Attachment color per alert level, keyed by OK, INFO, WARNING and CRITICAL.
+	Colors map[string]string `toml:"colors"`
+	// Icon emoji per alert level, keyed by OK, INFO, WARNING and CRITICAL.
+	Emoji map[string]string `toml:"emoji"`
+
+	// Additional named workspaces.
+	Workspaces []WorkspaceConfig `toml:"workspace"`
+}
+
+// Configuration of a named Slack workspace.
+// Alerts select a workspace by name, the top level configuration is the default workspace.
+type WorkspaceConfig struct {
+	Name      string            `toml:"name"`
+	URL       string            `toml:"url"`
+	Channel   string            `toml:"channel"`
+	Token     string            `toml:"token"`
+	WebAPIURL string            `toml:"web-api-url"`
+	Colors    map[string]string `toml:"colors"`
+	Emoji     map[string]string `toml:"emoji"`
 }
 
 func NewConfig() Config {
-	return Config{}
+	return Config{
+		WebAPIURL: DefaultWebAPIURL,
+	}
+}
+
+func (c Config) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	names := make(map[string]bool, len(c.Workspaces))
+	for _, w := range c.Workspaces {
+		if w.Name == "" {
+			return fmt.Errorf("slack workspace must have a name")
+		}
+		if names[w.Name] {
+			return fmt.Errorf("duplicate name %q for slack workspaces", w.Name)
+		}
+		names[w.Name] = true
+		if w.URL == "" && w.Token == "" {
+			return fmt.Errorf("slack workspace %q must have a url or a token", w.Name)
+		}
+		if err := validateLevels(w.Colors); err != nil {
+			return err
+		}
+		if err := validateLevels(w.Emoji); err != nil {
+			return err
+		}
+	}
+	if err := validateLevels(c.Colors); err != nil {
+		return err
+	}
+	return validateLevels(c.Emoji)
+}
+
+func validateLevels(m map[string]string) error {
+	for k := range m {
+		var l kapacitor.AlertLevel
+		if err := l.UnmarshalText([]byte(k)); err != nil {
+			return fmt.Errorf("invalid slack level setting: %s", err)
+		}
+	}
+	return nil
 }
@@ -8,29 +8,107 @@ import (
 	"io/ioutil"
 	"log"
 	"net/http"
+	"sync"
 
 	"github.com/influxdata/kapacitor"
+	"github.com/influxdata/kapacitor/services/storage"
 )
 
+const threadsNamespace = "slack_threads"
+
+var defaultColors = map[kapacitor.AlertLevel]string{
+	kapacitor.OKAlert:   "good",
+	kapacitor.InfoAlert: "good",
+	kapacitor.WarnAlert: "warning",
+	kapacitor.CritAlert: "danger",
+}
+
+type workspace struct {
+	url       string
+	channel   string
+	token     string
+	webAPIURL string
+	colors    map[kapacitor.AlertLevel]string
+	emoji     map[kapacitor.AlertLevel]string
+}
+
+func newWorkspace(c WorkspaceConfig) *workspace {
+	w := &workspace{
+		url:       c.URL,
+		channel:   c.Channel,
+		token:     c.Token,
+		webAPIURL: c.WebAPIURL,
+		colors:    make(map[kapacitor.AlertLevel]string, len(defaultColors)),
+		emoji:     make(map[kapacitor.AlertLevel]string, len(c.Emoji)),
+	}
+	if w.webAPIURL == "" {
+		w.webAPIURL = DefaultWebAPIURL
+	}
+	for l, color := range defaultColors {
+		w.colors[l] = color
+	}
+	// Levels have been checked by Config.Validate
+	for k, color := range c.Colors {
+		var l kapacitor.AlertLevel
+		if l.UnmarshalText([]byte(k)) == nil {
+			w.colors[l] = color
+		}
+	}
+	for k, emoji := range c.Emoji {
+		var l kapacitor.AlertLevel
+		if l.UnmarshalText([]byte(k)) == nil {
+			w.emoji[l] = emoji
+		}
+	}
+	return w
+}
+
 type Service struct {
-	channel          string
-	url              string
+	// Optional storage for the timestamps of threaded messages.
+	// If not set the timestamps are only kept in memory.
+	StorageService interface {
+		Store(namespace string) storage.Interface
+	}
+
+	workspaces       map[string]*workspace
 	global           bool
 	stateChangesOnly bool
-	logger           *log.Logger
+
+	// Timestamps of the messages starting a thread, keyed by workspace, channel and alert ID.
+	mu      sync.Mutex
+	threads map[string]string
+	store   storage.Interface
+
+	logger *log.Logger
 }
 
 func NewService(c Config, l *log.Logger) *Service {
-	return &Service{
-		channel:          c.Channel,
-		url:              c.URL,
-		global:           c.Global,
+	s := &Service{
+		workspaces: make(map[string]*workspace, len(c.Workspaces)+1),
+		global:     c.Global,
+		threads:    make(map[string]string),
+
 		stateChangesOnly: c.StateChangesOnly,
 		logger:           l,
 	}
+	s.workspaces[""] = newWorkspace(WorkspaceConfig{
+		URL:       c.URL,
+		Channel:   c.Channel,
+		Token:     c.Token,
+		WebAPIURL: c.WebAPIURL,
+		Colors:    c.Colors,
+		Emoji:     c.Emoji,
+	})
+	for _, wc := range c.Workspaces {
+		s.workspaces[wc.Name] = newWorkspace(wc)
+	}
+	return s
 }
 
 func (s *Service) Open() error {
+	if s.StorageService != nil {
+		s.store = s.StorageService.Store(threadsNamespace)
+	}
 	return nil
 }
 
@@ -47,35 +125,91 @@ func (s *Service) StateChangesOnly() bool {
 
 // slack attachment info
 type attachment struct {
-	Fallback string `json:"fallback"`
-	Color    string `json:"color"`
-	Text     string `json:"text"`
+	Fallback string                 `json:"fallback"`
+	Color    string                 `json:"color"`
+	Title    string                 `json:"title,omitempty"`
+	Text     string                 `json:"text"`
+	Fields   []kapacitor.SlackField `json:"fields,omitempty"`
+	ImageURL string                 `json:"image_url,omitempty"`
 }
 
-func (s *Service) Alert(channel, message string, level kapacitor.AlertLevel) error {
-	if channel == "" {
-		channel = s.channel
+// Check that alerts can be sent to the workspace,
+// i.e. that it exists and that it has a token if the alerts are threaded.
+func (s *Service) ValidateWorkspace(workspaceName string, thread bool) error {
+	_, err := s.workspace(workspaceName, thread)
+	return err
+}
+
+func (s *Service) workspace(name string, thread bool) (*workspace, error) {
+	w, ok := s.workspaces[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown Slack workspace %q", name)
+	}
+	if thread && w.token == "" {
+		return nil, fmt.Errorf("Slack workspace %q has no token, cannot thread alerts", name)
+	}
+	return w, nil
+}
+
+// Post a message for the alert to Slack.
+//
+// If thread is true, the first message for an alert ID starts a thread
+// and subsequent messages for the same alert ID are posted as replies.
+// The thread is finished once the alert recovers.
+// Threading requires a Web API token for the workspace.
+// If imageURL is not empty the image is shown in the attachment.
+func (s *Service) Alert(workspaceName, channel, alertID, message, title string, fields []kapacitor.SlackField, imageURL string, level kapacitor.AlertLevel, thread bool) error {
+	w, err := s.workspace(workspaceName, thread)
+	if err != nil {
+		return err
 	}
-	var color string
-	switch level {
-	case kapacitor.WarnAlert:
-		color = "warning"
-	case kapacitor.CritAlert:
-		color = "danger"
-	default:
-		color = "good"
+	if channel == "" {
+		channel = w.channel
 	}
 	a := attachment{
 		Fallback: message,
+		Title:    title,
 		Text:     message,
-		Color:    color,
+		Color:    w.colors[level],
+		Fields:   fields,
+		ImageURL: imageURL,
 	}
 	postData := make(map[string]interface{})
 	postData["channel"] = channel
 	postData["username"] = kapacitor.Product
 	postData["text"] = ""
 	postData["attachments"] = []attachment{a}
+	if emoji := w.emoji[level]; emoji != "" {
+		postData["icon_emoji"] = emoji
+	}
+
+	if w.token == "" {
+		return s.postWebhook(w, postData)
+	}
+
+	threadKey := workspaceName + "/" + channel + "/" + alertID
+	var threadTS string
+	if thread {
+		threadTS = s.threadTS(threadKey)
+		if threadTS != "" {
+			postData["thread_ts"] = threadTS
+		}
+	}
+	ts, err := s.postWebAPI(w, postData)
+	if err != nil {
+		return err
+	}
+	if thread {
+		if level == kapacitor.OKAlert {
+			s.setThreadTS(threadKey, "")
+		} else if threadTS == "" {
+			s.setThreadTS(threadKey, ts)
+		}
+	}
+	return nil
+}
 
+func (s *Service) postWebhook(w *workspace, postData map[string]interface{}) error {
 	var post bytes.Buffer
 	enc := json.NewEncoder(&post)
 	err := enc.Encode(postData)
@@ -83,7 +217,7 @@ func (s *Service) Alert(channel, message string, level kapacitor.AlertLevel) err
 		return err
 	}
 
-	resp, err := http.Post(s.url, "application/json", &post)
+	resp, err := http.Post(w.url, "application/json", &post)
 	if err != nil {
 		return err
 	}
@@ -104,3 +238,82 @@ func (s *Service) Alert(channel, message string, level kapacitor.AlertLevel) err
 	}
 	return nil
 }
+
+// Post the message using the Web API, returning the timestamp of the posted message.
+func (s *Service) postWebAPI(w *workspace, postData map[string]interface{}) (string, error) {
+	var post bytes.Buffer
+	enc := json.NewEncoder(&post)
+	err := enc.Encode(postData)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequest("POST", w.webAPIURL, &post)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("Authorization", "Bearer "+w.token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	type response struct {
+		OK    bool   `json:"ok"`
+		TS    string `json:"ts"`
+		Error string `json:"error"`
+	}
+	r := &response{}
+	if err := json.Unmarshal(body, r); err != nil || resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to understand Slack response. code: %d content: %s", resp.StatusCode, string(body))
+	}
+	if !r.OK {
+		return "", errors.New(r.Error)
+	}
+	return r.TS, nil
+}
+
+// Return the timestamp of the message starting the thread, or empty if none exists.
+func (s *Service) threadTS(key string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if ts, ok := s.threads[key]; ok {
+		return ts
+	}
+	if s.store != nil {
+		kv, err := s.store.Get(key)
+		if err == nil {
+			s.threads[key] = string(kv.Value)
+			return string(kv.Value)
+		} else if err != storage.ErrNoKeyExists {
+			s.logger.Println("E! failed to load Slack thread timestamp", err)
+		}
+	}
+	return ""
+}
+
+// Set the timestamp of the message starting the thread, an empty timestamp removes the thread.
+func (s *Service) setThreadTS(key, ts string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var err error
+	if ts == "" {
+		delete(s.threads, key)
+		if s.store != nil {
+			err = s.store.Delete(key)
+		}
+	} else {
+		s.threads[key] = ts
+		if s.store != nil {
+			err = s.store.Put(key, []byte(ts))
+		}
+	}
+	if err != nil {
+		s.logger.Println("E! failed to store Slack thread timestamp", err)
+	}
+}
@@ -3,6 +3,7 @@ package smtp
 import (
 	"crypto/tls"
 	"errors"
+	"io"
 	"log"
 	"sync"
 	"time"
@@ -96,7 +97,10 @@ func (s *Service) runMailer() {
 	}
 }
 
-func (s *Service) SendMail(to []string, subject, body string) error {
+// Send an HTML email.
+// The images are embedded in the email by file name
+// and can be referenced in the body as 'cid:<name>'.
+func (s *Service) SendMail(to []string, subject, body string, images map[string][]byte) error {
 	if len(to) == 0 {
 		to = s.c.To
 	}
@@ -108,6 +112,13 @@ func (s *Service) SendMail(to []string, subject, body string) error {
 	m.SetHeader("To", to...)
 	m.SetHeader("Subject", subject)
 	m.SetBody("text/html", body)
+	for name, data := range images {
+		data := data
+		m.Embed(name, gomail.SetCopyFunc(func(w io.Writer) error {
+			_, err := w.Write(data)
+			return err
+		}))
+	}
 	s.mail <- m
 	return nil
 }
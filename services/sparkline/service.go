@@ -0,0 +1,219 @@
+// The sparkline service renders sparkline graphs of alert values as PNG images.
+//
+// The values are part of the URL of the image,
+// so the graph shows the values at the time of the alert
+// and stays available after the task is stopped or Kapacitor restarts.
+package sparkline
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/influxdata/kapacitor/services/httpd"
+)
+
+const sparklinePath = "/sparkline.png"
+
+// Size of the rendered sparkline images in pixels.
+const (
+	Width  = 120
+	Height = 30
+)
+
+// Maximum number of values rendered in a sparkline, one per pixel column of the image.
+// Bounds the length of the URLs, which are fetched by chat and mail clients.
+const MaxValues = Width
+
+// Number of steps per pixel row to which values are rounded in URLs.
+const stepsPerPixel = 10
+
+var (
+	lineColor = color.RGBA{R: 0x1f, G: 0x77, B: 0xb4, A: 0xff}
+	lastColor = color.RGBA{R: 0xd6, G: 0x27, B: 0x28, A: 0xff}
+)
+
+type Service struct {
+	routes []httpd.Route
+
+	HTTPDService interface {
+		AddRoutes([]httpd.Route) error
+		DelRoutes([]httpd.Route)
+		URL() string
+	}
+	logger *log.Logger
+}
+
+func NewService(l *log.Logger) *Service {
+	return &Service{
+		logger: l,
+	}
+}
+
+func (s *Service) Open() error {
+	s.routes = []httpd.Route{{
+		Name:        "sparkline",
+		Method:      "GET",
+		Pattern:     sparklinePath,
+		HandlerFunc: s.handleSparkline,
+	}}
+	return s.HTTPDService.AddRoutes(s.routes)
+}
+
+func (s *Service) Close() error {
+	s.HTTPDService.DelRoutes(s.routes)
+	return nil
+}
+
+// Return the URL of the sparkline graph of the values.
+// The values are rounded to the precision the graph can show, to keep the URL short.
+func (s *Service) URL(values []float64) string {
+	return s.HTTPDService.URL() + sparklinePath + "?values=" + url.QueryEscape(strings.Join(formatValues(values), ","))
+}
+
+// Format the values with the fewest significant digits
+// that still resolve a fraction of a pixel of the graph.
+func formatValues(values []float64) []string {
+	if len(values) == 0 {
+		return nil
+	}
+	min, max := values[0], values[0]
+	for _, v := range values {
+		min = math.Min(min, v)
+		max = math.Max(max, v)
+	}
+	// A flat line is drawn in the middle whatever the values are.
+	digits := 3
+	if max > min {
+		step := (max - min) / (Height * stepsPerPixel)
+		magnitude := math.Max(math.Abs(min), math.Abs(max))
+		digits = int(math.Ceil(math.Log10(magnitude/step))) + 1
+		if digits < 1 {
+			digits = 1
+		} else if digits > 17 {
+			digits = 17
+		}
+	}
+	strs := make([]string, len(values))
+	for i, v := range values {
+		strs[i] = strconv.FormatFloat(v, 'g', digits, 64)
+	}
+	return strs
+}
+
+func (s *Service) handleSparkline(w http.ResponseWriter, r *http.Request) {
+	values, err := parseValues(r.URL.Query().Get("values"))
+	if err != nil {
+		httpd.HttpError(w, err.Error(), true, http.StatusBadRequest)
+		return
+	}
+	b, err := Render(values)
+	if err != nil {
+		httpd.HttpError(w, err.Error(), true, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	w.Write(b)
+}
+
+func parseValues(s string) ([]float64, error) {
+	if s == "" {
+		return nil, nil
+	}
+	strs := strings.Split(s, ",")
+	if len(strs) > MaxValues {
+		return nil, fmt.Errorf("too many sparkline values, the maximum is %d", MaxValues)
+	}
+	values := make([]float64, len(strs))
+	for i, str := range strs {
+		v, err := strconv.ParseFloat(str, 64)
+		if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil, fmt.Errorf("invalid sparkline value %q", str)
+		}
+		values[i] = v
+	}
+	return values, nil
+}
+
+// Render the values as a PNG sparkline, the last value is marked with a dot.
+func Render(values []float64) ([]byte, error) {
+	img := image.NewRGBA(image.Rect(0, 0, Width, Height))
+	if len(values) > 0 {
+		min, max := values[0], values[0]
+		for _, v := range values {
+			min = math.Min(min, v)
+			max = math.Max(max, v)
+		}
+		// Leave a margin of 2 pixels for the dot marking the last value.
+		const margin = 2
+		x := func(i int) int {
+			if len(values) == 1 {
+				return Width - 1 - margin
+			}
+			return margin + i*(Width-1-2*margin)/(len(values)-1)
+		}
+		y := func(v float64) int {
+			if max == min {
+				return Height / 2
+			}
+			return Height - 1 - margin - int((v-min)/(max-min)*float64(Height-1-2*margin)+0.5)
+		}
+		for i := 1; i < len(values); i++ {
+			drawLine(img, x(i-1), y(values[i-1]), x(i), y(values[i]), lineColor)
+		}
+		lx, ly := x(len(values)-1), y(values[len(values)-1])
+		for dx := -1; dx <= 1; dx++ {
+			for dy := -1; dy <= 1; dy++ {
+				img.Set(lx+dx, ly+dy, lastColor)
+			}
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Draw a line using Bresenham's algorithm.
+func drawLine(img *image.RGBA, x0, y0, x1, y1 int, c color.Color) {
+	dx := x1 - x0
+	if dx < 0 {
+		dx = -dx
+	}
+	dy := y1 - y0
+	if dy > 0 {
+		dy = -dy
+	}
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	err := dx + dy
+	for {
+		img.Set(x0, y0, c)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * err
+		if e2 >= dy {
+			err += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			err += dx
+			y0 += sy
+		}
+	}
+}
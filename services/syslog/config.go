@@ -0,0 +1,90 @@
+package syslog
+
+import (
+	"fmt"
+)
+
+const (
+	DefaultNetwork          = "udp"
+	DefaultAddress          = "localhost:514"
+	DefaultFormat           = "rfc5424"
+	DefaultFacility         = "local0"
+	DefaultAppName          = "kapacitor"
+	DefaultEnterpriseNumber = 32473
+)
+
+type Config struct {
+	// Whether syslog integration is enabled.
+	Enabled bool `toml:"enabled"`
+	// The network of the syslog server, one of udp, tcp, unix or unixgram.
+	Network string `toml:"network"`
+	// The address of the syslog server, a host:port pair or the path of a unix socket.
+	Address string `toml:"address"`
+	// Whether to use TLS, only applies to the tcp network.
+	TLS bool `toml:"tls"`
+	// Path to CA file
+	SSLCA string `toml:"ssl-ca"`
+	// Path to host cert file
+	SSLCert string `toml:"ssl-cert"`
+	// Path to cert key file
+	SSLKey string `toml:"ssl-key"`
+	// Use TLS but skip chain & host verification
+	InsecureSkipVerify bool `toml:"insecure-skip-verify"`
+	// The message format, either rfc5424 or rfc3164.
+	Format string `toml:"format"`
+	// The default facility, can be overridden per alert.
+	Facility string `toml:"facility"`
+	// The APP-NAME or TAG of the messages.
+	AppName string `toml:"app-name"`
+	// The HOSTNAME of the messages, if empty uses the hostname of the machine.
+	Hostname string `toml:"hostname"`
+	// The private enterprise number used in the structured data IDs of rfc5424 messages.
+	EnterpriseNumber int `toml:"enterprise-number"`
+	// Whether every alert should automatically go to syslog
+	Global bool `toml:"global"`
+}
+
+func NewConfig() Config {
+	return Config{
+		Network:          DefaultNetwork,
+		Address:          DefaultAddress,
+		Format:           DefaultFormat,
+		Facility:         DefaultFacility,
+		AppName:          DefaultAppName,
+		EnterpriseNumber: DefaultEnterpriseNumber,
+	}
+}
+
+func (c Config) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	switch c.Network {
+	case "udp", "tcp", "unix", "unixgram":
+	default:
+		return fmt.Errorf("invalid syslog network %q, must be one of udp, tcp, unix or unixgram", c.Network)
+	}
+	if c.TLS {
+		if c.Network != "tcp" {
+			return fmt.Errorf("syslog tls can only be used with the tcp network")
+		}
+		if _, err := getTLSConfig(c.SSLCA, c.SSLCert, c.SSLKey, c.InsecureSkipVerify); err != nil {
+			return err
+		}
+	}
+	if c.Address == "" {
+		return fmt.Errorf("syslog address must not be empty")
+	}
+	switch c.Format {
+	case "rfc5424", "rfc3164":
+	default:
+		return fmt.Errorf("invalid syslog format %q, must be one of rfc5424 or rfc3164", c.Format)
+	}
+	if _, err := ParseFacility(c.Facility); err != nil {
+		return err
+	}
+	if c.EnterpriseNumber <= 0 {
+		return fmt.Errorf("syslog enterprise-number must be positive")
+	}
+	return nil
+}
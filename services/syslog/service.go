@@ -0,0 +1,336 @@
+package syslog
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/kapacitor"
+)
+
+// Timeout for connecting to and writing to the syslog server.
+const ioTimeout = 10 * time.Second
+
+var facilities = map[string]int{
+	"kern":     0,
+	"user":     1,
+	"mail":     2,
+	"daemon":   3,
+	"auth":     4,
+	"syslog":   5,
+	"lpr":      6,
+	"news":     7,
+	"uucp":     8,
+	"cron":     9,
+	"authpriv": 10,
+	"ftp":      11,
+	"local0":   16,
+	"local1":   17,
+	"local2":   18,
+	"local3":   19,
+	"local4":   20,
+	"local5":   21,
+	"local6":   22,
+	"local7":   23,
+}
+
+// Parse a facility name into its numerical code.
+func ParseFacility(name string) (int, error) {
+	f, ok := facilities[strings.ToLower(name)]
+	if !ok {
+		return 0, fmt.Errorf("unknown syslog facility %q", name)
+	}
+	return f, nil
+}
+
+// Severity returns the syslog severity of an alert level.
+func Severity(level kapacitor.AlertLevel) int {
+	switch level {
+	case kapacitor.CritAlert:
+		// critical
+		return 2
+	case kapacitor.WarnAlert:
+		// warning
+		return 4
+	case kapacitor.OKAlert:
+		// notice
+		return 5
+	default:
+		// informational
+		return 6
+	}
+}
+
+type Service struct {
+	network          string
+	address          string
+	tlsConfig        *tls.Config
+	format           string
+	facility         string
+	appName          string
+	hostname         string
+	enterpriseNumber int
+	global           bool
+
+	mu   sync.Mutex
+	conn net.Conn
+
+	logger *log.Logger
+}
+
+func NewService(c Config, l *log.Logger) (*Service, error) {
+	hostname := c.Hostname
+	if hostname == "" {
+		hostname, _ = os.Hostname()
+	}
+	var tlsConfig *tls.Config
+	if c.TLS {
+		var err error
+		tlsConfig, err = getTLSConfig(c.SSLCA, c.SSLCert, c.SSLKey, c.InsecureSkipVerify)
+		if err != nil {
+			return nil, err
+		}
+		if c.InsecureSkipVerify {
+			l.Printf("W! Using InsecureSkipVerify when connecting to syslog @ %s this is insecure!", c.Address)
+		}
+	}
+	return &Service{
+		network:          c.Network,
+		address:          c.Address,
+		tlsConfig:        tlsConfig,
+		format:           c.Format,
+		facility:         c.Facility,
+		appName:          c.AppName,
+		hostname:         hostname,
+		enterpriseNumber: c.EnterpriseNumber,
+		global:           c.Global,
+		logger:           l,
+	}, nil
+}
+
+func (s *Service) Open() error {
+	return nil
+}
+
+func (s *Service) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn != nil {
+		err := s.conn.Close()
+		s.conn = nil
+		return err
+	}
+	return nil
+}
+
+func (s *Service) Global() bool {
+	return s.global
+}
+
+// Send an alert message to the syslog server.
+// If facility is empty the facility from the configuration is used.
+func (s *Service) Alert(facility, alertID, task, message string, level kapacitor.AlertLevel, t time.Time, tags map[string]string) error {
+	if facility == "" {
+		facility = s.facility
+	}
+	f, err := ParseFacility(facility)
+	if err != nil {
+		return err
+	}
+	pri := f*8 + Severity(level)
+
+	var msg []byte
+	if s.format == "rfc3164" {
+		msg = s.rfc3164(pri, message, t)
+	} else {
+		msg = s.rfc5424(pri, alertID, task, message, level, t, tags)
+	}
+	return s.write(msg)
+}
+
+// Format a message as described in RFC 5424.
+// The alert ID, task and level, and the tags are sent as two structured data elements.
+func (s *Service) rfc5424(pri int, alertID, task, message string, level kapacitor.AlertLevel, t time.Time, tags map[string]string) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "<%d>1 %s %s %s %d alert ",
+		pri,
+		t.UTC().Format("2006-01-02T15:04:05.000000Z07:00"),
+		headerField(s.hostname, 255),
+		headerField(s.appName, 48),
+		os.Getpid(),
+	)
+	fmt.Fprintf(&buf, `[alert@%d id="%s" task="%s" level="%s"]`,
+		s.enterpriseNumber,
+		escapeParamValue(alertID),
+		escapeParamValue(task),
+		level,
+	)
+	if len(tags) > 0 {
+		keys := make([]string, 0, len(tags))
+		for k := range tags {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		fmt.Fprintf(&buf, "[tags@%d", s.enterpriseNumber)
+		for _, k := range keys {
+			fmt.Fprintf(&buf, ` %s="%s"`, paramName(k), escapeParamValue(tags[k]))
+		}
+		buf.WriteByte(']')
+	}
+	buf.WriteByte(' ')
+	buf.WriteString(message)
+	return buf.Bytes()
+}
+
+// Format a message as described in RFC 3164.
+func (s *Service) rfc3164(pri int, message string, t time.Time) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "<%d>%s %s %s[%d]: %s",
+		pri,
+		t.Format(time.Stamp),
+		headerField(s.hostname, 255),
+		headerField(s.appName, 32),
+		os.Getpid(),
+		message,
+	)
+	return buf.Bytes()
+}
+
+// Write the message, reconnecting once if the connection was broken.
+func (s *Service) write(msg []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var err error
+	for i := 0; i < 2; i++ {
+		if s.conn == nil {
+			s.conn, err = s.dial()
+			if err != nil {
+				return err
+			}
+		}
+		s.conn.SetWriteDeadline(time.Now().Add(ioTimeout))
+		_, err = s.conn.Write(s.frame(msg))
+		if err == nil {
+			return nil
+		}
+		s.conn.Close()
+		s.conn = nil
+	}
+	return err
+}
+
+func (s *Service) dial() (net.Conn, error) {
+	if s.tlsConfig != nil {
+		return tls.DialWithDialer(&net.Dialer{Timeout: ioTimeout}, s.network, s.address, s.tlsConfig)
+	}
+	return net.DialTimeout(s.network, s.address, ioTimeout)
+}
+
+// Frame the message for the network.
+// Datagrams carry a single message, on streams RFC 5424 messages are
+// prefixed by their length and RFC 3164 messages are terminated by a newline,
+// so newlines within RFC 3164 messages are escaped.
+func (s *Service) frame(msg []byte) []byte {
+	switch s.network {
+	case "tcp", "unix":
+		if s.format == "rfc3164" {
+			return append(escapeNewlines(msg), '\n')
+		}
+		return append([]byte(fmt.Sprintf("%d ", len(msg))), msg...)
+	default:
+		return msg
+	}
+}
+
+// Return a header field of printable ASCII characters without spaces,
+// truncated to the maximum length. Empty values are replaced by the NILVALUE.
+func headerField(v string, max int) string {
+	f := strings.Map(func(r rune) rune {
+		if r <= ' ' || r > '~' {
+			return -1
+		}
+		return r
+	}, v)
+	if len(f) > max {
+		f = f[:max]
+	}
+	if f == "" {
+		return "-"
+	}
+	return f
+}
+
+// Return a valid SD-NAME for the tag key.
+func paramName(k string) string {
+	n := strings.Map(func(r rune) rune {
+		if r <= ' ' || r > '~' || r == '=' || r == ']' || r == '"' {
+			return '_'
+		}
+		return r
+	}, k)
+	if len(n) > 32 {
+		n = n[:32]
+	}
+	if n == "" {
+		return "_"
+	}
+	return n
+}
+
+var newlineEscaper = strings.NewReplacer("\\", `\\`, "\n", `\n`, "\r", `\r`)
+
+// Escape the newlines of the message as \n and \r, and backslashes as \\.
+func escapeNewlines(msg []byte) []byte {
+	return []byte(newlineEscaper.Replace(string(msg)))
+}
+
+var paramValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`)
+
+func escapeParamValue(v string) string {
+	return paramValueEscaper.Replace(v)
+}
+
+// getTLSConfig creates a tls.Config object from the given certs, key, and CA files.
+// you must give the full path to the files.
+func getTLSConfig(
+	SSLCA, SSLCert, SSLKey string,
+	InsecureSkipVerify bool,
+) (*tls.Config, error) {
+	t := &tls.Config{
+		InsecureSkipVerify: InsecureSkipVerify,
+	}
+	if SSLCert != "" && SSLKey != "" {
+		cert, err := tls.LoadX509KeyPair(SSLCert, SSLKey)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"Could not load TLS client key/certificate: %s",
+				err)
+		}
+		t.Certificates = []tls.Certificate{cert}
+	} else if SSLCert != "" {
+		return nil, fmt.Errorf("Must provide both key and cert files: only cert file provided.")
+	} else if SSLKey != "" {
+		return nil, fmt.Errorf("Must provide both key and cert files: only key file provided.")
+	}
+
+	if SSLCA != "" {
+		caCert, err := ioutil.ReadFile(SSLCA)
+		if err != nil {
+			return nil, fmt.Errorf("Could not load TLS CA: %s",
+				err)
+		}
+		caCertPool := x509.NewCertPool()
+		caCertPool.AppendCertsFromPEM(caCert)
+		t.RootCAs = caCertPool
+	}
+	return t, nil
+}
@@ -14,6 +14,8 @@ var (
 	ErrTaskExists       = errors.New("task already exists")
 	ErrNoTaskExists     = errors.New("no task exists")
 	ErrNoSnapshotExists = errors.New("no snapshot exists")
+	ErrLibraryExists    = errors.New("library already exists")
+	ErrNoLibraryExists  = errors.New("no library exists")
 )
 
 // Data access object for Task Snapshot data.
@@ -49,6 +51,33 @@ type SnapshotDAO interface {
 	Put(id string, snapshot *Snapshot) error
 	// Whether a snapshot exists in the store.
 	Exists(id string) (bool, error)
+	// Delete a snapshot.
+	// Deleting a non-existent snapshot is not an error.
+	Delete(id string) error
+}
+
+// Data access object for TICKscript library data.
+type LibraryDAO interface {
+	// Retrieve a library
+	Get(id string) (Library, error)
+
+	// Create a library.
+	// ErrLibraryExists is returned if a library already exists with the same ID.
+	Create(l Library) error
+
+	// Replace an existing library.
+	// ErrNoLibraryExists is returned if the library does not exist.
+	Replace(l Library) error
+
+	// Delete a library.
+	// It is not an error to delete an non-existent library.
+	Delete(id string) error
+
+	// List libraries matching a pattern.
+	// The pattern is shell/glob matching see https://golang.org/pkg/path/#Match
+	// Offset and limit are pagination bounds. Offset is inclusive starting at index 0.
+	// More results may exist while the number of returned items is equal to limit.
+	List(pattern string, offset, limit int) ([]Library, error)
 }
 
 //--------------------------------------------------------------------
@@ -102,6 +131,19 @@ type DBRP struct {
 
 type Snapshot struct {
 	NodeSnapshots map[string][]byte
+	// Hash of the TICKscript the snapshot was taken from.
+	TICKscriptHash string
+}
+
+type Library struct {
+	// Unique identifier for the library, i.e. the path used to import it.
+	ID string
+	// The TICKscript statements of the library.
+	TICKscript string
+	// Created Date
+	Created time.Time
+	// The time the library was last modified
+	Modified time.Time
 }
 
 const (
@@ -309,6 +351,11 @@ func (d *snapshotKV) Exists(id string) (bool, error) {
 	return d.store.Exists(key)
 }
 
+func (d *snapshotKV) Delete(id string) error {
+	key := d.snapshotDataKey(id)
+	return d.store.Delete(key)
+}
+
 func (d *snapshotKV) Get(id string) (*Snapshot, error) {
 	exists, err := d.Exists(id)
 	if err != nil {
@@ -324,3 +371,147 @@ func (d *snapshotKV) Get(id string) (*Snapshot, error) {
 	}
 	return d.decodeSnapshot(data.Value)
 }
+
+const (
+	libraryDataPrefix    = "/libraries/data/"
+	libraryIndexesPrefix = "/libraries/indexes/"
+)
+
+// Key/Value store based implementation of the LibraryDAO
+type libraryKV struct {
+	store storage.Interface
+}
+
+func newLibraryKV(store storage.Interface) *libraryKV {
+	return &libraryKV{
+		store: store,
+	}
+}
+
+func (d *libraryKV) encodeLibrary(l Library) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := gob.NewEncoder(&buf)
+	err := enc.Encode(l)
+	return buf.Bytes(), err
+}
+
+func (d *libraryKV) decodeLibrary(data []byte) (Library, error) {
+	var library Library
+	dec := gob.NewDecoder(bytes.NewReader(data))
+	err := dec.Decode(&library)
+	return library, err
+}
+
+// Create a key for the library data
+func (d *libraryKV) libraryDataKey(id string) string {
+	return libraryDataPrefix + id
+}
+
+// Create a key for a given index and value.
+// Libraries are indexed the same way as tasks.
+func (d *libraryKV) libraryIndexKey(index, value string) string {
+	return libraryIndexesPrefix + index + value
+}
+
+func (d *libraryKV) Get(id string) (Library, error) {
+	key := d.libraryDataKey(id)
+	if exists, err := d.store.Exists(key); err != nil {
+		return Library{}, err
+	} else if !exists {
+		return Library{}, ErrNoLibraryExists
+	}
+	kv, err := d.store.Get(key)
+	if err != nil {
+		return Library{}, err
+	}
+	return d.decodeLibrary(kv.Value)
+}
+
+func (d *libraryKV) Create(l Library) error {
+	key := d.libraryDataKey(l.ID)
+
+	exists, err := d.store.Exists(key)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return ErrLibraryExists
+	}
+
+	data, err := d.encodeLibrary(l)
+	if err != nil {
+		return err
+	}
+	// Put data
+	err = d.store.Put(key, data)
+	if err != nil {
+		return err
+	}
+	// Put ID index
+	indexKey := d.libraryIndexKey(idIndex, l.ID)
+	return d.store.Put(indexKey, []byte(l.ID))
+}
+
+func (d *libraryKV) Replace(l Library) error {
+	key := d.libraryDataKey(l.ID)
+
+	exists, err := d.store.Exists(key)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrNoLibraryExists
+	}
+
+	data, err := d.encodeLibrary(l)
+	if err != nil {
+		return err
+	}
+	return d.store.Put(key, data)
+}
+
+func (d *libraryKV) Delete(id string) error {
+	key := d.libraryDataKey(id)
+	indexKey := d.libraryIndexKey(idIndex, id)
+
+	dataErr := d.store.Delete(key)
+	indexErr := d.store.Delete(indexKey)
+	if dataErr != nil {
+		return dataErr
+	}
+	return indexErr
+}
+
+func (d *libraryKV) List(pattern string, offset, limit int) ([]Library, error) {
+	// List all library ids sorted by ID
+	ids, err := d.store.List(libraryIndexesPrefix + idIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	var match func([]byte) bool
+	if pattern != "" {
+		match = func(value []byte) bool {
+			id := string(value)
+			matched, _ := path.Match(pattern, id)
+			return matched
+		}
+	} else {
+		match = func([]byte) bool { return true }
+	}
+	matches := storage.DoListFunc(ids, match, offset, limit)
+
+	libraries := make([]Library, len(matches))
+	for i, id := range matches {
+		data, err := d.store.Get(d.libraryDataKey(string(id)))
+		if err != nil {
+			return nil, err
+		}
+		l, err := d.decodeLibrary(data.Value)
+		if err != nil {
+			return nil, err
+		}
+		libraries[i] = l
+	}
+	return libraries, nil
+}
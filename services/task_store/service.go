@@ -11,11 +11,13 @@ import (
 	"path/filepath"
 	"regexp"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/boltdb/bolt"
 	"github.com/influxdata/kapacitor"
 	"github.com/influxdata/kapacitor/client/v1"
+	"github.com/influxdata/kapacitor/pipeline"
 	"github.com/influxdata/kapacitor/services/httpd"
 	"github.com/influxdata/kapacitor/services/storage"
 	"github.com/influxdata/kapacitor/tick"
@@ -26,12 +28,19 @@ import (
 const (
 	tasksPath         = "/tasks"
 	tasksPathAnchored = "/tasks/"
+	alertsStatePath   = "/alerts/state"
+
+	librariesPath         = "/libraries"
+	librariesPathAnchored = "/libraries/"
+
+	convertPipelinePath = "/pipelines/convert"
 )
 
 type Service struct {
 	oldDBDir         string
 	tasks            TaskDAO
 	snapshots        SnapshotDAO
+	libraries        LibraryDAO
 	routes           []httpd.Route
 	snapshotInterval time.Duration
 	StorageService   interface {
@@ -49,11 +58,21 @@ type Service struct {
 			dbrps []kapacitor.DBRP,
 			snapshotInterval time.Duration,
 		) (*kapacitor.Task, error)
+		NewTaskWithImporter(
+			name,
+			script string,
+			tt kapacitor.TaskType,
+			dbrps []kapacitor.DBRP,
+			snapshotInterval time.Duration,
+			importer tick.ImportFunc,
+		) (*kapacitor.Task, error)
+		CheckTask(t *kapacitor.Task) error
 		StartTask(t *kapacitor.Task) (*kapacitor.ExecutingTask, error)
 		StopTask(name string) error
 		IsExecuting(name string) bool
 		ExecutionStats(name string) (kapacitor.ExecutionStats, error)
 		ExecutingDot(name string, labels bool) string
+		AlertStates() []kapacitor.AlertState
 	}
 
 	logger *log.Logger
@@ -81,6 +100,7 @@ func (ts *Service) Open() error {
 	store := ts.StorageService.Store(taskNamespace)
 	ts.tasks = newTaskKV(store)
 	ts.snapshots = newSnapshotKV(store)
+	ts.libraries = newLibraryKV(store)
 
 	// Perform migration to new storage service.
 	err := ts.migrate()
@@ -127,6 +147,55 @@ func (ts *Service) Open() error {
 			Pattern:     tasksPath,
 			HandlerFunc: ts.handleCreateTask,
 		},
+		{
+			Name:        "library",
+			Method:      "GET",
+			Pattern:     librariesPathAnchored,
+			HandlerFunc: ts.handleLibrary,
+		},
+		{
+			Name:        "deleteLibrary",
+			Method:      "DELETE",
+			Pattern:     librariesPathAnchored,
+			HandlerFunc: ts.handleDeleteLibrary,
+		},
+		{
+			// Satisfy CORS checks.
+			Name:        "/libraries/-cors",
+			Method:      "OPTIONS",
+			Pattern:     librariesPathAnchored,
+			HandlerFunc: httpd.ServeOptions,
+		},
+		{
+			Name:        "updateLibrary",
+			Method:      "PATCH",
+			Pattern:     librariesPathAnchored,
+			HandlerFunc: ts.handleUpdateLibrary,
+		},
+		{
+			Name:        "listLibraries",
+			Method:      "GET",
+			Pattern:     librariesPath,
+			HandlerFunc: ts.handleListLibraries,
+		},
+		{
+			Name:        "createLibrary",
+			Method:      "POST",
+			Pattern:     librariesPath,
+			HandlerFunc: ts.handleCreateLibrary,
+		},
+		{
+			Name:        "convertPipeline",
+			Method:      "POST",
+			Pattern:     convertPipelinePath,
+			HandlerFunc: ts.handleConvertPipeline,
+		},
+		{
+			Name:        "alertsState",
+			Method:      "GET",
+			Pattern:     alertsStatePath,
+			HandlerFunc: ts.handleAlertsState,
+		},
 	}
 
 	err = ts.HTTPDService.AddRoutes(ts.routes)
@@ -341,7 +410,8 @@ func (ts *Service) Load(id string) (*kapacitor.Task, error) {
 
 func (ts *Service) SaveSnapshot(id string, snapshot *kapacitor.TaskSnapshot) error {
 	s := &Snapshot{
-		NodeSnapshots: snapshot.NodeSnapshots,
+		NodeSnapshots:  snapshot.NodeSnapshots,
+		TICKscriptHash: snapshot.TICKscriptHash,
 	}
 	return ts.snapshots.Put(id, s)
 }
@@ -361,11 +431,21 @@ func (ts *Service) LoadSnapshot(id string) (*kapacitor.TaskSnapshot, error) {
 		return nil, err
 	}
 	s := &kapacitor.TaskSnapshot{
-		NodeSnapshots: snapshot.NodeSnapshots,
+		NodeSnapshots:  snapshot.NodeSnapshots,
+		TICKscriptHash: snapshot.TICKscriptHash,
 	}
 	return s, nil
 }
 
+// Load the TICKscript of a library, used to resolve imports.
+func (ts *Service) LoadLibrary(id string) (string, error) {
+	l, err := ts.libraries.Get(id)
+	if err != nil {
+		return "", err
+	}
+	return l.TICKscript, nil
+}
+
 type TaskInfo struct {
 	Name           string
 	Type           kapacitor.TaskType
@@ -663,6 +743,63 @@ func (ts *Service) handleListTasks(w http.ResponseWriter, r *http.Request) {
 	w.Write(httpd.MarshalJSON(response{tasks}, true))
 }
 
+// List the alerts that are not OK across all executing tasks.
+// The alerts can be filtered by level, one or more level parameters,
+// and by task ID, a task pattern parameter as used when listing tasks.
+func (ts *Service) handleAlertsState(w http.ResponseWriter, r *http.Request) {
+	var levels []kapacitor.AlertLevel
+	for _, l := range r.URL.Query()["level"] {
+		var level kapacitor.AlertLevel
+		if err := level.UnmarshalText([]byte(strings.ToUpper(l))); err != nil {
+			httpd.HttpError(w, fmt.Sprintf("invalid level parameter %q", l), true, http.StatusBadRequest)
+			return
+		}
+		levels = append(levels, level)
+	}
+	pattern := r.URL.Query().Get("task")
+	if _, err := path.Match(pattern, ""); err != nil {
+		httpd.HttpError(w, fmt.Sprintf("invalid task parameter %q: %s", pattern, err), true, http.StatusBadRequest)
+		return
+	}
+
+	alerts := make([]client.AlertState, 0)
+	for _, s := range ts.TaskMaster.AlertStates() {
+		if pattern != "" {
+			if matched, _ := path.Match(pattern, s.Task); !matched {
+				continue
+			}
+		}
+		if len(levels) > 0 {
+			found := false
+			for _, l := range levels {
+				if s.Level == l {
+					found = true
+					break
+				}
+			}
+			if !found {
+				continue
+			}
+		}
+		alerts = append(alerts, client.AlertState{
+			ID:       s.ID,
+			Task:     s.Task,
+			Node:     s.Node,
+			Level:    s.Level.String(),
+			Since:    s.Since,
+			Message:  s.Message,
+			Tags:     s.Tags,
+			Flapping: s.Flapping,
+		})
+	}
+
+	type response struct {
+		Alerts []client.AlertState `json:"alerts"`
+	}
+
+	w.Write(httpd.MarshalJSON(response{alerts}, true))
+}
+
 var validTaskID = regexp.MustCompile(`^[-\._\p{L}0-9]+$`)
 
 func (ts *Service) handleCreateTask(w http.ResponseWriter, r *http.Request) {
@@ -704,6 +841,11 @@ func (ts *Service) handleCreateTask(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Set tick script
+	task.TICKscript, err = taskTICKscript(task.TICKscript, task.Pipeline)
+	if err != nil {
+		httpd.HttpError(w, err.Error(), true, http.StatusBadRequest)
+		return
+	}
 	newTask.TICKscript = task.TICKscript
 	if newTask.TICKscript == "" {
 		httpd.HttpError(w, fmt.Sprintf("must provide TICKscript"), true, http.StatusBadRequest)
@@ -735,9 +877,9 @@ func (ts *Service) handleCreateTask(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Validate task
-	ktask, err := ts.newKapacitorTask(newTask)
+	ktask, err := ts.newCheckedKapacitorTask(newTask)
 	if err != nil {
-		httpd.HttpError(w, "invalid TICKscript: "+err.Error(), true, http.StatusBadRequest)
+		invalidTICKscriptError(w, err)
 		return
 	}
 
@@ -825,6 +967,12 @@ func (ts *Service) handleUpdateTask(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Set tick script
+	task.TICKscript, err = taskTICKscript(task.TICKscript, task.Pipeline)
+	if err != nil {
+		httpd.HttpError(w, err.Error(), true, http.StatusBadRequest)
+		return
+	}
+	scriptChanged := task.TICKscript != "" && task.TICKscript != existing.TICKscript
 	if task.TICKscript != "" {
 		existing.TICKscript = task.TICKscript
 	}
@@ -851,9 +999,9 @@ func (ts *Service) handleUpdateTask(w http.ResponseWriter, r *http.Request) {
 	statusChanged := previousStatus != existing.Status
 
 	// Validate task
-	_, err = ts.newKapacitorTask(existing)
+	_, err = ts.newCheckedKapacitorTask(existing)
 	if err != nil {
-		httpd.HttpError(w, "invalid TICKscript: "+err.Error(), true, http.StatusBadRequest)
+		invalidTICKscriptError(w, err)
 		return
 	}
 
@@ -868,6 +1016,16 @@ func (ts *Service) handleUpdateTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// The state of a changed TICKscript starts over.
+	// Snapshots saved later by the task still running the previous TICKscript
+	// are not restored either, since they are taken from a different TICKscript.
+	if scriptChanged {
+		if err := ts.snapshots.Delete(existing.ID); err != nil {
+			httpd.HttpError(w, err.Error(), true, http.StatusInternalServerError)
+			return
+		}
+	}
+
 	if statusChanged {
 		// Enable/Disable task
 		switch existing.Status {
@@ -879,6 +1037,12 @@ func (ts *Service) handleUpdateTask(w http.ResponseWriter, r *http.Request) {
 			}
 		case Disabled:
 			ts.stopTask(existing.ID)
+			// Disabling a task resets its state, i.e. when reloading it,
+			// while a task that is still enabled keeps its state across restarts.
+			if err := ts.snapshots.Delete(existing.ID); err != nil {
+				httpd.HttpError(w, err.Error(), true, http.StatusInternalServerError)
+				return
+			}
 		}
 	}
 
@@ -911,6 +1075,9 @@ func (ts *Service) deleteTask(id string) error {
 	if task.Status == Enabled {
 		ts.stopTask(id)
 	}
+	if err := ts.snapshots.Delete(id); err != nil {
+		return err
+	}
 	return ts.tasks.Delete(id)
 }
 
@@ -937,6 +1104,115 @@ func (ts *Service) newKapacitorTask(task Task) (*kapacitor.Task, error) {
 	)
 }
 
+// Create the kapacitor task for a task being defined or updated,
+// checking it against the configured services.
+func (ts *Service) newCheckedKapacitorTask(task Task) (*kapacitor.Task, error) {
+	t, err := ts.newKapacitorTask(task)
+	if err != nil {
+		return nil, err
+	}
+	if err := ts.TaskMaster.CheckTask(t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Return the TICKscript of a task that is defined either by its TICKscript
+// or by the JSON representation of its pipeline.
+func taskTICKscript(script string, p json.RawMessage) (string, error) {
+	if len(p) == 0 {
+		return script, nil
+	}
+	if script != "" {
+		return "", errors.New("must provide either a TICKscript or a pipeline, not both")
+	}
+	script, err := pipeline.JSONToTICKscript(p)
+	if err != nil {
+		return "", errors.Wrap(err, "invalid pipeline")
+	}
+	return script, nil
+}
+
+func (ts *Service) handleConvertPipeline(w http.ResponseWriter, r *http.Request) {
+	opt := client.ConvertPipelineOptions{}
+	dec := json.NewDecoder(r.Body)
+	err := dec.Decode(&opt)
+	if err != nil {
+		httpd.HttpError(w, "invalid JSON", true, http.StatusBadRequest)
+		return
+	}
+
+	var tt kapacitor.TaskType
+	switch opt.Type {
+	case client.StreamTask:
+		tt = kapacitor.StreamTask
+	case client.BatchTask:
+		tt = kapacitor.BatchTask
+	default:
+		httpd.HttpError(w, fmt.Sprintf("unknown type %q", opt.Type), true, http.StatusBadRequest)
+		return
+	}
+
+	script, err := taskTICKscript(opt.TICKscript, opt.Pipeline)
+	if err != nil {
+		httpd.HttpError(w, err.Error(), true, http.StatusBadRequest)
+		return
+	}
+	if script == "" {
+		httpd.HttpError(w, "must provide TICKscript or pipeline", true, http.StatusBadRequest)
+		return
+	}
+
+	// Evaluate the TICKscript so that the pipeline is validated
+	// and UDFs and libraries are resolved.
+	ktask, err := ts.TaskMaster.NewTask("", script, tt, nil, 0)
+	if err == nil {
+		err = ts.TaskMaster.CheckTask(ktask)
+	}
+	if err != nil {
+		invalidTICKscriptError(w, err)
+		return
+	}
+	data, err := json.Marshal(ktask.Pipeline)
+	if err != nil {
+		httpd.HttpError(w, err.Error(), true, http.StatusInternalServerError)
+		return
+	}
+	p := client.Pipeline{
+		Type:       opt.Type,
+		TICKscript: script,
+		Pipeline:   data,
+	}
+	w.Write(httpd.MarshalJSON(p, true))
+}
+
+// Write the error of an invalid TICKscript,
+// including each diagnostic with its position if the TICKscript failed the static checks.
+func invalidTICKscriptError(w http.ResponseWriter, err error) {
+	diagnostics, ok := err.(tick.Diagnostics)
+	if !ok {
+		httpd.HttpError(w, "invalid TICKscript: "+err.Error(), true, http.StatusBadRequest)
+		return
+	}
+	type response struct {
+		Error       string              `json:"error"`
+		Diagnostics []client.Diagnostic `json:"diagnostics"`
+	}
+	r := response{
+		Error:       "invalid TICKscript: " + err.Error(),
+		Diagnostics: make([]client.Diagnostic, len(diagnostics)),
+	}
+	for i, d := range diagnostics {
+		r.Diagnostics[i] = client.Diagnostic{
+			Line:    d.Line,
+			Char:    d.Char,
+			Message: d.Message,
+		}
+	}
+	w.WriteHeader(http.StatusBadRequest)
+	w.Write(httpd.MarshalJSON(r, true))
+}
+
 func (ts *Service) startTask(task Task) error {
 	t, err := ts.newKapacitorTask(task)
 	if err != nil {
@@ -996,3 +1272,331 @@ func (ts *Service) saveLastError(id string, errStr string) error {
 	task.Error = errStr
 	return ts.tasks.Replace(task)
 }
+
+// Library IDs are paths of one or more segments separated by '/'.
+var validLibraryID = regexp.MustCompile(`^[-\._\p{L}0-9]+(/[-\._\p{L}0-9]+)*$`)
+
+func validateLibraryID(id string) error {
+	if !validLibraryID.MatchString(id) {
+		return fmt.Errorf("library ID must contain only letters, numbers, '-', '.', '_' and '/' separators. %q", id)
+	}
+	for _, segment := range strings.Split(id, "/") {
+		if segment == "." || segment == ".." {
+			return fmt.Errorf("library ID must not contain relative path segments. %q", id)
+		}
+	}
+	return nil
+}
+
+const librariesBasePathAnchored = httpd.BasePath + librariesPathAnchored
+
+func (ts *Service) libraryIDFromPath(path string) (string, error) {
+	if len(path) <= len(librariesBasePathAnchored) {
+		return "", errors.New("must specify library id on path")
+	}
+	id := path[len(librariesBasePathAnchored):]
+	return id, nil
+}
+
+func (ts *Service) libraryLink(id string) client.Link {
+	return client.Link{Relation: client.Self, Href: path.Join(httpd.BasePath, librariesPath, id)}
+}
+
+func (ts *Service) convertLibrary(l Library) client.Library {
+	return client.Library{
+		Link:       ts.libraryLink(l.ID),
+		ID:         l.ID,
+		TICKscript: l.TICKscript,
+		Created:    l.Created,
+		Modified:   l.Modified,
+	}
+}
+
+func (ts *Service) handleLibrary(w http.ResponseWriter, r *http.Request) {
+	id, err := ts.libraryIDFromPath(r.URL.Path)
+	if err != nil {
+		httpd.HttpError(w, err.Error(), true, http.StatusBadRequest)
+		return
+	}
+	l, err := ts.libraries.Get(id)
+	if err != nil {
+		httpd.HttpError(w, err.Error(), true, http.StatusNotFound)
+		return
+	}
+	w.Write(httpd.MarshalJSON(ts.convertLibrary(l), true))
+}
+
+func (ts *Service) handleListLibraries(w http.ResponseWriter, r *http.Request) {
+	pattern := r.URL.Query().Get("pattern")
+
+	var err error
+	offset := int64(0)
+	offsetStr := r.URL.Query().Get("offset")
+	if offsetStr != "" {
+		offset, err = strconv.ParseInt(offsetStr, 10, 64)
+		if err != nil {
+			httpd.HttpError(w, fmt.Sprintf("invalid offset parameter %q must be an integer: %s", offsetStr, err), true, http.StatusBadRequest)
+			return
+		}
+	}
+
+	limit := int64(100)
+	limitStr := r.URL.Query().Get("limit")
+	if limitStr != "" {
+		limit, err = strconv.ParseInt(limitStr, 10, 64)
+		if err != nil {
+			httpd.HttpError(w, fmt.Sprintf("invalid limit parameter %q must be an integer: %s", limitStr, err), true, http.StatusBadRequest)
+			return
+		}
+	}
+
+	rawLibraries, err := ts.libraries.List(pattern, int(offset), int(limit))
+	if err != nil {
+		httpd.HttpError(w, err.Error(), true, http.StatusInternalServerError)
+		return
+	}
+	libraries := make([]client.Library, len(rawLibraries))
+	for i, l := range rawLibraries {
+		libraries[i] = ts.convertLibrary(l)
+	}
+
+	type response struct {
+		Libraries []client.Library `json:"libraries"`
+	}
+
+	w.Write(httpd.MarshalJSON(response{libraries}, true))
+}
+
+func (ts *Service) handleCreateLibrary(w http.ResponseWriter, r *http.Request) {
+	library := client.CreateLibraryOptions{}
+	dec := json.NewDecoder(r.Body)
+	err := dec.Decode(&library)
+	if err != nil {
+		httpd.HttpError(w, "invalid JSON", true, http.StatusBadRequest)
+		return
+	}
+	if err := validateLibraryID(library.ID); err != nil {
+		httpd.HttpError(w, err.Error(), true, http.StatusBadRequest)
+		return
+	}
+	if library.TICKscript == "" {
+		httpd.HttpError(w, "must provide TICKscript", true, http.StatusBadRequest)
+		return
+	}
+	// Validate the syntax, the library is only evaluated when imported.
+	if _, err := tick.Format(library.TICKscript); err != nil {
+		httpd.HttpError(w, "invalid TICKscript: "+err.Error(), true, http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	newLibrary := Library{
+		ID:         library.ID,
+		TICKscript: library.TICKscript,
+		Created:    now,
+		Modified:   now,
+	}
+	err = ts.libraries.Create(newLibrary)
+	if err != nil {
+		if err == ErrLibraryExists {
+			httpd.HttpError(w, fmt.Sprintf("library %s already exists", library.ID), true, http.StatusBadRequest)
+			return
+		}
+		httpd.HttpError(w, err.Error(), true, http.StatusInternalServerError)
+		return
+	}
+	w.Write(httpd.MarshalJSON(ts.convertLibrary(newLibrary), true))
+}
+
+func (ts *Service) handleUpdateLibrary(w http.ResponseWriter, r *http.Request) {
+	id, err := ts.libraryIDFromPath(r.URL.Path)
+	if err != nil {
+		httpd.HttpError(w, err.Error(), true, http.StatusBadRequest)
+		return
+	}
+	library := client.UpdateLibraryOptions{}
+	dec := json.NewDecoder(r.Body)
+	err = dec.Decode(&library)
+	if err != nil {
+		httpd.HttpError(w, "invalid JSON", true, http.StatusBadRequest)
+		return
+	}
+
+	existing, err := ts.libraries.Get(id)
+	if err != nil {
+		httpd.HttpError(w, "library does not exist, cannot update", true, http.StatusNotFound)
+		return
+	}
+	if library.TICKscript != "" {
+		if _, err := tick.Format(library.TICKscript); err != nil {
+			httpd.HttpError(w, "invalid TICKscript: "+err.Error(), true, http.StatusBadRequest)
+			return
+		}
+		// Tasks importing the library would fail to load.
+		if err := ts.checkLibraryImporters(id, library.TICKscript); err != nil {
+			httpd.HttpError(w, fmt.Sprintf("cannot update library %s, %v", id, err), true, http.StatusConflict)
+			return
+		}
+		existing.TICKscript = library.TICKscript
+	}
+	existing.Modified = time.Now()
+	err = ts.libraries.Replace(existing)
+	if err != nil {
+		httpd.HttpError(w, err.Error(), true, http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (ts *Service) handleDeleteLibrary(w http.ResponseWriter, r *http.Request) {
+	id, err := ts.libraryIDFromPath(r.URL.Path)
+	if err != nil {
+		httpd.HttpError(w, err.Error(), true, http.StatusBadRequest)
+		return
+	}
+	// Tasks and libraries importing the library would fail to load.
+	importer, err := ts.libraryImporter(id)
+	if err != nil {
+		httpd.HttpError(w, err.Error(), true, http.StatusInternalServerError)
+		return
+	}
+	if importer != "" {
+		httpd.HttpError(w, fmt.Sprintf("cannot delete library %s, it is imported by %s", id, importer), true, http.StatusConflict)
+		return
+	}
+	err = ts.libraries.Delete(id)
+	if err != nil {
+		httpd.HttpError(w, err.Error(), true, http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Return a description of a task or library importing the library,
+// or an empty string if the library is not imported.
+func (ts *Service) libraryImporter(id string) (string, error) {
+	ids := map[string]bool{id: true}
+	importer := ""
+	err := ts.walkTasks(func(task Task) bool {
+		if importsLibrary(task.TICKscript, ids) {
+			importer = "task " + task.ID
+		}
+		return importer == ""
+	})
+	if err != nil || importer != "" {
+		return importer, err
+	}
+	err = ts.walkLibraries(func(l Library) bool {
+		if importsLibrary(l.TICKscript, ids) {
+			importer = "library " + l.ID
+		}
+		return importer == ""
+	})
+	return importer, err
+}
+
+// Evaluate the tasks importing the library, directly or through other libraries,
+// with the updated TICKscript of the library.
+// Libraries are only evaluated when imported, so libraries importing the library
+// are evaluated as part of the tasks importing them.
+func (ts *Service) checkLibraryImporters(id, script string) error {
+	// The libraries importing the library, directly or through other libraries.
+	ids := map[string]bool{id: true}
+	for added := true; added; {
+		added = false
+		err := ts.walkLibraries(func(l Library) bool {
+			if !ids[l.ID] && importsLibrary(l.TICKscript, ids) {
+				ids[l.ID] = true
+				added = true
+			}
+			return true
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	importer := func(path string) (string, error) {
+		if path == id {
+			return script, nil
+		}
+		return ts.LoadLibrary(path)
+	}
+	var taskErr error
+	err := ts.walkTasks(func(task Task) bool {
+		if !importsLibrary(task.TICKscript, ids) {
+			return true
+		}
+		var tt kapacitor.TaskType
+		switch task.Type {
+		case StreamTask:
+			tt = kapacitor.StreamTask
+		case BatchTask:
+			tt = kapacitor.BatchTask
+		}
+		if _, err := ts.TaskMaster.NewTaskWithImporter(task.ID, task.TICKscript, tt, nil, 0, importer); err != nil {
+			taskErr = fmt.Errorf("task %s fails to evaluate: %v", task.ID, err)
+		}
+		return taskErr == nil
+	})
+	if err != nil {
+		return err
+	}
+	return taskErr
+}
+
+// Return whether the script imports one of the libraries.
+func importsLibrary(script string, ids map[string]bool) bool {
+	paths, err := tick.Imports(script)
+	if err != nil {
+		return false
+	}
+	for _, p := range paths {
+		if ids[p] {
+			return true
+		}
+	}
+	return false
+}
+
+// Call f with each stored task until it returns false.
+func (ts *Service) walkTasks(f func(Task) bool) error {
+	offset := 0
+	limit := 100
+	for {
+		tasks, err := ts.tasks.List("", offset, limit)
+		if err != nil {
+			return err
+		}
+		for _, task := range tasks {
+			if !f(task) {
+				return nil
+			}
+		}
+		if len(tasks) != limit {
+			return nil
+		}
+		offset += limit
+	}
+}
+
+// Call f with each stored library until it returns false.
+func (ts *Service) walkLibraries(f func(Library) bool) error {
+	offset := 0
+	limit := 100
+	for {
+		libraries, err := ts.libraries.List("", offset, limit)
+		if err != nil {
+			return err
+		}
+		for _, l := range libraries {
+			if !f(l) {
+				return nil
+			}
+		}
+		if len(libraries) != limit {
+			return nil
+		}
+		offset += limit
+	}
+}
@@ -0,0 +1,224 @@
+/*
+The ticket package keeps incident tickets in sync with the state of alerts.
+
+A ticket is opened when an alert reaches a configured level,
+commented on when the level of the alert changes
+and resolved when the alert recovers.
+The ticket IDs are stored per destination, i.e. a Jira project, and alert ID
+so that a restart does not open duplicate tickets.
+The ticketing systems themselves, i.e. Jira or ServiceNow, implement the Tracker interface.
+*/
+package ticket
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/influxdata/kapacitor"
+	"github.com/influxdata/kapacitor/services/storage"
+)
+
+// A ticket to open, rendered from the alert data.
+type Ticket struct {
+	Summary     string
+	Description string
+	// Additional system specific fields.
+	Fields map[string]string
+	Level  kapacitor.AlertLevel
+}
+
+// Tracker is the interface to a ticketing system.
+type Tracker interface {
+	// Open a new ticket returning its ID.
+	Open(t Ticket) (string, error)
+	// Add a comment to the ticket.
+	Comment(id, comment string) error
+	// Resolve the ticket with a closing comment in a single request,
+	// so that a failed request can be retried.
+	Resolve(id, comment string) error
+}
+
+// The stored state of the ticket of an alert.
+type state struct {
+	ID    string               `json:"id"`
+	Level kapacitor.AlertLevel `json:"level"`
+	// Set while the ticket is being opened,
+	// so that a ticket whose ID could not be stored is not opened twice.
+	Opening bool `json:"opening,omitempty"`
+}
+
+// Manager drives the lifecycle of the tickets of a Tracker.
+// The events of an alert are handled one at a time,
+// while the events of different alerts do not wait for each other's requests to the tracker.
+type Manager struct {
+	tracker Tracker
+	name    string
+
+	// Persistent store of ticket states, if nil the states are kept in memory.
+	store storage.Interface
+
+	// Protects states and locks.
+	mu     sync.Mutex
+	states map[string]state
+	// Locks of the alerts being handled by key.
+	locks map[string]*keyLock
+
+	logger *log.Logger
+}
+
+// Create a new Manager for a tracker, the name is used in comments and errors.
+func NewManager(name string, tracker Tracker, l *log.Logger) *Manager {
+	return &Manager{
+		tracker: tracker,
+		name:    name,
+		states:  make(map[string]state),
+		locks:   make(map[string]*keyLock),
+		logger:  l,
+	}
+}
+
+// Set the store persisting the ticket states.
+// Must be called before any alert is handled.
+func (m *Manager) SetStore(store storage.Interface) {
+	m.store = store
+}
+
+// Handle an alert event.
+//
+// A ticket is opened once the level reaches minLevel.
+// While a ticket is open, level changes are added as comments
+// and the OK level resolves the ticket.
+// The destination identifies where the tickets are opened, i.e. a Jira project,
+// each destination has its own ticket for an alert.
+func (m *Manager) Alert(destination, alertID, message string, level, minLevel kapacitor.AlertLevel, t Ticket) error {
+	key := alertID
+	if destination != "" {
+		key = destination + "/" + alertID
+	}
+	defer m.lock(key)()
+	s, ok, err := m.get(key)
+	if err != nil {
+		return err
+	}
+	switch {
+	case !ok:
+		if level == kapacitor.OKAlert || level < minLevel {
+			return nil
+		}
+		if err := m.put(key, state{Level: level, Opening: true}); err != nil {
+			return err
+		}
+		t.Level = level
+		id, err := m.tracker.Open(t)
+		if err != nil {
+			// No ticket was opened, so a retry may open it.
+			if err := m.delete(key); err != nil {
+				m.logger.Printf("E! failed to delete %s ticket state for alert %s: %v", m.name, alertID, err)
+			}
+			return fmt.Errorf("failed to open %s ticket: %s", m.name, err)
+		}
+		if err := m.put(key, state{ID: id, Level: level}); err != nil {
+			return fmt.Errorf("failed to store %s ticket %s of alert %s, the ticket will not be updated: %s", m.name, id, alertID, err)
+		}
+		return nil
+	case s.Opening:
+		// The ticket may have been opened without its ID being stored,
+		// so it can neither be updated nor opened again until the alert recovers.
+		if level == kapacitor.OKAlert {
+			return m.delete(key)
+		}
+		m.logger.Printf("E! %s ticket of alert %s is in an unknown state, not updating it", m.name, alertID)
+		return nil
+	case level == kapacitor.OKAlert:
+		comment := fmt.Sprintf("Alert %s recovered: %s", alertID, message)
+		if err := m.tracker.Resolve(s.ID, comment); err != nil {
+			return fmt.Errorf("failed to resolve %s ticket %s: %s", m.name, s.ID, err)
+		}
+		return m.delete(key)
+	case level != s.Level:
+		comment := fmt.Sprintf("Alert %s changed from %v to %v: %s", alertID, s.Level, level, message)
+		if err := m.tracker.Comment(s.ID, comment); err != nil {
+			return fmt.Errorf("failed to comment on %s ticket %s: %s", m.name, s.ID, err)
+		}
+		s.Level = level
+		return m.put(key, s)
+	}
+	return nil
+}
+
+// A lock of an alert, removed once no event of the alert is being handled.
+type keyLock struct {
+	sync.Mutex
+	// Number of events holding or waiting for the lock.
+	refs int
+}
+
+// Lock the key and return the function unlocking it.
+func (m *Manager) lock(key string) func() {
+	m.mu.Lock()
+	l, ok := m.locks[key]
+	if !ok {
+		l = new(keyLock)
+		m.locks[key] = l
+	}
+	l.refs++
+	m.mu.Unlock()
+
+	l.Lock()
+	return func() {
+		l.Unlock()
+		m.mu.Lock()
+		l.refs--
+		if l.refs == 0 {
+			delete(m.locks, key)
+		}
+		m.mu.Unlock()
+	}
+}
+
+func (m *Manager) get(key string) (state, bool, error) {
+	if m.store == nil {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		s, ok := m.states[key]
+		return s, ok, nil
+	}
+	kv, err := m.store.Get(key)
+	if err == storage.ErrNoKeyExists {
+		return state{}, false, nil
+	} else if err != nil {
+		return state{}, false, err
+	}
+	s := state{}
+	if err := json.Unmarshal(kv.Value, &s); err != nil {
+		m.logger.Printf("E! discarding invalid %s ticket state %s: %v", m.name, key, err)
+		return state{}, false, nil
+	}
+	return s, true, nil
+}
+
+func (m *Manager) put(key string, s state) error {
+	if m.store == nil {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		m.states[key] = s
+		return nil
+	}
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	return m.store.Put(key, data)
+}
+
+func (m *Manager) delete(key string) error {
+	if m.store == nil {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		delete(m.states, key)
+		return nil
+	}
+	return m.store.Delete(key)
+}
@@ -0,0 +1,102 @@
+package kapacitor
+
+import (
+	"sync"
+
+	"github.com/influxdata/kapacitor/models"
+)
+
+// File name of sparkline images embedded in emails.
+const sparklineImageName = "sparkline.png"
+
+// sparklines keeps the most recent values of a field per group.
+// A group that received no values while the other groups
+// received enough values to fill their series is idle and is dropped.
+type sparklines struct {
+	field string
+	size  int
+
+	mu     sync.Mutex
+	series map[models.GroupID]*sparklineSeries
+	// Number of values added to any series.
+	adds int64
+	// Number of added values at which idle series are dropped next.
+	nextSweep int64
+}
+
+// Ring buffer of the values of a group.
+type sparklineSeries struct {
+	values []float64
+	next   int
+	full   bool
+	// Number of values added to any series when the group last received a value.
+	lastAdd int64
+}
+
+func newSparklines(field string, size int) *sparklines {
+	return &sparklines{
+		field:  field,
+		size:   size,
+		series: make(map[models.GroupID]*sparklineSeries),
+	}
+}
+
+// Add the value of the field to the series of the group.
+// Points without a numeric value for the field are ignored.
+func (s *sparklines) add(group models.GroupID, fields models.Fields) {
+	var v float64
+	switch f := fields[s.field].(type) {
+	case float64:
+		v = f
+	case int64:
+		v = float64(f)
+	default:
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.adds++
+	ss, ok := s.series[group]
+	if !ok {
+		ss = &sparklineSeries{values: make([]float64, s.size)}
+		s.series[group] = ss
+	}
+	ss.values[ss.next] = v
+	ss.next++
+	if ss.next == len(ss.values) {
+		ss.next = 0
+		ss.full = true
+	}
+	ss.lastAdd = s.adds
+	if s.adds >= s.nextSweep {
+		s.sweep()
+	}
+}
+
+// Drop the idle series.
+// Sweeping once for every window of values keeps the cost per value constant.
+func (s *sparklines) sweep() {
+	window := int64(s.size * len(s.series))
+	for group, ss := range s.series {
+		if s.adds-ss.lastAdd > window {
+			delete(s.series, group)
+		}
+	}
+	s.nextSweep = s.adds + int64(s.size*len(s.series))
+}
+
+// Return a copy of the values of the group, oldest first.
+func (s *sparklines) values(group models.GroupID) []float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ss, ok := s.series[group]
+	if !ok {
+		return nil
+	}
+	if !ss.full {
+		return append([]float64(nil), ss.values[:ss.next]...)
+	}
+	values := make([]float64, 0, len(ss.values))
+	values = append(values, ss.values[ss.next:]...)
+	return append(values, ss.values[:ss.next]...)
+}
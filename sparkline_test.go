@@ -0,0 +1,127 @@
+package kapacitor
+
+import (
+	"bytes"
+	"image/png"
+	"io/ioutil"
+	"log"
+	"math"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/services/httpd"
+	"github.com/influxdata/kapacitor/services/sparkline"
+)
+
+func TestSparklines_Values(t *testing.T) {
+	s := newSparklines("value", 3)
+	for i := int64(1); i <= 5; i++ {
+		s.add("a", models.Fields{"value": i})
+	}
+	s.add("b", models.Fields{"value": 1.5})
+	s.add("b", models.Fields{"value": "not a number"})
+	s.add("b", models.Fields{"other": 2.0})
+
+	if exp, got := []float64{3, 4, 5}, s.values("a"); !reflect.DeepEqual(got, exp) {
+		t.Errorf("unexpected values for group a got %v exp %v", got, exp)
+	}
+	if exp, got := []float64{1.5}, s.values("b"); !reflect.DeepEqual(got, exp) {
+		t.Errorf("unexpected values for group b got %v exp %v", got, exp)
+	}
+	if got := s.values("c"); got != nil {
+		t.Errorf("unexpected values for unknown group got %v", got)
+	}
+}
+
+func TestSparklines_EvictIdle(t *testing.T) {
+	s := newSparklines("value", 2)
+	s.add("idle", models.Fields{"value": 1.0})
+	// The other groups keep receiving values until the idle group is dropped.
+	for i := 0; i < 10; i++ {
+		s.add("a", models.Fields{"value": 1.0})
+		s.add("b", models.Fields{"value": 1.0})
+	}
+	if got := s.values("idle"); got != nil {
+		t.Errorf("unexpected values for idle group got %v", got)
+	}
+	if exp, got := []float64{1, 1}, s.values("a"); !reflect.DeepEqual(got, exp) {
+		t.Errorf("unexpected values for group a got %v exp %v", got, exp)
+	}
+	if exp, got := 2, len(s.series); got != exp {
+		t.Errorf("unexpected number of series got %d exp %d", got, exp)
+	}
+}
+
+func TestRenderSparkline(t *testing.T) {
+	for _, values := range [][]float64{
+		nil,
+		{1},
+		{2, 2, 2},
+		{1, 5, -3, 4, 0},
+	} {
+		b, err := sparkline.Render(values)
+		if err != nil {
+			t.Fatal(err)
+		}
+		img, err := png.Decode(bytes.NewReader(b))
+		if err != nil {
+			t.Fatalf("invalid image for %v: %v", values, err)
+		}
+		if b := img.Bounds(); b.Dx() != sparkline.Width || b.Dy() != sparkline.Height {
+			t.Errorf("unexpected size for %v: %dx%d", values, b.Dx(), b.Dy())
+		}
+	}
+}
+
+type sparklineHTTPD struct{}
+
+func (sparklineHTTPD) AddRoutes([]httpd.Route) error { return nil }
+func (sparklineHTTPD) DelRoutes([]httpd.Route)       {}
+func (sparklineHTTPD) URL() string                   { return "http://localhost:9092/kapacitor/v1" }
+
+func TestSparklineURL_Rounded(t *testing.T) {
+	s := sparkline.NewService(log.New(ioutil.Discard, "", 0))
+	s.HTTPDService = sparklineHTTPD{}
+	testCases := []struct {
+		values []float64
+		// Maximum length of each value in the URL.
+		maxLen int
+	}{
+		{values: []float64{2, 2, 2}, maxLen: 1},
+		{values: []float64{1.0 / 3, 2.0 / 3, 99.123456789}, maxLen: 6},
+		{values: []float64{1000.0123456, 1000.0223456, 1000.0323456}, maxLen: 10},
+		{values: []float64{-5e-9, 1e-9, 3.333333e-9}, maxLen: 9},
+	}
+	for _, tc := range testCases {
+		u, err := url.Parse(s.URL(tc.values))
+		if err != nil {
+			t.Fatal(err)
+		}
+		strs := strings.Split(u.Query().Get("values"), ",")
+		if len(strs) != len(tc.values) {
+			t.Fatalf("%v: unexpected number of values in URL %s", tc.values, u)
+		}
+		min, max := tc.values[0], tc.values[0]
+		for _, v := range tc.values {
+			min = math.Min(min, v)
+			max = math.Max(max, v)
+		}
+		for i, str := range strs {
+			if len(str) > tc.maxLen {
+				t.Errorf("%v: value %q is longer than %d", tc.values, str, tc.maxLen)
+			}
+			v, err := strconv.ParseFloat(str, 64)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// The rounding error is below a pixel of the graph.
+			if pixel := (max - min) / sparkline.Height; math.Abs(v-tc.values[i]) > pixel {
+				t.Errorf("%v: value %q differs by more than a pixel from %v", tc.values, str, tc.values[i])
+			}
+		}
+	}
+}
@@ -3,6 +3,7 @@ package kapacitor
 import (
 	"fmt"
 	"log"
+	"sync"
 
 	"github.com/influxdata/kapacitor/models"
 	"github.com/influxdata/kapacitor/pipeline"
@@ -38,14 +39,16 @@ func (s *StreamNode) runSourceStream([]byte) error {
 
 type FromNode struct {
 	node
-	s             *pipeline.FromNode
+	s *pipeline.FromNode
+	// Protects the expression while it is snapshotted.
+	mu            sync.Mutex
 	expression    stateful.Expression
 	scopePool     stateful.ScopePool
 	dimensions    []string
 	allDimensions bool
-	db            string
-	rp            string
-	name          string
+	db            *nameMatcher
+	rp            *nameMatcher
+	name          *nameMatcher
 }
 
 // Create a new  FromNode which filters data from a source.
@@ -53,9 +56,9 @@ func newFromNode(et *ExecutingTask, n *pipeline.FromNode, l *log.Logger) (*FromN
 	sn := &FromNode{
 		node: node{Node: n, et: et, logger: l},
 		s:    n,
-		db:   n.Database,
-		rp:   n.RetentionPolicy,
-		name: n.Measurement,
+		db:   newNameMatcher(n.Databases),
+		rp:   newNameMatcher(n.RetentionPolicies),
+		name: newNameMatcher(n.Measurements),
 	}
 	sn.node.runF = sn.runStream
 	sn.allDimensions, sn.dimensions = determineDimensions(n.Dimensions)
@@ -73,7 +76,12 @@ func newFromNode(et *ExecutingTask, n *pipeline.FromNode, l *log.Logger) (*FromN
 	return sn, nil
 }
 
-func (s *FromNode) runStream([]byte) error {
+func (s *FromNode) runStream(snapshot []byte) error {
+	if snapshot != nil {
+		if err := s.restore(snapshot); err != nil {
+			s.logger.Println("E! failed to restore snapshot:", err)
+		}
+	}
 	for pt, ok := s.ins[0].NextPoint(); ok; pt, ok = s.ins[0].NextPoint() {
 		s.timer.Start()
 		if s.matches(pt) {
@@ -99,17 +107,19 @@ func (s *FromNode) runStream([]byte) error {
 }
 
 func (s *FromNode) matches(p models.Point) bool {
-	if s.db != "" && p.Database != s.db {
+	if !s.db.matches(p.Database) {
 		return false
 	}
-	if s.rp != "" && p.RetentionPolicy != s.rp {
+	if !s.rp.matches(p.RetentionPolicy) {
 		return false
 	}
-	if s.name != "" && p.Name != s.name {
+	if !s.name.matches(p.Name) {
 		return false
 	}
 	if s.expression != nil {
-		if pass, err := EvalPredicate(s.expression, s.scopePool, p.Time, p.Fields, p.Tags); err != nil {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if pass, err := EvalPredicate(s.expression, s.scopePool, p.Time, s.Location(), p.Fields, p.Tags); err != nil {
 			s.logger.Println("E! error while evaluating WHERE expression:", err)
 			return false
 		} else {
@@ -118,3 +128,53 @@ func (s *FromNode) matches(p models.Point) bool {
 	}
 	return true
 }
+
+// The points are not grouped yet, the state of the expression is snapshotted as the empty group.
+func (s *FromNode) snapshot() ([]byte, error) {
+	if s.expression == nil {
+		return nil, nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return snapshotExpressions(map[models.GroupID][]stateful.Expression{"": {s.expression}})
+}
+
+func (s *FromNode) restore(snapshot []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return restoreExpressions(snapshot, func(models.GroupID) ([]stateful.Expression, error) {
+		return []stateful.Expression{s.expression}, nil
+	})
+}
+
+// The maximum number of names for which the result of a regex match is cached.
+const maxNameMatcherCache = 1000
+
+// Matches names against a selection,
+// caching the results of regex matches as the same names are seen repeatedly.
+type nameMatcher struct {
+	sel   pipeline.NameSelection
+	cache map[string]bool
+}
+
+func newNameMatcher(sel pipeline.NameSelection) *nameMatcher {
+	m := &nameMatcher{sel: sel}
+	if sel.Regex != nil {
+		m.cache = make(map[string]bool)
+	}
+	return m
+}
+
+func (m *nameMatcher) matches(name string) bool {
+	if m.sel.Regex == nil {
+		return m.sel.Matches(name)
+	}
+	if match, ok := m.cache[name]; ok {
+		return match
+	}
+	match := m.sel.Regex.MatchString(name)
+	if len(m.cache) < maxNameMatcherCache {
+		m.cache[name] = match
+	}
+	return match
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log"
 	"math/rand"
+	"regexp"
 	"sync"
 	"time"
 
@@ -71,6 +72,9 @@ type Task struct {
 	Type             TaskType
 	DBRPs            []DBRP
 	SnapshotInterval time.Duration
+	// Hash of the TICKscript of the task,
+	// snapshots taken while running a different TICKscript are not restored.
+	TICKscriptHash string
 }
 
 func (t *Task) Dot() []byte {
@@ -84,7 +88,14 @@ func (t *Task) Measurements() []string {
 	t.Pipeline.Walk(func(node pipeline.Node) error {
 		switch streamNode := node.(type) {
 		case *pipeline.FromNode:
-			measurements = append(measurements, streamNode.Measurement)
+			switch sel := streamNode.Measurements; {
+			case sel.Regex != nil:
+				// Selected by MeasurementPatterns
+			case sel.IsEmpty():
+				measurements = append(measurements, "")
+			default:
+				measurements = append(measurements, sel.Names...)
+			}
 		}
 		return nil
 	})
@@ -92,6 +103,18 @@ func (t *Task) Measurements() []string {
 	return measurements
 }
 
+// returns the regexes selecting measurements from all FromNodes
+func (t *Task) MeasurementPatterns() []*regexp.Regexp {
+	var patterns []*regexp.Regexp
+	t.Pipeline.Walk(func(node pipeline.Node) error {
+		if from, ok := node.(*pipeline.FromNode); ok && from.Measurements.Regex != nil {
+			patterns = append(patterns, from.Measurements.Regex)
+		}
+		return nil
+	})
+	return patterns
+}
+
 // ----------------------------------
 // ExecutingTask
 
@@ -182,6 +205,13 @@ func (et *ExecutingTask) link() error {
 		return err
 	}
 
+	// Fuse windows with the InfluxQL nodes reducing them.
+	for _, n := range et.nodes {
+		if w, ok := n.(*WindowNode); ok {
+			w.fuse()
+		}
+	}
+
 	// The first node is always the source node
 	et.source = et.nodes[0]
 	return nil
@@ -194,6 +224,12 @@ func (et *ExecutingTask) start(ins []*Edge, snapshot *TaskSnapshot) error {
 		et.source.addParentEdge(in)
 	}
 	validSnapshot := false
+	// The state of a changed TICKscript starts over,
+	// even if the task still running the previous TICKscript saved a snapshot after the change.
+	if snapshot != nil && snapshot.TICKscriptHash != "" && snapshot.TICKscriptHash != et.Task.TICKscriptHash {
+		et.logger.Println("I! task TICKscript changed not using snapshot")
+		snapshot = nil
+	}
 	if snapshot != nil {
 		err := et.walk(func(n Node) error {
 			_, ok := snapshot.NodeSnapshots[n.Name()]
@@ -366,6 +402,18 @@ func (et *ExecutingTask) ExecutionStats() (ExecutionStats, error) {
 	return executionStats, nil
 }
 
+// Return the states of all alerts of the task that are not OK.
+func (et *ExecutingTask) AlertStates() []AlertState {
+	var states []AlertState
+	et.walk(func(n Node) error {
+		if an, ok := n.(*AlertNode); ok {
+			states = append(states, an.AlertStates()...)
+		}
+		return nil
+	})
+	return states
+}
+
 // Return a graphviz .dot formatted byte array.
 // Label edges with relavant execution information.
 func (et *ExecutingTask) EDot(labels bool) []byte {
@@ -451,6 +499,12 @@ func (et *ExecutingTask) createNode(p pipeline.Node, l *log.Logger) (n Node, err
 		n, err = newQueryNode(et, t, l)
 	case *pipeline.WindowNode:
 		n, err = newWindowNode(et, t, l)
+	case *pipeline.LateNode:
+		n, err = newLateNode(et, t, l)
+	case *pipeline.FillNode:
+		n, err = newFillNode(et, t, l)
+	case *pipeline.ExtractNode:
+		n, err = newExtractNode(et, t, l)
 	case *pipeline.HTTPOutNode:
 		n, err = newHTTPOutNode(et, t, l)
 	case *pipeline.InfluxDBOutNode:
@@ -463,6 +517,8 @@ func (et *ExecutingTask) createNode(p pipeline.Node, l *log.Logger) (n Node, err
 		n, err = newUnionNode(et, t, l)
 	case *pipeline.JoinNode:
 		n, err = newJoinNode(et, t, l)
+	case *pipeline.LookupJoinNode:
+		n, err = newLookupJoinNode(et, t, l)
 	case *pipeline.EvalNode:
 		n, err = newEvalNode(et, t, l)
 	case *pipeline.WhereNode:
@@ -496,11 +552,14 @@ func (et *ExecutingTask) createNode(p pipeline.Node, l *log.Logger) (n Node, err
 
 type TaskSnapshot struct {
 	NodeSnapshots map[string][]byte
+	// Hash of the TICKscript the snapshot was taken from, empty for older snapshots.
+	TICKscriptHash string
 }
 
 func (et *ExecutingTask) Snapshot() (*TaskSnapshot, error) {
 	snapshot := &TaskSnapshot{
-		NodeSnapshots: make(map[string][]byte),
+		NodeSnapshots:  make(map[string][]byte),
+		TICKscriptHash: et.Task.TICKscriptHash,
 	}
 	err := et.walk(func(n Node) error {
 		data, err := n.snapshot()
@@ -1,9 +1,13 @@
 package kapacitor
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"log"
+	"regexp"
+	"sort"
 	"sync"
 	"time"
 
@@ -12,6 +16,7 @@ import (
 	"github.com/influxdata/kapacitor/models"
 	"github.com/influxdata/kapacitor/pipeline"
 	"github.com/influxdata/kapacitor/services/httpd"
+	"github.com/influxdata/kapacitor/services/storage"
 	"github.com/influxdata/kapacitor/tick"
 	"github.com/influxdata/kapacitor/timer"
 	"github.com/influxdata/kapacitor/udf"
@@ -37,10 +42,14 @@ type TaskMaster struct {
 		DelRoutes([]httpd.Route)
 		URL() string
 	}
+	SparklineService interface {
+		URL(values []float64) string
+	}
 	TaskStore interface {
 		SaveSnapshot(id string, snapshot *TaskSnapshot) error
 		HasSnapshot(id string) bool
 		LoadSnapshot(id string) (*TaskSnapshot, error)
+		LoadLibrary(id string) (string, error)
 	}
 	DeadmanService pipeline.DeadmanService
 
@@ -50,10 +59,18 @@ type TaskMaster struct {
 		NewDefaultClient() (client.Client, error)
 		NewNamedClient(name string) (client.Client, error)
 	}
+	DeliveryService interface {
+		QueueSize() int
+		Retries() int
+		InitialBackoff() time.Duration
+		MaxBackoff() time.Duration
+		Timeout() time.Duration
+		Store() storage.Interface
+	}
 	SMTPService interface {
 		Global() bool
 		StateChangesOnly() bool
-		SendMail(to []string, subject string, msg string) error
+		SendMail(to []string, subject string, msg string, images map[string][]byte) error
 	}
 	OpsGenieService interface {
 		Global() bool
@@ -67,10 +84,31 @@ type TaskMaster struct {
 		Global() bool
 		Alert(serviceKey, incidentKey, desc string, level AlertLevel, details interface{}) error
 	}
+	PagerDuty2Service interface {
+		Global() bool
+		Alert(routingKey, alertID, desc, source string, level AlertLevel, t time.Time, details map[string]interface{}) error
+	}
+	AlertmanagerService interface {
+		Global() bool
+		Alert(endpoint, source, alertID, alertName, message, details string, level AlertLevel, t time.Time, tags map[string]string) error
+		Clear(source string)
+		Endpoint(name string) (string, bool)
+	}
+	JiraService interface {
+		Alert(project, issueType, alertID, message, summary, description string, fields map[string]string, level, minLevel AlertLevel) error
+	}
+	ServiceNowService interface {
+		Alert(alertID, message, summary, description string, fields map[string]string, level, minLevel AlertLevel) error
+	}
+	SyslogService interface {
+		Global() bool
+		Alert(facility, alertID, task, message string, level AlertLevel, t time.Time, tags map[string]string) error
+	}
 	SlackService interface {
 		Global() bool
 		StateChangesOnly() bool
-		Alert(channel, message string, level AlertLevel) error
+		Alert(workspace, channel, alertID, message, title string, fields []SlackField, imageURL string, level AlertLevel, thread bool) error
+		ValidateWorkspace(workspace string, thread bool) error
 	}
 	HipChatService interface {
 		Global() bool
@@ -114,6 +152,16 @@ type TaskMaster struct {
 	// we have only the task id, and they are called after the task is deleted from TaskMaster.tasks
 	taskToForkKeys map[string][]forkKey
 
+	// Forks of incoming streams selecting measurements by regex, by task id.
+	patternForks map[string][]patternFork
+
+	// Cache of the edges of all forks a (db, rp, measurement) is sent to,
+	// so that points are routed without matching the pattern forks every time.
+	// The cache is reset whenever forks are added or deleted,
+	// or when it holds maxForkCacheSize keys so that it does not grow with the number of measurements.
+	forkCacheMu sync.Mutex
+	forkCache   map[forkKey][]*Edge
+
 	// Set of incoming batches
 	batches map[string][]BatchCollector
 
@@ -128,17 +176,29 @@ type TaskMaster struct {
 	wg      sync.WaitGroup
 }
 
+// Maximum number of keys in the fork cache.
+const maxForkCacheSize = 10000
+
 type forkKey struct {
 	Database        string
 	RetentionPolicy string
 	Measurement     string
 }
 
+type patternFork struct {
+	Database        string
+	RetentionPolicy string
+	Pattern         *regexp.Regexp
+	Edge            *Edge
+}
+
 // Create a new Executor with a given clock.
 func NewTaskMaster(l LogService) *TaskMaster {
 	return &TaskMaster{
 		forks:          make(map[forkKey]map[string]*Edge),
 		taskToForkKeys: make(map[string][]forkKey),
+		patternForks:   make(map[string][]patternFork),
+		forkCache:      make(map[forkKey][]*Edge),
 		batches:        make(map[string][]BatchCollector),
 		tasks:          make(map[string]*ExecutingTask),
 		LogService:     l,
@@ -152,14 +212,21 @@ func NewTaskMaster(l LogService) *TaskMaster {
 func (tm *TaskMaster) New() *TaskMaster {
 	n := NewTaskMaster(tm.LogService)
 	n.HTTPDService = tm.HTTPDService
+	n.SparklineService = tm.SparklineService
 	n.UDFService = tm.UDFService
 	n.DeadmanService = tm.DeadmanService
 	n.TaskStore = tm.TaskStore
 	n.InfluxDBService = tm.InfluxDBService
+	n.DeliveryService = tm.DeliveryService
 	n.SMTPService = tm.SMTPService
 	n.OpsGenieService = tm.OpsGenieService
 	n.VictorOpsService = tm.VictorOpsService
 	n.PagerDutyService = tm.PagerDutyService
+	n.PagerDuty2Service = tm.PagerDuty2Service
+	n.AlertmanagerService = tm.AlertmanagerService
+	n.JiraService = tm.JiraService
+	n.ServiceNowService = tm.ServiceNowService
+	n.SyslogService = tm.SyslogService
 	n.SlackService = tm.SlackService
 	n.HipChatService = tm.HipChatService
 	n.AlertaService = tm.AlertaService
@@ -218,6 +285,15 @@ func (tm *TaskMaster) Drain() {
 	for id, _ := range tm.taskToForkKeys {
 		tm.delFork(id)
 	}
+	for id := range tm.patternForks {
+		tm.delFork(id)
+	}
+}
+
+// Hash of a TICKscript identifying the TICKscript a snapshot was taken from.
+func hashTICKscript(script string) string {
+	sum := sha256.Sum256([]byte(script))
+	return hex.EncodeToString(sum[:])
 }
 
 // Create a new task in the context of a TaskMaster
@@ -227,14 +303,32 @@ func (tm *TaskMaster) NewTask(
 	tt TaskType,
 	dbrps []DBRP,
 	snapshotInterval time.Duration,
+) (*Task, error) {
+	return tm.NewTaskWithImporter(id, script, tt, dbrps, snapshotInterval, nil)
+}
+
+// Create a new task resolving imports with the importer instead of the stored libraries,
+// e.g. to evaluate a task against a library before the library is updated.
+// The stored libraries are used if the importer is nil.
+func (tm *TaskMaster) NewTaskWithImporter(
+	id,
+	script string,
+	tt TaskType,
+	dbrps []DBRP,
+	snapshotInterval time.Duration,
+	importer tick.ImportFunc,
 ) (*Task, error) {
 	t := &Task{
 		ID:               id,
 		Type:             tt,
 		DBRPs:            dbrps,
 		SnapshotInterval: snapshotInterval,
+		TICKscriptHash:   hashTICKscript(script),
 	}
 	scope := tm.CreateTICKScope()
+	if importer != nil {
+		scope.SetImporter(importer)
+	}
 
 	var srcEdge pipeline.EdgeType
 	switch tt {
@@ -252,6 +346,38 @@ func (tm *TaskMaster) NewTask(
 	return t, nil
 }
 
+// Check a task being defined, i.e. statically type check its pipeline
+// and check it against the configured services,
+// i.e. that the Slack workspaces and Alertmanager endpoints it sends alerts to exist.
+// Stored tasks are not checked when they are loaded,
+// so that loading stays fast and a change of configuration does not prevent them from starting.
+func (tm *TaskMaster) CheckTask(t *Task) error {
+	if diagnostics := t.Pipeline.Check(); len(diagnostics) > 0 {
+		return diagnostics
+	}
+	return t.Pipeline.Walk(func(n pipeline.Node) error {
+		an, ok := n.(*pipeline.AlertNode)
+		if !ok {
+			return nil
+		}
+		if tm.SlackService != nil {
+			for _, s := range an.SlackHandlers {
+				if err := tm.SlackService.ValidateWorkspace(s.Workspace, s.IsThreaded); err != nil {
+					return fmt.Errorf("%s: %v", an.Name(), err)
+				}
+			}
+		}
+		if tm.AlertmanagerService != nil {
+			for _, am := range an.AlertmanagerHandlers {
+				if _, ok := tm.AlertmanagerService.Endpoint(am.Endpoint); !ok {
+					return fmt.Errorf("%s: unknown Alertmanager endpoint %q", an.Name(), am.Endpoint)
+				}
+			}
+		}
+		return nil
+	})
+}
+
 func (tm *TaskMaster) waitForForks() {
 	if tm.drained {
 		return
@@ -264,6 +390,10 @@ func (tm *TaskMaster) waitForForks() {
 func (tm *TaskMaster) CreateTICKScope() *tick.Scope {
 	scope := tick.NewScope()
 	scope.Set("time", func(d time.Duration) time.Duration { return d })
+	// Resolve imports from the stored libraries
+	if tm.TaskStore != nil {
+		scope.SetImporter(tm.TaskStore.LoadLibrary)
+	}
 	// Add dynamic methods to the scope for UDFs
 	if tm.UDFService != nil {
 		for _, f := range tm.UDFService.List() {
@@ -306,7 +436,7 @@ func (tm *TaskMaster) StartTask(t *Task) (*ExecutingTask, error) {
 	var ins []*Edge
 	switch et.Task.Type {
 	case StreamTask:
-		e, err := tm.newFork(et.Task.ID, et.Task.DBRPs, et.Task.Measurements())
+		e, err := tm.newFork(et.Task.ID, et.Task.DBRPs, et.Task.Measurements(), et.Task.MeasurementPatterns())
 		if err != nil {
 			return nil, err
 		}
@@ -390,6 +520,33 @@ func (tm *TaskMaster) ExecutionStats(id string) (ExecutionStats, error) {
 	return task.ExecutionStats()
 }
 
+// Return the states of all alerts that are not OK across all executing tasks,
+// ordered by task, node and alert ID.
+func (tm *TaskMaster) AlertStates() []AlertState {
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
+	var states []AlertState
+	for _, et := range tm.tasks {
+		states = append(states, et.AlertStates()...)
+	}
+	sort.Sort(alertStatesByID(states))
+	return states
+}
+
+type alertStatesByID []AlertState
+
+func (s alertStatesByID) Len() int      { return len(s) }
+func (s alertStatesByID) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s alertStatesByID) Less(i, j int) bool {
+	if s[i].Task != s[j].Task {
+		return s[i].Task < s[j].Task
+	}
+	if s[i].Node != s[j].Node {
+		return s[i].Node < s[j].Node
+	}
+	return s[i].ID < s[j].ID
+}
+
 func (tm *TaskMaster) ExecutingDot(id string, labels bool) string {
 	tm.mu.RLock()
 	defer tm.mu.RUnlock()
@@ -435,22 +592,62 @@ func (tm *TaskMaster) forkPoint(p models.Point) {
 		Measurement:     p.Name,
 	}
 
+	for _, edge := range tm.forkEdges(key) {
+		edge.CollectPoint(p)
+	}
+}
+
+// Return the edges of the forks of the key, each edge at most once.
+// Must have acquired the read lock before calling.
+func (tm *TaskMaster) forkEdges(key forkKey) []*Edge {
+	tm.forkCacheMu.Lock()
+	defer tm.forkCacheMu.Unlock()
+	if edges, ok := tm.forkCache[key]; ok {
+		return edges
+	}
+
 	// If we have empty measurement in this db,rp we need to send it all
 	// the points
 	emptyMeasurementKey := forkKey{
-		Database:        p.Database,
-		RetentionPolicy: p.RetentionPolicy,
+		Database:        key.Database,
+		RetentionPolicy: key.RetentionPolicy,
 		Measurement:     "",
 	}
 
-	// Merge the results to the forks map
-	for _, edge := range tm.forks[key] {
-		edge.CollectPoint(p)
+	var edges []*Edge
+	added := make(map[string]bool)
+	add := func(task string, edge *Edge) {
+		if !added[task] {
+			added[task] = true
+			edges = append(edges, edge)
+		}
 	}
-
-	for _, edge := range tm.forks[emptyMeasurementKey] {
-		edge.CollectPoint(p)
+	for task, edge := range tm.forks[key] {
+		add(task, edge)
+	}
+	for task, edge := range tm.forks[emptyMeasurementKey] {
+		add(task, edge)
+	}
+	for task, forks := range tm.patternForks {
+		for _, f := range forks {
+			if f.Database == key.Database && f.RetentionPolicy == key.RetentionPolicy && f.Pattern.MatchString(key.Measurement) {
+				add(task, f.Edge)
+				break
+			}
+		}
+	}
+	if len(tm.forkCache) >= maxForkCacheSize {
+		tm.forkCache = make(map[forkKey][]*Edge)
 	}
+	tm.forkCache[key] = edges
+	return edges
+}
+
+// Reset the cache of fork edges, must have acquired the write lock before calling.
+func (tm *TaskMaster) resetForkCache() {
+	tm.forkCacheMu.Lock()
+	defer tm.forkCacheMu.Unlock()
+	tm.forkCache = make(map[forkKey][]*Edge)
 }
 
 func (tm *TaskMaster) WritePoints(database, retentionPolicy string, consistencyLevel imodels.ConsistencyLevel, points []imodels.Point) error {
@@ -475,10 +672,10 @@ func (tm *TaskMaster) WritePoints(database, retentionPolicy string, consistencyL
 	return nil
 }
 
-func (tm *TaskMaster) NewFork(taskName string, dbrps []DBRP, measurements []string) (*Edge, error) {
+func (tm *TaskMaster) NewFork(taskName string, dbrps []DBRP, measurements []string, patterns []*regexp.Regexp) (*Edge, error) {
 	tm.mu.Lock()
 	defer tm.mu.Unlock()
-	return tm.newFork(taskName, dbrps, measurements)
+	return tm.newFork(taskName, dbrps, measurements, patterns)
 }
 
 func forkKeys(dbrps []DBRP, measurements []string) []forkKey {
@@ -500,10 +697,11 @@ func forkKeys(dbrps []DBRP, measurements []string) []forkKey {
 }
 
 // internal newFork, must have acquired lock before calling.
-func (tm *TaskMaster) newFork(taskName string, dbrps []DBRP, measurements []string) (*Edge, error) {
+func (tm *TaskMaster) newFork(taskName string, dbrps []DBRP, measurements []string, patterns []*regexp.Regexp) (*Edge, error) {
 	if tm.closed {
 		return nil, ErrTaskMasterClosed
 	}
+	defer tm.resetForkCache()
 
 	e := newEdge(taskName, "stream", "stream0", pipeline.StreamEdge, defaultEdgeBufferSize, tm.LogService)
 
@@ -523,6 +721,17 @@ func (tm *TaskMaster) newFork(taskName string, dbrps []DBRP, measurements []stri
 		tm.forks[key] = tasksMap
 	}
 
+	for _, dbrp := range dbrps {
+		for _, pattern := range patterns {
+			tm.patternForks[taskName] = append(tm.patternForks[taskName], patternFork{
+				Database:        dbrp.Database,
+				RetentionPolicy: dbrp.RetentionPolicy,
+				Pattern:         pattern,
+				Edge:            e,
+			})
+		}
+	}
+
 	return e, nil
 }
 
@@ -534,6 +743,7 @@ func (tm *TaskMaster) DelFork(id string) {
 
 // internal delFork function, must have lock to call
 func (tm *TaskMaster) delFork(id string) {
+	defer tm.resetForkCache()
 
 	// mark if we already closed the edge because the edge is replicated
 	// by it's fork keys (db,rp,measurement)
@@ -559,6 +769,14 @@ func (tm *TaskMaster) delFork(id string) {
 
 	// remove mapping from task id to it's keys
 	delete(tm.taskToForkKeys, id)
+
+	// Close the edge of a task selecting measurements only by pattern
+	if forks, ok := tm.patternForks[id]; ok {
+		if !isEdgeClosed && len(forks) > 0 {
+			forks[0].Edge.Close()
+		}
+		delete(tm.patternForks, id)
+	}
 }
 
 func (tm *TaskMaster) SnapshotTask(id string) (*TaskSnapshot, error) {
@@ -0,0 +1,133 @@
+package kapacitor
+
+import (
+	"errors"
+	"io/ioutil"
+	"log"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick"
+)
+
+type testLogService struct{}
+
+func (testLogService) NewLogger(prefix string, flag int) *log.Logger {
+	return log.New(ioutil.Discard, prefix, flag)
+}
+
+type testDeadman struct{}
+
+func (testDeadman) Interval() time.Duration { return 0 }
+func (testDeadman) Threshold() float64      { return 0 }
+func (testDeadman) Id() string              { return "" }
+func (testDeadman) Message() string         { return "" }
+func (testDeadman) Global() bool            { return false }
+
+type testSnapshotStore struct {
+	snapshot *TaskSnapshot
+}
+
+func (s *testSnapshotStore) SaveSnapshot(id string, snapshot *TaskSnapshot) error {
+	s.snapshot = snapshot
+	return nil
+}
+func (s *testSnapshotStore) HasSnapshot(id string) bool { return s.snapshot != nil }
+func (s *testSnapshotStore) LoadSnapshot(id string) (*TaskSnapshot, error) {
+	return s.snapshot, nil
+}
+func (s *testSnapshotStore) LoadLibrary(id string) (string, error) {
+	return "", errors.New("not implemented")
+}
+
+func TestTaskMaster_SnapshotChangedTICKscript(t *testing.T) {
+	store := &testSnapshotStore{}
+	tm := NewTaskMaster(testLogService{})
+	tm.TaskStore = store
+	tm.DeadmanService = testDeadman{}
+	if err := tm.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer tm.Close()
+
+	// The state of the where node.
+	expression := &tick.BinaryNode{
+		Operator: tick.TokenGreater,
+		Left:     &tick.ReferenceNode{Reference: "value"},
+		Right: &tick.FunctionNode{
+			Func: "mean",
+			Args: []tick.Node{&tick.ReferenceNode{Reference: "value"}},
+		},
+	}
+	w := newTestWhereNode(expression)
+	if _, _, err := w.groupExpression(""); err != nil {
+		t.Fatal(err)
+	}
+	whereState, err := w.snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const script = `stream|from().measurement('cpu')|where(lambda: "value" > mean("value"))`
+	dbrps := []DBRP{{Database: "db", RetentionPolicy: "rp"}}
+	// Run the task and return the state of its where node.
+	restoredState := func(script string) []byte {
+		task, err := tm.NewTask("test", script, StreamTask, dbrps, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		et, err := tm.StartTask(task)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The nodes restore their snapshot when they start running,
+		// stopping the task waits for them.
+		if err := tm.StopTask("test"); err != nil {
+			t.Fatal(err)
+		}
+		snapshot, err := et.Snapshot()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for name, data := range snapshot.NodeSnapshots {
+			if strings.HasPrefix(name, "where") {
+				return data
+			}
+		}
+		t.Fatal("no where node in the task snapshot")
+		return nil
+	}
+
+	if state := restoredState(script); state != nil {
+		t.Fatalf("unexpected state of a task without a snapshot: %s", state)
+	}
+
+	// Save a snapshot of the task with the state of the where node.
+	task, err := tm.NewTask("test", script, StreamTask, dbrps, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.snapshot = &TaskSnapshot{
+		NodeSnapshots:  make(map[string][]byte),
+		TICKscriptHash: task.TICKscriptHash,
+	}
+	task.Pipeline.Walk(func(n pipeline.Node) error {
+		store.snapshot.NodeSnapshots[n.Name()] = nil
+		if _, ok := n.(*pipeline.WhereNode); ok {
+			store.snapshot.NodeSnapshots[n.Name()] = whereState
+		}
+		return nil
+	})
+
+	if got := restoredState(script); string(got) != string(whereState) {
+		t.Errorf("snapshot of the same TICKscript not restored: got %s exp %s", got, whereState)
+	}
+	// Same pipeline but a different TICKscript, e.g. a snapshot saved by the task
+	// still running the previous TICKscript after the task was updated.
+	changed := script + "\n// changed\n"
+	if got := restoredState(changed); got != nil {
+		t.Errorf("snapshot of a different TICKscript restored: %s", got)
+	}
+}
@@ -0,0 +1,256 @@
+/*
+The templates package provides the function library available to all alert templates.
+
+Besides the builtin functions of text/template, i.e. printf and urlquery, the following functions are available:
+
+	humanize         Format a number with an SI prefix, i.e. 1234567 -> 1.235M.
+	humanizeBytes    Format a number of bytes with a binary prefix, i.e. 1536 -> 1.5KiB.
+	humanizeDuration Format a duration or a number of seconds, i.e. 3725 -> 1h 2m 5s.
+	toUpper          Convert a string to upper case.
+	toLower          Convert a string to lower case.
+	join             Join a list of values with a separator, i.e. join .List ", ".
+	default          Return the default if the value is empty, i.e. .Value | default "n/a".
+	round            Round a number to a number of decimal places, i.e. .Value | round 2.
+	timeFormat       Format a time in a timezone, i.e. .Time | timeFormat "15:04" "Europe/Paris".
+	tag              Lookup a tag with an optional fallback, i.e. tag .Tags "host" "unknown".
+	field            Lookup a field with an optional fallback, i.e. field .Fields "value" 0.
+*/
+package templates
+
+import (
+	"encoding/json"
+	"fmt"
+	html "html/template"
+	"math"
+	"reflect"
+	"strings"
+	text "text/template"
+	"time"
+)
+
+// Return the function map of alert templates.
+func Funcs() map[string]interface{} {
+	return map[string]interface{}{
+		"humanize":         humanize,
+		"humanizeBytes":    humanizeBytes,
+		"humanizeDuration": humanizeDuration,
+		"toUpper":          strings.ToUpper,
+		"toLower":          strings.ToLower,
+		"join":             join,
+		"default":          defaultValue,
+		"round":            round,
+		"timeFormat":       timeFormat,
+		"tag":              tag,
+		"field":            field,
+	}
+}
+
+// Parse a text template with the alert template functions.
+func ParseText(name, tmpl string) (*text.Template, error) {
+	return text.New(name).Funcs(text.FuncMap(Funcs())).Parse(tmpl)
+}
+
+// Parse an HTML template with the alert template functions.
+// HTML templates can additionally use the json function
+// to render a value as JSON.
+func ParseHTML(name, tmpl string) (*html.Template, error) {
+	funcs := html.FuncMap(Funcs())
+	funcs["json"] = func(v interface{}) html.JS {
+		a, _ := json.Marshal(v)
+		return html.JS(a)
+	}
+	return html.New(name).Funcs(funcs).Parse(tmpl)
+}
+
+// Convert any numeric value to a float64.
+func toFloat(v interface{}) (float64, error) {
+	switch n := v.(type) {
+	case float64:
+		return n, nil
+	case int64:
+		return float64(n), nil
+	case time.Duration:
+		return n.Seconds(), nil
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), nil
+	}
+	return 0, fmt.Errorf("cannot convert %v of type %T to a number", v, v)
+}
+
+var (
+	largeSIPrefixes = []string{"", "k", "M", "G", "T", "P", "E", "Z", "Y"}
+	smallSIPrefixes = []string{"", "m", "u", "n", "p", "f", "a", "z", "y"}
+	binaryPrefixes  = []string{"", "Ki", "Mi", "Gi", "Ti", "Pi", "Ei", "Zi", "Yi"}
+)
+
+func humanize(v interface{}) (string, error) {
+	f, err := toFloat(v)
+	if err != nil {
+		return "", err
+	}
+	if f == 0 || math.IsNaN(f) || math.IsInf(f, 0) {
+		return fmt.Sprintf("%.4g", f), nil
+	}
+	i := 0
+	if math.Abs(f) >= 1 {
+		for math.Abs(f) >= 1000 && i < len(largeSIPrefixes)-1 {
+			f /= 1000
+			i++
+		}
+		return fmt.Sprintf("%.4g%s", f, largeSIPrefixes[i]), nil
+	}
+	for math.Abs(f) < 1 && i < len(smallSIPrefixes)-1 {
+		f *= 1000
+		i++
+	}
+	return fmt.Sprintf("%.4g%s", f, smallSIPrefixes[i]), nil
+}
+
+func humanizeBytes(v interface{}) (string, error) {
+	f, err := toFloat(v)
+	if err != nil {
+		return "", err
+	}
+	i := 0
+	for math.Abs(f) >= 1024 && i < len(binaryPrefixes)-1 {
+		f /= 1024
+		i++
+	}
+	return fmt.Sprintf("%.4g%sB", f, binaryPrefixes[i]), nil
+}
+
+// Format a duration, numbers are interpreted as seconds.
+func humanizeDuration(v interface{}) (string, error) {
+	var d time.Duration
+	if dur, ok := v.(time.Duration); ok {
+		d = dur
+	} else {
+		f, err := toFloat(v)
+		if err != nil {
+			return "", err
+		}
+		d = time.Duration(f * float64(time.Second))
+	}
+	sign := ""
+	if d < 0 {
+		sign = "-"
+		d = -d
+	}
+	if d < time.Second {
+		if d < time.Millisecond {
+			return sign + d.String(), nil
+		}
+		return fmt.Sprintf("%s%.4gms", sign, float64(d)/float64(time.Millisecond)), nil
+	}
+	units := []struct {
+		unit time.Duration
+		name string
+	}{
+		{24 * time.Hour, "d"},
+		{time.Hour, "h"},
+		{time.Minute, "m"},
+		{time.Second, "s"},
+	}
+	parts := make([]string, 0, len(units))
+	for _, u := range units {
+		if n := d / u.unit; n > 0 || (u.unit == time.Second && len(parts) == 0) {
+			parts = append(parts, fmt.Sprintf("%d%s", n, u.name))
+			d -= n * u.unit
+		}
+	}
+	return sign + strings.Join(parts, " "), nil
+}
+
+func join(list interface{}, sep string) (string, error) {
+	switch l := list.(type) {
+	case []string:
+		return strings.Join(l, sep), nil
+	case nil:
+		return "", nil
+	}
+	rv := reflect.ValueOf(list)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return "", fmt.Errorf("cannot join value of type %T", list)
+	}
+	strs := make([]string, rv.Len())
+	for i := range strs {
+		strs[i] = fmt.Sprint(rv.Index(i).Interface())
+	}
+	return strings.Join(strs, sep), nil
+}
+
+// Return def if v is nil or the zero value of its type.
+func defaultValue(def, v interface{}) interface{} {
+	if v == nil {
+		return def
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Map, reflect.Array, reflect.String:
+		if rv.Len() == 0 {
+			return def
+		}
+	case reflect.Ptr, reflect.Interface:
+		if rv.IsNil() {
+			return def
+		}
+	default:
+		if reflect.DeepEqual(v, reflect.Zero(rv.Type()).Interface()) {
+			return def
+		}
+	}
+	return v
+}
+
+func round(places int, v interface{}) (float64, error) {
+	f, err := toFloat(v)
+	if err != nil {
+		return 0, err
+	}
+	p := math.Pow(10, float64(places))
+	r := f * p
+	if r < 0 {
+		r = math.Ceil(r - 0.5)
+	} else {
+		r = math.Floor(r + 0.5)
+	}
+	return r / p, nil
+}
+
+// Format a time in a timezone, an empty timezone means UTC.
+func timeFormat(layout, tz string, t time.Time) (string, error) {
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return "", err
+	}
+	return t.In(loc).Format(layout), nil
+}
+
+// Lookup a tag, returning the first fallback or an empty string if it does not exist.
+func tag(tags map[string]string, key string, fallback ...string) string {
+	if v, ok := tags[key]; ok {
+		return v
+	}
+	if len(fallback) > 0 {
+		return fallback[0]
+	}
+	return ""
+}
+
+// Lookup a field, returning the first fallback or nil if it does not exist.
+func field(fields map[string]interface{}, key string, fallback ...interface{}) interface{} {
+	if v, ok := fields[key]; ok {
+		return v
+	}
+	if len(fallback) > 0 {
+		return fallback[0]
+	}
+	return nil
+}
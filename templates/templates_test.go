@@ -0,0 +1,78 @@
+package templates
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestParseText(t *testing.T) {
+	data := map[string]interface{}{
+		"Tags":   map[string]string{"host": "serverA"},
+		"Fields": map[string]interface{}{"value": 93.1234567, "bytes": int64(1536), "elapsed": int64(3725)},
+		"List":   []string{"a", "b", "c"},
+		"Empty":  "",
+		"Time":   time.Date(2016, 6, 1, 12, 30, 0, 0, time.UTC),
+	}
+	testCases := []struct {
+		tmpl string
+		exp  string
+	}{
+		{tmpl: `{{ printf "%.1f" (field .Fields "value") }}`, exp: "93.1"},
+		{tmpl: `{{ field .Fields "value" | round 2 }}`, exp: "93.12"},
+		{tmpl: `{{ field .Fields "missing" 0 | round 2 }}`, exp: "0"},
+		{tmpl: `{{ humanize 1234567 }}`, exp: "1.235M"},
+		{tmpl: `{{ humanize 0.0012 }}`, exp: "1.2m"},
+		{tmpl: `{{ field .Fields "bytes" | humanizeBytes }}`, exp: "1.5KiB"},
+		{tmpl: `{{ field .Fields "elapsed" | humanizeDuration }}`, exp: "1h 2m 5s"},
+		{tmpl: `{{ humanizeDuration 90061 }}`, exp: "1d 1h 1m 1s"},
+		{tmpl: `{{ humanizeDuration 0.25 }}`, exp: "250ms"},
+		{tmpl: `{{ tag .Tags "host" | toUpper }}`, exp: "SERVERA"},
+		{tmpl: `{{ tag .Tags "dc" "unknown" }}`, exp: "unknown"},
+		{tmpl: `{{ "ABC" | toLower }}`, exp: "abc"},
+		{tmpl: `{{ join .List ", " }}`, exp: "a, b, c"},
+		{tmpl: `{{ .Empty | default "n/a" }}`, exp: "n/a"},
+		{tmpl: `{{ tag .Tags "host" | default "n/a" }}`, exp: "serverA"},
+		{tmpl: `{{ .Time | timeFormat "2006-01-02 15:04 MST" "America/New_York" }}`, exp: "2016-06-01 08:30 EDT"},
+		{tmpl: `{{ .Time | timeFormat "15:04" "" }}`, exp: "12:30"},
+		{tmpl: `{{ urlquery "a b&c" }}`, exp: "a+b%26c"},
+	}
+	for _, tc := range testCases {
+		tmpl, err := ParseText("test", tc.tmpl)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.tmpl, err)
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, data); err != nil {
+			t.Errorf("%s: %v", tc.tmpl, err)
+			continue
+		}
+		if got := buf.String(); got != tc.exp {
+			t.Errorf("%s: got %q exp %q", tc.tmpl, got, tc.exp)
+		}
+	}
+}
+
+func TestParseHTML(t *testing.T) {
+	tmpl, err := ParseHTML("test", `<b>{{ .Value | round 1 }}</b> {{ json .Tags }}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	data := map[string]interface{}{
+		"Value": 1.25,
+		"Tags":  map[string]string{"host": "a"},
+	}
+	if err := tmpl.Execute(&buf, data); err != nil {
+		t.Fatal(err)
+	}
+	if exp, got := `<b>1.3</b> {&#34;host&#34;:&#34;a&#34;}`, buf.String(); got != exp {
+		t.Errorf("got %q exp %q", got, exp)
+	}
+}
+
+func TestParseText_UnknownFunction(t *testing.T) {
+	if _, err := ParseText("test", `{{ .Value | humanise }}`); err == nil {
+		t.Error("expected error for unknown function")
+	}
+}
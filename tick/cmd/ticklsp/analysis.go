@@ -0,0 +1,347 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick"
+)
+
+// An open TICKscript.
+type document struct {
+	uri  string
+	text string
+	// The AST of the last version of the text that parsed,
+	// used to resolve vars while the text is being edited.
+	root tick.Node
+}
+
+// Deadman service for evaluating TICKscripts, global deadman alerts are never added.
+type deadman struct{}
+
+func (deadman) Interval() time.Duration { return 0 }
+func (deadman) Threshold() float64      { return 0 }
+func (deadman) Id() string              { return "" }
+func (deadman) Message() string         { return "" }
+func (deadman) Global() bool            { return false }
+
+// Matches the position in errors from the parser and evaluator.
+var errorPosition = regexp.MustCompile(`line (\d+) char ?(\d+)`)
+
+// Parse and evaluate the document and return the problems found.
+func (d *document) check() []diagnostic {
+	root, err := tick.Parse(d.text)
+	if err != nil {
+		return []diagnostic{d.errorDiagnostic(err)}
+	}
+	d.root = root
+
+	// The UDFs defined on the server are not known,
+	// so any script using them cannot be evaluated.
+	if usesDynamicMethods(root) {
+		return nil
+	}
+
+	edge := pipeline.StreamEdge
+	if isReferenced(root, "batch") {
+		edge = pipeline.BatchEdge
+	}
+	scope := tick.NewScope()
+	scope.Set("time", func(d time.Duration) time.Duration { return d })
+	scope.SetImporter(d.importLibrary)
+	p, err := pipeline.CreatePipeline(d.text, edge, scope, deadman{})
+	if err == nil {
+		if diagnostics := p.Check(); len(diagnostics) > 0 {
+			err = diagnostics
+		}
+	}
+	if err == nil {
+		return nil
+	}
+	if tickDiagnostics, ok := err.(tick.Diagnostics); ok {
+		diagnostics := make([]diagnostic, len(tickDiagnostics))
+		for i, td := range tickDiagnostics {
+			diagnostics[i] = diagnostic{
+				Range:    d.tokenRange(d.offsetOfLineChar(td.Line, td.Char)),
+				Severity: severityError,
+				Source:   "ticklsp",
+				Message:  td.Message,
+			}
+		}
+		return diagnostics
+	}
+	return []diagnostic{d.errorDiagnostic(err)}
+}
+
+// Libraries are imported from the .tick files in the directory of the document.
+func (d *document) importLibrary(id string) (string, error) {
+	u, err := url.Parse(d.uri)
+	if err != nil || u.Scheme != "file" {
+		return "", fmt.Errorf("cannot import %q into a document that is not a file", id)
+	}
+	data, err := ioutil.ReadFile(filepath.Join(filepath.Dir(u.Path), id+".tick"))
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func (d *document) errorDiagnostic(err error) diagnostic {
+	msg := err.Error()
+	offset := 0
+	if m := errorPosition.FindStringSubmatchIndex(msg); m != nil {
+		line, _ := strconv.Atoi(msg[m[2]:m[3]])
+		char, _ := strconv.Atoi(msg[m[4]:m[5]])
+		offset = d.offsetOfLineChar(line, char)
+		if m[0] == 0 {
+			// Remove the position prefix, it is part of the diagnostic.
+			msg = strings.TrimPrefix(msg[m[1]:], ": ")
+		}
+	}
+	return diagnostic{
+		Range:    d.tokenRange(offset),
+		Severity: severityError,
+		Source:   "ticklsp",
+		Message:  msg,
+	}
+}
+
+// The range of the identifier at the offset, or of a single character.
+func (d *document) tokenRange(offset int) lspRange {
+	end := identEnd(d.text, offset)
+	if end == offset && end < len(d.text) && d.text[end] != '\n' {
+		end++
+	}
+	return lspRange{Start: d.position(offset), End: d.position(end)}
+}
+
+// The byte offset of the 1-based line and byte char used by tick positions.
+func (d *document) offsetOfLineChar(line, char int) int {
+	offset := 0
+	for l := 1; l < line; l++ {
+		i := strings.IndexByte(d.text[offset:], '\n')
+		if i == -1 {
+			return len(d.text)
+		}
+		offset += i + 1
+	}
+	if char > 0 {
+		offset += char - 1
+	}
+	if offset > len(d.text) {
+		offset = len(d.text)
+	}
+	return offset
+}
+
+// Convert the byte offset to a position, whose characters are counted in UTF-16 code units.
+func (d *document) position(offset int) position {
+	if offset > len(d.text) {
+		offset = len(d.text)
+	}
+	lineStart := strings.LastIndexByte(d.text[:offset], '\n') + 1
+	p := position{Line: strings.Count(d.text[:lineStart], "\n")}
+	for _, r := range d.text[lineStart:offset] {
+		p.Character++
+		if r >= 0x10000 {
+			p.Character++
+		}
+	}
+	return p
+}
+
+// Convert the position to a byte offset.
+func (d *document) offset(p position) int {
+	offset := 0
+	for l := 0; l < p.Line; l++ {
+		i := strings.IndexByte(d.text[offset:], '\n')
+		if i == -1 {
+			return len(d.text)
+		}
+		offset += i + 1
+	}
+	for c := 0; c < p.Character && offset < len(d.text) && d.text[offset] != '\n'; {
+		r, n := utf8.DecodeRuneInString(d.text[offset:])
+		offset += n
+		c++
+		if r >= 0x10000 {
+			c++
+		}
+	}
+	return offset
+}
+
+// The full range of the text.
+func (d *document) fullRange() lspRange {
+	return lspRange{Start: position{}, End: d.position(len(d.text))}
+}
+
+// Call f for each node of the AST.
+func walk(n tick.Node, f func(tick.Node)) {
+	if n == nil || reflect.ValueOf(n).IsNil() {
+		return
+	}
+	f(n)
+	switch node := n.(type) {
+	case *tick.ListNode:
+		for _, c := range node.Nodes {
+			walk(c, f)
+		}
+	case *tick.DeclarationNode:
+		walk(node.Left, f)
+		walk(node.Right, f)
+	case *tick.FunctionDefNode:
+		walk(node.Body, f)
+	case *tick.ChainNode:
+		walk(node.Left, f)
+		walk(node.Right, f)
+	case *tick.FunctionNode:
+		for _, arg := range node.Args {
+			walk(arg, f)
+		}
+	case *tick.LambdaNode:
+		walk(node.Node, f)
+	case *tick.BinaryNode:
+		walk(node.Left, f)
+		walk(node.Right, f)
+	case *tick.UnaryNode:
+		walk(node.Node, f)
+	case *tick.ListLiteralNode:
+		for _, e := range node.Elements {
+			walk(e, f)
+		}
+	}
+}
+
+func usesDynamicMethods(root tick.Node) (found bool) {
+	walk(root, func(n tick.Node) {
+		if c, ok := n.(*tick.ChainNode); ok && c.Operator == tick.TokenAt {
+			found = true
+		}
+	})
+	return
+}
+
+func isReferenced(root tick.Node, ident string) (found bool) {
+	walk(root, func(n tick.Node) {
+		if i, ok := n.(*tick.IdentifierNode); ok && i.Ident == ident {
+			found = true
+		}
+	})
+	return
+}
+
+// The top level declaration of the var or function.
+func declaration(root tick.Node, name string) tick.Node {
+	list, ok := root.(*tick.ListNode)
+	if !ok {
+		return nil
+	}
+	for _, n := range list.Nodes {
+		switch decl := n.(type) {
+		case *tick.DeclarationNode:
+			if decl.Left.Ident == name {
+				return decl
+			}
+		case *tick.FunctionDefNode:
+			if decl.Name == name {
+				return decl
+			}
+		}
+	}
+	return nil
+}
+
+// The maximum depth of vars referencing other vars that is resolved.
+const maxResolveDepth = 32
+
+// The pipeline type of the identifier, either a source or a var.
+func (s *server) identType(root tick.Node, ident string, depth int) reflect.Type {
+	switch ident {
+	case "stream":
+		return streamNode
+	case "batch":
+		return batchNode
+	}
+	if depth > maxResolveDepth {
+		return nil
+	}
+	if decl, ok := declaration(root, ident).(*tick.DeclarationNode); ok {
+		return s.exprType(root, decl.Right, depth+1)
+	}
+	return nil
+}
+
+// Matches the start of a var declaration in the blanked text.
+var varDeclaration = regexp.MustCompile(`\bvar\s+(\w+)\s*=`)
+
+// The pipeline type of the var declared in the blanked text,
+// used when the var is not in the AST because the text has never parsed.
+func (s *server) varType(blanked string, ident string, depth int) reflect.Type {
+	if depth > maxResolveDepth {
+		return nil
+	}
+	for _, m := range varDeclaration.FindAllStringSubmatchIndex(blanked, -1) {
+		if blanked[m[2]:m[3]] != ident {
+			continue
+		}
+		head, segments, ok := chainBefore(blanked, chainEnd(blanked, m[1]))
+		if !ok {
+			return nil
+		}
+		var t reflect.Type
+		switch head {
+		case "stream":
+			t = streamNode
+		case "batch":
+			t = batchNode
+		default:
+			t = s.varType(blanked, head, depth+1)
+		}
+		for _, seg := range segments {
+			t = s.nodeTypes.result(t, seg.op, seg.name)
+		}
+		return t
+	}
+	return nil
+}
+
+// The pipeline type of the expression.
+func (s *server) exprType(root tick.Node, n tick.Node, depth int) reflect.Type {
+	switch node := n.(type) {
+	case *tick.IdentifierNode:
+		return s.identType(root, node.Ident, depth)
+	case *tick.ChainNode:
+		f, ok := node.Right.(*tick.FunctionNode)
+		if !ok {
+			return nil
+		}
+		return s.nodeTypes.result(s.exprType(root, node.Left, depth), node.Operator, f.Func)
+	}
+	return nil
+}
+
+// The pipeline type of the chain expression ending at the offset.
+func (s *server) chainType(d *document, blanked string, offset int) reflect.Type {
+	head, segments, ok := chainBefore(blanked, offset)
+	if !ok {
+		return nil
+	}
+	t := s.identType(d.root, head, 0)
+	if t == nil && declaration(d.root, head) == nil {
+		t = s.varType(blanked, head, 0)
+	}
+	for _, seg := range segments {
+		t = s.nodeTypes.result(t, seg.op, seg.name)
+	}
+	return t
+}
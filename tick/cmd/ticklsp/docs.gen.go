@@ -0,0 +1,688 @@
+// Generated by gendocs.go from the pipeline package, DO NOT EDIT.
+
+package main
+
+var pipelineDocs = &docIndex{
+	types: map[string]string{
+		"AlertNode":           "An AlertNode can trigger an event of varying severity levels,\nand pass the event to alert handlers. The criteria for triggering\nan alert is specified via a [lambda expression](/kapacitor/latest/tick/expr/).\nSee AlertNode.Info, AlertNode.Warn, and AlertNode.Crit below.\n\nDifferent event handlers can be configured for each AlertNode.\nSome handlers like Email, HipChat, Sensu, Slack, OpsGenie, VictorOps, PagerDuty, PagerDuty2, Alertmanager, Syslog and Talk have a configuration\noption 'global' that indicates that all alerts implicitly use the handler.\n\nAvailable event handlers:\n\n   * log -- log alert data to file.\n   * post -- HTTP POST data to a specified URL.\n   * email -- Send and email with alert data.\n   * exec -- Execute a command passing alert data over STDIN.\n   * HipChat -- Post alert message to HipChat room.\n   * Alerta -- Post alert message to Alerta.\n   * Sensu -- Post alert message to Sensu client.\n   * Slack -- Post alert message to Slack channel.\n   * OpsGenie -- Send alert to OpsGenie.\n   * VictorOps -- Send alert to VictorOps.\n   * PagerDuty -- Send alert to PagerDuty.\n   * PagerDuty2 -- Send alert to PagerDuty using the Events API v2.\n   * Jira -- Open and track a Jira issue for the alert.\n   * ServiceNow -- Open and track a ServiceNow incident for the alert.\n   * Alertmanager -- Send alert to Prometheus Alertmanager.\n   * Syslog -- Send alert message to a syslog server.\n   * Talk -- Post alert message to Talk client.\n\nSee below for more details on configuring each handler.\n\nEach event that gets sent to a handler contains the following alert data:\n\n   * ID -- the ID of the alert, user defined.\n   * Message -- the alert message, user defined.\n   * Details -- the alert details, user defined HTML content.\n   * Time -- the time the alert occurred.\n   * Duration -- the duration of the alert in nanoseconds.\n   * Level -- one of OK, INFO, WARNING or CRITICAL.\n   * Data -- influxql.Result containing the data that triggered the alert.\n\nEvents are sent to handlers if the alert is in a state other than 'OK'\nor the alert just changed to the 'OK' state from a non 'OK' state (a.k.a. the alert recovered).\nUsing the AlertNode.StateChangesOnly property events will only be sent to handlers\nif the alert changed state.\n\nIt is valid to configure multiple alert handlers, even with the same type.\n\nEvents are queued and sent to the handlers in the background.\nFailed deliveries are retried with exponential backoff according to the 'delivery'\nsection of the configuration. The number of sent, failed and retried deliveries\nper handler type are reported in the 'alert_handlers' statistics.\n\nExample:\n  stream\n          .groupBy('service')\n      |alert()\n          .id('kapacitor/{{ index .Tags \"service\" }}')\n          .message('{{ .ID }} is {{ .Level }} value:{{ index .Fields \"value\" }}')\n          .info(lambda: \"value\" > 10)\n          .warn(lambda: \"value\" > 20)\n          .crit(lambda: \"value\" > 30)\n          .post(\"http://example.com/api/alert\")\n          .post(\"http://another.example.com/api/alert\")\n          .email().to('oncall@example.com')\n\nIt is assumed that each successive level filters a subset\nof the previous level. As a result, the filter will only be applied if\na data point passed the previous level.\nIn the above example, if value = 15 then the INFO and\nWARNING expressions would be evaluated, but not the\nCRITICAL expression.\nEach expression maintains its own state.\n\nAvailable Statistics:\n\n   * alerts_triggered -- Total number of alerts triggered\n   * oks_triggered -- Number of OK alerts triggered\n   * infos_triggered -- Number of Info alerts triggered\n   * warns_triggered -- Number of Warn alerts triggered\n   * crits_triggered -- Number of Crit alerts triggered",
+		"AlertaHandler":       "",
+		"AlertmanagerHandler": "",
+		"BatchNode":           "A node that handles creating several child QueryNodes.\nEach call to `query` creates a child batch node that\ncan further be configured. See QueryNode\nThe `batch` variable in batch tasks is an instance of\na BatchNode.\n\nExample:\n    var errors = batch\n                     |query('SELECT value from errors')\n                     ...\n    var views = batch\n                     |query('SELECT value from views')\n                     ...\n\nAvailable Statistics:\n\n   * query_errors -- number of errors when querying\n   * connect_errors -- number of errors connecting to InfluxDB\n   * batches_queried -- number of batches returned from queries\n   * points_queried -- total number of points in batches",
+		"DefaultNode":         "Defaults fields and tags on data points.\n\nExample:\n   stream\n       |default()\n           .field('value', 0.0)\n           .tag('host', '')\n\nThe above example will set the field `value` to float64(0) if it does not already exist\nIt will also set the tag `host` to string(\"\") if it does not already exist.\n\nAvailable Statistics:\n\n   * fields_defaulted -- number of fields that were missing\n   * tags_defaulted -- number of tags that were missing",
+		"DerivativeNode":      "Compute the derivative of a stream or batch.\nThe derivative is computed on a single field\nand behaves similarly to the InfluxQL derivative\nfunction. Deriviative is not a MapReduce function\nand as a result is not part of the normal influxql functions.\n\nExample:\n    stream\n        |from()\n            .measurement('net_rx_packets')\n        |derivative('value')\n           .unit(1s) // default\n           .nonNegative()\n        ...\n\nComputes the derivative via:\n   (current - previous ) / ( time_difference / unit)\n\nFor batch edges the derivative is computed for each\npoint in the batch and because of boundary conditions\nthe number of points is reduced by one.",
+		"EmailHandler":        "Email AlertHandler",
+		"EvalNode":            "Evaluates expressions on each data point it receives.\nA list of expressions may be provided and will be evaluated in the order they are given\nand results of previous expressions are made available to later expressions.\nSee the property EvalNode.As for details on how to reference the results.\n\nExample:\n   stream\n       |eval(lambda: \"error_count\" / \"total_count\")\n         .as('error_percent')\n\nThe above example will add a new field `error_percent` to each\ndata point with the result of `error_count / total_count` where\n`error_count` and `total_count` are existing fields on the data point.\n\nA data point is dropped if an expression references a missing field or tag.\nUse the `if`, `isPresent` functions and the `??` operator to handle missing values instead.\n\nExample:\n   stream\n       |eval(lambda: if(isPresent(\"total_count\"), \"error_count\" / \"total_count\", 0.0), lambda: \"region\" ?? 'unknown')\n         .as('error_percent', 'region')\n\nThe `??` operator returns the referenced value, or the right operand if the reference is missing.\nThe `if` function only evaluates the branch selected by the condition.\n\nAvailable Statistics:\n\n   * eval_errors -- number of errors evaluating any expressions.",
+		"ExecHandler":         "",
+		"ExtractNode":         "Extract parts of a tag or string field into new tags or fields\nusing the named capture groups of a regular expression.\n\nExample:\n   stream\n       |from()\n           .measurement('cpu')\n           .groupBy('host')\n       |extract('host', /^(?P<role>[a-z]+)-(?P<number>[0-9]+)\\.(?P<dc>[a-z0-9]+)$/)\n           .tags('role', 'dc')\n           .regroup()\n       |window()\n           .period(1m)\n           .every(1m)\n       |count('value')\n\nThe above example splits the host `web-12.dc3` into the tags `role` and `dc`\nwith the values `web` and `dc3`, and the string field `number` with the value `12`.\nSince the data is regrouped the points are counted per host, role and dc.\n\nNamed capture groups are written as string fields, unless they are written as tags with the tags property.\nUnnamed capture groups are ignored.\nData whose tag or field is missing or does not match the regular expression is passed on unchanged.\n\nAvailable Statistics:\n\n   * points_unmatched -- number of points whose tag or field was missing or did not match",
+		"FillNode":            "Fill gaps in the data of each group with generated points.\nA point is generated for each expected interval without data,\nso that nodes like derivative or dashboards fed by httpOut do not misbehave on gaps.\nStream points are filled per group as the next point arrives,\nbatch points are filled between the points of each batch.\n\nExample:\n   stream\n       |from()\n           .measurement('requests')\n           .groupBy('host')\n       |fill(10s)\n           .value('previous')\n       |derivative('count')\n\nThe above example expects a point every `10 seconds` for each host.\nIf a host misses a point, a point with the field values of the previous point is generated.\n\nUnlike the JoinNode fill property, which only applies within joins,\nthe fill node applies to any stream or batch data.\n\nGaps missing more than maxPoints points, e.g. after an outage of the source, are not filled.\n\nAvailable Statistics:\n\n   * points_filled -- number of points generated\n   * points_skipped -- number of points not generated for gaps missing more than maxPoints points",
+		"FromNode":            "A FromNode selects a subset of the data flowing through a StreamNode.\nThe stream node allows you to select which portion of the stream you want to process.\n\nExample:\n   stream\n       |from()\n          .database('mydb')\n          .retentionPolicy('myrp')\n          .measurement('mymeasurement')\n          .where(lambda: \"host\" =~ /logger\\d+/)\n       |window()\n       ...\n\nThe above example selects only data points from the database `mydb`\nand retention policy `myrp` and measurement `mymeasurement` where\nthe tag `host` matches the regex `logger\\d+`\n\nThe database, retention policy and measurement can also be selected\nby a list of names or by a regex.\n\nExample:\n   stream\n       |from()\n          .database('mydb', 'otherdb')\n          .measurement(/^cpu_/)\n       ...\n\nThe above example selects the data points from the databases `mydb` and `otherdb`\nof all measurements starting with `cpu_`.",
+		"GroupByNode":         "A GroupByNode will group the incoming data.\nEach group is then processed independently for the rest of the pipeline.\nOnly tags that are dimensions in the grouping will be preserved;\nall other tags are dropped.\n\nExample:\n   stream\n       |groupBy('service', 'datacenter')\n       ...\n\nThe above example groups the data along two dimensions `service` and `datacenter`.\nGroups are dynamically created as new data arrives and each group is processed\nindependently.",
+		"HTTPOutNode":         "An HTTPOutNode caches the most recent data for each group it has received.\n\nThe cached data is available at the given endpoint.\nThe endpoint is the relative path from the API endpoint of the running task.\nFor example if the task endpoint is at \"/kapacitor/v1/tasks/<task_id>\" and endpoint is\n\"top10\", then the data can be requested from \"/kapacitor/v1/tasks/<task_id>/top10\".\n\nExample:\n   stream\n       |window()\n           .period(10s)\n           .every(5s)\n       |top('value', 10)\n       //Publish the top 10 results over the last 10s updated every 5s.\n       |httpOut('top10')",
+		"HipChatHandler":      "",
+		"InfluxDBOutNode":     "Writes the data to InfluxDB as it is received.\n\nExample:\n   stream\n       |eval(lambda: \"errors\" / \"total\")\n           .as('error_percent')\n       // Write the transformed data to InfluxDB\n       |influxDBOut()\n           .database('mydb')\n           .retentionPolicy('myrp')\n           .measurement('errors')\n           .tag('kapacitor', 'true')\n           .tag('version', '0.2')\n\nAvailable Statistics:\n\n   * points_written -- number of points written to InfluxDB\n   * write_errors -- number of errors attempting to write to InfluxDB",
+		"InfluxQLNode":        "An InfluxQLNode performs the available function from the InfluxQL language.\nThese function can be performed on a stream or batch edge.\nThe resulting edge is dependent on the function.\nFor a stream edge all points with the same time are accumulated into the function.\nFor a batch edge all points in the batch are accumulated into the function.\n\nExample:\n   stream\n       |window()\n           .period(10s)\n           .every(10s)\n       // Sum the values for each 10s window of data.\n       |sum('value')\n\nNote: Derivative has its own implementation as a DerivativeNode instead of as part of the\nInfluxQL functions.",
+		"JiraHandler":         "",
+		"JoinNode":            "Joins the data from any number of nodes.\nAs each data point is received from a parent node it is paired\nwith the next data points from the other parent nodes with a\nmatching timestamp. Each parent node contributes at most one point\nto each joined point. A tolerance can be supplied to join points\nthat do not have perfectly aligned timestamps.\nAny points that fall within the tolerance are joined on the timestamp.\nIf multiple points fall within the same tolerance window than they are joined in the order\nthey arrive.\n\nAliases are used to prefix all fields from the respective nodes.\n\nThe join can be an inner or outer join, see the JoinNode.Fill property.\n\nExample:\n   var errors = stream\n       |from()\n           .measurement('errors')\n   var requests = stream\n       |from()\n           .measurement('requests')\n   // Join the errors and requests streams\n   errors\n       |join(requests)\n           // Provide prefix names for the fields of the data points.\n           .as('errors', 'requests')\n           // points that are within 1 second are considered the same time.\n           .tolerance(1s)\n           // fill missing values with 0, implies outer join.\n           .fill(0.0)\n           // name the resulting stream\n           .streamName('error_rate')\n       // Both the \"value\" fields from each parent have been prefixed\n       // with the respective names 'errors' and 'requests'.\n       |eval(lambda: \"errors.value\" / \"requests.value\")\n          .as('rate')\n       ...\n\nIn the above example the `errors` and `requests` streams are joined\nand then transformed to calculate a combined field.",
+		"LateNode":            "A stream of the points dropped by a WindowNode because they arrived after the allowed lateness.\nThe points are passed on unmodified.\n\nExample:\n   var win = stream\n       |window()\n           .period(1m)\n           .every(1m)\n           .allowedLateness(10s)\n   win\n       |late()\n       |log()\n\nThe above example logs all points arriving later than `10 seconds` after the end of their window.",
+		"LogHandler":          "",
+		"LogNode":             "A node that logs all data that passes through the node.\n\nExample:\n   stream.from()...\n     |window()\n         .period(10s)\n         .every(10s)\n     |log()\n     |count('value')",
+		"LookupJoinNode":      "Enriches the data with the fields of slowly changing reference data.\nUnlike the JoinNode, points are not paired by time.\nInstead the reference parent is kept as a table containing the latest point\nfor each combination of the dimensions of the lookup.\nEach point of the data is enriched with the fields of the matching table entry.\nThe reference data is applied as it arrives,\nthe data is never held back waiting for reference data.\nAs a consequence the order of data and reference points with close times is not defined:\na data point may or may not be enriched with a reference point\nthat has the same or a slightly earlier time.\nSend the reference data ahead of the data if the order matters.\n\nExample:\n   var deploys = stream\n       |from()\n           .measurement('deploys')\n   stream\n       |from()\n           .measurement('errors')\n       |lookupJoin(deploys)\n           .on('host')\n           .as('deploy')\n           // Forget deploys older than a day.\n           .ttl(24h)\n           // Use a default version for hosts without a known deploy.\n           .default('version', 'unknown')\n       |groupBy('deploy.version')\n       ...\n\nIn the above example each errors point is enriched with the fields of the latest\ndeploys point with the same host tag, i.e. the 'deploy.version' field.",
+		"NameSelection":       "A selection of names, either a list of names or a regex.\nAn empty selection selects any name.",
+		"NoOpNode":            "A node that does not perform any operation.\n\n*Do not use this node in a TICKscript there should be no need for it.*\n\nIf a node does not have any children, then its emitted count remains zero.\nUsing a NoOpNode is a work around so that statistics are accurately reported\nfor nodes with no real children.\nA NoOpNode is automatically appended to any node that is a source for a StatsNode\nand does not have any children.",
+		"OpsGenieHandler":     "",
+		"PagerDuty2Handler":   "",
+		"PagerDutyHandler":    "",
+		"Pipeline":            "A complete data processing pipeline. Starts with a single source.",
+		"PostHandler":         "",
+		"QueryNode":           "A QueryNode defines a source and a schedule for\nprocessing batch data. The data is queried from\nan InfluxDB database and then passed into the data pipeline.\n\nExample:\nbatch\n    |query('''\n        SELECT mean(\"value\")\n        FROM \"telegraf\".\"default\".cpu_usage_idle\n        WHERE \"host\" = 'serverA'\n    ''')\n        .period(1m)\n        .every(20s)\n        .groupBy(time(10s), 'cpu')\n    ...\n\nIn the above example InfluxDB is queried every 20 seconds; the window of time returned\nspans 1 minute and is grouped into 10 second buckets.",
+		"ReduceCreater":       "",
+		"SampleNode":          "Sample points or batches.\nOne point will be emitted every count or duration specified.\n\nExample:\n   stream\n       |sample(3)\n\nKeep every third data point or batch.\n\nExample:\n   stream\n       |sample(10s)\n\nKeep only samples that land on the 10s boundary.\nSee FromNode.Truncate, QueryNode.GroupBy time or WindowNode.Align\nfor ensuring data is aligned with a boundary.",
+		"SensuHandler":        "",
+		"ServiceNowHandler":   "",
+		"ShiftNode":           "Shift points and batches in time, this is useful for comparing\nbatches or points from different times.\n\nExample:\n   stream\n       |shift(5m)\n\nShift all data points 5m forward in time.\n\nExample:\n   stream\n       |shift(-10s)\n\nShift all data points 10s backward in time.",
+		"SlackField":          "A field of a Slack message attachment.",
+		"SlackHandler":        "",
+		"StatsNode":           "A StatsNode emits internal statistics about the another node at a given interval.\n\nThe interval represents how often to emit the statistics based on real time.\nThis means the interval time is independent of the times of the data points the other node is receiving.\nAs a result the StatsNode is a root node in the task pipeline.\n\nThe currently available internal statistics:\n\n   * emitted -- the number of points or batches this node has sent to its children.\n\nEach stat is available as a field in the data stream.\n\nThe stats are in groups according to the original data.\nMeaning that if the source node is grouped by the tag 'host' as an example,\nthen the counts are output per host with the appropriate 'host' tag.\nSince its possible for groups to change when crossing a node only the emitted groups\nare considered.\n\nExample:\n    var data = stream\n        |from()...\n    // Emit statistics every 1 minute and cache them via the HTTP API.\n    data\n        |stats(1m)\n        |httpOut('stats')\n    // Continue normal processing of the data stream\n    data...\n\nWARNING: It is not recommended to join the stats stream with the original data stream.\nSince they operate on different clocks you could potentially create a deadlock.\nThis is a limitation of the current implementation and may be removed in the future.",
+		"StreamNode":          "A StreamNode represents the source of data being\nstreamed to Kapacitor via any of its inputs.\nThe `stream` variable in stream tasks is an instance of\na StreamNode.\nStreamNode.From is the method/property of this node.",
+		"SyslogHandler":       "",
+		"TalkHandler":         "",
+		"TopBottomCallInfo":   "",
+		"UDFNode":             "A UDFNode is a node that can run a User Defined Function (UDF) in a separate process.\n\nA UDF is a custom script or binary that can communicate via Kapacitor's UDF RPC protocol.\nThe path and arguments to the UDF program are specified in Kapacitor's configuration.\nUsing TICKscripts you can invoke and configure your UDF for each task.\n\nSee the [README.md](https://github.com/influxdata/kapacitor/tree/master/udf/agent/)\nfor details on how to write your own UDF.\n\nUDFs are configured via Kapacitor's main configuration file.\n\nExample:\n   [udf]\n   [udf.functions]\n       # Example moving average UDF.\n       [udf.functions.movingAverage]\n           prog = \"/path/to/executable/moving_avg\"\n           args = []\n           timeout = \"10s\"\n\nUDFs are first class objects in TICKscripts and are referenced via their configuration name.\n\nExample:\n    // Given you have a UDF that computes a moving average\n    // The UDF can define what its options are and then can be\n    // invoked via a TICKscript like so:\n    stream\n        |from()...\n        @movingAverage()\n            .field('value')\n            .size(100)\n            .as('mavg')\n        |httpOut('movingaverage')\n\nNOTE: The UDF process runs as the same user as the Kapacitor daemon.\nAs a result make the user is properly secured as well as the configuration file.",
+		"UnionNode":           "Takes the union of all of its parents.\nThe union is just a simple pass through.\nEach data points received from each parent is passed onto children nodes\nwithout modification.\n\nExample:\n   var logins = stream\n       |from()\n           .measurement('logins')\n   var logouts = stream\n       |from()\n           .measurement('logouts')\n   var frontpage = stream\n       |from()\n           .measurement('frontpage')\n   // Union all user actions into a single stream\n   logins\n       |union(logouts, frontpage)\n           .rename('user_actions')\n       ...",
+		"VictorOpsHandler":    "",
+		"WhereNode":           "The WhereNode filters the data stream by a given expression.\n\nExample:\nvar sums = stream\n    |from()\n        .groupBy('service', 'host')\n    |sum('value')\n//Watch particular host for issues.\nsums\n   |where(lambda: \"host\" == 'h001.example.com')\n   |alert()\n       .crit(lambda: TRUE)\n       .email().to('user@example.com')",
+		"WindowNode":          "Windows data over time.\nA window has a length defined by `period`\nand a frequency at which it emits the window to the pipeline.\n\nExample:\n   stream\n       |window()\n           .period(10m)\n           .every(5m)\n       |httpOut('recent')\n\nThe above windowing example emits a window to the pipeline every `5 minutes`\nand the window contains the last `10 minutes` worth of data.\nAs a result each time the window is emitted it contains half new data and half old data.\n\nWindows can also be defined by a number of points or by sessions of activity.\n\nExample:\n   stream\n       |window()\n           .periodCount(100)\n           .everyCount(10)\n       |mean('value')\n\nThe above windowing example emits a window of the last `100` points of each group\nevery `10` points.\n\nExample:\n   stream\n       |from()\n           .measurement('requests')\n           .groupBy('user')\n       |window()\n           .session(30m)\n       |count('value')\n\nThe above windowing example emits a window for each session of requests of a user,\na session ends when no request has been received from the user for `30 minutes`.\n\nWindows can wait for points arriving out of order, see allowedLateness.\n\nExample:\n   var win = stream\n       |window()\n           .period(1m)\n           .every(1m)\n           .allowedLateness(30s)\n   win\n       |sum('value')\n       |influxDBOutput()\n           .database('mydb')\n           .measurement('sums')\n   win\n       |late()\n       |influxDBOutput()\n           .database('mydb')\n           .measurement('late')\n\nThe above windowing example emits each window once points `30 seconds` past the end of the window\nhave been received, so that points arriving up to `30 seconds` late are included in their window.\nPoints arriving later than that are written to the 'late' measurement.\n\nNOTE: A window with equal period and every whose only child is one of the\ncount, sum, mean, min, max, first, last, spread or stddev functions\ndoes not keep the points of the window in memory.\nInstead each point is reduced into the function as it arrives, the results are the same.\n\nNOTE: Time for a window (or any node) is implemented by inspecting the times on the incoming data points.\nAs a result if the incoming data stream stops then no more windows will be emitted because time is no longer\nincreasing for the window node.",
+	},
+	members: map[string]map[string]string{
+		"AlertNode": {
+			"Alerta":                   "Send the alert to Alerta.\n\nExample:\n   [alerta]\n     enabled = true\n     url = \"https://alerta.yourdomain\"\n     token = \"9hiWoDOZ9IbmHsOTeST123ABciWTIqXQVFDo63h9\"\n     environment = \"Production\"\n     origin = \"Kapacitor\"\n\nIn order to not post a message every alert interval\nuse AlertNode.StateChangesOnly so that only events\nwhere the alert changed state are sent to Alerta.\n\nSend alerts to Alerta. The resource and event properties are required.\n\nExample:\n   stream\n        |alert()\n            .alerta()\n                .resource('Hostname or service')\n                .event('Something went wrong')\n\nAlerta also accepts optional alert information.\n\nExample:\n   stream\n        |alert()\n            .alerta()\n                .resource('Hostname or service')\n                .event('Something went wrong')\n                .environment('Development')\n                .group('Dev. Servers')\n\nNOTE: Alerta cannot be configured globally because of its required properties.",
+			"AlertaHandlers":           "Send alert to Alerta.",
+			"Alertmanager":             "Send the alert to Prometheus Alertmanager.\nAlerts are posted to the '/api/v1/alerts' endpoint of Alertmanager.\n\nExample:\n   [alertmanager]\n     enabled = true\n     url = \"http://localhost:9093\"\n\nExample:\n   stream\n        |alert()\n            .alertmanager()\n\nThe labels of the Alertmanager alert are the tags of the alert data\nplus the 'alertname' and 'severity' labels.\nThe alert name defaults to the name of the alert data, i.e. the measurement.\nThe severity is 'critical', 'warning' or 'info' depending on the alert level.\nThe message and the details of the alert are sent as the 'summary' and\n'description' annotations and the alert ID as the 'alert_id' annotation.\n\nAlertmanager alerts start when the alert leaves the OK level\nand end when it returns to the OK level.\nSince the severity is a label, changing the level starts a new Alertmanager alert\nand ends the one with the previous severity.\nActive alerts are re-sent periodically, see the 'resend-interval' option,\nso that Alertmanager does not consider them resolved.\n\nAdditional named endpoints can be configured.\n\nExample:\n   [[alertmanager.endpoint]]\n     name = \"ops\"\n     url = \"http://alertmanager.ops.example.com:9093\"\n\nExample:\n   stream\n        |alert()\n            .alertmanager()\n                .endpoint('ops')\n                .alertName('HighCPU')\n\nSend alerts named 'HighCPU' to the Alertmanager of the 'ops' endpoint.\n\nIf the 'alertmanager' section in the configuration has the option: global = true\nthen all alerts are sent to Alertmanager without the need to explicitly state it\nin the TICKscript.",
+			"AlertmanagerHandlers":     "Send alert to Alertmanager.",
+			"All":                      "Indicates an alert should trigger only if all points in a batch match the criteria\nDoes not apply to stream alerts.",
+			"AllFlag":                  "Indicates an alert should trigger only if all points in a batch match the criteria",
+			"ChainMethods":             "",
+			"Crit":                     "Filter expression for the CRITICAL alert level.\nAn empty value indicates the level is invalid and is skipped.",
+			"Details":                  "Template for constructing a detailed HTML message for the alert.\nThe same template data is available as the AlertNode.Message property,\nin addition to a Message field that contains the rendered Message value.\n\nThe intent is that the Message property be a single line summary while the\nDetails property is a more detailed message possibly spanning multiple lines,\nand containing HTML formatting.\n\nThis template is rendered using the html/template package in Go so that\nsafe and valid HTML can be generated.\n\nThe `json` method is available within the template to convert any variable to a valid\nJSON string.\n\nExample:\n   |alert()\n      .id('{{ .Name }}')\n      .details('''\n<h1>{{ .ID }}</h1>\n<b>{{ .Message }}</b>\nValue: {{ index .Fields \"value\" }}\n''')\n      .email()\n\nDefault: {{ json . }}",
+			"DurationField":            "Optional field key to add the alert duration to the data.\nThe duration is always in units of nanoseconds.",
+			"Email":                    "Email the alert data.\n\nIf the To list is empty, the To addresses from the configuration are used.\nThe email subject is the AlertNode.Message property.\nThe email body is the AlertNode.Details property.\nThe emails are sent as HTML emails and so the body can contain html markup.\n\nIf the 'smtp' section in the configuration has the option: global = true\nthen all alerts are sent via email without the need to explicitly state it\nin the TICKscript.\n\nExample:\n   |alert()\n      .id('{{ .Name }}')\n      // Email subject\n      .meassage('{{ .ID }}:{{ .Level }}')\n      //Email body as HTML\n      .details('''\n<h1>{{ .ID }}</h1>\n<b>{{ .Message }}</b>\nValue: {{ index .Fields \"value\" }}\n''')\n      .email()\n\nSend an email with custom subject and body.\n\nExample:\n    [smtp]\n      enabled = true\n      host = \"localhost\"\n      port = 25\n      username = \"\"\n      password = \"\"\n      from = \"kapacitor@example.com\"\n      to = [\"oncall@example.com\"]\n      # Set global to true so all alert trigger emails.\n      global = true\n      state-changes-only =  true\n\nExample:\n   stream\n        |alert()\n\nSend email to 'oncall@example.com' from 'kapacitor@example.com'",
+			"EmailHandlers":            "Email handlers",
+			"Exec":                     "Execute a command whenever an alert is triggered and pass the alert data over STDIN in JSON format.",
+			"ExecHandlers":             "A commands to run when an alert triggers",
+			"FlapHigh":                 "",
+			"FlapLow":                  "",
+			"Flapping":                 "Perform flap detection on the alerts.\nThe method used is similar method to Nagios:\nhttps://assets.nagios.com/downloads/nagioscore/docs/nagioscore/3/en/flapping.html\n\nEach different alerting level is considered a different state.\nThe low and high thresholds are inverted thresholds of a percentage of state changes.\nMeaning that if the percentage of state changes goes above the `high`\nthreshold, the alert enters a flapping state. The alert remains in the flapping state\nuntil the percentage of state changes goes below the `low` threshold.\nTypical values are low: 0.25 and high: 0.5. The percentage values represent the number state changes\nover the total possible number of state changes. A percentage change of 0.5 means that the alert changed\nstate in half of the recorded history, and remained the same in the other half of the history.",
+			"HipChat":                  "If the 'hipchat' section in the configuration has the option: global = true\nthen all alerts are sent to HipChat without the need to explicitly state it\nin the TICKscript.\n\nExample:\n   [hipchat]\n     enabled = true\n     url = \"https://orgname.hipchat.com/v2/room\"\n     room = \"Test Room\"\n     token = \"9hiWoDOZ9IbmHsOTeST123ABciWTIqXQVFDo63h9\"\n     global = true\n     state-changes-only = true\n\nExample:\n   stream\n        |alert()\n\nSend alert to HipChat using default room 'Test Room'.",
+			"HipChatHandlers":          "Send alert to HipChat.",
+			"History":                  "Number of previous states to remember when computing flapping levels and\nchecking for state changes.\nMinimum value is 2 in order to keep track of current and previous states.\n\nDefault: 21",
+			"Id":                       "Template for constructing a unique ID for a given alert.\n\nAvailable template data:\n\n   * Name -- Measurement name.\n   * TaskName -- The name of the task\n   * Group -- Concatenation of all group-by tags of the form [key=value,]+.\n       If no groupBy is performed equal to literal 'nil'.\n   * Tags -- Map of tags. Use '{{ index .Tags \"key\" }}' to get a specific tag value.\n\nExample:\n  stream\n      |from()\n          .measurement('cpu')\n          .groupBy('cpu')\n      |alert()\n          .id('kapacitor/{{ .Name }}/{{ .Group }}')\n\nID: kapacitor/cpu/cpu=cpu0,\n\nExample:\n  stream\n      |from()\n          .measurement('cpu')\n          .groupBy('service')\n      |alert()\n          .id('kapacitor/{{ index .Tags \"service\" }}')\n\nID: kapacitor/authentication\n\nExample:\n  stream\n      |from()\n          .measurement('cpu')\n          .groupBy('service', 'host')\n      |alert()\n          .id('kapacitor/{{ index .Tags \"service\" }}/{{ index .Tags \"host\" }}')\n\nID: kapacitor/authentication/auth001.example.com\n\nDefault: {{ .Name }}:{{ .Group }}",
+			"IdField":                  "Optional field key to add to the data, containing the alert ID as a string.",
+			"IdTag":                    "Optional tag key to use when tagging the data with the alert ID.",
+			"Info":                     "Filter expression for the INFO alert level.\nAn empty value indicates the level is invalid and is skipped.",
+			"IsStateChangesOnly":       "Send alerts only on state changes.",
+			"Jira":                     "Open a Jira issue when the alert reaches a level and keep it in sync with the alert.\nChanges of the alert level are added as comments to the issue and\nonce the alert recovers the issue is commented on and resolved.\nThe issue keys are stored per alert ID so that restarting Kapacitor does not open duplicate issues.\n\nExample:\n   [jira]\n     enabled = true\n     url = \"https://example.atlassian.net\"\n     username = \"kapacitor\"\n     token = \"xxxxxxxxx\"\n     project = \"OPS\"\n     issue-type = \"Task\"\n     resolve-transition-id = \"31\"\n\nExample:\n   stream\n        |alert()\n            .jira()\n                .minLevel('CRITICAL')\n                .summary('{{ .Name }} on {{ index .Tags \"host\" }} is {{ .Level }}')\n                .field('labels', 'kapacitor')\n\nOpen an issue in the 'OPS' project once the alert is CRITICAL.",
+			"JiraHandlers":             "Open Jira issues for the alert.",
+			"LevelField":               "Optional field key to add to the data, containing the alert level as a string.",
+			"LevelTag":                 "Optional tag key to use when tagging the data with the alert level.",
+			"Log":                      "Log JSON alert data to file. One event per line.\nMust specify the absolute path to the log file.\nIt will be created if it does not exist.\nExample:\n   stream\n        |alert()\n            .log('/tmp/alert')\n\nExample:\n   stream\n        |alert()\n            .log('/tmp/alert')\n            .mode(0644)",
+			"LogHandlers":              "Log JSON alert data to file. One event per line.",
+			"Message":                  "Template for constructing a meaningful message for the alert.\n\nAvailable template data:\n\n   * ID -- The ID of the alert.\n   * Name -- Measurement name.\n   * TaskName -- The name of the task\n   * Group -- Concatenation of all group-by tags of the form [key=value,]+.\n       If no groupBy is performed equal to literal 'nil'.\n   * Tags -- Map of tags. Use '{{ index .Tags \"key\" }}' to get a specific tag value.\n   * Level -- Alert Level, one of: INFO, WARNING, CRITICAL.\n   * Fields -- Map of fields. Use '{{ index .Fields \"key\" }}' to get a specific field value.\n   * Time -- The time of the point that triggered the event.\n\nAll alert templates can use the following functions:\n\n   * humanize, humanizeBytes, humanizeDuration -- Format numbers, bytes and durations in seconds.\n   * toUpper, toLower -- Change the case of a string.\n   * join -- Join a list with a separator.\n   * default -- Replace an empty value, i.e. '{{ .Value | default \"n/a\" }}'.\n   * round -- Round a number to a number of decimal places, i.e. '{{ .Value | round 2 }}'.\n   * timeFormat -- Format a time in a timezone, i.e. '{{ .Time | timeFormat \"15:04\" \"Europe/Paris\" }}'.\n   * tag, field -- Lookup a tag or field with a fallback, i.e. '{{ tag .Tags \"host\" \"unknown\" }}'.\n   * printf, urlquery -- The builtin Go template functions.\n\nTemplates are validated when the task is defined.\n\nExample:\n  stream\n      |from()\n          .measurement('cpu')\n          .groupBy('service', 'host')\n      |alert()\n          .id('{{ index .Tags \"service\" }}/{{ index .Tags \"host\" }}')\n          .message('{{ .ID }} is {{ .Level}} value: {{ field .Fields \"value\" 0 | round 2 }}')\n\nMessage: authentication/auth001.example.com is CRITICAL value:42.12\n\nDefault: {{ .ID }} is {{ .Level }}",
+			"OpsGenie":                 "Send alert to OpsGenie.\nTo use OpsGenie alerting you must first enable the 'Alert Ingestion API'\nin the 'Integrations' section of OpsGenie.\nThen place the API key from the URL into the 'opsgenie' section of the Kapacitor configuration.\n\nExample:\n   [opsgenie]\n     enabled = true\n     api-key = \"xxxxx\"\n     teams = [\"everyone\"]\n     recipients = [\"jim\", \"bob\"]\n\nWith the correct configuration you can now use OpsGenie in TICKscripts.\n\nExample:\n   stream\n        |alert()\n            .opsGenie()\n\nSend alerts to OpsGenie using the teams and recipients in the configuration file.\n\nExample:\n   stream\n        |alert()\n            .opsGenie()\n            .teams('team_rocket','team_test')\n\nSend alerts to OpsGenie with team set to 'team_rocket' and 'team_test'\n\nIf the 'opsgenie' section in the configuration has the option: global = true\nthen all alerts are sent to OpsGenie without the need to explicitly state it\nin the TICKscript.\n\nExample:\n   [opsgenie]\n     enabled = true\n     api-key = \"xxxxx\"\n     recipients = [\"johndoe\"]\n     global = true\n\nExample:\n   stream\n        |alert()\n\nSend alert to OpsGenie using the default recipients, found in the configuration.",
+			"OpsGenieHandlers":         "Send alert to OpsGenie",
+			"PagerDuty":                "Send the alert to PagerDuty.\nTo use PagerDuty alerting you must first follow the steps to enable a new 'Generic API' service.\n\nFrom https://developer.pagerduty.com/documentation/integration/events\n\n   1. In your account, under the Services tab, click \"Add New Service\".\n   2. Enter a name for the service and select an escalation policy. Then, select \"Generic API\" for the Service Type.\n   3. Click the \"Add Service\" button.\n   4. Once the service is created, you'll be taken to the service page. On this page, you'll see the \"Service key\", which is needed to access the API\n\nPlace the 'service key' into the 'pagerduty' section of the Kapacitor configuration as the option 'service-key'.\n\nExample:\n   [pagerduty]\n     enabled = true\n     service-key = \"xxxxxxxxx\"\n\nWith the correct configuration you can now use PagerDuty in TICKscripts.\n\nExample:\n   stream\n        |alert()\n            .pagerDuty()\n\nIf the 'pagerduty' section in the configuration has the option: global = true\nthen all alerts are sent to PagerDuty without the need to explicitly state it\nin the TICKscript.\n\nExample:\n   [pagerduty]\n     enabled = true\n     service-key = \"xxxxxxxxx\"\n     global = true\n\nExample:\n   stream\n        |alert()\n\nSend alert to PagerDuty.",
+			"PagerDuty2":               "Send the alert to PagerDuty using the Events API v2.\nTo use the Events API v2 you must first add an integration of type\n'Use our API directly' with 'Events API v2' to a PagerDuty service.\nPlace the integration key into the 'pagerduty2' section of the Kapacitor configuration as the option 'routing-key'.\n\nExample:\n   [pagerduty2]\n     enabled = true\n     routing-key = \"xxxxxxxxx\"\n\nWith the correct configuration you can now use PagerDuty2 in TICKscripts.\n\nExample:\n   stream\n        |alert()\n            .pagerDuty2()\n\nThe alert ID is used as the dedup key of the PagerDuty incident.\nEvents for the OK level resolve the incident, all other levels trigger it.\nThe incident severity is 'critical', 'warning' or 'info' depending on the alert level\nand the fields of the alert data are sent as the custom details.\nThe 'host' tag is used as the source of the event, if present.\n\nExample:\n   stream\n        |alert()\n            .pagerDuty2()\n                .routingKey('team_rocket')\n\nSend alerts to the PagerDuty service with the integration key 'team_rocket'.\n\nIf the 'pagerduty2' section in the configuration has the option: global = true\nthen all alerts are sent to PagerDuty without the need to explicitly state it\nin the TICKscript.\n\nExample:\n   [pagerduty2]\n     enabled = true\n     routing-key = \"xxxxxxxxx\"\n     global = true\n\nExample:\n   stream\n        |alert()\n\nSend alert to PagerDuty using the Events API v2.",
+			"PagerDuty2Handlers":       "Send alert to PagerDuty using the Events API v2.",
+			"PagerDutyHandlers":        "Send alert to PagerDuty.",
+			"Post":                     "HTTP POST JSON alert data to a specified URL.",
+			"PostHandlers":             "Post the JSON alert data to the specified URL.",
+			"Sensu":                    "Send the alert to Sensu.\n\nExample:\n   [sensu]\n     enabled = true\n     url = \"http://sensu:3030\"\n     source = \"Kapacitor\"\n\nExample:\n   stream\n        |alert()\n            .sensu()\n\nSend alerts to Sensu client.",
+			"SensuHandlers":            "Send alert to Sensu.",
+			"ServiceNow":               "Open a ServiceNow incident when the alert reaches a level and keep it in sync with the alert.\nChanges of the alert level are added as work notes to the incident and\nonce the alert recovers the incident is resolved.\nThe urgency and impact of the incident are set from the alert level.\nThe incident IDs are stored per alert ID so that restarting Kapacitor does not open duplicate incidents.\n\nExample:\n   [servicenow]\n     enabled = true\n     url = \"https://example.service-now.com\"\n     username = \"kapacitor\"\n     password = \"xxxxxxxxx\"\n\nExample:\n   stream\n        |alert()\n            .serviceNow()\n                .field('assignment_group', 'Operations')\n                .field('cmdb_ci', '{{ index .Tags \"host\" }}')\n\nOpen an incident assigned to the 'Operations' group once the alert is WARNING or CRITICAL.",
+			"ServiceNowHandlers":       "Open ServiceNow incidents for the alert.",
+			"Slack":                    "Send the alert to Slack.\nTo allow Kapacitor to post to Slack,\ngo to the URL https://slack.com/services/new/incoming-webhook\nand create a new incoming webhook and place the generated URL\nin the 'slack' configuration section.\n\nExample:\n   [slack]\n     enabled = true\n     url = \"https://hooks.slack.com/services/xxxxxxxxx/xxxxxxxxx/xxxxxxxxxxxxxxxxxxxxxxxx\"\n     channel = \"#general\"\n\nIn order to not post a message every alert interval\nuse AlertNode.StateChangesOnly so that only events\nwhere the alert changed state are posted to the channel.\n\nExample:\n   stream\n        |alert()\n            .slack()\n\nSend alerts to Slack channel in the configuration file.\n\nExample:\n   stream\n        |alert()\n            .slack()\n            .channel('#alerts')\n\nSend alerts to Slack channel '#alerts'\n\nExample:\n   stream\n        |alert()\n            .slack()\n            .channel('@jsmith')\n\nSend alert to user '@jsmith'\n\nIf the 'slack' section in the configuration has the option: global = true\nthen all alerts are sent to Slack without the need to explicitly state it\nin the TICKscript.\n\nExample:\n   [slack]\n     enabled = true\n     url = \"https://hooks.slack.com/services/xxxxxxxxx/xxxxxxxxx/xxxxxxxxxxxxxxxxxxxxxxxx\"\n     channel = \"#general\"\n     global = true\n     state-changes-only = true\n\nExample:\n   stream\n        |alert()\n\nSend alert to Slack using default channel '#general'.\n\nAdditional named workspaces can be configured,\neach with its own webhook URL or Web API token.\nAlerts are sent to the top level workspace unless a workspace is selected.\n\nExample:\n   [[slack.workspace]]\n     name = \"ops\"\n     url = \"https://hooks.slack.com/services/yyyyyyyyy/yyyyyyyyy/yyyyyyyyyyyyyyyyyyyyyyyy\"\n     channel = \"#ops\"\n\nExample:\n   stream\n        |alert()\n            .slack()\n            .workspace('ops')\n\nSend alerts to the '#ops' channel of the 'ops' workspace.\n\nMessages are sent as attachments colored by the alert level.\nThe colors and an icon emoji per level can be set in the configuration.\nThe attachment can have a title and a list of fields,\nthe title and field values are templates with access to the same data as the AlertNode.Details property.\n\nExample:\n   [slack]\n     enabled = true\n     url = \"https://hooks.slack.com/services/xxxxxxxxx/xxxxxxxxx/xxxxxxxxxxxxxxxxxxxxxxxx\"\n     [slack.colors]\n       CRITICAL = \"#ff0000\"\n     [slack.emoji]\n       CRITICAL = \":fire:\"\n\nExample:\n   stream\n        |alert()\n            .slack()\n            .title('{{ .Name }} is {{ .Level }}')\n            .shortField('Host', '{{ index .Tags \"host\" }}')\n            .shortField('Value', '{{ index .Fields \"value\" }}')\n            .field('Message', '{{ .Message }}')",
+			"SlackHandlers":            "Send alert to Slack.",
+			"Sparkline":                "Keep the recent values of a field per group and render them as a sparkline graph.\nThe graphs are PNG images served by the HTTP API at\n'/kapacitor/v1/sparkline.png', the values at the time of the alert are part of the URL\nso the graph remains available after the task is stopped.\nThe URL of the graph of the group is added to the alert data as 'sparkline',\nshown as the image of Slack attachments\nand the graph is embedded as an inline image in emails.\n\nExample:\n  stream\n      |from()\n          .measurement('cpu')\n          .groupBy('host')\n      |alert()\n          .crit(lambda: \"usage_idle\" < 10)\n          .sparkline('usage_idle')\n          .sparklinePoints(60)\n          .slack()\n\nShow the last 60 values of 'usage_idle' of the host in Slack.",
+			"SparklineField":           "Field of which the recent values are rendered as a sparkline graph.",
+			"SparklinePoints":          "Number of recent values per group rendered in the sparkline graph.\nMinimum value is 2, maximum value is 120, the width of the graph in pixels.\n\nDefault: 30",
+			"StateChangesOnly":         "Only sends events where the state changed.\nEach different alert level OK, INFO, WARNING, and CRITICAL\nare considered different states.\n\nExample:\n  stream\n      |from()\n          .measurement('cpu')\n      |window()\n           .period(10s)\n           .every(10s)\n      |alert()\n          .crit(lambda: \"value\" > 10)\n          .stateChangesOnly()\n          .slack()\n\nIf the \"value\" is greater than 10 for a total of 60s, then\nonly two events will be sent. First, when the value crosses\nthe threshold, and second, when it falls back into an OK state.\nWithout stateChangesOnly, the alert would have triggered 7 times:\n6 times for each 10s period where the condition was met and once more\nfor the recovery.\n\nAn optional maximum interval duration can be provided.\nAn event will not be ignore (aka trigger an alert) if more than the maximum interval has elapsed\nsince the last alert.\n\nExample:\n  stream\n      |from()\n          .measurement('cpu')\n      |window()\n           .period(10s)\n           .every(10s)\n      |alert()\n          .crit(lambda: \"value\" > 10)\n          .stateChangesOnly(10m)\n          .slack()\n\nThe abvove usage will only trigger alerts to slack on state changes or at least every 10 minutes.",
+			"StateChangesOnlyDuration": "Maximum interval to ignore non state changed events",
+			"Syslog":                   "Send the alert to a syslog server.\nMessages are sent over UDP, TCP, optionally using TLS, or a local unix socket\nin either the RFC 5424 or the RFC 3164 format.\n\nExample:\n   [syslog]\n     enabled = true\n     network = \"tcp\"\n     address = \"siem.example.com:6514\"\n     tls = true\n     format = \"rfc5424\"\n     facility = \"local0\"\n\nExample:\n   stream\n        |alert()\n            .syslog()\n\nThe severity of the messages is 'critical', 'warning', 'informational' or 'notice'\nfor the CRITICAL, WARNING, INFO and OK levels respectively.\nRFC 5424 messages carry the alert ID, task name and level as well as the tags\nof the alert data as structured data.\n\nExample:\n   stream\n        |alert()\n            .syslog()\n                .facility('auth')\n\nSend alerts to syslog using the 'auth' facility.\n\nIf the 'syslog' section in the configuration has the option: global = true\nthen all alerts are sent to syslog without the need to explicitly state it\nin the TICKscript.",
+			"SyslogHandlers":           "Send alert to syslog.",
+			"Talk":                     "Send the alert to Talk.\nTo use Talk alerting you must first follow the steps to create a new incoming webhook.\n\n   1. Go to the URL https:/account.jianliao.com/signin.\n   2. Sign in with you account. under the Team tab, click \"Integrations\".\n   3. Select \"Customize service\", click incoming Webhook \"Add\" button.\n   4. After choose the topic to connect with \"xxx\", click \"Confirm Add\" button.\n   5. Once the service is created, you'll see the \"Generate Webhook url\".\n\nPlace the 'Generate Webhook url' into the 'Talk' section of the Kapacitor configuration as the option 'url'.\n\nExample:\n   [talk]\n     enabled = true\n     url = \"https://jianliao.com/v2/services/webhook/uuid\"\n     author_name = \"Kapacitor\"\n\nExample:\n   stream\n        |alert()\n            .talk()\n\nSend alerts to Talk client.",
+			"TalkHandlers":             "Send alert to Talk.",
+			"UseFlapping":              "",
+			"VictorOps":                "Send alert to VictorOps.\nTo use VictorOps alerting you must first enable the 'Alert Ingestion API'\nin the 'Integrations' section of VictorOps.\nThen place the API key from the URL into the 'victorops' section of the Kapacitor configuration.\n\nExample:\n   [victorops]\n     enabled = true\n     api-key = \"xxxxx\"\n     routing-key = \"everyone\"\n\nWith the correct configuration you can now use VictorOps in TICKscripts.\n\nExample:\n   stream\n        |alert()\n            .victorOps()\n\nSend alerts to VictorOps using the routing key in the configuration file.\n\nExample:\n   stream\n        |alert()\n            .victorOps()\n            .routingKey('team_rocket')\n\nSend alerts to VictorOps with routing key 'team_rocket'\n\nIf the 'victorops' section in the configuration has the option: global = true\nthen all alerts are sent to VictorOps without the need to explicitly state it\nin the TICKscript.\n\nExample:\n   [victorops]\n     enabled = true\n     api-key = \"xxxxx\"\n     routing-key = \"everyone\"\n     global = true\n\nExample:\n   stream\n        |alert()\n\nSend alert to VictorOps using the default routing key, found in the configuration.",
+			"VictorOpsHandlers":        "Send alert to VictorOps.",
+			"Warn":                     "Filter expression for the WARNING alert level.\nAn empty value indicates the level is invalid and is skipped.",
+		},
+		"AlertaHandler": {
+			"Environment": "Alerta environment.\nCan be a template and has access to the same data as the AlertNode.Details property.\nDefaut is set from the configuration.",
+			"Event":       "Alerta event.\nCan be a template and has access to the same data as the idInfo property.\nDefault: {{ .ID }}",
+			"Group":       "Alerta group.\nCan be a template and has access to the same data as the AlertNode.Details property.\nDefault: {{ .Group }}",
+			"Origin":      "Alerta origin.\nIf empty uses the origin from the configuration.",
+			"Resource":    "Alerta resource.\nCan be a template and has access to the same data as the AlertNode.Details property.\nDefault: {{ .Name }}",
+			"Service":     "List of effected Services",
+			"Services":    "List of effected services.\nIf not specified defaults to the Name of the stream.",
+			"Token":       "Alerta authentication token.\nIf empty uses the token from the configuration.",
+			"Value":       "Alerta value.\nCan be a template and has access to the same data as the AlertNode.Details property.\nDefault is an empty string.",
+		},
+		"AlertmanagerHandler": {
+			"AlertName": "Value of the 'alertname' label.\nIf empty uses the name of the alert data.",
+			"Endpoint":  "Name of the configured Alertmanager endpoint.\nIf empty uses the top level URL from the configuration.",
+		},
+		"BatchNode": {
+			"Query": "The query to execute. Must not contain a time condition\nin the `WHERE` clause or contain a `GROUP BY` clause.\nThe time conditions are added dynamically according to the period, offset and schedule.\nThe `GROUP BY` clause is added dynamically according to the dimensions\npassed to the `groupBy` method.",
+		},
+		"DefaultNode": {
+			"Field":  "Define a field default.",
+			"Fields": "Set of fields to default",
+			"Tag":    "Define a tag default.",
+			"Tags":   "Set of tags to default",
+		},
+		"DerivativeNode": {
+			"As":              "The new name of the derivative field.\nDefault is the name of the field used\nwhen calculating the derivative.",
+			"Field":           "The field to use when calculating the derivative",
+			"NonNegative":     "If called the derivative will skip negative results.",
+			"NonNegativeFlag": "Where negative values are acceptable.",
+			"Unit":            "The time unit of the resulting derivative value.\nDefault: 1s",
+		},
+		"EdgeType": {
+			"String": "",
+		},
+		"EmailHandler": {
+			"ToList": "List of email recipients.",
+		},
+		"EvalNode": {
+			"As":          "List of names for each expression.\nThe expressions are evaluated in order and the result\nof a previous expression will be available in later expressions\nvia the name provided.\n\nExample:\n   stream\n       |eval(lambda: \"value\" * \"value\", lambda: 1.0 / \"value2\")\n           .as('value2', 'inv_value2')\n\nThe above example calculates two fields from the value and names them\n`value2` and `inv_value2` respectively.",
+			"AsList":      "The name of the field that results from applying the expression.",
+			"Expressions": "",
+			"Keep":        "If called the existing fields will be preserved in addition\nto the new fields being set.\nIf not called then only new fields are preserved.\n\nOptionally intermediate values can be discarded\nby passing a list of field names.\nOnly fields in the list will be kept.\nIf no list is given then all fields, new and old, are kept.\n\nExample:\n   stream\n       |eval(lambda: \"value\" * \"value\", lambda: 1.0 / \"value2\")\n           .as('value2', 'inv_value2')\n           .keep('value', 'inv_value2')\n\nIn the above example the original field `value` is preserved.\nIn addition the new field `value2` is calculated and used in evaluating\n`inv_value2` but is discarded before the point is sent on to children nodes.\nThe resulting point has only two fields `value` and `inv_value2`.",
+			"KeepFlag":    "",
+			"KeepList":    "List of fields to keep\nif empty and KeepFlag is true\nkeep all fields.",
+			"Quiet":       "Suppress errors during evaluation.",
+			"QuiteFlag":   "",
+		},
+		"ExecHandler": {
+			"Command": "The command to execute",
+		},
+		"ExtractNode": {
+			"Pattern":     "The regular expression whose named capture groups are extracted.",
+			"Regroup":     "Group the data by the tags written in addition to the current dimensions.\nBatches are split into a batch for each of the new groups.",
+			"RegroupFlag": "Whether to group by the tags written, in addition to the current dimensions.",
+			"Source":      "The name of the tag or string field to extract from.",
+			"Tags":        "Write the named capture groups as tags instead of fields.",
+			"TagsList":    "The names of the capture groups written as tags.",
+		},
+		"FillNode": {
+			"Every":     "The expected interval between points.",
+			"MaxPoints": "The maximum number of points generated for a gap.\nGaps missing more points are not filled.\nDefault: 1000",
+			"Value":     "The value of the fields of generated points.\nOptions are:\n\n  - null - (default) fill fields with null.\n    Lambda expressions treat null fields as missing,\n    i.e. isPresent() is false and the ?? operator uses its default.\n  - previous - fill fields with the values of the previous point.\n  - linear - interpolate numeric fields linearly between the previous and next point,\n    other fields are filled with the values of the previous point.\n  - Any numerical value - fill fields with the given value.",
+		},
+		"FromNode": {
+			"ChainMethods":      "",
+			"Database":          "Select the data of one or more databases by name,\nor of the databases matching a regex.\nThe selection only filters the data of the dbrps of the task,\na regex does not add databases to the task.\n\nExample:\n   stream\n       |from()\n           .database(/^prod_/)",
+			"Databases":         "The database names.\nIf empty any database will be used.",
+			"Dimensions":        "The dimensions by which to group to the data.",
+			"Expression":        "An expression to filter the data stream.",
+			"From":              "Creates a new stream node that can be further\nfiltered using the Database, RetentionPolicy, Measurement and Where properties.\nFrom can be called multiple times to create multiple\nindependent forks of the data stream.\n\nExample:\n   // Select the 'cpu' measurement from just the database 'mydb'\n   // and retention policy 'myrp'.\n   var cpu = stream\n       |from()\n           .database('mydb')\n           .retentionPolicy('myrp')\n           .measurement('cpu')\n   // Select the 'load' measurement from any database and retention policy.\n   var load = stream\n       |from()\n           .measurement('load')\n   // Join cpu and load streams and do further processing.\n   cpu\n       |join(load)\n           .as('cpu', 'load')\n       ...",
+			"GroupBy":           "Group the data by a set of tags.\n\nCan pass literal * to group by all dimensions.\nExample:\n stream\n     |from()\n         .groupBy(*)",
+			"Measurement":       "Select the data of one or more measurements by name,\nor of the measurements matching a regex.\n\nExample:\n   stream\n       |from()\n           .measurement('cpu', 'mem')",
+			"Measurements":      "The measurement names.\nIf empty any measurement will be used.",
+			"RetentionPolicies": "The retention policy names.\nIf empty any retention policy will be used.",
+			"RetentionPolicy":   "Select the data of one or more retention policies by name,\nor of the retention policies matching a regex.\nThe selection only filters the data of the dbrps of the task,\na regex does not add retention policies to the task.",
+			"Round":             "Optional duration for rounding timestamps.\nHelpful to ensure data points land on specific boundaries\nExample:\n   stream\n      |from()\n          .measurement('mydata')\n          .round(1s)\n\nAll incoming data will be rounded to the nearest 1 second boundary.",
+			"Truncate":          "Optional duration for truncating timestamps.\nHelpful to ensure data points land on specific boundaries\nExample:\n   stream\n      |from()\n          .measurement('mydata')\n          .truncate(1s)\n\nAll incoming data will be truncated to 1 second resolution.",
+			"Where":             "Filter the current stream using the given expression.\nThis expression is a Kapacitor expression. Kapacitor\nexpressions are a superset of InfluxQL WHERE expressions.\nSee the [expression](https://docs.influxdata.com/kapacitor/latest/tick/expr/) docs for more information.\n\nMultiple calls to the Where method will `AND` together each expression.\n\nExample:\n   stream\n      |from()\n         .where(lambda: condition1)\n         .where(lambda: condition2)\n\nThe above is equivalent to this\nExample:\n   stream\n      |from()\n         .where(lambda: condition1 AND condition2)\n\nNOTE: Becareful to always use `|from` if you want multiple different streams.\n\nExample:\n var data = stream\n     |from()\n         .measurement('cpu')\n var total = data\n     .where(lambda: \"cpu\" == 'cpu-total')\n var others = data\n     .where(lambda: \"cpu\" != 'cpu-total')\n\nThe example above is equivalent to the example below,\nwhich is obviously not what was intended.\n\nExample:\n var data = stream\n     |from()\n         .measurement('cpu')\n         .where(lambda: \"cpu\" == 'cpu-total' AND \"cpu\" != 'cpu-total')\n var total = data\n var others = total\n\nThe example below will create two different streams each selecting\na different subset of the original stream.\n\nExample:\n var data = stream\n     |from()\n         .measurement('cpu')\n var total = stream\n     |from()\n         .measurement('cpu')\n         .where(lambda: \"cpu\" == 'cpu-total')\n var others = stream\n     |from()\n         .measurement('cpu')\n         .where(lambda: \"cpu\" != 'cpu-total')\n\nIf empty then all data points are considered to match.",
+		},
+		"GroupByNode": {
+			"Dimensions": "The dimensions by which to group to the data.",
+		},
+		"HTTPOutNode": {
+			"Endpoint": "The relative path where the cached data is exposed",
+		},
+		"HipChatHandler": {
+			"Room":  "HipChat room in which to post messages.\nIf empty uses the channel from the configuration.",
+			"Token": "HipChat authentication token.\nIf empty uses the token from the configuration.",
+		},
+		"InfluxDBOutNode": {
+			"Buffer":           "Number of points to buffer when writing to InfluxDB.\nDefault: 1000",
+			"Cluster":          "The name of the InfluxDB instance to connect to.\nIf empty the configured default will be used.",
+			"Database":         "The name of the database.",
+			"FlushInterval":    "Write points to InfluxDB after interval even if buffer is not full.\nDefault: 10s",
+			"Measurement":      "The name of the measurement.",
+			"Precision":        "The precision to use when writing the data.",
+			"RetentionPolicy":  "The name of the retention policy.",
+			"Tag":              "Add a static tag to all data points.\nTag can be called more than once.",
+			"Tags":             "Static set of tags to add to all data points before writing them.",
+			"WriteConsistency": "The write consistency to use when writing the data.",
+		},
+		"InfluxQLNode": {
+			"As":            "The name of the field, defaults to the name of\nfunction used (i.e. .mean -> 'mean')",
+			"Field":         "",
+			"Method":        "",
+			"PointTimes":    "",
+			"ReduceCreater": "",
+			"UsePointTimes": "Use the time of the selected point instead of the time of the batch.\n\nOnly applies to selector functions like first, last, top, bottom, etc.\nAggregation functions always use the batch time.",
+		},
+		"JiraHandler": {
+			"Description": "Description of the issue.\nCan be a template and has access to the same data as the AlertNode.Details property.\nDefault is the details of the alert.",
+			"Field":       "Set an additional field of the issue, i.e. a custom field.\nThe value can be a template and has access to the same data as the AlertNode.Details property.",
+			"FieldsMap":   "Additional issue fields.",
+			"IssueType":   "Jira issue type.\nIf empty uses the issue type from the configuration.",
+			"MinLevel":    "Lowest level for which an issue is opened, one of INFO, WARNING or CRITICAL.\nDefault is WARNING.",
+			"Project":     "Jira project key.\nIf empty uses the project from the configuration.",
+			"Summary":     "Summary of the issue.\nCan be a template and has access to the same data as the AlertNode.Details property.\nDefault is the message of the alert.",
+		},
+		"JoinNode": {
+			"As":           "Prefix names for all fields from the respective nodes.\nEach field from the parent nodes will be prefixed with the provided name and a '.'.\nSee the example above.\n\nThe names cannot have a dot '.' character.",
+			"ChainMethods": "",
+			"Dimensions":   "The dimensions on which to join",
+			"Fill":         "Fill the data.\nThe fill option implies the type of join: inner or full outer\nOptions are:\n\n  - none - (default) skip rows where a point is missing, inner join.\n  - null - fill missing points with null, full outer join.\n  - Any numerical value - fill fields with given value, full outer join.",
+			"Names":        "The alias names of the two parents.\nNote:\n      Names[1] corresponds to the left  parent\n      Names[0] corresponds to the right parent",
+			"On":           "Join on specific dimensions.\nFor example given two measurements:\n\n1. building_power -- tagged by building, value is the total power consumed by the building.\n2. floor_power -- tagged by building and floor, values is the total power consumed by the floor.\n\nYou want to calculate the percentage of the total building power consumed by each floor.\n\nExample:\n   var buidling = stream\n       |from()\n           .measurement('building_power')\n           .groupBy('building')\n   var floor = stream\n       |from()\n           .measurement('floor_power')\n           .groupBy('building', 'floor')\n   building\n       |join(floor)\n           .as('building', 'floor')\n           .on('building')\n       |eval(lambda: \"floor.value\" / \"building.value\")\n           ... // Values here are grouped by 'building' and 'floor'",
+			"StreamName":   "The name of this new joined data stream.\nIf empty the name of the left parent is used.",
+			"Tolerance":    "The maximum duration of time that two incoming points\ncan be apart and still be considered to be equal in time.\nThe joined data point's time will be rounded to the nearest\nmultiple of the tolerance duration.",
+		},
+		"LogHandler": {
+			"FilePath": "Absolute path the the log file.\nIt will be created if it does not exist.",
+			"Mode":     "File's mode and permissions, default is 0600",
+		},
+		"LogNode": {
+			"Level":  "The level at which to log the data.\nOne of: DEBUG, INFO, WARN, ERROR\nDefault: INFO",
+			"Prefix": "Optional prefix to add to all log messages",
+		},
+		"LookupJoinNode": {
+			"As":           "Prefix the names of the reference fields with the name and a '.'.\nWithout a prefix the reference fields are added as is,\nexisting fields of the data are never overwritten.\n\nThe name cannot have a dot '.' character.",
+			"ChainMethods": "",
+			"Default":      "Set the default value of a reference field when no table entry exists.\nThe name is the name of the reference field without the prefix.",
+			"Defaults":     "Default reference field values used when no table entry exists.",
+			"Dimensions":   "The dimensions on which to lookup the reference data.\nIf empty the latest reference point is used for all data.",
+			"On":           "Lookup the reference data on specific tags.\nBoth the data and the reference data need to have the tags.",
+			"Prefix":       "Optional prefix for the names of the reference fields.",
+			"Ttl":          "The maximum age of a table entry relative to the time of the data point.\nOlder entries are treated as missing and are dropped from the table.\nIf zero the entries are kept indefinitely.",
+		},
+		"NameSelection": {
+			"IsEmpty": "Whether the selection selects any name.",
+			"Matches": "Whether the selection selects the name.",
+			"Names":   "",
+			"Regex":   "",
+		},
+		"OpsGenieHandler": {
+			"Recipients":     "The list of recipients to be alerted. If empty defaults to the recipients from the configuration.",
+			"RecipientsList": "OpsGenie Recipients.",
+			"Teams":          "The list of teams to be alerted. If empty defaults to the teams from the configuration.",
+			"TeamsList":      "OpsGenie Teams.",
+		},
+		"PagerDuty2Handler": {
+			"RoutingKey": "The integration key to use for the alert.\nDefaults to the value in the configuration if empty.",
+		},
+		"PagerDutyHandler": {
+			"ServiceKey": "The service key to use for the alert.\nDefaults to the value in the configuration if empty.",
+		},
+		"Pipeline": {
+			"Check":         "Statically check the pipeline without any data.\nThe edges of the nodes must match and expressions must type check\nagainst the field types that are known from the nodes before them.\n\nThe types of fields are only known when they are created by the pipeline,\ni.e. by eval, default and InfluxQL nodes, other fields are not checked.\n\nThe checks are not part of CreatePipeline, so that they only run\nwhen a task is defined and not each time it is loaded.",
+			"Dot":           "Return a graphviz .dot formatted byte array.",
+			"MarshalJSON":   "Marshal the pipeline to JSON.\nOnly the calls recorded while evaluating the TICKscript of the pipeline are marshaled,\nnodes created or modified directly in Go are not.",
+			"UnmarshalJSON": "Unmarshal the pipeline from JSON by evaluating the TICKscript of its calls.\nUDFs and the global deadman switch are not available, use CreatePipelineFromJSON instead if they are needed.",
+			"Walk":          "Walks the entire pipeline and calls func f on each node exactly once.\nf will be called on a node n only after all of its parents have already had f called.",
+		},
+		"PostHandler": {
+			"URL": "The POST URL.",
+		},
+		"QueryNode": {
+			"Align":        "Align start and stop times for quiries with even boundaries of the QueryNode.Every property.\nDoes not apply if using the QueryNode.Cron property.\nIf the node has a timezone the boundaries are aligned in the wall clock time of the time zone.",
+			"AlignFlag":    "Align start and end times with the Every value\nDoes not apply if Cron is used.",
+			"ChainMethods": "",
+			"Cluster":      "The name of a configured InfluxDB cluster.\nIf empty the default cluster will be used.",
+			"Cron":         "Define a schedule using a cron syntax.\n\nThe specific cron implementation is documented here:\nhttps://github.com/gorhill/cronexpr#implementation\n\nThe schedule is in the timezone of the node, or the local time zone of the server.\n\nThe Cron property is mutually exclusive with the Every property.",
+			"Dimensions":   "The list of dimensions for the group-by clause.",
+			"Every":        "How often to query InfluxDB.\n\nThe Every property is mutually exclusive with the Cron property.",
+			"Fill":         "Fill the data.\nOptions are:\n\n  - Any numerical value\n  - null - exhibits the same behavior as the default\n  - previous - reports the value of the previous window\n  - none - suppresses timestamps and values where the value is null",
+			"GroupBy":      "Group the data by a set of dimensions.\nCan specify one time dimension.\n\nThis property adds a `GROUP BY` clause to the query\nso all the normal behaviors when quering InfluxDB with a `GROUP BY` apply.\nMore details: https://influxdb.com/docs/v0.9/query_language/data_exploration.html#the-group-by-clause\n\nExample:\n   batch\n       |query(...)\n           .groupBy(time(10s), 'tag1', 'tag2'))",
+			"Offset":       "How far back in time to query from the current time\n\nFor example an Offest of 2 hours and an Every of 5m,\nKapacitor will query InfluxDB every 5 minutes for the window of data 2 hours ago.\n\nThis applies to Cron schedules as well. If the cron specifies to run every Sunday at\n1 AM and the Offset is 1 hour. Then at 1 AM on Sunday the data from 12 AM will be queried.",
+			"Period":       "The period or length of time that will be queried from InfluxDB",
+			"QueryStr":     "The query text",
+		},
+		"ReduceCreater": {
+			"CreateBooleanBulkFloatReducer":   "",
+			"CreateBooleanBulkIntegerReducer": "",
+			"CreateBooleanBulkReducer":        "",
+			"CreateBooleanBulkStringReducer":  "",
+			"CreateBooleanFloatReducer":       "",
+			"CreateBooleanIntegerReducer":     "",
+			"CreateBooleanReducer":            "",
+			"CreateBooleanStringReducer":      "",
+			"CreateFloatBooleanReducer":       "",
+			"CreateFloatBulkBooleanReducer":   "",
+			"CreateFloatBulkIntegerReducer":   "",
+			"CreateFloatBulkReducer":          "",
+			"CreateFloatBulkStringReducer":    "",
+			"CreateFloatIntegerReducer":       "",
+			"CreateFloatReducer":              "",
+			"CreateFloatStringReducer":        "",
+			"CreateIntegerBooleanReducer":     "",
+			"CreateIntegerBulkBooleanReducer": "",
+			"CreateIntegerBulkFloatReducer":   "",
+			"CreateIntegerBulkReducer":        "",
+			"CreateIntegerBulkStringReducer":  "",
+			"CreateIntegerFloatReducer":       "",
+			"CreateIntegerReducer":            "",
+			"CreateIntegerStringReducer":      "",
+			"CreateStringBooleanReducer":      "",
+			"CreateStringBulkBooleanReducer":  "",
+			"CreateStringBulkFloatReducer":    "",
+			"CreateStringBulkIntegerReducer":  "",
+			"CreateStringBulkReducer":         "",
+			"CreateStringFloatReducer":        "",
+			"CreateStringIntegerReducer":      "",
+			"CreateStringReducer":             "",
+			"IsSimpleSelector":                "",
+			"IsStreamTransformation":          "",
+			"TopBottomCallInfo":               "",
+		},
+		"SampleNode": {
+			"Duration": "Keep one point or batch every Duration",
+			"N":        "Keep every N point or batch",
+		},
+		"ServiceNowHandler": {
+			"Description": "Description of the incident.\nCan be a template and has access to the same data as the AlertNode.Details property.\nDefault is the details of the alert.",
+			"Field":       "Set an additional field of the incident, i.e. 'assignment_group'.\nThe value can be a template and has access to the same data as the AlertNode.Details property.",
+			"FieldsMap":   "Additional incident fields.",
+			"MinLevel":    "Lowest level for which an incident is opened, one of INFO, WARNING or CRITICAL.\nDefault is WARNING.",
+			"Summary":     "Short description of the incident.\nCan be a template and has access to the same data as the AlertNode.Details property.\nDefault is the message of the alert.",
+		},
+		"ShiftNode": {
+			"Shift": "Keep one point or batch every Duration",
+		},
+		"SlackField": {
+			"Title": "",
+			"Value": "Can be a template and has access to the same data as the AlertNode.Details property.",
+		},
+		"SlackHandler": {
+			"Channel":         "Slack channel in which to post messages.\nIf empty uses the channel from the configuration.",
+			"Field":           "Add a field to the message attachment.\nThe value can be a template and has access to the same data as the AlertNode.Details property.",
+			"FieldsList":      "Fields of the message attachment.",
+			"IsThreaded":      "Whether to thread alert messages.",
+			"ShortField":      "Add a short field to the message attachment.\nShort fields are displayed side by side and before all other fields.\nThe value can be a template and has access to the same data as the AlertNode.Details property.",
+			"ShortFieldsList": "Short fields of the message attachment.",
+			"Thread":          "Thread the alert messages.\nThe first message of an alert starts a new thread,\nall following messages, including the recovery, are posted as replies to it.\nOnce the alert recovers the next message starts a new thread.\nThreading requires a Web API token in the configuration of the workspace.\n\nExample:\n   stream\n        |alert()\n            .slack()\n            .thread()",
+			"Title":           "Title of the message attachment.\nCan be a template and has access to the same data as the AlertNode.Details property.\nDefault is no title.",
+			"Workspace":       "Name of the configured Slack workspace to post messages to.\nIf empty uses the top level workspace from the configuration.\nThe workspace must exist when the task is defined.",
+		},
+		"StatsNode": {
+			"Interval":   "",
+			"SourceNode": "",
+		},
+		"StreamNode": {
+			"From": "Creates a new FromNode that can be further\nfiltered using the Database, RetentionPolicy, Measurement and Where properties.\nFrom can be called multiple times to create multiple\nindependent forks of the data stream.\n\nExample:\n   // Select the 'cpu' measurement from just the database 'mydb'\n   // and retention policy 'myrp'.\n   var cpu = stream\n       |from()\n           .database('mydb')\n           .retentionPolicy('myrp')\n           .measurement('cpu')\n   // Select the 'load' measurement from any database and retention policy.\n   var load = stream\n       |from()\n           .measurement('load')\n   // Join cpu and load streams and do further processing.\n   cpu\n       |join(load)\n           .as('cpu', 'load')\n       ...",
+		},
+		"SyslogHandler": {
+			"Facility": "The syslog facility of the messages, i.e. 'daemon', 'auth' or 'local0' to 'local7'.\nIf empty uses the facility from the configuration.",
+		},
+		"TopBottomCallInfo": {
+			"FieldsAndTags": "",
+		},
+		"UDFNode": {
+			"CallChainMethod": "",
+			"Desc":            "",
+			"HasChainMethod":  "",
+			"HasProperty":     "",
+			"Options":         "Options that were set on the node",
+			"Property":        "",
+			"SetProperty":     "",
+			"UDFName":         "",
+		},
+		"UnionNode": {
+			"Rename": "The new name of the stream.\nIf empty the name of the left node\n(i.e. `leftNode.union(otherNode1, otherNode2)`) is used.",
+		},
+		"VictorOpsHandler": {
+			"RoutingKey": "The routing key to use for the alert.\nDefaults to the value in the configuration if empty.",
+		},
+		"WhereNode": {
+			"Expression": "The expression predicate.",
+			"Where":      "And another expression onto the existing expression.",
+		},
+		"WindowNode": {
+			"Align":           "Wether to align the window edges with the zero time.\nIf not aligned the window starts and ends relative to the\nfirst data point it receives.\n\nIf the node has a timezone the edges are aligned in the wall clock time of the time zone,\ni.e. a window with an every of 1d ends at midnight.\nWindows of whole days are 23 or 25 hours long on the days daylight saving time starts or ends.",
+			"AlignFlag":       "Wether to align the window edges with the zero time",
+			"AllowedLateness": "How long after the end of a window points for the window are accepted.\nThe window is held open until a point past the end of the window plus the allowed lateness,\nthe watermark, has been received for the group.\nPoints older than the open windows are dropped, unless sent to a late node.\nOnly applies to windows defined by period and every.",
+			"Every":           "How often the current window is emitted into the pipeline.",
+			"EveryCount":      "How often, in number of points per group, the window is emitted into the pipeline.\nThe first window is emitted once PeriodCount points have been received.",
+			"Late":            "Create a stream of the points arriving too late for the window.\nWithout a late node such points are dropped.\nThe window must have an allowedLateness.",
+			"Period":          "The period, or length in time, of the window.",
+			"PeriodCount":     "The number of points per group in the window.\nMust be used together with EveryCount instead of Period and Every.",
+			"Reemit":          "Emit each window as soon as it ends, without waiting for the allowed lateness.\nEach late point arriving within the allowed lateness causes the windows containing it\nto be emitted again, corrected with the late point.",
+			"ReemitFlag":      "Wether to emit windows at their end and emit them again for each late point.",
+			"Session":         "Window the data of each group by sessions of activity.\nA session window is emitted once no data has been received\nfor the group for longer than the gap.\nThe session ends when data for any group arrives after the gap has passed,\nsince time is based on the incoming data points.",
+			"SessionGap":      "The inactivity gap closing a session window.",
+		},
+		"chainnode": {
+			"Alert":       "Create an alert node, which can trigger alerts.",
+			"Bottom":      "Select the bottom `num` points for `field` and sort by any extra tags or fields.",
+			"Count":       "Count the number of points.",
+			"Default":     "Create a node that can set defaults for missing tags or fields.",
+			"Derivative":  "Create a new node that computes the derivative of adjacent points.",
+			"Distinct":    "Produce batch of only the distinct points.",
+			"Elapsed":     "Compute the elapsed time between points",
+			"Eval":        "Create an eval node that will evaluate the given transformation function to each data point.\n A list of expressions may be provided and will be evaluated in the order they are given\nand results of previous expressions are made available to later expressions.",
+			"Extract":     "Create a node that extracts the named capture groups of a regular expression,\nmatched against a tag or string field, into new tags or fields.",
+			"Fill":        "Create a new node that fills gaps in the data with generated points.",
+			"First":       "Select the first point.",
+			"GroupBy":     "Group the data by a set of tags.\n\nCan pass literal * to group by all dimensions.\nExample:\n   |groupBy(*)",
+			"HttpOut":     "Create an http output node that caches the most recent data it has received.\nThe cached data is available at the given endpoint.\nThe endpoint is the relative path from the API endpoint of the running task.\nFor example if the task endpoint is at \"/api/v1/task/<task_name>\" and endpoint is\n\"top10\", then the data can be requested from \"/api/v1/task/<task_name>/top10\".",
+			"InfluxDBOut": "Create an influxdb output node that will store the incoming data into InfluxDB.",
+			"Join":        "Join this node with other nodes. The data is joined on timestamp.",
+			"Last":        "Select the last point.",
+			"Log":         "Create a node that logs all data it receives.",
+			"LookupJoin":  "Enrich this node with the latest data of a reference node, see LookupJoinNode.",
+			"Max":         "Select the maximum point.",
+			"Mean":        "Compute the mean of the data.",
+			"Median":      "Compute the median of the data. Note, this method is not a selector,\nif you want the median point use .percentile(field, 50.0).",
+			"Min":         "Select the minimum point.",
+			"Percentile":  "Select a point at the given percentile. This is a selector function, no interpolation between points is performed.",
+			"Sample":      "Create a new node that samples the incoming points or batches.\n\nOne point will be emitted every count or duration specified.",
+			"Shift":       "Create a new node that shifts the incoming points or batches in time.",
+			"Spread":      "Compute the difference between min and max points.",
+			"Stddev":      "Compute the standard deviation.",
+			"Sum":         "Compute the sum of all values.",
+			"Top":         "Select the top `num` points for `field` and sort by any extra tags or fields.",
+			"Union":       "Perform the union of this node and all other given nodes.",
+			"Where":       "Create a new node that filters the data stream by a given expression.",
+			"Window":      "Create a new node that windows the stream by time.\n\nNOTE: Window can only be applied to stream edges.",
+		},
+		"node": {
+			"Children":          "",
+			"Deadman":           "Helper function for creating an alert on low throughput, aka deadman's switch.\n\n- Threshold -- trigger alert if throughput drops below threshold in points/interval.\n- Interval -- how often to check the throughput.\n- Expressions -- optional list of expressions to also evaluate. Useful for time of day alerting.\n\nExample:\n   var data = stream\n       |from()...\n   // Trigger critical alert if the throughput drops below 100 points per 10s and checked every 10s.\n   data\n       |deadman(100.0, 10s)\n   //Do normal processing of data\n   data...\n\nThe above is equivalent to this\nExample:\n   var data = stream\n       |from()...\n   // Trigger critical alert if the throughput drops below 100 points per 10s and checked every 10s.\n   data\n       |stats(10s)\n       |derivative('emitted')\n           .unit(10s)\n           .nonNegative()\n       |alert()\n           .id('node \\'stream0\\' in task \\'{{ .TaskName }}\\'')\n           .message('{{ .ID }} is {{ if eq .Level \"OK\" }}alive{{ else }}dead{{ end }}: {{ index .Fields \"emitted\" | printf \"%0.3f\" }} points/10s.')\n           .crit(lamdba: \"emitted\" <= 100.0)\n   //Do normal processing of data\n   data...\n\nThe `id` and `message` alert properties can be configured globally via the 'deadman' configuration section.\n\nSince the AlertNode is the last piece it can be further modified as normal.\nExample:\n   var data = stream\n       |from()...\n   // Trigger critical alert if the throughput drops below 100 points per 1s and checked every 10s.\n   data\n       |deadman(100.0, 10s)\n           .slack()\n           .channel('#dead_tasks')\n   //Do normal processing of data\n   data...\n\nYou can specify additional lambda expressions to further constrain when the deadman's switch is triggered.\nExample:\n   var data = stream\n       |from()...\n   // Trigger critical alert if the throughput drops below 100 points per 10s and checked every 10s.\n   // Only trigger the alert if the time of day is between 8am-5pm.\n   data\n       |deadman(100.0, 10s, lambda: hour(\"time\") >= 8 AND hour(\"time\") <= 17)\n   //Do normal processing of data\n   data...",
+			"Desc":              "",
+			"ID":                "",
+			"Location":          "",
+			"Name":              "",
+			"Parents":           "",
+			"Provides":          "",
+			"RecordChainMethod": "",
+			"RecordProperty":    "",
+			"SetName":           "",
+			"SetPosition":       "",
+			"Stats":             "Create a new stream of data that contains the internal statistics of the node.\nThe interval represents how often to emit the statistics based on real time.\nThis means the interval time is independent of the times of the data points the source node is receiving.",
+			"Timezone":          "The time zone of the node, i.e. 'Europe/Berlin'.\n\nLambda expressions, the alignment of windows and the schedule of batch queries\nuse the wall clock time of the time zone, including its daylight saving time transitions.\nNodes without a time zone use the time zone of their first parent,\nso the time zone of a whole task is set on its from or query node.\n\nWithout a time zone lambda expressions and cron schedules use the local time zone of the server\nand windows and batch queries are aligned in UTC.\n\nExample:\n   stream\n       |from()\n           .measurement('requests')\n           .timezone('Europe/Berlin')\n       |where(lambda: hour(\"time\") >= 9 AND hour(\"time\") < 17)\n       |window()\n           .period(1d)\n           .every(1d)\n           .align()\n\nThe window emits the requests of each day from midnight to midnight in Berlin.",
+			"Wants":             "",
+		},
+	},
+	ignored: map[string]map[string]bool{
+		"AlertNode": {
+			"AlertaHandlers":           true,
+			"AlertmanagerHandlers":     true,
+			"AllFlag":                  true,
+			"ChainMethods":             true,
+			"EmailHandlers":            true,
+			"ExecHandlers":             true,
+			"FlapHigh":                 true,
+			"FlapLow":                  true,
+			"HipChatHandlers":          true,
+			"IsStateChangesOnly":       true,
+			"JiraHandlers":             true,
+			"LogHandlers":              true,
+			"OpsGenieHandlers":         true,
+			"PagerDuty2Handlers":       true,
+			"PagerDutyHandlers":        true,
+			"PostHandlers":             true,
+			"SensuHandlers":            true,
+			"ServiceNowHandlers":       true,
+			"SlackHandlers":            true,
+			"SparklineField":           true,
+			"StateChangesOnlyDuration": true,
+			"SyslogHandlers":           true,
+			"TalkHandlers":             true,
+			"UseFlapping":              true,
+			"VictorOpsHandlers":        true,
+		},
+		"AlertaHandler": {
+			"Service": true,
+		},
+		"DefaultNode": {
+			"Fields": true,
+		},
+		"DerivativeNode": {
+			"Field":           true,
+			"NonNegativeFlag": true,
+		},
+		"EmailHandler": {
+			"ToList": true,
+		},
+		"EvalNode": {
+			"AsList":      true,
+			"Expressions": true,
+			"KeepFlag":    true,
+			"KeepList":    true,
+			"QuiteFlag":   true,
+		},
+		"ExecHandler": {
+			"Command": true,
+		},
+		"ExtractNode": {
+			"Pattern":     true,
+			"RegroupFlag": true,
+			"Source":      true,
+			"TagsList":    true,
+		},
+		"FillNode": {
+			"Every": true,
+		},
+		"FromNode": {
+			"ChainMethods":      true,
+			"Databases":         true,
+			"Dimensions":        true,
+			"Expression":        true,
+			"Measurements":      true,
+			"RetentionPolicies": true,
+		},
+		"GroupByNode": {
+			"Dimensions": true,
+		},
+		"HTTPOutNode": {
+			"Endpoint": true,
+		},
+		"InfluxDBOutNode": {
+			"Tags": true,
+		},
+		"InfluxQLNode": {
+			"Field":         true,
+			"Method":        true,
+			"PointTimes":    true,
+			"ReduceCreater": true,
+		},
+		"JiraHandler": {
+			"FieldsMap": true,
+		},
+		"JoinNode": {
+			"ChainMethods": true,
+			"Dimensions":   true,
+			"Names":        true,
+		},
+		"LogHandler": {
+			"FilePath": true,
+		},
+		"LookupJoinNode": {
+			"ChainMethods": true,
+			"Defaults":     true,
+			"Dimensions":   true,
+			"Prefix":       true,
+		},
+		"OpsGenieHandler": {
+			"RecipientsList": true,
+			"TeamsList":      true,
+		},
+		"Pipeline": {
+			"Check":         true,
+			"Dot":           true,
+			"MarshalJSON":   true,
+			"UnmarshalJSON": true,
+			"Walk":          true,
+		},
+		"PostHandler": {
+			"URL": true,
+		},
+		"QueryNode": {
+			"AlignFlag":    true,
+			"ChainMethods": true,
+			"Dimensions":   true,
+			"QueryStr":     true,
+		},
+		"SampleNode": {
+			"Duration": true,
+			"N":        true,
+		},
+		"ServiceNowHandler": {
+			"FieldsMap": true,
+		},
+		"ShiftNode": {
+			"Shift": true,
+		},
+		"SlackHandler": {
+			"FieldsList":      true,
+			"IsThreaded":      true,
+			"ShortFieldsList": true,
+		},
+		"StatsNode": {
+			"Interval":   true,
+			"SourceNode": true,
+		},
+		"UDFNode": {
+			"CallChainMethod": true,
+			"Desc":            true,
+			"HasChainMethod":  true,
+			"HasProperty":     true,
+			"Options":         true,
+			"Property":        true,
+			"SetProperty":     true,
+		},
+		"WhereNode": {
+			"Expression": true,
+		},
+		"WindowNode": {
+			"AlignFlag":  true,
+			"ReemitFlag": true,
+			"SessionGap": true,
+		},
+		"node": {
+			"Children":          true,
+			"Desc":              true,
+			"ID":                true,
+			"Location":          true,
+			"Name":              true,
+			"Parents":           true,
+			"Provides":          true,
+			"RecordChainMethod": true,
+			"RecordProperty":    true,
+			"SetName":           true,
+			"SetPosition":       true,
+			"Wants":             true,
+		},
+	},
+	embedded: map[string][]string{
+		"AlertNode":           {"chainnode"},
+		"AlertaHandler":       {"AlertNode"},
+		"AlertmanagerHandler": {"AlertNode"},
+		"BatchNode":           {"node"},
+		"DefaultNode":         {"chainnode"},
+		"DerivativeNode":      {"chainnode"},
+		"EmailHandler":        {"AlertNode"},
+		"EvalNode":            {"chainnode"},
+		"ExecHandler":         {"AlertNode"},
+		"ExtractNode":         {"chainnode"},
+		"FillNode":            {"chainnode"},
+		"FromNode":            {"chainnode"},
+		"GroupByNode":         {"chainnode"},
+		"HTTPOutNode":         {"chainnode"},
+		"HipChatHandler":      {"AlertNode"},
+		"InfluxDBOutNode":     {"node"},
+		"InfluxQLNode":        {"chainnode"},
+		"JiraHandler":         {"AlertNode"},
+		"JoinNode":            {"chainnode"},
+		"LateNode":            {"chainnode"},
+		"LogHandler":          {"AlertNode"},
+		"LogNode":             {"chainnode"},
+		"LookupJoinNode":      {"chainnode"},
+		"NoOpNode":            {"chainnode"},
+		"OpsGenieHandler":     {"AlertNode"},
+		"PagerDuty2Handler":   {"AlertNode"},
+		"PagerDutyHandler":    {"AlertNode"},
+		"PostHandler":         {"AlertNode"},
+		"QueryNode":           {"chainnode"},
+		"SampleNode":          {"chainnode"},
+		"SensuHandler":        {"AlertNode"},
+		"ServiceNowHandler":   {"AlertNode"},
+		"ShiftNode":           {"chainnode"},
+		"SlackHandler":        {"AlertNode"},
+		"StatsNode":           {"chainnode"},
+		"StreamNode":          {"node"},
+		"SyslogHandler":       {"AlertNode"},
+		"TalkHandler":         {"AlertNode"},
+		"UDFNode":             {"chainnode"},
+		"UnionNode":           {"chainnode"},
+		"VictorOpsHandler":    {"AlertNode"},
+		"WhereNode":           {"chainnode"},
+		"WindowNode":          {"chainnode"},
+		"chainnode":           {"node"},
+	},
+}
@@ -0,0 +1,48 @@
+package main
+
+//go:generate go run gendocs.go ../../../pipeline docs.gen.go
+
+// Index of the doc comments of the pipeline types, fields and methods,
+// read from the pipeline package source the same way tickdoc reads them.
+// The index of the pipeline package is generated into docs.gen.go,
+// so the hover docs do not depend on the source being available at runtime.
+type docIndex struct {
+	types map[string]string
+	// Docs of the fields and methods of each type.
+	members map[string]map[string]string
+	// Fields and methods hidden from the TICKscript documentation.
+	ignored map[string]map[string]bool
+	// Names of the anonymous fields of each type.
+	embedded map[string][]string
+}
+
+// The doc of the type.
+func (d *docIndex) typeDoc(name string) string {
+	if d == nil {
+		return ""
+	}
+	return d.types[name]
+}
+
+// The doc of the field or method of the type, including the ones promoted from anonymous fields.
+// Reports whether the member is hidden from the documentation.
+func (d *docIndex) memberDoc(typ, name string) (doc string, ignored bool) {
+	if d == nil {
+		return "", false
+	}
+	seen := make(map[string]bool)
+	queue := []string{typ}
+	for len(queue) > 0 {
+		t := queue[0]
+		queue = queue[1:]
+		if seen[t] {
+			continue
+		}
+		seen[t] = true
+		if doc, ok := d.members[t][name]; ok {
+			return doc, d.ignored[t][name]
+		}
+		queue = append(queue, d.embedded[t]...)
+	}
+	return "", false
+}
@@ -0,0 +1,280 @@
+// +build ignore
+
+// Gendocs generates docs.gen.go with the doc comments of the pipeline package,
+// so the hover docs are part of the ticklsp binary.
+//
+// Usage: go run gendocs.go PIPELINE_DIR OUTPUT_FILE
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"log"
+	"os"
+	"sort"
+	"strings"
+)
+
+const tickIgnore = "tick:ignore"
+
+type docIndex struct {
+	types    map[string]string
+	members  map[string]map[string]string
+	ignored  map[string]map[string]bool
+	embedded map[string][]string
+}
+
+func main() {
+	if len(os.Args) != 3 {
+		log.Fatal("usage: go run gendocs.go PIPELINE_DIR OUTPUT_FILE")
+	}
+	d, err := loadDocs(os.Args[1])
+	if err != nil {
+		log.Fatal(err)
+	}
+	src, err := d.source()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := ioutil.WriteFile(os.Args[2], src, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func loadDocs(dir string) (*docIndex, error) {
+	fset := token.NewFileSet()
+	skipTest := func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go")
+	}
+	pkgs, err := parser.ParseDir(fset, dir, skipTest, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	d := &docIndex{
+		types:    make(map[string]string),
+		members:  make(map[string]map[string]string),
+		ignored:  make(map[string]map[string]bool),
+		embedded: make(map[string][]string),
+	}
+	for _, pkg := range pkgs {
+		for _, f := range pkg.Files {
+			for _, decl := range f.Decls {
+				switch decl := decl.(type) {
+				case *ast.GenDecl:
+					d.addGenDecl(decl)
+				case *ast.FuncDecl:
+					d.addFuncDecl(decl)
+				}
+			}
+		}
+	}
+	d.pruneUnexported()
+	return d, nil
+}
+
+// Remove the unexported types not embedded, directly or indirectly, in exported types.
+func (d *docIndex) pruneUnexported() {
+	used := make(map[string]bool)
+	var queue []string
+	for t := range d.embedded {
+		if ast.IsExported(t) {
+			queue = append(queue, t)
+		}
+	}
+	for len(queue) > 0 {
+		t := queue[0]
+		queue = queue[1:]
+		for _, e := range d.embedded[t] {
+			if !used[e] {
+				used[e] = true
+				queue = append(queue, e)
+			}
+		}
+	}
+	for t := range d.members {
+		if !ast.IsExported(t) && !used[t] {
+			delete(d.members, t)
+			delete(d.ignored, t)
+		}
+	}
+	for t := range d.embedded {
+		if !ast.IsExported(t) && !used[t] {
+			delete(d.embedded, t)
+		}
+	}
+}
+
+func (d *docIndex) addGenDecl(decl *ast.GenDecl) {
+	if decl.Tok != token.TYPE {
+		return
+	}
+	for _, spec := range decl.Specs {
+		t := spec.(*ast.TypeSpec)
+		s, ok := t.Type.(*ast.StructType)
+		if !ok {
+			continue
+		}
+		// Unexported types are only indexed for the members they promote to exported types.
+		if ast.IsExported(t.Name.Name) {
+			doc := decl.Doc
+			if t.Doc != nil {
+				doc = t.Doc
+			}
+			d.types[t.Name.Name] = docText(doc)
+		}
+		for _, field := range s.Fields.List {
+			if field.Names == nil {
+				if name := typeName(field.Type); name != "" {
+					d.embedded[t.Name.Name] = append(d.embedded[t.Name.Name], name)
+				}
+				continue
+			}
+			for _, name := range field.Names {
+				d.addMember(t.Name.Name, name.Name, field.Doc)
+			}
+		}
+	}
+}
+
+func (d *docIndex) addFuncDecl(decl *ast.FuncDecl) {
+	if decl.Recv == nil || len(decl.Recv.List) == 0 {
+		return
+	}
+	if recv := typeName(decl.Recv.List[0].Type); recv != "" {
+		d.addMember(recv, decl.Name.Name, decl.Doc)
+	}
+}
+
+func (d *docIndex) addMember(typ, name string, doc *ast.CommentGroup) {
+	if !ast.IsExported(name) {
+		return
+	}
+	if d.members[typ] == nil {
+		d.members[typ] = make(map[string]string)
+		d.ignored[typ] = make(map[string]bool)
+	}
+	d.members[typ][name] = docText(doc)
+	d.ignored[typ][name] = isIgnored(doc)
+}
+
+// The name of a type or pointer to a type.
+func typeName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return t.Name
+	case *ast.StarExpr:
+		return typeName(t.X)
+	}
+	return ""
+}
+
+func isIgnored(cg *ast.CommentGroup) bool {
+	if cg == nil {
+		return false
+	}
+	for _, l := range cg.List {
+		if strings.TrimSpace(strings.TrimLeft(l.Text, "/")) == tickIgnore {
+			return true
+		}
+	}
+	return false
+}
+
+// The text of the comment without the tickdoc directives.
+func docText(cg *ast.CommentGroup) string {
+	if cg == nil {
+		return ""
+	}
+	lines := strings.Split(cg.Text(), "\n")
+	text := lines[:0]
+	for _, l := range lines {
+		if strings.HasPrefix(strings.TrimSpace(l), "tick:") {
+			continue
+		}
+		text = append(text, l)
+	}
+	return strings.TrimSpace(strings.Join(text, "\n"))
+}
+
+// The Go source of the index, with the entries sorted so the output is stable.
+func (d *docIndex) source() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString("// Generated by gendocs.go from the pipeline package, DO NOT EDIT.\n\n")
+	buf.WriteString("package main\n\n")
+	buf.WriteString("var pipelineDocs = &docIndex{\n")
+	buf.WriteString("types: map[string]string{\n")
+	for _, t := range sortedKeys(d.types) {
+		fmt.Fprintf(&buf, "%q: %q,\n", t, d.types[t])
+	}
+	buf.WriteString("},\n")
+	buf.WriteString("members: map[string]map[string]string{\n")
+	for _, t := range sortedMemberTypes(d.members) {
+		fmt.Fprintf(&buf, "%q: {\n", t)
+		for _, m := range sortedKeys(d.members[t]) {
+			fmt.Fprintf(&buf, "%q: %q,\n", m, d.members[t][m])
+		}
+		buf.WriteString("},\n")
+	}
+	buf.WriteString("},\n")
+	buf.WriteString("ignored: map[string]map[string]bool{\n")
+	for _, t := range sortedMemberTypes(d.members) {
+		var names []string
+		for m, ignored := range d.ignored[t] {
+			if ignored {
+				names = append(names, m)
+			}
+		}
+		if len(names) == 0 {
+			continue
+		}
+		sort.Strings(names)
+		fmt.Fprintf(&buf, "%q: {\n", t)
+		for _, m := range names {
+			fmt.Fprintf(&buf, "%q: true,\n", m)
+		}
+		buf.WriteString("},\n")
+	}
+	buf.WriteString("},\n")
+	buf.WriteString("embedded: map[string][]string{\n")
+	var types []string
+	for t := range d.embedded {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	for _, t := range types {
+		fmt.Fprintf(&buf, "%q: {", t)
+		for i, e := range d.embedded[t] {
+			if i > 0 {
+				buf.WriteString(", ")
+			}
+			fmt.Fprintf(&buf, "%q", e)
+		}
+		buf.WriteString("},\n")
+	}
+	buf.WriteString("},\n")
+	buf.WriteString("}\n")
+	return format.Source(buf.Bytes())
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedMemberTypes(m map[string]map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
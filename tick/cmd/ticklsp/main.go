@@ -0,0 +1,57 @@
+// Ticklsp is a language server for TICKscripts implementing the Language Server Protocol over STDIN and STDOUT.
+//
+// It provides:
+//
+//    * diagnostics from parsing and evaluating the TICKscript into a pipeline,
+//    * completion of the chain methods and properties of the pipeline nodes,
+//    * hover docs from the comments of the pipeline package, embedded when ticklsp is built,
+//    * go to definition of vars and functions,
+//    * formatting via tick.Format.
+//
+// Libraries are imported from the .tick files in the directory of the TICKscript.
+// TICKscripts calling UDFs are only parsed, since the UDFs defined on the server are not known.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+)
+
+var logFile = flag.String("log", "", "file to write the log to, the log is discarded if empty.")
+
+func usage() {
+	message := `Usage: %s [options]
+
+    Serves the Language Server Protocol over STDIN and STDOUT.
+
+Options:
+`
+	fmt.Fprintf(os.Stderr, message, os.Args[0])
+	flag.PrintDefaults()
+}
+
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+
+	logger := log.New(ioutil.Discard, "[ticklsp] ", log.LstdFlags)
+	if *logFile != "" {
+		f, err := os.OpenFile(*logFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+		logger.SetOutput(f)
+	}
+
+	s := newServer(newConn(os.Stdin, os.Stdout), pipelineDocs, logger)
+	if err := s.serve(); err != nil {
+		if err != errExit {
+			logger.Println("E!", err)
+		}
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,360 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick"
+)
+
+var (
+	pipelineNode = reflect.TypeOf((*pipeline.Node)(nil)).Elem()
+	streamNode   = reflect.TypeOf((*pipeline.StreamNode)(nil))
+	batchNode    = reflect.TypeOf((*pipeline.BatchNode)(nil))
+)
+
+// The chain methods and properties of a pipeline type,
+// discovered with the same reflection that is used to evaluate TICKscripts.
+type nodeType struct {
+	t reflect.Type
+	// Result types of the chain methods.
+	chainMethods map[string]reflect.Type
+	// Result types of the property methods, the type itself for properties that are fields.
+	properties map[string]reflect.Type
+}
+
+func newNodeType(t reflect.Type) *nodeType {
+	nt := &nodeType{
+		t:            t,
+		chainMethods: make(map[string]reflect.Type),
+		properties:   make(map[string]reflect.Type),
+	}
+	obj := newZero(t.Elem(), 0).Interface()
+	var extraChainMethods map[string]reflect.Value
+	if pd, ok := obj.(tick.PartialDescriber); ok {
+		extraChainMethods = pd.ChainMethods()
+	}
+	describer, err := tick.NewReflectionDescriber(obj, extraChainMethods)
+	if err != nil {
+		return nt
+	}
+	for _, name := range describer.ChainMethodNames() {
+		var ft reflect.Type
+		if m, ok := extraChainMethods[goName(name)]; ok {
+			ft = m.Type()
+		} else if m, ok := t.MethodByName(goName(name)); ok {
+			// Skip the receiver
+			ft = methodType(m)
+		}
+		// Only methods that create nodes are chain methods in a TICKscript.
+		if ft != nil && ft.NumOut() > 0 && ft.Out(0).Implements(pipelineNode) {
+			nt.chainMethods[name] = ft.Out(0)
+		}
+	}
+	for _, name := range describer.PropertyNames() {
+		nt.properties[name] = t
+		if m, ok := t.MethodByName(goName(name)); ok {
+			if ft := methodType(m); ft.NumOut() > 0 && ft.Out(0).Kind() == reflect.Ptr {
+				nt.properties[name] = ft.Out(0)
+			}
+		}
+	}
+	return nt
+}
+
+// Create a pointer to a zero value of the struct type.
+// Embedded pointers are allocated as well, i.e. the *AlertNode of the alert handlers,
+// since their methods are promoted to the struct.
+func newZero(t reflect.Type, depth int) reflect.Value {
+	v := reflect.New(t)
+	if depth > maxResolveDepth {
+		return v
+	}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous && f.Type.Kind() == reflect.Ptr && f.Type.Elem().Kind() == reflect.Struct && v.Elem().Field(i).CanSet() {
+			v.Elem().Field(i).Set(newZero(f.Type.Elem(), depth+1))
+		}
+	}
+	return v
+}
+
+// The type of a method without its receiver.
+func methodType(m reflect.Method) reflect.Type {
+	in := make([]reflect.Type, 0, m.Type.NumIn()-1)
+	for i := 1; i < m.Type.NumIn(); i++ {
+		in = append(in, m.Type.In(i))
+	}
+	out := make([]reflect.Type, m.Type.NumOut())
+	for i := range out {
+		out[i] = m.Type.Out(i)
+	}
+	return reflect.FuncOf(in, out, m.Type.IsVariadic())
+}
+
+// Cache of the node types by their reflect type.
+type nodeTypes map[reflect.Type]*nodeType
+
+func (ts nodeTypes) get(t reflect.Type) *nodeType {
+	if t == nil || t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	nt, ok := ts[t]
+	if !ok {
+		nt = newNodeType(t)
+		ts[t] = nt
+	}
+	return nt
+}
+
+// The type of the result of calling the method on the type with the chain operator.
+// Returns nil if the result type cannot be known.
+func (ts nodeTypes) result(t reflect.Type, op tick.TokenType, name string) reflect.Type {
+	nt := ts.get(t)
+	if nt == nil {
+		return nil
+	}
+	switch op {
+	case tick.TokenPipe:
+		return nt.chainMethods[name]
+	case tick.TokenDot:
+		return nt.properties[name]
+	}
+	// Dynamic methods, i.e. UDFs, are not known.
+	return nil
+}
+
+// The signature of the chain method or property as it is called in a TICKscript.
+func (nt *nodeType) signature(name string) string {
+	m, ok := nt.t.MethodByName(goName(name))
+	if !ok {
+		if f, ok := nt.t.Elem().FieldByName(goName(name)); ok {
+			return name + "(" + f.Type.String() + ")"
+		}
+		return name + "()"
+	}
+	ft := methodType(m)
+	var buf bytes.Buffer
+	buf.WriteString(name)
+	buf.WriteByte('(')
+	for i := 0; i < ft.NumIn(); i++ {
+		if i != 0 {
+			buf.WriteString(", ")
+		}
+		if ft.IsVariadic() && i == ft.NumIn()-1 {
+			buf.WriteString("..." + ft.In(i).Elem().String())
+		} else {
+			buf.WriteString(ft.In(i).String())
+		}
+	}
+	buf.WriteByte(')')
+	if ft.NumOut() > 0 {
+		buf.WriteByte(' ')
+		buf.WriteString(ft.Out(0).String())
+	}
+	return buf.String()
+}
+
+// The Go name of the method or field with the TICKscript name.
+func goName(name string) string {
+	r, n := utf8.DecodeRuneInString(name)
+	return string(unicode.ToUpper(r)) + name[n:]
+}
+
+// A call in a chain expression, i.e. |window() or .period(10s).
+type segment struct {
+	op   tick.TokenType
+	name string
+}
+
+// Find the chain expression that ends at the offset in the blanked text,
+// and return the identifier it starts with and its calls.
+func chainBefore(s string, offset int) (head string, segments []segment, ok bool) {
+	i := offset
+	for {
+		i = skipSpaceBack(s, i)
+		call := false
+		if i > 0 && s[i-1] == ')' {
+			i = matchParenBack(s, i-1)
+			if i < 0 {
+				return "", nil, false
+			}
+			i = skipSpaceBack(s, i)
+			call = true
+		}
+		start := identStartBack(s, i)
+		name := s[start:i]
+		if name == "" {
+			return "", nil, false
+		}
+		j := skipSpaceBack(s, start)
+		if j > 0 {
+			if op, isOp := chainOperator(s[j-1]); isOp {
+				segments = append([]segment{{op: op, name: name}}, segments...)
+				i = j - 1
+				continue
+			}
+		}
+		if call {
+			// The chain starts with a global function, its type is not known.
+			return "", nil, false
+		}
+		return name, segments, true
+	}
+}
+
+// Return the end of the chain expression that starts at offset i in the blanked text.
+func chainEnd(s string, i int) int {
+	i = skipSpace(s, i)
+	end := identEnd(s, i)
+	for end > i {
+		i = skipSpace(s, end)
+		if i == len(s) {
+			break
+		}
+		if _, ok := chainOperator(s[i]); !ok {
+			break
+		}
+		start := skipSpace(s, i+1)
+		i = identEnd(s, start)
+		if i == start {
+			// Incomplete call
+			break
+		}
+		if j := skipSpace(s, i); j < len(s) && s[j] == '(' {
+			i = matchParen(s, j)
+			if i < 0 {
+				break
+			}
+			i++
+		}
+		end = i
+	}
+	return end
+}
+
+func chainOperator(c byte) (tick.TokenType, bool) {
+	switch c {
+	case '|':
+		return tick.TokenPipe, true
+	case '.':
+		return tick.TokenDot, true
+	case '@':
+		return tick.TokenAt, true
+	}
+	return tick.TokenError, false
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func skipSpaceBack(s string, i int) int {
+	for i > 0 && strings.IndexByte(" \t\r\n", s[i-1]) != -1 {
+		i--
+	}
+	return i
+}
+
+func identStartBack(s string, i int) int {
+	for i > 0 && isIdentByte(s[i-1]) {
+		i--
+	}
+	return i
+}
+
+func identEnd(s string, i int) int {
+	for i < len(s) && isIdentByte(s[i]) {
+		i++
+	}
+	return i
+}
+
+func skipSpace(s string, i int) int {
+	for i < len(s) && strings.IndexByte(" \t\r\n", s[i]) != -1 {
+		i++
+	}
+	return i
+}
+
+// Return the index of the ')' matching the '(' at index i, or -1.
+func matchParen(s string, i int) int {
+	depth := 0
+	for ; i < len(s); i++ {
+		switch s[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// Return the index of the '(' matching the ')' at index i, or -1.
+func matchParenBack(s string, i int) int {
+	depth := 0
+	for ; i >= 0; i-- {
+		switch s[i] {
+		case ')':
+			depth++
+		case '(':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// Replace the contents of comments, strings and references with spaces,
+// so that the text can be scanned for chain expressions without tokenizing it.
+// The offsets of the text are unchanged.
+func blank(text string) string {
+	b := []byte(text)
+	for i := 0; i < len(b); i++ {
+		switch {
+		case b[i] == '/' && i+1 < len(b) && b[i+1] == '/':
+			for ; i < len(b) && b[i] != '\n'; i++ {
+				b[i] = ' '
+			}
+		case strings.HasPrefix(text[i:], "'''"):
+			end := strings.Index(text[i+3:], "'''")
+			if end == -1 {
+				end = len(b) - i - 3
+			}
+			blankRange(b, i+3, i+3+end)
+			i += end + 5
+		case b[i] == '\'' || b[i] == '"':
+			quote := b[i]
+			j := i + 1
+			for ; j < len(b) && b[j] != quote && b[j] != '\n'; j++ {
+				if b[j] == '\\' {
+					j++
+				}
+			}
+			if j > len(b) {
+				j = len(b)
+			}
+			blankRange(b, i+1, j)
+			i = j
+		}
+	}
+	return string(b)
+}
+
+func blankRange(b []byte, start, end int) {
+	for i := start; i < end && i < len(b); i++ {
+		if b[i] != '\n' {
+			b[i] = ' '
+		}
+	}
+}
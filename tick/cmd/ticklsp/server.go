@@ -0,0 +1,393 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/influxdata/kapacitor/tick"
+)
+
+var errExit = errors.New("exit")
+
+// Language server for TICKscripts over a single connection.
+type server struct {
+	conn      *conn
+	docs      *docIndex
+	nodeTypes nodeTypes
+	documents map[string]*document
+	shutdown  bool
+	logger    *log.Logger
+}
+
+func newServer(c *conn, docs *docIndex, logger *log.Logger) *server {
+	return &server{
+		conn:      c,
+		docs:      docs,
+		nodeTypes: make(nodeTypes),
+		documents: make(map[string]*document),
+		logger:    logger,
+	}
+}
+
+// Serve requests until the client exits.
+// Returns errExit if the client exited without shutting down the server first.
+func (s *server) serve() error {
+	for {
+		req, err := s.conn.read()
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			if respErr, ok := err.(*responseError); ok {
+				s.conn.replyError(nil, respErr)
+				continue
+			}
+			return err
+		}
+		if req.Method == "exit" {
+			if !s.shutdown {
+				return errExit
+			}
+			return nil
+		}
+		result, err := s.handle(req)
+		if req.ID == nil {
+			// Notifications have no response.
+			if err != nil {
+				s.logger.Printf("E! %s: %v", req.Method, err)
+			}
+			continue
+		}
+		if err != nil {
+			respErr, ok := err.(*responseError)
+			if !ok {
+				respErr = &responseError{Code: codeInternalError, Message: err.Error()}
+			}
+			err = s.conn.replyError(req.ID, respErr)
+		} else {
+			err = s.conn.reply(req.ID, result)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (s *server) handle(req *request) (interface{}, error) {
+	switch req.Method {
+	case "initialize":
+		return s.initialize(), nil
+	case "initialized":
+		return nil, nil
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+	case "textDocument/didOpen":
+		var params didOpenParams
+		if err := unmarshalParams(req, &params); err != nil {
+			return nil, err
+		}
+		d := &document{uri: params.TextDocument.URI, text: params.TextDocument.Text}
+		s.documents[d.uri] = d
+		return nil, s.publishDiagnostics(d)
+	case "textDocument/didChange":
+		var params didChangeParams
+		if err := unmarshalParams(req, &params); err != nil {
+			return nil, err
+		}
+		d, err := s.document(params.TextDocument.URI)
+		if err != nil {
+			return nil, err
+		}
+		// The full text is synced, the last change is the current text.
+		if l := len(params.ContentChanges); l > 0 {
+			d.text = params.ContentChanges[l-1].Text
+		}
+		return nil, s.publishDiagnostics(d)
+	case "textDocument/didClose":
+		var params didCloseParams
+		if err := unmarshalParams(req, &params); err != nil {
+			return nil, err
+		}
+		delete(s.documents, params.TextDocument.URI)
+		return nil, s.conn.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{
+			URI:         params.TextDocument.URI,
+			Diagnostics: []diagnostic{},
+		})
+	case "textDocument/completion":
+		var params textDocumentPositionParams
+		if err := unmarshalParams(req, &params); err != nil {
+			return nil, err
+		}
+		d, err := s.document(params.TextDocument.URI)
+		if err != nil {
+			return nil, err
+		}
+		return s.completion(d, d.offset(params.Position)), nil
+	case "textDocument/hover":
+		var params textDocumentPositionParams
+		if err := unmarshalParams(req, &params); err != nil {
+			return nil, err
+		}
+		d, err := s.document(params.TextDocument.URI)
+		if err != nil {
+			return nil, err
+		}
+		return s.hover(d, d.offset(params.Position)), nil
+	case "textDocument/definition":
+		var params textDocumentPositionParams
+		if err := unmarshalParams(req, &params); err != nil {
+			return nil, err
+		}
+		d, err := s.document(params.TextDocument.URI)
+		if err != nil {
+			return nil, err
+		}
+		return s.definition(d, d.offset(params.Position)), nil
+	case "textDocument/formatting":
+		var params formattingParams
+		if err := unmarshalParams(req, &params); err != nil {
+			return nil, err
+		}
+		d, err := s.document(params.TextDocument.URI)
+		if err != nil {
+			return nil, err
+		}
+		return s.format(d)
+	}
+	return nil, &responseError{Code: codeMethodNotFound, Message: fmt.Sprintf("unknown method %q", req.Method)}
+}
+
+func unmarshalParams(req *request, params interface{}) error {
+	if err := json.Unmarshal(req.Params, params); err != nil {
+		return &responseError{Code: codeInvalidParams, Message: err.Error()}
+	}
+	return nil
+}
+
+func (s *server) document(uri string) (*document, error) {
+	d, ok := s.documents[uri]
+	if !ok {
+		return nil, &responseError{Code: codeInvalidParams, Message: fmt.Sprintf("unknown document %q", uri)}
+	}
+	return d, nil
+}
+
+func (s *server) initialize() interface{} {
+	type completionOptions struct {
+		TriggerCharacters []string `json:"triggerCharacters"`
+	}
+	type capabilities struct {
+		TextDocumentSync           int               `json:"textDocumentSync"`
+		CompletionProvider         completionOptions `json:"completionProvider"`
+		HoverProvider              bool              `json:"hoverProvider"`
+		DefinitionProvider         bool              `json:"definitionProvider"`
+		DocumentFormattingProvider bool              `json:"documentFormattingProvider"`
+	}
+	type serverInfo struct {
+		Name string `json:"name"`
+	}
+	return struct {
+		Capabilities capabilities `json:"capabilities"`
+		ServerInfo   serverInfo   `json:"serverInfo"`
+	}{
+		Capabilities: capabilities{
+			// Full text sync
+			TextDocumentSync:           1,
+			CompletionProvider:         completionOptions{TriggerCharacters: []string{"|", ".", "@"}},
+			HoverProvider:              true,
+			DefinitionProvider:         true,
+			DocumentFormattingProvider: true,
+		},
+		ServerInfo: serverInfo{Name: "ticklsp"},
+	}
+}
+
+func (s *server) publishDiagnostics(d *document) error {
+	diagnostics := d.check()
+	if diagnostics == nil {
+		diagnostics = []diagnostic{}
+	}
+	return s.conn.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{
+		URI:         d.uri,
+		Diagnostics: diagnostics,
+	})
+}
+
+// Complete the chain method or property being typed,
+// or the vars, sources and keywords outside of a chain.
+func (s *server) completion(d *document, offset int) []completionItem {
+	blanked := blank(d.text)
+	start := identStartBack(blanked, offset)
+	i := skipSpaceBack(blanked, start)
+	if i > 0 {
+		if op, ok := chainOperator(blanked[i-1]); ok {
+			return s.memberCompletion(s.chainType(d, blanked, i-1), op)
+		}
+	}
+
+	items := []completionItem{
+		{Label: "stream", Kind: completionVariable, Detail: "*pipeline.StreamNode", Documentation: s.markdown(s.docs.typeDoc("StreamNode"))},
+		{Label: "batch", Kind: completionVariable, Detail: "*pipeline.BatchNode", Documentation: s.markdown(s.docs.typeDoc("BatchNode"))},
+	}
+	for _, keyword := range []string{"var", "lambda", "import", "def", "TRUE", "FALSE"} {
+		items = append(items, completionItem{Label: keyword, Kind: completionKeyword})
+	}
+	if list, ok := d.root.(*tick.ListNode); ok {
+		for _, n := range list.Nodes {
+			switch decl := n.(type) {
+			case *tick.DeclarationNode:
+				items = append(items, completionItem{Label: decl.Left.Ident, Kind: completionVariable})
+			case *tick.FunctionDefNode:
+				items = append(items, completionItem{Label: decl.Name, Kind: completionFunction, Detail: functionDefSignature(decl)})
+			}
+		}
+	}
+	return items
+}
+
+func (s *server) memberCompletion(t reflect.Type, op tick.TokenType) []completionItem {
+	nt := s.nodeTypes.get(t)
+	if nt == nil {
+		return []completionItem{}
+	}
+	var names map[string]reflect.Type
+	kind := completionMethod
+	switch op {
+	case tick.TokenPipe:
+		names = nt.chainMethods
+	case tick.TokenDot:
+		names = nt.properties
+		kind = completionProperty
+	}
+	items := make([]completionItem, 0, len(names))
+	for name := range names {
+		doc, ignored := s.docs.memberDoc(nt.t.Elem().Name(), goName(name))
+		if ignored {
+			continue
+		}
+		items = append(items, completionItem{
+			Label:         name,
+			Kind:          kind,
+			Detail:        nt.signature(name),
+			Documentation: s.markdown(doc),
+		})
+	}
+	sort.Sort(byLabel(items))
+	return items
+}
+
+func (s *server) markdown(doc string) *markupContent {
+	if doc == "" {
+		return nil
+	}
+	return &markupContent{Kind: "markdown", Value: doc}
+}
+
+// Show the docs of the chain method, property, source or var under the offset.
+func (s *server) hover(d *document, offset int) *hover {
+	blanked := blank(d.text)
+	start := identStartBack(blanked, offset)
+	end := identEnd(blanked, offset)
+	if start == end {
+		return nil
+	}
+	name := blanked[start:end]
+	r := lspRange{Start: d.position(start), End: d.position(end)}
+
+	var value string
+	i := skipSpaceBack(blanked, start)
+	if op, ok := chainOperator(byteBefore(blanked, i)); ok {
+		nt := s.nodeTypes.get(s.chainType(d, blanked, i-1))
+		if nt == nil || s.nodeTypes.result(nt.t, op, name) == nil {
+			return nil
+		}
+		doc, _ := s.docs.memberDoc(nt.t.Elem().Name(), goName(name))
+		value = fmt.Sprintf("```\n%s\n```\n\n%s", nt.signature(name), doc)
+	} else {
+		switch decl := declaration(d.root, name).(type) {
+		case *tick.DeclarationNode:
+			value = fmt.Sprintf("```\nvar %s\n```", name)
+			if t := s.identType(d.root, name, 0); t != nil {
+				value = fmt.Sprintf("```\nvar %s %s\n```", name, t)
+			}
+		case *tick.FunctionDefNode:
+			value = fmt.Sprintf("```\n%s\n```", functionDefSignature(decl))
+		default:
+			t := s.identType(d.root, name, 0)
+			if t == nil {
+				return nil
+			}
+			value = fmt.Sprintf("```\n%s %s\n```\n\n%s", name, t, s.docs.typeDoc(t.Elem().Name()))
+		}
+	}
+	return &hover{
+		Contents: markupContent{Kind: "markdown", Value: strings.TrimSpace(value)},
+		Range:    &r,
+	}
+}
+
+func byteBefore(s string, i int) byte {
+	if i <= 0 {
+		return 0
+	}
+	return s[i-1]
+}
+
+func functionDefSignature(def *tick.FunctionDefNode) string {
+	params := make([]string, len(def.Params))
+	for i, p := range def.Params {
+		params[i] = p.Ident
+	}
+	return fmt.Sprintf("def %s(%s)", def.Name, strings.Join(params, ", "))
+}
+
+// Find the declaration of the var or function under the offset.
+func (s *server) definition(d *document, offset int) []location {
+	blanked := blank(d.text)
+	start := identStartBack(blanked, offset)
+	end := identEnd(blanked, offset)
+	if start == end {
+		return nil
+	}
+	name := blanked[start:end]
+	if _, ok := chainOperator(byteBefore(blanked, skipSpaceBack(blanked, start))); ok {
+		// Chain methods and properties are not declared in the TICKscript.
+		return nil
+	}
+	var declStart int
+	switch decl := declaration(d.root, name).(type) {
+	case *tick.DeclarationNode:
+		declStart = decl.Left.Position()
+	case *tick.FunctionDefNode:
+		// The position of the definition is the def keyword.
+		declStart = decl.Position()
+		if i := strings.Index(d.text[declStart:], name); i != -1 {
+			declStart += i
+		}
+	default:
+		return nil
+	}
+	return []location{{
+		URI:   d.uri,
+		Range: lspRange{Start: d.position(declStart), End: d.position(declStart + len(name))},
+	}}
+}
+
+// Format the whole document with tick.Format.
+func (s *server) format(d *document) ([]textEdit, error) {
+	formatted, err := tick.Format(d.text)
+	if err != nil {
+		return nil, &responseError{Code: codeInternalError, Message: err.Error()}
+	}
+	if formatted == d.text {
+		return []textEdit{}, nil
+	}
+	return []textEdit{{Range: d.fullRange(), NewText: formatted}}, nil
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"go/ast"
+	"io/ioutil"
+	"log"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func newTestServer() *server {
+	return newServer(nil, pipelineDocs, log.New(ioutil.Discard, "", 0))
+}
+
+// Create a document and check it, so the vars can be resolved.
+func newTestDocument(text string) (*document, []diagnostic) {
+	d := &document{uri: "file:///tmp/test.tick", text: text}
+	return d, d.check()
+}
+
+func TestServer_Diagnostics(t *testing.T) {
+	testCases := []struct {
+		text     string
+		start    position
+		contains string
+	}{
+		{
+			text:     "stream\n    |from()\n        .measurement('cpu'\n",
+			start:    position{Line: 3, Character: 0},
+			contains: "unexpected EOF",
+		},
+		{
+			text:     "stream\n    |from()\n        .measurement('cpu')\n    |window()\n        .period(10s)\n        .every(10s)\n    |mean(lambda: \"value\")\n",
+			start:    position{Line: 6, Character: 5},
+			contains: "did you use double quotes",
+		},
+	}
+	for _, tc := range testCases {
+		_, diagnostics := newTestDocument(tc.text)
+		if len(diagnostics) != 1 {
+			t.Errorf("%q: unexpected number of diagnostics: got %d exp 1: %v", tc.text, len(diagnostics), diagnostics)
+			continue
+		}
+		if got := diagnostics[0].Range.Start; got != tc.start {
+			t.Errorf("%q: unexpected diagnostic position: got %v exp %v", tc.text, got, tc.start)
+		}
+		if !strings.Contains(diagnostics[0].Message, tc.contains) {
+			t.Errorf("%q: unexpected diagnostic message: got %q exp it to contain %q", tc.text, diagnostics[0].Message, tc.contains)
+		}
+	}
+
+	if _, diagnostics := newTestDocument("stream\n    |from()\n        .measurement('cpu')\n"); len(diagnostics) != 0 {
+		t.Errorf("unexpected diagnostics for a valid script: %v", diagnostics)
+	}
+}
+
+func TestServer_Completion(t *testing.T) {
+	s := newTestServer()
+	d, _ := newTestDocument("var data = stream\n    |from()\n\ndata\n    |")
+	items := s.completion(d, len(d.text))
+	labels := make(map[string]bool, len(items))
+	for i, item := range items {
+		labels[item.Label] = true
+		if i > 0 && items[i-1].Label > item.Label {
+			t.Errorf("completion items are not sorted: %q before %q", items[i-1].Label, item.Label)
+		}
+	}
+	for _, exp := range []string{"window", "alert", "eval"} {
+		if !labels[exp] {
+			t.Errorf("missing chain method %q in completion items", exp)
+		}
+	}
+	if labels["measurement"] {
+		t.Error("unexpected property measurement in chain method completion items")
+	}
+
+	d, _ = newTestDocument("var data = stream\n    |from()\n\n")
+	items = s.completion(d, len(d.text))
+	labels = make(map[string]bool, len(items))
+	for _, item := range items {
+		labels[item.Label] = true
+	}
+	for _, exp := range []string{"stream", "batch", "var", "data"} {
+		if !labels[exp] {
+			t.Errorf("missing %q in top level completion items", exp)
+		}
+	}
+}
+
+func TestServer_Hover(t *testing.T) {
+	s := newTestServer()
+	d, _ := newTestDocument("stream\n    |from()\n    |window()\n        .period(10s)\n")
+	offset := strings.Index(d.text, "period") + 2
+	h := s.hover(d, offset)
+	if h == nil {
+		t.Fatal("expected hover on property period")
+	}
+	if doc, _ := pipelineDocs.memberDoc("WindowNode", "Period"); doc == "" || !strings.Contains(h.Contents.Value, doc) {
+		t.Errorf("unexpected hover value, exp it to contain the doc of WindowNode.Period:\n%s", h.Contents.Value)
+	}
+	exp := lspRange{Start: position{Line: 3, Character: 9}, End: position{Line: 3, Character: 15}}
+	if h.Range == nil || *h.Range != exp {
+		t.Errorf("unexpected hover range: got %v exp %v", h.Range, exp)
+	}
+
+	if h := s.hover(d, strings.Index(d.text, "10s")+4); h != nil {
+		t.Errorf("unexpected hover outside of an identifier: %v", h)
+	}
+}
+
+func TestServer_Definition(t *testing.T) {
+	s := newTestServer()
+	d, _ := newTestDocument("var data = stream\n    |from()\n\ndata\n    |window()\n")
+	got := s.definition(d, strings.LastIndex(d.text, "data")+1)
+	exp := []location{{
+		URI:   d.uri,
+		Range: lspRange{Start: position{Line: 0, Character: 4}, End: position{Line: 0, Character: 8}},
+	}}
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("unexpected definition: got %v exp %v", got, exp)
+	}
+
+	if got := s.definition(d, strings.Index(d.text, "window")+1); got != nil {
+		t.Errorf("unexpected definition of a chain method: %v", got)
+	}
+}
+
+func TestServer_Format(t *testing.T) {
+	s := newTestServer()
+	d, _ := newTestDocument("stream|from().measurement('cpu')")
+	edits, err := s.format(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := "stream\n    |from()\n        .measurement('cpu')\n"
+	if len(edits) != 1 || edits[0].NewText != exp {
+		t.Fatalf("unexpected format edits: got %v exp a single edit with %q", edits, exp)
+	}
+	if edits[0].Range != d.fullRange() {
+		t.Errorf("unexpected format range: got %v exp %v", edits[0].Range, d.fullRange())
+	}
+
+	d.text = exp
+	edits, err = s.format(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(edits) != 0 {
+		t.Errorf("unexpected format edits for a formatted document: %v", edits)
+	}
+}
+
+func TestPipelineDocs_Exported(t *testing.T) {
+	embedded := make(map[string]bool)
+	for _, names := range pipelineDocs.embedded {
+		for _, name := range names {
+			embedded[name] = true
+		}
+	}
+	for typ, members := range pipelineDocs.members {
+		if !ast.IsExported(typ) && !embedded[typ] {
+			t.Errorf("unexpected unexported type %s", typ)
+		}
+		for name := range members {
+			if !ast.IsExported(name) {
+				t.Errorf("unexpected unexported member %s.%s", typ, name)
+			}
+		}
+	}
+	// Exported members of unexported types are promoted to the nodes.
+	if doc, _ := pipelineDocs.memberDoc("FillNode", "Window"); doc == "" {
+		t.Error("missing doc of the chaining method FillNode.Window")
+	}
+}
@@ -0,0 +1,48 @@
+package tick
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// A problem found while checking a TICKscript, at the position of the offending node.
+type Diagnostic struct {
+	Line    int
+	Char    int
+	Message string
+}
+
+// Create a diagnostic at the position.
+// The position may be nil for nodes that were not created from a TICKscript.
+func NewDiagnostic(p Position, fmtStr string, args ...interface{}) Diagnostic {
+	d := Diagnostic{
+		Message: fmt.Sprintf(fmtStr, args...),
+	}
+	if p != nil {
+		d.Line = p.Line()
+		d.Char = p.Char()
+	}
+	return d
+}
+
+func (d Diagnostic) Error() string {
+	if d.Line == 0 {
+		return d.Message
+	}
+	return fmt.Sprintf("line %d char %d: %s", d.Line, d.Char, d.Message)
+}
+
+// All the problems found while checking a TICKscript.
+type Diagnostics []Diagnostic
+
+// Error lists each diagnostic on its own line.
+func (d Diagnostics) Error() string {
+	var buf bytes.Buffer
+	for i, diagnostic := range d {
+		if i != 0 {
+			buf.WriteByte('\n')
+		}
+		buf.WriteString(diagnostic.Error())
+	}
+	return buf.String()
+}
@@ -9,6 +9,7 @@ import (
 	"reflect"
 	"regexp"
 	"runtime"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -117,6 +118,7 @@ func eval(n Node, scope *Scope, stck *stack) (err error) {
 		}
 	case *LambdaNode:
 		// Catch panic from resolveIdents and return as error.
+		var resolved Node
 		err = func() (e error) {
 			defer func(ep *error) {
 				err := recover()
@@ -124,13 +126,24 @@ func eval(n Node, scope *Scope, stck *stack) (err error) {
 					*ep = err.(error)
 				}
 			}(&e)
-			node.Node = resolveIdents(node.Node, scope)
+			resolved = resolveIdents(node.Node, scope)
 			return e
 		}()
 		if err != nil {
 			return
 		}
-		stck.Push(node.Node)
+		stck.Push(resolved)
+	case *ImportNode:
+		err = evalImport(node, scope)
+		if err != nil {
+			return
+		}
+	case *FunctionDefNode:
+		scope.Set(node.Name, &userFunc{
+			def:     node,
+			scope:   scope,
+			library: scope.library,
+		})
 	case *DeclarationNode:
 		err = eval(node.Left, scope, stck)
 		if err != nil {
@@ -157,34 +170,29 @@ func eval(n Node, scope *Scope, stck *stack) (err error) {
 		if err != nil {
 			return
 		}
+	case *ListLiteralNode:
+		values, err := evalValues(node.Elements, scope, stck)
+		if err != nil {
+			return err
+		}
+		stck.Push(values)
 	case *FunctionNode:
-		args := make([]interface{}, len(node.Args))
-		for i, arg := range node.Args {
-			err = eval(arg, scope, stck)
-			if err != nil {
-				return
-			}
-			a := stck.Pop()
-			switch typed := a.(type) {
-			case *IdentifierNode:
-				// Resolve identifier
-				a, err = scope.Get(typed.Ident)
-				if err != nil {
-					return err
-				}
-			case unboundFunc:
-				// Call global func
-				a, err = typed(nil)
-				if err != nil {
-					return err
-				}
+		values, err := evalValues(node.Args, scope, stck)
+		if err != nil {
+			return err
+		}
+		// Lists are expanded into the arguments of the function.
+		args := make([]interface{}, 0, len(values))
+		for _, v := range values {
+			if list, ok := v.([]interface{}); ok {
+				args = append(args, list...)
+			} else {
+				args = append(args, v)
 			}
-
-			args[i] = a
 		}
 		err = evalFunc(node, scope, stck, args)
 		if err != nil {
-			return
+			return err
 		}
 	case *ListNode:
 		for _, n := range node.Nodes {
@@ -203,6 +211,116 @@ func eval(n Node, scope *Scope, stck *stack) (err error) {
 	return nil
 }
 
+// Evaluate each node to a value,
+// resolving identifiers and calling global funcs.
+func evalValues(nodes []Node, scope *Scope, stck *stack) ([]interface{}, error) {
+	values := make([]interface{}, len(nodes))
+	for i, n := range nodes {
+		err := eval(n, scope, stck)
+		if err != nil {
+			return nil, err
+		}
+		v, err := resolveValue(stck.Pop(), scope)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = v
+	}
+	return values, nil
+}
+
+// Resolve an identifier or call a global func to get its value.
+func resolveValue(v interface{}, scope *Scope) (interface{}, error) {
+	switch typed := v.(type) {
+	case *IdentifierNode:
+		// Resolve identifier
+		return scope.Get(typed.Ident)
+	case unboundFunc:
+		// Call global func
+		return typed(nil)
+	}
+	return v, nil
+}
+
+// Maximum depth of nested user defined function calls.
+const maxCallDepth = 100
+
+// A function defined in TICKscript via def.
+type userFunc struct {
+	def *FunctionDefNode
+	// The scope the function was defined in.
+	scope *Scope
+	// Path of the library defining the function, empty if defined by the script.
+	library string
+}
+
+// Return the name of the function in errors, including the library defining it.
+func (u *userFunc) String() string {
+	if u.library != "" {
+		return fmt.Sprintf("%s of library %q", u.def.Name, u.library)
+	}
+	return u.def.Name
+}
+
+// Call the function by evaluating its body with the parameters set to the args.
+func (u *userFunc) call(f *FunctionNode, caller *Scope, args []interface{}) (interface{}, error) {
+	if len(args) != len(u.def.Params) {
+		return nil, errorf(f, "function %s expects %d arguments, got %d", u.def.Name, len(u.def.Params), len(args))
+	}
+	if caller.depth >= maxCallDepth {
+		return nil, errorf(f, "function %s exceeded the maximum call depth of %d", u.def.Name, maxCallDepth)
+	}
+	scope := u.scope.child(caller.depth + 1)
+	scope.library = u.library
+	for i, param := range u.def.Params {
+		scope.Set(param.Ident, args[i])
+	}
+	stck := &stack{}
+	err := eval(u.def.Body, scope, stck)
+	if err != nil {
+		return nil, errorf(f, "in function %s: %s", u, err)
+	}
+	v, err := resolveValue(stck.Pop(), scope)
+	if err != nil {
+		return nil, errorf(f, "in function %s: %s", u, err)
+	}
+	return v, nil
+}
+
+// Evaluate the statements of a library in the scope.
+// Importing the same library more than once has no effect.
+func evalImport(n *ImportNode, scope *Scope) error {
+	if done, ok := scope.imports[n.Path]; ok {
+		if !done {
+			return errorf(n, "import cycle importing %q", n.Path)
+		}
+		return nil
+	}
+	importer := scope.Importer()
+	if importer == nil {
+		return errorf(n, "cannot import %q, no libraries are available", n.Path)
+	}
+	script, err := importer(n.Path)
+	if err != nil {
+		return errorf(n, "failed to import %q: %s", n.Path, err)
+	}
+	root, err := parse(script)
+	if err != nil {
+		return errorf(n, "failed to import %q: %s", n.Path, err)
+	}
+	scope.imports[n.Path] = false
+	parent := scope.library
+	scope.library = n.Path
+	err = eval(root, scope, &stack{})
+	scope.library = parent
+	if err != nil {
+		delete(scope.imports, n.Path)
+		return errorf(n, "in library %q: %s", n.Path, err)
+	}
+	scope.imports[n.Path] = true
+	return nil
+}
+
 func evalUnary(p Position, op TokenType, scope *Scope, stck *stack) error {
 	v := stck.Pop()
 	switch op {
@@ -242,7 +360,10 @@ func evalUnary(p Position, op TokenType, scope *Scope, stck *stack) error {
 }
 
 func evalDeclaration(scope *Scope, stck *stack) error {
-	r := stck.Pop()
+	r, err := resolveValue(stck.Pop(), scope)
+	if err != nil {
+		return err
+	}
 	l := stck.Pop()
 	i := l.(*IdentifierNode)
 	scope.Set(i.Ident, r)
@@ -251,14 +372,10 @@ func evalDeclaration(scope *Scope, stck *stack) error {
 
 func evalChain(p Position, scope *Scope, stck *stack) error {
 	r := stck.Pop()
-	l := stck.Pop()
-	// Resolve identifier
-	if left, ok := l.(*IdentifierNode); ok {
-		var err error
-		l, err = scope.Get(left.Ident)
-		if err != nil {
-			return err
-		}
+	// Resolve identifier or call global func
+	l, err := resolveValue(stck.Pop(), scope)
+	if err != nil {
+		return err
 	}
 	switch right := r.(type) {
 	case unboundFunc:
@@ -320,6 +437,9 @@ func evalFunc(f *FunctionNode, scope *Scope, stck *stack, args []interface{}) er
 			if fnc == nil {
 				return nil, fmt.Errorf("line %d char%d: no global function %q defined", f.Line(), f.Char(), f.Func)
 			}
+			if uf, ok := fnc.(*userFunc); ok {
+				return uf.call(f, scope, args)
+			}
 			method := reflect.ValueOf(fnc)
 			o, err := callMethodReflection(method, args)
 			return o, wrapError(f, err)
@@ -347,7 +467,18 @@ func evalFunc(f *FunctionNode, scope *Scope, stck *stack, args []interface{}) er
 		case chainFunc:
 			if describer.HasChainMethod(name) {
 				o, err := describer.CallChainMethod(name, args...)
-				return o, wrapError(f, err)
+				if err != nil {
+					return nil, wrapError(f, err)
+				}
+				setPosition(o, f)
+				recordChainMethod(o, obj, f, args)
+				return o, nil
+			}
+			// Call a user defined function with the object as the first argument.
+			if fnc, _ := scope.Get(name); fnc != nil {
+				if uf, ok := fnc.(*userFunc); ok {
+					return uf.call(f, scope, append([]interface{}{obj}, args...))
+				}
 			}
 			if describer.HasProperty(name) {
 				return nil, errorf(f, "no chaining method %q on %T, but property does exist. Use '.' operator instead: 'node.%s(..)'.", name, obj, name)
@@ -358,7 +489,11 @@ func evalFunc(f *FunctionNode, scope *Scope, stck *stack, args []interface{}) er
 		case propertyFunc:
 			if describer.HasProperty(name) {
 				o, err := describer.SetProperty(name, args...)
-				return o, wrapError(f, err)
+				if err != nil {
+					return nil, wrapError(f, err)
+				}
+				recordProperty(obj, f, args)
+				return o, nil
 			}
 			if describer.HasChainMethod(name) {
 				return nil, errorf(f, "no property method %q on %T, but chaining method does exist. Use '|' operator instead: 'node|%s(..)'.", name, obj, name)
@@ -373,6 +508,8 @@ func evalFunc(f *FunctionNode, scope *Scope, stck *stack, args []interface{}) er
 				if err != nil {
 					return nil, err
 				}
+				setPosition(ret, f)
+				recordChainMethod(ret, obj, f, args)
 				return ret, nil
 			}
 			if describer.HasProperty(name) {
@@ -392,6 +529,39 @@ func evalFunc(f *FunctionNode, scope *Scope, stck *stack, args []interface{}) er
 	return nil
 }
 
+// Implemented by objects created by chaining or dynamic methods
+// that record where in the TICKscript they were created.
+type Positioned interface {
+	SetPosition(p Position)
+}
+
+func setPosition(o interface{}, p Position) {
+	if positioned, ok := o.(Positioned); ok {
+		positioned.SetPosition(p)
+	}
+}
+
+// Implemented by objects that record the calls creating and configuring them
+// while evaluating a TICKscript, so that the calls can be replayed.
+type Recorder interface {
+	// Record the chaining or dynamic method called on the receiver that created the object.
+	RecordChainMethod(receiver interface{}, method string, dynamic bool, args []interface{})
+	// Record a property method called on the object.
+	RecordProperty(name string, args []interface{})
+}
+
+func recordChainMethod(o, receiver interface{}, f *FunctionNode, args []interface{}) {
+	if recorder, ok := o.(Recorder); ok {
+		recorder.RecordChainMethod(receiver, f.Func, f.Type == dynamicFunc, args)
+	}
+}
+
+func recordProperty(o interface{}, f *FunctionNode, args []interface{}) {
+	if recorder, ok := o.(Recorder); ok {
+		recorder.RecordProperty(f.Func, args)
+	}
+}
+
 // Wraps any object as a SelfDescriber using reflection.
 //
 // Uses tags on fields to determine if a method is really a PropertyMethod
@@ -602,6 +772,33 @@ func getChainMethods(desc string, rv reflect.Value, propertyMethods map[string]r
 	return chainMethods, nil
 }
 
+// The sorted names of the chain methods, as used in a TICKscript.
+func (r *ReflectionDescriber) ChainMethodNames() []string {
+	return tickNames(r.chainMethods)
+}
+
+// The sorted names of the properties and property methods, as used in a TICKscript.
+func (r *ReflectionDescriber) PropertyNames() []string {
+	names := tickNames(r.propertyMethods)
+	for _, name := range tickNames(r.properties) {
+		if _, ok := r.propertyMethods[capilatizeFirst(name)]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func tickNames(m map[string]reflect.Value) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		r, n := utf8.DecodeRuneInString(name)
+		names = append(names, string(unicode.ToLower(r))+name[n:])
+	}
+	sort.Strings(names)
+	return names
+}
+
 func (r *ReflectionDescriber) Desc() string {
 	return fmt.Sprintf("%T", r.obj)
 }
@@ -691,29 +888,46 @@ func capilatizeFirst(s string) string {
 }
 
 // Resolve all identifiers immediately in the tree with their value from the scope.
-// This operation is performed in place.
+// The tree is not modified, nodes containing identifiers are copied.
 // Panics if the scope value does not exist or if the value cannot be expressed as a literal.
 func resolveIdents(n Node, scope *Scope) Node {
 	switch node := n.(type) {
 	case *IdentifierNode:
 		v, err := scope.Get(node.Ident)
 		if err != nil {
-			panic(err)
+			panic(wrapError(node, err))
 		}
 		return valueToLiteralNode(node.position, v)
 	case *UnaryNode:
-		node.Node = resolveIdents(node.Node, scope)
+		c := *node
+		c.Node = resolveIdents(node.Node, scope)
+		return &c
 	case *BinaryNode:
-		node.Left = resolveIdents(node.Left, scope)
-		node.Right = resolveIdents(node.Right, scope)
+		c := *node
+		c.Left = resolveIdents(node.Left, scope)
+		c.Right = resolveIdents(node.Right, scope)
+		return &c
 	case *FunctionNode:
+		c := *node
+		c.Args = make([]Node, len(node.Args))
 		for i, arg := range node.Args {
-			node.Args[i] = resolveIdents(arg, scope)
+			c.Args[i] = resolveIdents(arg, scope)
+		}
+		return &c
+	case *ListLiteralNode:
+		c := *node
+		c.Elements = make([]Node, len(node.Elements))
+		for i, element := range node.Elements {
+			c.Elements[i] = resolveIdents(element, scope)
 		}
+		return &c
 	case *ListNode:
+		c := *node
+		c.Nodes = make([]Node, len(node.Nodes))
 		for i, n := range node.Nodes {
-			node.Nodes[i] = resolveIdents(n, scope)
+			c.Nodes[i] = resolveIdents(n, scope)
 		}
+		return &c
 	}
 	return n
 }
@@ -753,6 +967,18 @@ func valueToLiteralNode(p position, v interface{}) Node {
 			position: p,
 			Regex:    value,
 		}
+	case []interface{}:
+		elements := make([]Node, len(value))
+		for i, e := range value {
+			elements[i] = valueToLiteralNode(p, e)
+		}
+		return &ListLiteralNode{
+			position: p,
+			Elements: elements,
+		}
+	case Node:
+		// References and lambda expressions are used as is.
+		return value
 	default:
 		panic(errorf(p, "unsupported literal type %T", v))
 	}
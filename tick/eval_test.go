@@ -1,8 +1,10 @@
 package tick_test
 
 import (
+	"bytes"
 	"fmt"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -247,6 +249,169 @@ var m = !n
 
 }
 
+func TestEvaluate_ListLiterals(t *testing.T) {
+	script := `
+var opts = ['c', 21.5]
+
+var s2 = a|structB()
+
+s2|structC()
+	.options(opts, 7h)
+`
+
+	scope := tick.NewScope()
+	a := &structA{}
+	scope.Set("a", a)
+
+	err := tick.Evaluate(script, scope)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts, err := scope.Get("opts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp, got := []interface{}{"c", 21.5}, opts; !reflect.DeepEqual(exp, got) {
+		t.Errorf("unexpected opts exp:%v got%v", exp, got)
+	}
+
+	s2I, err := scope.Get("s2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s3 := *s2I.(*structB).c
+	c := structC{
+		field1: "c",
+		field2: 21.5,
+		field3: time.Hour * 7,
+	}
+	if !reflect.DeepEqual(s3, c) {
+		t.Errorf("unexpected s3 exp:%v got%v", c, s3)
+	}
+}
+
+func TestEvaluate_Import(t *testing.T) {
+	libraries := map[string]string{
+		"lib/common.tick": `
+var unit = 'percent'
+
+def crit(threshold) = lambda: "value" > threshold
+
+def fragment(node, f) = node|structB()|structC().options(unit, f, 1h)
+`,
+	}
+	script := `
+import 'lib/common.tick'
+import 'lib/common.tick'
+
+var c = crit(80)
+
+var s = a|fragment(2.5)
+`
+
+	scope := tick.NewScope()
+	scope.SetImporter(func(path string) (string, error) {
+		l, ok := libraries[path]
+		if !ok {
+			return "", fmt.Errorf("no library %s", path)
+		}
+		return l, nil
+	})
+	a := &structA{}
+	scope.Set("a", a)
+
+	err := tick.Evaluate(script, scope)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := scope.Get("c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	c.(tick.Node).Format(&buf, "", false)
+	if exp, got := `"value" > 80`, buf.String(); exp != got {
+		t.Errorf("unexpected c exp:%q got:%q", exp, got)
+	}
+
+	s, err := scope.Get("s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := &structC{
+		field1: "percent",
+		field2: 2.5,
+		field3: time.Hour,
+	}
+	if !reflect.DeepEqual(exp, s) {
+		t.Errorf("unexpected s exp:%v got:%v", exp, s)
+	}
+}
+
+func TestEvaluate_ImportErrors(t *testing.T) {
+	libraries := map[string]string{
+		"a": "import 'b'",
+		"b": "import 'a'",
+		"c": "def f(x) = lambda: x > y",
+	}
+	importer := func(path string) (string, error) {
+		l, ok := libraries[path]
+		if !ok {
+			return "", fmt.Errorf("no library %s", path)
+		}
+		return l, nil
+	}
+	testCases := []struct {
+		script string
+		err    string
+	}{
+		{
+			script: "import 'a'",
+			err:    `line 1 char 1: in library "a": line 1 char 1: in library "b": line 1 char 1: import cycle importing "a"`,
+		},
+		{
+			script: "import 'missing'",
+			err:    `line 1 char 1: failed to import "missing": no library missing`,
+		},
+		{
+			script: "import 'c'\nvar v = f(1)",
+			err:    `line 2 char 9: in function f of library "c": line 1 char 24: name "y" is undefined`,
+		},
+		{
+			script: "def f(x) = lambda: x > y\nvar v = f(1)",
+			err:    `line 2 char 9: in function f: line 1 char 24: name "y" is undefined`,
+		},
+		{
+			script: "def f(x, y) = lambda: x > y\nvar v = f(1)",
+			err:    `line 2 char 9: function f expects 2 arguments, got 1`,
+		},
+		{
+			script: "def f(x) = f(x)\nvar v = f(1)",
+			err:    `function f exceeded the maximum call depth of 100`,
+		},
+	}
+	for _, tc := range testCases {
+		scope := tick.NewScope()
+		scope.SetImporter(importer)
+		err := tick.Evaluate(tc.script, scope)
+		if err == nil {
+			t.Errorf("expected error evaluating %q", tc.script)
+			continue
+		}
+		if !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("unexpected error evaluating %q: exp %q got %q", tc.script, tc.err, err.Error())
+		}
+	}
+
+	// Without an importer
+	err := tick.Evaluate("import 'a'", tick.NewScope())
+	if exp, got := `line 1 char 1: cannot import "a", no libraries are available`, fmt.Sprint(err); exp != got {
+		t.Errorf("unexpected error exp %q got %q", exp, got)
+	}
+}
+
 // Test that using the wrong chain operator fails
 func TestStrictEvaluate(t *testing.T) {
 	script := `
@@ -848,6 +1013,19 @@ func TestReflectionDescriber(t *testing.T) {
 	}
 }
 
+func TestReflectionDescriber_Names(t *testing.T) {
+	rdA, err := tick.NewReflectionDescriber(new(A), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp, got := []string{"chainMethodA", "hiddenChainMethod"}, rdA.ChainMethodNames(); !reflect.DeepEqual(exp, got) {
+		t.Errorf("unexpected chain method names got: %v exp: %v", got, exp)
+	}
+	if exp, got := []string{"aProperty", "hiddenPropertyMethod", "propertyMethodA"}, rdA.PropertyNames(); !reflect.DeepEqual(exp, got) {
+		t.Errorf("unexpected property names got: %v exp: %v", got, exp)
+	}
+}
+
 func TestReflectionDescriberErrors(t *testing.T) {
 	_, err := tick.NewReflectionDescriber(nil, nil)
 	if err == nil {
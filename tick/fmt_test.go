@@ -69,6 +69,45 @@ func TestFormat(t *testing.T) {
 			script: `global(lambda: ("a" + (1)) / (( 4 +"b") * ("c")))`,
 			exp:    "global(lambda: (\"a\" + 1) / ((4 + \"b\") * \"c\"))\n",
 		},
+		{
+			script: `global(lambda: if(isPresent("a"),"a"??0  +1, 'none'))`,
+			exp:    "global(lambda: if(isPresent(\"a\"), \"a\" ?? 0 + 1, 'none'))\n",
+		},
+		{
+			script: `var hosts=['a','b' , 'c']`,
+			exp:    "var hosts = ['a', 'b', 'c']\n",
+		},
+		{
+			script: `global(lambda: "host" in ['a',/^b/]  AND "cpu"  not   in [0,-1, 2.5])`,
+			exp:    "global(lambda: \"host\" in ['a', /^b/] AND \"cpu\" not in [0, -1, 2.5])\n",
+		},
+		{
+			script: `var x = [
+'a',
+// Second
+'b']`,
+			exp: `var x = [
+    'a',
+    // Second
+    'b'
+]
+`,
+		},
+		{
+			script: `import   'lib/common.tick'
+def  crit( field,threshold )=lambda:  field>threshold
+// Standard alert
+def alertOn(node,level)=node|alert().crit(level)`,
+			exp: `import 'lib/common.tick'
+
+def crit(field, threshold) = lambda: field > threshold
+
+// Standard alert
+def alertOn(node, level) = node
+    |alert()
+        .crit(level)
+`,
+		},
 		{
 			script: `global(lambda: (1 + 2 - 3 * 4 / 5) < (sin(6)) AND (TRUE OR FALSE))`,
 			exp:    "global(lambda: (1 + 2 - 3 * 4 / 5) < sin(6) AND (TRUE OR FALSE))\n",
@@ -305,3 +344,43 @@ max
 		}
 	}
 }
+
+// Slashes in regexes are escaped, so the formatted script can be parsed again.
+func TestFormat_RegexSlash(t *testing.T) {
+	testCases := []struct {
+		script string
+		exp    string
+		regex  string
+	}{
+		{
+			script: `global(lambda: "host" =~ /^server\/[0-9]+$/)`,
+			exp:    "global(lambda: \"host\" =~ /^server\\/[0-9]+$/)\n",
+			regex:  `^server/[0-9]+$`,
+		},
+		{
+			script: `global(lambda: "path" =~ /^\/api\/v[12]\//)`,
+			exp:    "global(lambda: \"path\" =~ /^\\/api\\/v[12]\\//)\n",
+			regex:  `^/api/v[12]/`,
+		},
+	}
+
+	for _, tc := range testCases {
+		got, err := Format(tc.script)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tc.exp {
+			t.Fatalf("unexpected format:\nexp:\n%s\ngot:\n%s", tc.exp, got)
+		}
+		root, err := Parse(got)
+		if err != nil {
+			t.Fatalf("failed to parse formatted script %q: %v", got, err)
+		}
+		// global(lambda: "field" =~ /regex/)
+		lambda := root.(*ListNode).Nodes[0].(*FunctionNode).Args[0].(*LambdaNode)
+		regex := lambda.Node.(*BinaryNode).Right.(*RegexNode).Regex.String()
+		if regex != tc.regex {
+			t.Errorf("unexpected regex after formatting: got %q exp %q", regex, tc.regex)
+		}
+	}
+}
@@ -1,10 +1,12 @@
 package tick
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"math"
 	"strconv"
+	"sync"
 	"time"
 )
 
@@ -16,6 +18,21 @@ type Func interface {
 	Call(...interface{}) (interface{}, error)
 }
 
+// A stateful Func that needs the time of the point the expression is evaluated for,
+// i.e. to compute a rate per second.
+type TimeFunc interface {
+	Func
+	CallAt(t time.Time, args ...interface{}) (interface{}, error)
+}
+
+// A stateful Func whose state can be saved and restored,
+// so that the state survives task restarts via task snapshots.
+type SnapshotFunc interface {
+	Func
+	Snapshot() ([]byte, error)
+	Restore(data []byte) error
+}
+
 // Lookup for functions
 type Funcs map[string]Func
 
@@ -82,18 +99,48 @@ func init() {
 	statelessFuncs["year"] = &year{}
 }
 
+// Statefull functions -- need new instance for each expression
+var statefulFuncs = map[string]func() Func{
+	"sigma":        func() Func { return &sigma{} },
+	"count":        func() Func { return &count{} },
+	"ewma":         func() Func { return &ewma{} },
+	"delta":        func() Func { return &delta{} },
+	"rate":         func() Func { return &rate{} },
+	"spread":       func() Func { return &spread{} },
+	"mean":         func() Func { return &mean{} },
+	"elapsedSince": func() Func { return &elapsedSince{} },
+}
+
 // Return set of built-in Funcs
 func NewFunctions() Funcs {
-	funcs := make(Funcs, len(statelessFuncs)+2)
+	funcs := make(Funcs, len(statelessFuncs)+len(statefulFuncs))
 	for n, f := range statelessFuncs {
 		funcs[n] = f
 	}
+	for n, newFunc := range statefulFuncs {
+		funcs[n] = newFunc()
+	}
+	return funcs
+}
 
-	// Statefull functions -- need new instance
-	funcs["sigma"] = &sigma{}
-	funcs["count"] = &count{}
+// Return a new instance of the built-in stateful function,
+// reports false if the function is not a stateful function.
+func NewStatefulFunc(name string) (Func, bool) {
+	newFunc, ok := statefulFuncs[name]
+	if !ok {
+		return nil, false
+	}
+	return newFunc(), true
+}
 
-	return funcs
+// Whether the built-in function needs the time of the point, see TimeFunc.
+func IsTimeFunc(name string) bool {
+	newFunc, ok := statefulFuncs[name]
+	if !ok {
+		return false
+	}
+	_, ok = newFunc().(TimeFunc)
+	return ok
 }
 
 type math1Func func(float64) float64
@@ -349,31 +396,34 @@ func (*str) Call(args ...interface{}) (v interface{}, err error) {
 }
 
 type count struct {
-	n int64
+	N int64
 }
 
 func (c *count) Reset() {
-	c.n = 0
+	c.N = 0
 }
 
 // Counts the number of values processed.
 func (c *count) Call(args ...interface{}) (v interface{}, err error) {
-	c.n++
-	return c.n, nil
+	c.N++
+	return c.N, nil
 }
 
+func (c *count) Snapshot() ([]byte, error) { return json.Marshal(c) }
+func (c *count) Restore(data []byte) error { return json.Unmarshal(data, c) }
+
 type sigma struct {
-	mean     float64
-	variance float64
-	m2       float64
-	n        float64
+	Mean     float64
+	Variance float64
+	M2       float64
+	N        float64
 }
 
 func (s *sigma) Reset() {
-	s.mean = 0
-	s.variance = 0
-	s.m2 = 0
-	s.n = 0
+	s.Mean = 0
+	s.Variance = 0
+	s.M2 = 0
+	s.N = 0
 }
 
 // Computes the number of standard devaitions a given value is from the running mean.
@@ -385,16 +435,269 @@ func (s *sigma) Call(args ...interface{}) (interface{}, error) {
 	if !ok {
 		return nil, ErrNotFloat
 	}
-	s.n++
-	delta := x - s.mean
-	s.mean = s.mean + delta/s.n
-	s.m2 = s.m2 + delta*(x-s.mean)
-	s.variance = s.m2 / (s.n - 1)
+	s.N++
+	delta := x - s.Mean
+	s.Mean = s.Mean + delta/s.N
+	s.M2 = s.M2 + delta*(x-s.Mean)
+	s.Variance = s.M2 / (s.N - 1)
 
-	if s.n < 2 {
+	if s.N < 2 {
 		return float64(0), nil
 	}
-	return math.Abs(x-s.mean) / math.Sqrt(s.variance), nil
+	return math.Abs(x-s.Mean) / math.Sqrt(s.Variance), nil
+}
+
+func (s *sigma) Snapshot() ([]byte, error) { return json.Marshal(s) }
+func (s *sigma) Restore(data []byte) error { return json.Unmarshal(data, s) }
+
+type ewma struct {
+	Value   float64
+	Started bool
+}
+
+func (e *ewma) Reset() {
+	e.Value = 0
+	e.Started = false
+}
+
+// Computes the exponentially weighted moving average of the values,
+// the weight alpha of the latest value is between 0 exclusive and 1 inclusive.
+func (e *ewma) Call(args ...interface{}) (interface{}, error) {
+	if len(args) != 2 {
+		return 0, errors.New("ewma expects exactly two arguments")
+	}
+	x, ok := args[0].(float64)
+	if !ok {
+		return nil, ErrNotFloat
+	}
+	alpha, ok := args[1].(float64)
+	if !ok {
+		return nil, ErrNotFloat
+	}
+	if alpha <= 0 || alpha > 1 {
+		return nil, fmt.Errorf("ewma alpha must be in (0, 1], got %v", alpha)
+	}
+	if !e.Started {
+		e.Value = x
+		e.Started = true
+	} else {
+		e.Value = alpha*x + (1-alpha)*e.Value
+	}
+	return e.Value, nil
+}
+
+func (e *ewma) Snapshot() ([]byte, error) { return json.Marshal(e) }
+func (e *ewma) Restore(data []byte) error { return json.Unmarshal(data, e) }
+
+type delta struct {
+	Last    float64
+	Started bool
+}
+
+func (d *delta) Reset() {
+	d.Last = 0
+	d.Started = false
+}
+
+// Computes the difference between a value and the previous value, 0 for the first value.
+func (d *delta) Call(args ...interface{}) (interface{}, error) {
+	if len(args) != 1 {
+		return 0, errors.New("delta expects exactly one argument")
+	}
+	x, ok := args[0].(float64)
+	if !ok {
+		return nil, ErrNotFloat
+	}
+	var v float64
+	if d.Started {
+		v = x - d.Last
+	}
+	d.Last = x
+	d.Started = true
+	return v, nil
+}
+
+func (d *delta) Snapshot() ([]byte, error) { return json.Marshal(d) }
+func (d *delta) Restore(data []byte) error { return json.Unmarshal(data, d) }
+
+type rate struct {
+	Last     float64
+	LastTime time.Time
+	Started  bool
+}
+
+func (r *rate) Reset() {
+	r.Last = 0
+	r.LastTime = time.Time{}
+	r.Started = false
+}
+
+func (r *rate) Call(args ...interface{}) (interface{}, error) {
+	return nil, errors.New("rate needs the time of the point")
+}
+
+// Computes the change per second between a value and the previous value using the times of the points,
+// 0 for the first value and for points with the same time.
+func (r *rate) CallAt(t time.Time, args ...interface{}) (interface{}, error) {
+	if len(args) != 1 {
+		return 0, errors.New("rate expects exactly one argument")
+	}
+	x, ok := args[0].(float64)
+	if !ok {
+		return nil, ErrNotFloat
+	}
+	var v float64
+	if r.Started && t.After(r.LastTime) {
+		v = (x - r.Last) / t.Sub(r.LastTime).Seconds()
+	}
+	r.Last = x
+	r.LastTime = t
+	r.Started = true
+	return v, nil
+}
+
+func (r *rate) Snapshot() ([]byte, error) { return json.Marshal(r) }
+func (r *rate) Restore(data []byte) error { return json.Unmarshal(data, r) }
+
+type spread struct {
+	Min     float64
+	Max     float64
+	Started bool
+}
+
+func (s *spread) Reset() {
+	s.Min = 0
+	s.Max = 0
+	s.Started = false
+}
+
+// Computes the difference between the largest and the smallest value processed.
+func (s *spread) Call(args ...interface{}) (interface{}, error) {
+	if len(args) != 1 {
+		return 0, errors.New("spread expects exactly one argument")
+	}
+	x, ok := args[0].(float64)
+	if !ok {
+		return nil, ErrNotFloat
+	}
+	if !s.Started {
+		s.Min = x
+		s.Max = x
+		s.Started = true
+	}
+	s.Min = math.Min(s.Min, x)
+	s.Max = math.Max(s.Max, x)
+	return s.Max - s.Min, nil
+}
+
+func (s *spread) Snapshot() ([]byte, error) { return json.Marshal(s) }
+func (s *spread) Restore(data []byte) error { return json.Unmarshal(data, s) }
+
+type mean struct {
+	Mean float64
+	N    float64
+}
+
+func (m *mean) Reset() {
+	m.Mean = 0
+	m.N = 0
+}
+
+// Computes the running mean of the values processed.
+func (m *mean) Call(args ...interface{}) (interface{}, error) {
+	if len(args) != 1 {
+		return 0, errors.New("mean expects exactly one argument")
+	}
+	x, ok := args[0].(float64)
+	if !ok {
+		return nil, ErrNotFloat
+	}
+	m.N++
+	m.Mean += (x - m.Mean) / m.N
+	return m.Mean, nil
+}
+
+func (m *mean) Snapshot() ([]byte, error) { return json.Marshal(m) }
+func (m *mean) Restore(data []byte) error { return json.Unmarshal(data, m) }
+
+type elapsedSince struct {
+	// The value formatted with its type,
+	// so that it compares the same after being restored from a snapshot.
+	Value   string
+	Since   time.Time
+	Started bool
+}
+
+func (e *elapsedSince) Reset() {
+	e.Value = ""
+	e.Since = time.Time{}
+	e.Started = false
+}
+
+func (e *elapsedSince) Call(args ...interface{}) (interface{}, error) {
+	return nil, errors.New("elapsedSince needs the time of the point")
+}
+
+// Computes the seconds elapsed since the value last changed using the times of the points,
+// 0 for the first value and whenever the value changes.
+func (e *elapsedSince) CallAt(t time.Time, args ...interface{}) (interface{}, error) {
+	if len(args) != 1 {
+		return 0, errors.New("elapsedSince expects exactly one argument")
+	}
+	v := fmt.Sprintf("%T %v", args[0], args[0])
+	if !e.Started || v != e.Value {
+		e.Value = v
+		e.Since = t
+		e.Started = true
+	}
+	return t.Sub(e.Since).Seconds(), nil
+}
+
+func (e *elapsedSince) Snapshot() ([]byte, error) { return json.Marshal(e) }
+func (e *elapsedSince) Restore(data []byte) error { return json.Unmarshal(data, e) }
+
+// Return the time argument of a time function,
+// in the time zone of the optional second argument, i.e. hour("time", 'Europe/Berlin').
+func timeArg(name string, args []interface{}) (time.Time, error) {
+	if len(args) != 1 && len(args) != 2 {
+		return time.Time{}, errors.New(name + " expects one or two arguments")
+	}
+	t, ok := args[0].(time.Time)
+	if !ok {
+		return time.Time{}, fmt.Errorf("cannot convert %T to time.Time", args[0])
+	}
+	if len(args) == 1 {
+		return t, nil
+	}
+	tz, ok := args[1].(string)
+	if !ok {
+		return time.Time{}, fmt.Errorf("cannot pass %T as time zone to %s, must be string", args[1], name)
+	}
+	loc, err := loadLocation(tz)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return t.In(loc), nil
+}
+
+// Cache of the loaded time zones, loading a time zone reads its database file.
+var locations = struct {
+	sync.Mutex
+	m map[string]*time.Location
+}{m: make(map[string]*time.Location)}
+
+func loadLocation(tz string) (*time.Location, error) {
+	locations.Lock()
+	defer locations.Unlock()
+	if loc, ok := locations.m[tz]; ok {
+		return loc, nil
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return nil, err
+	}
+	locations.m[tz] = loc
+	return loc, nil
 }
 
 type minute struct {
@@ -404,17 +707,12 @@ func (*minute) Reset() {
 }
 
 // Return the minute within the hour for the given time, within the range [0,59].
-func (*minute) Call(args ...interface{}) (v interface{}, err error) {
-	if len(args) != 1 {
-		return 0, errors.New("minute expects exactly one argument")
+func (*minute) Call(args ...interface{}) (interface{}, error) {
+	t, err := timeArg("minute", args)
+	if err != nil {
+		return 0, err
 	}
-	switch a := args[0].(type) {
-	case time.Time:
-		v = int64(a.Minute())
-	default:
-		err = fmt.Errorf("cannot convert %T to time.Time", a)
-	}
-	return
+	return int64(t.Minute()), nil
 }
 
 type hour struct {
@@ -424,17 +722,12 @@ func (*hour) Reset() {
 }
 
 // Return the hour within the day for the given time, within the range [0,23].
-func (*hour) Call(args ...interface{}) (v interface{}, err error) {
-	if len(args) != 1 {
-		return 0, errors.New("hour expects exactly one argument")
+func (*hour) Call(args ...interface{}) (interface{}, error) {
+	t, err := timeArg("hour", args)
+	if err != nil {
+		return 0, err
 	}
-	switch a := args[0].(type) {
-	case time.Time:
-		v = int64(a.Hour())
-	default:
-		err = fmt.Errorf("cannot convert %T to time.Time", a)
-	}
-	return
+	return int64(t.Hour()), nil
 }
 
 type weekday struct {
@@ -444,17 +737,12 @@ func (*weekday) Reset() {
 }
 
 // Return the weekday within the week for the given time, within the range [0,6] where 0 is Sunday.
-func (*weekday) Call(args ...interface{}) (v interface{}, err error) {
-	if len(args) != 1 {
-		return 0, errors.New("weekday expects exactly one argument")
+func (*weekday) Call(args ...interface{}) (interface{}, error) {
+	t, err := timeArg("weekday", args)
+	if err != nil {
+		return 0, err
 	}
-	switch a := args[0].(type) {
-	case time.Time:
-		v = int64(a.Weekday())
-	default:
-		err = fmt.Errorf("cannot convert %T to time.Time", a)
-	}
-	return
+	return int64(t.Weekday()), nil
 }
 
 type day struct {
@@ -464,17 +752,12 @@ func (*day) Reset() {
 }
 
 // Return the day within the month for the given time, within the range [1,31] depending on the month.
-func (*day) Call(args ...interface{}) (v interface{}, err error) {
-	if len(args) != 1 {
-		return 0, errors.New("day expects exactly one argument")
+func (*day) Call(args ...interface{}) (interface{}, error) {
+	t, err := timeArg("day", args)
+	if err != nil {
+		return 0, err
 	}
-	switch a := args[0].(type) {
-	case time.Time:
-		v = int64(a.Day())
-	default:
-		err = fmt.Errorf("cannot convert %T to time.Time", a)
-	}
-	return
+	return int64(t.Day()), nil
 }
 
 type month struct {
@@ -484,17 +767,12 @@ func (*month) Reset() {
 }
 
 // Return the month within the year for the given time, within the range [1,12].
-func (*month) Call(args ...interface{}) (v interface{}, err error) {
-	if len(args) != 1 {
-		return 0, errors.New("month expects exactly one argument")
+func (*month) Call(args ...interface{}) (interface{}, error) {
+	t, err := timeArg("month", args)
+	if err != nil {
+		return 0, err
 	}
-	switch a := args[0].(type) {
-	case time.Time:
-		v = int64(a.Month())
-	default:
-		err = fmt.Errorf("cannot convert %T to time.Time", a)
-	}
-	return
+	return int64(t.Month()), nil
 }
 
 type year struct {
@@ -504,15 +782,10 @@ func (*year) Reset() {
 }
 
 // Return the year for the given time.
-func (*year) Call(args ...interface{}) (v interface{}, err error) {
-	if len(args) != 1 {
-		return 0, errors.New("year expects exactly one argument")
+func (*year) Call(args ...interface{}) (interface{}, error) {
+	t, err := timeArg("year", args)
+	if err != nil {
+		return 0, err
 	}
-	switch a := args[0].(type) {
-	case time.Time:
-		v = int64(a.Year())
-	default:
-		err = fmt.Errorf("cannot convert %T to time.Time", a)
-	}
-	return
+	return int64(t.Year()), nil
 }
@@ -24,6 +24,8 @@ const (
 	TokenIdent
 	TokenReference
 	TokenLambda
+	TokenImport
+	TokenDef
 	TokenNumber
 	TokenString
 	TokenDuration
@@ -35,10 +37,14 @@ const (
 	TokenFalse
 	TokenRegex
 	TokenComment
+	TokenLBracket
+	TokenRBracket
 
 	// begin operator tokens
 	begin_tok_operator
 
+	TokenDefault
+
 	//begin mathematical operators
 	begin_tok_operator_math
 
@@ -71,6 +77,8 @@ const (
 	TokenGreaterEqual
 	TokenRegexEqual
 	TokenRegexNotEqual
+	TokenIn
+	TokenNotIn
 
 	//end comparison operators
 	end_tok_operator_comp
@@ -81,6 +89,7 @@ const (
 
 var operatorStr = [...]string{
 	TokenNot:           "!",
+	TokenDefault:       "??",
 	TokenPlus:          "+",
 	TokenMinus:         "-",
 	TokenMult:          "*",
@@ -94,6 +103,8 @@ var operatorStr = [...]string{
 	TokenGreaterEqual:  ">=",
 	TokenRegexEqual:    "=~",
 	TokenRegexNotEqual: "!~",
+	TokenIn:            "in",
+	TokenNotIn:         "not in",
 	TokenAnd:           "AND",
 	TokenOr:            "OR",
 }
@@ -107,6 +118,10 @@ const (
 	KW_False  = "FALSE"
 	KW_Var    = "var"
 	KW_Lambda = "lambda"
+	KW_In     = "in"
+	KW_Not    = "not"
+	KW_Import = "import"
+	KW_Def    = "def"
 )
 
 var keywords = map[string]TokenType{
@@ -116,6 +131,9 @@ var keywords = map[string]TokenType{
 	KW_False:  TokenFalse,
 	KW_Var:    TokenVar,
 	KW_Lambda: TokenLambda,
+	KW_In:     TokenIn,
+	KW_Import: TokenImport,
+	KW_Def:    TokenDef,
 }
 
 func init() {
@@ -134,6 +152,10 @@ func (t TokenType) String() string {
 		return "EOF"
 	case t == TokenVar:
 		return "var"
+	case t == TokenImport:
+		return "import"
+	case t == TokenDef:
+		return "def"
 	case t == TokenIdent:
 		return "identifier"
 	case t == TokenReference:
@@ -160,6 +182,10 @@ func (t TokenType) String() string {
 		return "("
 	case t == TokenRParen:
 		return ")"
+	case t == TokenLBracket:
+		return "["
+	case t == TokenRBracket:
+		return "]"
 	case t == TokenComma:
 		return ","
 	case t == TokenNot:
@@ -330,10 +356,16 @@ func lexToken(l *lexer) stateFn {
 			l.ignore()
 		case r == '(':
 			l.emit(TokenLParen)
-			return lexToken
+			return lexArgument
 		case r == ')':
 			l.emit(TokenRParen)
 			return lexToken
+		case r == '[':
+			l.emit(TokenLBracket)
+			return lexArgument
+		case r == ']':
+			l.emit(TokenRBracket)
+			return lexToken
 		case r == '.':
 			l.emit(TokenDot)
 			return lexToken
@@ -345,7 +377,7 @@ func lexToken(l *lexer) stateFn {
 			return lexToken
 		case r == ',':
 			l.emit(TokenComma)
-			return lexToken
+			return lexArgument
 		case r == eof:
 			l.emit(TokenEOF)
 			return nil
@@ -356,7 +388,7 @@ func lexToken(l *lexer) stateFn {
 	}
 }
 
-const operatorChars = "+-*/><!=%"
+const operatorChars = "+-*/><!=%?"
 
 func isOperatorChar(r rune) bool {
 	return strings.IndexRune(operatorChars, r) != -1
@@ -369,6 +401,12 @@ func lexOperator(l *lexer) stateFn {
 			op := strToOperator[l.current()]
 			l.emit(op)
 			return lexToken
+		case '?':
+			if !l.expect('?') {
+				return l.errorf("invalid character '?'")
+			}
+			l.emit(TokenDefault)
+			return lexToken
 		case '/':
 			if l.peek() == '/' {
 				l.backup()
@@ -427,6 +465,10 @@ func lexIdentOrKeyword(l *lexer) stateFn {
 			//absorb
 		default:
 			l.backup()
+			if l.current() == KW_Not && l.expectNotIn() {
+				l.emit(TokenNotIn)
+				return lexToken
+			}
 			if t := keywords[l.current()]; t > 0 {
 				if t == TokenLambda && l.next() != ':' {
 					return l.errorf("missing ':' on lambda keyword")
@@ -440,6 +482,21 @@ func lexIdentOrKeyword(l *lexer) stateFn {
 	}
 }
 
+// expectNotIn consumes the 'in' keyword following 'not',
+// reporting whether it was found.
+func (l *lexer) expectNotIn() bool {
+	rest := l.input[l.pos:]
+	trimmed := strings.TrimLeftFunc(rest, unicode.IsSpace)
+	if len(trimmed) == len(rest) || !strings.HasPrefix(trimmed, KW_In) {
+		return false
+	}
+	if r, _ := utf8.DecodeRuneInString(trimmed[len(KW_In):]); isValidIdent(r) {
+		return false
+	}
+	l.pos += len(rest) - len(trimmed) + len(KW_In)
+	return true
+}
+
 // isValidIdent reports whether r is either a letter or a digit
 func isValidIdent(r rune) bool {
 	return unicode.IsDigit(r) || unicode.IsLetter(r) || r == '_'
@@ -544,6 +601,17 @@ func lexSingleOrTripleString(l *lexer) stateFn {
 	}
 }
 
+// Lex the start of an argument or list element, which can be a regex.
+// A leading '/' cannot be division, as there is no left operand,
+// so it is a regex unless it is the start of a comment.
+func lexArgument(l *lexer) stateFn {
+	l.ignoreSpace()
+	if strings.HasPrefix(l.input[l.pos:], "/") && !strings.HasPrefix(l.input[l.pos:], "//") {
+		return lexRegex
+	}
+	return lexToken
+}
+
 func lexRegex(l *lexer) stateFn {
 	if n := l.next(); n != '/' {
 		return l.errorf(`unexpected "%c" expected "/"`, n)
@@ -424,6 +424,81 @@ func TestLexer(t *testing.T) {
 				token{TokenEOF, 33, ""},
 			},
 		},
+		{
+			in: `f(/^cpu/, /mem/)`,
+			tokens: []token{
+				token{TokenIdent, 0, "f"},
+				token{TokenLParen, 1, "("},
+				token{TokenRegex, 2, "/^cpu/"},
+				token{TokenComma, 8, ","},
+				token{TokenRegex, 10, "/mem/"},
+				token{TokenRParen, 15, ")"},
+				token{TokenEOF, 16, ""},
+			},
+		},
+		{
+			in: `"a" ?? 0`,
+			tokens: []token{
+				token{TokenReference, 0, `"a"`},
+				token{TokenDefault, 4, "??"},
+				token{TokenNumber, 7, "0"},
+				token{TokenEOF, 8, ""},
+			},
+		},
+		{
+			in: `?`,
+			tokens: []token{
+				token{TokenError, 0, "invalid character '?'"},
+			},
+		},
+		{
+			in: `['a', /^b/]`,
+			tokens: []token{
+				token{TokenLBracket, 0, "["},
+				token{TokenString, 1, "'a'"},
+				token{TokenComma, 4, ","},
+				token{TokenRegex, 6, "/^b/"},
+				token{TokenRBracket, 10, "]"},
+				token{TokenEOF, 11, ""},
+			},
+		},
+		{
+			in: `"host" in x`,
+			tokens: []token{
+				token{TokenReference, 0, `"host"`},
+				token{TokenIn, 7, "in"},
+				token{TokenIdent, 10, "x"},
+				token{TokenEOF, 11, ""},
+			},
+		},
+		{
+			in: `"host" not  in x`,
+			tokens: []token{
+				token{TokenReference, 0, `"host"`},
+				token{TokenNotIn, 7, "not  in"},
+				token{TokenIdent, 15, "x"},
+				token{TokenEOF, 16, ""},
+			},
+		},
+		{
+			in: `not inside`,
+			tokens: []token{
+				token{TokenIdent, 0, "not"},
+				token{TokenIdent, 4, "inside"},
+				token{TokenEOF, 10, ""},
+			},
+		},
+		{
+			in: `f( // comment
+)`,
+			tokens: []token{
+				token{TokenIdent, 0, "f"},
+				token{TokenLParen, 1, "("},
+				token{TokenComment, 3, "// comment\n"},
+				token{TokenRParen, 14, ")"},
+				token{TokenEOF, 15, ""},
+			},
+		},
 
 		//Space
 		{
@@ -0,0 +1,334 @@
+// Package lint finds risky but valid constructs in TICKscripts.
+//
+// The TICKscript is only parsed, not evaluated, so that scripts using
+// libraries or UDFs defined on a server can be linted as well.
+// The pipelines are reconstructed from the chain expressions and vars of the script
+// and each enabled rule inspects them for problems.
+package lint
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick"
+)
+
+// A problem found by a rule, at the position of the offending node.
+type Problem struct {
+	Rule    string `json:"rule"`
+	Line    int    `json:"line"`
+	Char    int    `json:"char"`
+	Message string `json:"message"`
+}
+
+func newProblem(rule string, p tick.Position, fmtStr string, args ...interface{}) Problem {
+	return Problem{
+		Rule:    rule,
+		Line:    p.Line(),
+		Char:    p.Char(),
+		Message: fmt.Sprintf(fmtStr, args...),
+	}
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("line %d char %d: %s: %s", p.Line, p.Char, p.Rule, p.Message)
+}
+
+// Sorts problems by position and rule name.
+type byPosition []Problem
+
+func (p byPosition) Len() int      { return len(p) }
+func (p byPosition) Swap(i, j int) { p[i], p[j] = p[j], p[i] }
+func (p byPosition) Less(i, j int) bool {
+	if p[i].Line != p[j].Line {
+		return p[i].Line < p[j].Line
+	}
+	if p[i].Char != p[j].Char {
+		return p[i].Char < p[j].Char
+	}
+	return p[i].Rule < p[j].Rule
+}
+
+// A check of TICKscripts for a kind of problem.
+type Rule struct {
+	Name        string
+	Description string
+	check       func(s *script, c Config) []Problem
+}
+
+// All the rules, enabled unless disabled by the config.
+var Rules = []Rule{
+	{
+		Name:        "window-align",
+		Description: "A window of a stream task without .align(), its boundaries depend on the time of the first point received.",
+		check:       checkWindowAlign,
+	},
+	{
+		Name:        "group-by-all-alert",
+		Description: "An alert fed by groupBy(*), the number of alerts grows with the cardinality of the tags.",
+		check:       checkGroupByAllAlert,
+	},
+	{
+		Name:        "alert-without-handlers",
+		Description: "An alert without any handlers, its alerts are not sent anywhere.",
+		check:       checkAlertWithoutHandlers,
+	},
+	{
+		Name:        "unused-var",
+		Description: "A var that is declared but never used.",
+		check:       checkUnusedVar,
+	},
+	{
+		Name:        "stream-without-where",
+		Description: "A stream task reading a high-volume measurement without .where().",
+		check:       checkStreamWithoutWhere,
+	},
+	{
+		Name:        "batch-every-period",
+		Description: "A batch query with an .every() shorter than its .period(), each point is queried more than once.",
+		check:       checkBatchEveryPeriod,
+	},
+	{
+		Name:        "deprecated-handler",
+		Description: "An alert using a deprecated handler.",
+		check:       checkDeprecatedHandler,
+	},
+}
+
+func findRule(name string) (Rule, bool) {
+	for _, r := range Rules {
+		if r.Name == name {
+			return r, true
+		}
+	}
+	return Rule{}, false
+}
+
+// Configuration of the rules.
+type Config struct {
+	// Rules enabled or disabled by name.
+	// Rules that are not listed are enabled.
+	Rules map[string]bool `toml:"rules" json:"rules"`
+	// Measurements that stream tasks must filter with .where().
+	HighVolumeMeasurements []string `toml:"high-volume-measurements" json:"high-volume-measurements"`
+	// Alert handlers that are deprecated, by their TICKscript name.
+	DeprecatedHandlers []string `toml:"deprecated-handlers" json:"deprecated-handlers"`
+}
+
+func NewConfig() Config {
+	return Config{
+		Rules:              make(map[string]bool),
+		DeprecatedHandlers: []string{"hipChat"},
+	}
+}
+
+func (c Config) Validate() error {
+	for name := range c.Rules {
+		if _, ok := findRule(name); !ok {
+			return fmt.Errorf("unknown lint rule %q", name)
+		}
+	}
+	for _, h := range c.DeprecatedHandlers {
+		if !alertHandlers[h] {
+			return fmt.Errorf("unknown alert handler %q", h)
+		}
+	}
+	return nil
+}
+
+// Whether the rule is enabled.
+func (c Config) Enabled(rule string) bool {
+	enabled, ok := c.Rules[rule]
+	return !ok || enabled
+}
+
+// Lint the TICKscript with the enabled rules.
+// The problems are sorted by their position.
+// Returns an error if the TICKscript cannot be parsed.
+func Lint(script string, c Config) ([]Problem, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	root, err := tick.Parse(script)
+	if err != nil {
+		return nil, err
+	}
+	s := newScript(root)
+	problems := []Problem{}
+	for _, r := range Rules {
+		if c.Enabled(r.Name) {
+			problems = append(problems, r.check(s, c)...)
+		}
+	}
+	sort.Stable(byPosition(problems))
+	return problems, nil
+}
+
+func checkWindowAlign(s *script, c Config) (problems []Problem) {
+	for _, n := range s.nodes {
+		if n.name != "window" || n.source() != "stream" {
+			continue
+		}
+		if n.hasProperty("align") || n.hasProperty("periodCount") || n.hasProperty("everyCount") || n.hasProperty("session") {
+			continue
+		}
+		problems = append(problems, newProblem("window-align", n.fn, "window of a stream task without .align(), its boundaries depend on the time of the first point received"))
+	}
+	return
+}
+
+func checkGroupByAllAlert(s *script, c Config) (problems []Problem) {
+	for _, n := range s.nodes {
+		if n.name != "alert" {
+			continue
+		}
+		if g := n.grouping(); g != nil && isGroupByAll(g) {
+			problems = append(problems, newProblem("group-by-all-alert", n.fn, "alert is fed by groupBy(*) on line %d, an alert is created for every series", g.Line()))
+		}
+	}
+	return
+}
+
+func checkAlertWithoutHandlers(s *script, c Config) (problems []Problem) {
+	for _, n := range s.nodes {
+		if n.name != "alert" {
+			continue
+		}
+		handled := false
+		for _, p := range n.properties {
+			if alertHandlers[p.Func] {
+				handled = true
+				break
+			}
+		}
+		if !handled {
+			problems = append(problems, newProblem("alert-without-handlers", n.fn, "alert has no handlers, its alerts are not sent anywhere"))
+		}
+	}
+	return
+}
+
+func checkUnusedVar(s *script, c Config) (problems []Problem) {
+	for _, decl := range s.declarations {
+		if !s.used[decl.Left.Ident] {
+			problems = append(problems, newProblem("unused-var", decl.Left, "var %s is declared but never used", decl.Left.Ident))
+		}
+	}
+	return
+}
+
+func checkStreamWithoutWhere(s *script, c Config) (problems []Problem) {
+	if len(c.HighVolumeMeasurements) == 0 {
+		return
+	}
+	for _, n := range s.nodes {
+		if n.name != "from" || n.source() != "stream" || n.hasProperty("where") {
+			continue
+		}
+		filtered := false
+		for _, child := range n.children {
+			if child.name == "where" {
+				filtered = true
+				break
+			}
+		}
+		if filtered {
+			continue
+		}
+		for _, p := range n.properties {
+			if p.Func != "measurement" {
+				continue
+			}
+			if m, ok := matchMeasurement(p.Args, c.HighVolumeMeasurements); ok {
+				problems = append(problems, newProblem("stream-without-where", n.fn, "stream task reads the high-volume measurement %q without .where()", m))
+			}
+		}
+	}
+	return
+}
+
+func checkBatchEveryPeriod(s *script, c Config) (problems []Problem) {
+	for _, n := range s.nodes {
+		if n.name != "query" {
+			continue
+		}
+		every, period := n.durationProperty("every"), n.durationProperty("period")
+		if every == nil || period == nil || every.Dur >= period.Dur {
+			continue
+		}
+		problems = append(problems, newProblem("batch-every-period", every, "query runs every %s but queries a period of %s, each point is queried more than once", every.Dur, period.Dur))
+	}
+	return
+}
+
+func checkDeprecatedHandler(s *script, c Config) (problems []Problem) {
+	deprecated := make(map[string]bool, len(c.DeprecatedHandlers))
+	for _, h := range c.DeprecatedHandlers {
+		deprecated[h] = true
+	}
+	for _, n := range s.nodes {
+		if n.name != "alert" {
+			continue
+		}
+		for _, p := range n.properties {
+			if deprecated[p.Func] {
+				problems = append(problems, newProblem("deprecated-handler", p, "the %s handler is deprecated", p.Func))
+			}
+		}
+	}
+	return
+}
+
+// Whether the groupBy chain method or property groups by all tags.
+func isGroupByAll(f *tick.FunctionNode) bool {
+	for _, arg := range f.Args {
+		if _, ok := arg.(*tick.StarNode); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Find the first of the measurements selected by the args of .measurement().
+func matchMeasurement(args []tick.Node, measurements []string) (string, bool) {
+	for _, arg := range args {
+		switch a := arg.(type) {
+		case *tick.StringNode:
+			for _, m := range measurements {
+				if a.Literal == m {
+					return m, true
+				}
+			}
+		case *tick.RegexNode:
+			for _, m := range measurements {
+				if a.Regex.MatchString(m) {
+					return m, true
+				}
+			}
+		case *tick.ListLiteralNode:
+			if m, ok := matchMeasurement(a.Elements, measurements); ok {
+				return m, true
+			}
+		}
+	}
+	return "", false
+}
+
+// The TICKscript names of the alert handler properties,
+// i.e. the PostHandlers field of the AlertNode is set by the post property.
+var alertHandlers = func() map[string]bool {
+	handlers := make(map[string]bool)
+	t := reflect.TypeOf(pipeline.AlertNode{})
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := f.Tag.Get("tick")
+		if f.Type.Kind() != reflect.Slice || !strings.HasSuffix(f.Name, "Handlers") || name == "" {
+			continue
+		}
+		handlers[strings.ToLower(name[:1])+name[1:]] = true
+	}
+	return handlers
+}()
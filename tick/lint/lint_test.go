@@ -0,0 +1,253 @@
+package lint_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/influxdata/kapacitor/tick/lint"
+)
+
+func TestLint(t *testing.T) {
+	testCases := []struct {
+		name   string
+		script string
+		config func(c *lint.Config)
+		exp    []string
+	}{
+		{
+			name: "clean",
+			script: `stream
+    |from()
+        .measurement('cpu')
+        .where(lambda: "host" == 'a')
+    |window()
+        .period(1m)
+        .every(1m)
+        .align()
+    |mean('value')
+    |alert()
+        .crit(lambda: "mean" > 90)
+        .post('http://example.com')
+`,
+			config: func(c *lint.Config) {
+				c.HighVolumeMeasurements = []string{"cpu"}
+			},
+		},
+		{
+			name: "window-align",
+			script: `var data = stream
+    |from()
+data
+    |window()
+        .period(1m)
+        .every(1m)
+    |log()
+data
+    |window()
+        .periodCount(10)
+        .everyCount(10)
+    |log()
+batch
+    |query('SELECT value FROM cpu')
+        .period(1m)
+        .every(1m)
+    |window()
+        .period(1m)
+        .every(1m)
+    |log()
+`,
+			exp: []string{
+				"line 4 char 6: window-align: window of a stream task without .align(), its boundaries depend on the time of the first point received",
+			},
+		},
+		{
+			name: "group-by-all-alert",
+			script: `stream
+    |from()
+        .groupBy(*)
+    |alert()
+        .log('/tmp/alert')
+stream
+    |from()
+    |groupBy(*)
+    |groupBy('host')
+    |alert()
+        .log('/tmp/alert')
+batch
+    |query('SELECT value FROM cpu')
+        .period(1m)
+        .every(1m)
+        .groupBy(*)
+    |mean('value')
+    |alert()
+        .log('/tmp/alert')
+`,
+			exp: []string{
+				"line 4 char 6: group-by-all-alert: alert is fed by groupBy(*) on line 3, an alert is created for every series",
+				"line 18 char 6: group-by-all-alert: alert is fed by groupBy(*) on line 16, an alert is created for every series",
+			},
+		},
+		{
+			name: "alert-without-handlers",
+			script: `stream
+    |from()
+    |alert()
+        .crit(lambda: "value" > 90)
+        .id('cpu')
+stream
+    |from()
+    |alert()
+        .crit(lambda: "value" > 90)
+        .pagerDuty2()
+`,
+			exp: []string{
+				"line 3 char 6: alert-without-handlers: alert has no handlers, its alerts are not sent anywhere",
+			},
+		},
+		{
+			name: "unused-var",
+			script: `var threshold = 90
+var unused = 10
+var period = 1m
+def high(n) = n|where(lambda: "value" > threshold)
+stream
+    |from()
+    |high()
+    |window()
+        .period(period)
+        .every(period)
+        .align()
+    |log()
+`,
+			exp: []string{
+				"line 2 char 5: unused-var: var unused is declared but never used",
+			},
+		},
+		{
+			name: "stream-without-where",
+			script: `stream
+    |from()
+        .measurement('cpu')
+    |log()
+stream
+    |from()
+        .measurement(/^mem/)
+    |log()
+stream
+    |from()
+        .measurement(['disk', 'cpu'])
+    |where(lambda: "host" == 'a')
+    |log()
+stream
+    |from()
+        .measurement('net')
+    |log()
+`,
+			config: func(c *lint.Config) {
+				c.HighVolumeMeasurements = []string{"cpu", "mem_total"}
+			},
+			exp: []string{
+				"line 2 char 6: stream-without-where: stream task reads the high-volume measurement \"cpu\" without .where()",
+				"line 6 char 6: stream-without-where: stream task reads the high-volume measurement \"mem_total\" without .where()",
+			},
+		},
+		{
+			name: "batch-every-period",
+			script: `batch
+    |query('SELECT value FROM cpu')
+        .period(5m)
+        .every(1m)
+    |log()
+batch
+    |query('SELECT value FROM cpu')
+        .period(1m)
+        .every(5m)
+    |log()
+`,
+			exp: []string{
+				"line 4 char 16: batch-every-period: query runs every 1m0s but queries a period of 5m0s, each point is queried more than once",
+			},
+		},
+		{
+			name: "deprecated-handler",
+			script: `stream
+    |from()
+    |alert()
+        .hipChat()
+        .slack()
+`,
+			exp: []string{
+				"line 4 char 10: deprecated-handler: the hipChat handler is deprecated",
+			},
+		},
+		{
+			name: "disabled",
+			script: `var unused = 10
+stream
+    |from()
+    |alert()
+        .hipChat()
+`,
+			config: func(c *lint.Config) {
+				c.Rules["unused-var"] = false
+				c.Rules["deprecated-handler"] = false
+			},
+		},
+		{
+			name: "sorted",
+			script: `var unused = 10
+stream
+    |from()
+    |window()
+        .period(1m)
+        .every(1m)
+    |alert()
+`,
+			exp: []string{
+				"line 1 char 5: unused-var: var unused is declared but never used",
+				"line 4 char 6: window-align: window of a stream task without .align(), its boundaries depend on the time of the first point received",
+				"line 7 char 6: alert-without-handlers: alert has no handlers, its alerts are not sent anywhere",
+			},
+		},
+	}
+	for _, tc := range testCases {
+		c := lint.NewConfig()
+		if tc.config != nil {
+			tc.config(&c)
+		}
+		problems, err := lint.Lint(tc.script, c)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.name, err)
+		}
+		got := make([]string, len(problems))
+		for i, p := range problems {
+			got[i] = p.String()
+		}
+		if tc.exp == nil {
+			tc.exp = []string{}
+		}
+		if !reflect.DeepEqual(got, tc.exp) {
+			t.Errorf("%s: unexpected problems:\ngot\n%q\nexp\n%q", tc.name, got, tc.exp)
+		}
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	c := lint.NewConfig()
+	c.Rules["no-such-rule"] = false
+	if err := c.Validate(); err == nil || err.Error() != `unknown lint rule "no-such-rule"` {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	c = lint.NewConfig()
+	c.DeprecatedHandlers = []string{"pager"}
+	if err := c.Validate(); err == nil || err.Error() != `unknown alert handler "pager"` {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestLint_ParseError(t *testing.T) {
+	if _, err := lint.Lint("stream|from(", lint.NewConfig()); err == nil {
+		t.Error("expected parse error")
+	}
+}
@@ -0,0 +1,218 @@
+package lint
+
+import (
+	"reflect"
+
+	"github.com/influxdata/kapacitor/tick"
+)
+
+// A node of a pipeline reconstructed from the chain expressions of a TICKscript.
+type node struct {
+	// The name of the chain method that created the node,
+	// or stream or batch for the sources.
+	name string
+	// The call of the chain method, nil for the sources.
+	fn         *tick.FunctionNode
+	properties []*tick.FunctionNode
+	parents    []*node
+	children   []*node
+}
+
+func (n *node) hasProperty(name string) bool {
+	for _, p := range n.properties {
+		if p.Func == name {
+			return true
+		}
+	}
+	return false
+}
+
+// The duration argument of the last call of the property, or nil.
+func (n *node) durationProperty(name string) *tick.DurationNode {
+	var d *tick.DurationNode
+	for _, p := range n.properties {
+		if p.Func == name && len(p.Args) == 1 {
+			d, _ = p.Args[0].(*tick.DurationNode)
+		}
+	}
+	return d
+}
+
+// The source the node is fed by, either stream or batch.
+// Empty if the source is not known, i.e. the chain starts with an imported var.
+func (n *node) source() string {
+	for ; n != nil; n = n.firstParent() {
+		if n.fn == nil {
+			return n.name
+		}
+	}
+	return ""
+}
+
+func (n *node) firstParent() *node {
+	if len(n.parents) == 0 {
+		return nil
+	}
+	return n.parents[0]
+}
+
+// The call grouping the data the node receives, either the groupBy chain method
+// or the groupBy property of a from or query node.
+// Nil if the data is not grouped.
+func (n *node) grouping() *tick.FunctionNode {
+	for p := n.firstParent(); p != nil; p = p.firstParent() {
+		if p.name == "groupBy" {
+			return p.fn
+		}
+		if p.name == "from" || p.name == "query" {
+			var groupBy *tick.FunctionNode
+			for _, prop := range p.properties {
+				if prop.Func == "groupBy" {
+					groupBy = prop
+				}
+			}
+			return groupBy
+		}
+	}
+	return nil
+}
+
+// The pipelines and vars of a TICKscript.
+type script struct {
+	nodes []*node
+	// The top level var declarations.
+	declarations []*tick.DeclarationNode
+	// Identifiers referenced anywhere in the script.
+	used map[string]bool
+
+	sources map[string]*node
+	vars    map[string]*node
+}
+
+func newScript(root tick.Node) *script {
+	s := &script{
+		used:    make(map[string]bool),
+		sources: make(map[string]*node),
+		vars:    make(map[string]*node),
+	}
+	walk(root, func(n tick.Node) {
+		if ident, ok := n.(*tick.IdentifierNode); ok {
+			s.used[ident.Ident] = true
+		}
+	})
+	if list, ok := root.(*tick.ListNode); ok {
+		for _, n := range list.Nodes {
+			s.statement(n)
+		}
+	}
+	return s
+}
+
+func (s *script) statement(n tick.Node) {
+	switch n := n.(type) {
+	case *tick.DeclarationNode:
+		s.declarations = append(s.declarations, n)
+		s.vars[n.Left.Ident] = s.eval(n.Right)
+	case *tick.FunctionDefNode, *tick.ImportNode, *tick.CommentNode:
+	default:
+		s.eval(n)
+	}
+}
+
+// Evaluate the expression into the pipeline node it creates, if any.
+func (s *script) eval(n tick.Node) *node {
+	switch n := n.(type) {
+	case *tick.IdentifierNode:
+		switch n.Ident {
+		case "stream", "batch":
+			src, ok := s.sources[n.Ident]
+			if !ok {
+				src = &node{name: n.Ident}
+				s.sources[n.Ident] = src
+			}
+			return src
+		}
+		return s.vars[n.Ident]
+	case *tick.ChainNode:
+		left := s.eval(n.Left)
+		f, ok := n.Right.(*tick.FunctionNode)
+		if !ok {
+			return nil
+		}
+		switch n.Operator {
+		case tick.TokenDot:
+			if left != nil {
+				left.properties = append(left.properties, f)
+			}
+			s.evalArgs(f)
+			return left
+		default:
+			child := &node{name: f.Func, fn: f}
+			s.link(left, child)
+			// Nodes passed as arguments are parents as well, i.e. join and union.
+			for _, p := range s.evalArgs(f) {
+				s.link(p, child)
+			}
+			s.nodes = append(s.nodes, child)
+			return child
+		}
+	case *tick.FunctionNode:
+		s.evalArgs(n)
+	}
+	return nil
+}
+
+func (s *script) evalArgs(f *tick.FunctionNode) []*node {
+	var nodes []*node
+	for _, arg := range f.Args {
+		if n := s.eval(arg); n != nil {
+			nodes = append(nodes, n)
+		}
+	}
+	return nodes
+}
+
+func (s *script) link(parent, child *node) {
+	if parent == nil {
+		return
+	}
+	parent.children = append(parent.children, child)
+	child.parents = append(child.parents, parent)
+}
+
+// Call f for each node of the AST.
+func walk(n tick.Node, f func(tick.Node)) {
+	if n == nil || reflect.ValueOf(n).IsNil() {
+		return
+	}
+	f(n)
+	switch node := n.(type) {
+	case *tick.ListNode:
+		for _, c := range node.Nodes {
+			walk(c, f)
+		}
+	case *tick.DeclarationNode:
+		// The declared identifier is not a use of the var.
+		walk(node.Right, f)
+	case *tick.FunctionDefNode:
+		walk(node.Body, f)
+	case *tick.ChainNode:
+		walk(node.Left, f)
+		walk(node.Right, f)
+	case *tick.FunctionNode:
+		for _, arg := range node.Args {
+			walk(arg, f)
+		}
+	case *tick.LambdaNode:
+		walk(node.Node, f)
+	case *tick.BinaryNode:
+		walk(node.Left, f)
+		walk(node.Right, f)
+	case *tick.UnaryNode:
+		walk(node.Node, f)
+	case *tick.ListLiteralNode:
+		for _, e := range node.Elements {
+			walk(e, f)
+		}
+	}
+}
@@ -324,6 +324,83 @@ func (n *DeclarationNode) SetComment(c *CommentNode) {
 	n.Comment = c
 }
 
+// Imports the statements of a library.
+type ImportNode struct {
+	position
+	Path    string
+	Comment *CommentNode
+}
+
+func newImport(p position, path string, c *CommentNode) *ImportNode {
+	return &ImportNode{
+		position: p,
+		Path:     path,
+		Comment:  c,
+	}
+}
+
+func (n *ImportNode) String() string {
+	return fmt.Sprintf("ImportNode@%v{%s}%v", n.position, n.Path, n.Comment)
+}
+
+func (n *ImportNode) Format(buf *bytes.Buffer, indent string, onNewLine bool) {
+	if n.Comment != nil {
+		n.Comment.Format(buf, indent, onNewLine)
+	}
+	buf.WriteString(KW_Import)
+	buf.WriteByte(' ')
+	(&StringNode{Literal: n.Path}).Format(buf, indent, false)
+}
+func (n *ImportNode) SetComment(c *CommentNode) {
+	n.Comment = c
+}
+
+// Defines a function with named parameters and a body expression.
+type FunctionDefNode struct {
+	position
+	Name    string
+	Params  []*IdentifierNode
+	Body    Node
+	Comment *CommentNode
+}
+
+func newFunctionDef(p position, name string, params []*IdentifierNode, body Node, c *CommentNode) *FunctionDefNode {
+	return &FunctionDefNode{
+		position: p,
+		Name:     name,
+		Params:   params,
+		Body:     body,
+		Comment:  c,
+	}
+}
+
+func (n *FunctionDefNode) String() string {
+	return fmt.Sprintf("FunctionDefNode@%v{%s %v %v}%v", n.position, n.Name, n.Params, n.Body, n.Comment)
+}
+
+func (n *FunctionDefNode) Format(buf *bytes.Buffer, indent string, onNewLine bool) {
+	if n.Comment != nil {
+		n.Comment.Format(buf, indent, onNewLine)
+	}
+	buf.WriteString(KW_Def)
+	buf.WriteByte(' ')
+	buf.WriteString(n.Name)
+	buf.WriteByte('(')
+	for i, param := range n.Params {
+		if i != 0 {
+			buf.WriteString(", ")
+		}
+		param.Format(buf, indent, false)
+	}
+	buf.WriteString(") ")
+	buf.WriteString(TokenAsgn.String())
+	buf.WriteByte(' ')
+	n.Body.Format(buf, indent, false)
+}
+func (n *FunctionDefNode) SetComment(c *CommentNode) {
+	n.Comment = c
+}
+
 type ChainNode struct {
 	position
 	Left     Node
@@ -574,7 +651,8 @@ func (n *RegexNode) Format(buf *bytes.Buffer, indent string, onNewLine bool) {
 	}
 	writeIndent(buf, indent, onNewLine)
 	buf.WriteByte('/')
-	buf.WriteString(n.Regex.String())
+	// Escape slashes '/'
+	buf.WriteString(strings.Replace(n.Regex.String(), "/", `\/`, -1))
 	buf.WriteByte('/')
 }
 
@@ -724,6 +802,57 @@ func (n *LambdaNode) SetComment(c *CommentNode) {
 	n.Comment = c
 }
 
+// Holds a list literal of values
+type ListLiteralNode struct {
+	position
+	Elements  []Node
+	Comment   *CommentNode
+	MultiLine bool
+}
+
+func newListLiteral(p position, elements []Node, multi bool, c *CommentNode) *ListLiteralNode {
+	return &ListLiteralNode{
+		position:  p,
+		Elements:  elements,
+		Comment:   c,
+		MultiLine: multi,
+	}
+}
+
+func (n *ListLiteralNode) String() string {
+	return fmt.Sprintf("ListLiteralNode@%v{%v}%v", n.position, n.Elements, n.Comment)
+}
+
+func (n *ListLiteralNode) Format(buf *bytes.Buffer, indent string, onNewLine bool) {
+	if n.Comment != nil {
+		n.Comment.Format(buf, indent, onNewLine)
+		onNewLine = true
+	}
+	writeIndent(buf, indent, onNewLine)
+	buf.WriteByte('[')
+	elementIndent := indent + indentStep
+	for i, element := range n.Elements {
+		if i != 0 {
+			buf.WriteByte(',')
+			if !n.MultiLine {
+				buf.WriteByte(' ')
+			}
+		}
+		if n.MultiLine {
+			buf.WriteByte('\n')
+		}
+		element.Format(buf, elementIndent, n.MultiLine)
+	}
+	if n.MultiLine && len(n.Elements) > 0 {
+		buf.WriteByte('\n')
+		buf.WriteString(indent)
+	}
+	buf.WriteByte(']')
+}
+func (n *ListLiteralNode) SetComment(c *CommentNode) {
+	n.Comment = c
+}
+
 //Holds a function call with its args
 type ListNode struct {
 	position
@@ -17,6 +17,28 @@ type parser struct {
 	peekCount int
 }
 
+// Parse the TICKscript and return the root of its AST without evaluating it.
+func Parse(text string) (Node, error) {
+	return parse(text)
+}
+
+// Imports returns the paths of the libraries imported by the script.
+func Imports(text string) ([]string, error) {
+	root, err := parse(text)
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	if list, ok := root.(*ListNode); ok {
+		for _, n := range list.Nodes {
+			if i, ok := n.(*ImportNode); ok {
+				paths = append(paths, i.Path)
+			}
+		}
+	}
+	return paths, nil
+}
+
 // parse returns a Node, created by parsing the DSL described in the
 // argument string. If an error is encountered, parsing stops and a nil Node
 // is returned with the error.
@@ -194,6 +216,10 @@ func (p *parser) statement(c *CommentNode) (Node, *CommentNode) {
 	switch t := p.peek().typ; t {
 	case TokenVar:
 		return p.declaration(c)
+	case TokenImport:
+		return p.importStatement(c), nil
+	case TokenDef:
+		return p.functionDef(c)
 	default:
 		return p.expression(c)
 	}
@@ -210,6 +236,39 @@ func (p *parser) declaration(c *CommentNode) (Node, *CommentNode) {
 	return newDecl(p.position(op.pos), v, b, c), extra
 }
 
+//parse an import statement
+func (p *parser) importStatement(c *CommentNode) Node {
+	if c == nil {
+		c = p.comment()
+	}
+	tok := p.expect(TokenImport)
+	path := p.expect(TokenString)
+	return newImport(p.position(tok.pos), newString(p.position(path.pos), path.val, nil).Literal, c)
+}
+
+//parse a function definition statement
+func (p *parser) functionDef(c *CommentNode) (Node, *CommentNode) {
+	if c == nil {
+		c = p.comment()
+	}
+	tok := p.expect(TokenDef)
+	ident := p.expect(TokenIdent)
+	p.expect(TokenLParen)
+	var params []*IdentifierNode
+	for p.peek().typ != TokenRParen {
+		param := p.expect(TokenIdent)
+		params = append(params, newIdent(p.position(param.pos), param.val, nil))
+		if p.next().typ != TokenComma {
+			p.backup()
+			break
+		}
+	}
+	p.expect(TokenRParen)
+	p.expect(TokenAsgn)
+	body, extra := p.expression(nil)
+	return newFunctionDef(p.position(tok.pos), ident.val, params, body, c), extra
+}
+
 //parse a 'var ident' expression
 func (p *parser) vr() *IdentifierNode {
 	p.expect(TokenVar)
@@ -226,6 +285,8 @@ func (p *parser) expression(c *CommentNode) (Node, *CommentNode) {
 	case TokenIdent:
 		term := p.funcOrIdent(globalFunc, c)
 		return p.chain(term)
+	case TokenLambda:
+		return p.lambda(c), nil
 	default:
 		return p.primary(c), nil
 	}
@@ -305,15 +366,20 @@ func (p *parser) parameter() (n Node) {
 	case TokenIdent:
 		n, _ = p.expression(c)
 	case TokenLambda:
-		lambda := p.next()
-		l := p.binaryExpr()
-		n = newLambda(p.position(lambda.pos), l, c)
+		n = p.lambda(c)
 	default:
 		n = p.primary(c)
 	}
 	return
 }
 
+//parse a lambda expression
+func (p *parser) lambda(c *CommentNode) Node {
+	lambda := p.expect(TokenLambda)
+	l := p.binaryExpr()
+	return newLambda(p.position(lambda.pos), l, c)
+}
+
 // parse the lambda expression.
 func (p *parser) binaryExpr() Node {
 	return p.precedence(p.primary(nil), 0)
@@ -327,6 +393,8 @@ var precedence = [...]int{
 	TokenNotEqual:      2,
 	TokenRegexEqual:    2,
 	TokenRegexNotEqual: 2,
+	TokenIn:            2,
+	TokenNotIn:         2,
 	TokenGreater:       3,
 	TokenGreaterEqual:  3,
 	TokenLess:          3,
@@ -336,6 +404,7 @@ var precedence = [...]int{
 	TokenMult:          5,
 	TokenDiv:           5,
 	TokenMod:           5,
+	TokenDefault:       6,
 }
 
 // parse the expression considering operator precedence.
@@ -426,6 +495,8 @@ func (p *parser) primary(c *CommentNode) Node {
 		return p.star(c)
 	case tok.typ == TokenReference:
 		return p.reference(c)
+	case tok.typ == TokenLBracket:
+		return p.listLiteral(c)
 	case tok.typ == TokenIdent:
 		p.next()
 		if p.peek().typ == TokenLParen {
@@ -505,6 +576,22 @@ func (p *parser) reference(c *CommentNode) Node {
 	return r
 }
 
+//parse a list literal
+func (p *parser) listLiteral(c *CommentNode) Node {
+	lbracket := p.expect(TokenLBracket)
+	var elements []Node
+	for p.peek().typ != TokenRBracket {
+		elements = append(elements, p.primary(nil))
+		if p.next().typ != TokenComma {
+			p.backup()
+			break
+		}
+	}
+	rbracket := p.expect(TokenRBracket)
+	multiLine := p.hasNewLine(lbracket.pos, rbracket.pos)
+	return newListLiteral(p.position(lbracket.pos), elements, multiLine, c)
+}
+
 func (p *parser) boolean(c *CommentNode) Node {
 	n := p.next()
 	num, err := newBool(p.position(n.pos), n.val, c)
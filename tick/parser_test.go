@@ -162,6 +162,122 @@ func TestParseStatements(t *testing.T) {
 				},
 			},
 		},
+		{
+			script: `var x = ['a', 1]`,
+			Root: &ListNode{
+				position: position{
+					pos:  0,
+					line: 1,
+					char: 1,
+				},
+				Nodes: []Node{
+					&DeclarationNode{
+						position: position{
+							pos:  6,
+							line: 1,
+							char: 7,
+						},
+						Left: &IdentifierNode{
+							position: position{
+								pos:  4,
+								line: 1,
+								char: 5,
+							},
+							Ident: "x",
+						},
+						Right: &ListLiteralNode{
+							position: position{
+								pos:  8,
+								line: 1,
+								char: 9,
+							},
+							Elements: []Node{
+								&StringNode{
+									position: position{
+										pos:  9,
+										line: 1,
+										char: 10,
+									},
+									Literal: "a",
+								},
+								&NumberNode{
+									position: position{
+										pos:  14,
+										line: 1,
+										char: 15,
+									},
+									IsInt: true,
+									Int64: 1,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			script: `import 'lib/a.tick'`,
+			Root: &ListNode{
+				position: position{
+					pos:  0,
+					line: 1,
+					char: 1,
+				},
+				Nodes: []Node{
+					&ImportNode{
+						position: position{
+							pos:  0,
+							line: 1,
+							char: 1,
+						},
+						Path: "lib/a.tick",
+					},
+				},
+			},
+		},
+		{
+			script: `def f(a) = lambda: a`,
+			Root: &ListNode{
+				position: position{
+					pos:  0,
+					line: 1,
+					char: 1,
+				},
+				Nodes: []Node{
+					&FunctionDefNode{
+						position: position{
+							pos:  0,
+							line: 1,
+							char: 1,
+						},
+						Name: "f",
+						Params: []*IdentifierNode{{
+							position: position{
+								pos:  6,
+								line: 1,
+								char: 7,
+							},
+							Ident: "a",
+						}},
+						Body: &LambdaNode{
+							position: position{
+								pos:  11,
+								line: 1,
+								char: 12,
+							},
+							Node: &IdentifierNode{
+								position: position{
+									pos:  19,
+									line: 1,
+									char: 20,
+								},
+								Ident: "a",
+							},
+						},
+					},
+				},
+			},
+		},
 		{
 			script: `var x = TRUE`,
 			Root: &ListNode{
@@ -1469,3 +1585,19 @@ var x = stream
 		}
 	}
 }
+
+func TestImports(t *testing.T) {
+	imports, err := Imports(`import 'lib/a.tick'
+// Comment
+import 'b'
+
+stream
+	|from()
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := []string{"lib/a.tick", "b"}; !reflect.DeepEqual(imports, exp) {
+		t.Errorf("unexpected imports got %v exp %v", imports, exp)
+	}
+}
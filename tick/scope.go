@@ -7,11 +7,23 @@ import (
 
 type DynamicMethod func(self interface{}, args ...interface{}) (interface{}, error)
 
+// Returns the TICKscript of the library at path.
+type ImportFunc func(path string) (string, error)
+
 // Contains a set of variables references and their values.
 type Scope struct {
 	variables map[string]interface{}
 
 	dynamicMethods map[string]DynamicMethod
+
+	importer ImportFunc
+	// Set of libraries imported into the scope,
+	// false while the library is being imported.
+	imports map[string]bool
+	// Path of the library being imported, empty while evaluating the script itself.
+	library string
+	// Depth of user defined function calls.
+	depth int
 }
 
 //Initialize a new Scope object.
@@ -19,7 +31,24 @@ func NewScope() *Scope {
 	return &Scope{
 		variables:      make(map[string]interface{}),
 		dynamicMethods: make(map[string]DynamicMethod),
+		imports:        make(map[string]bool),
+	}
+}
+
+// Create a scope for calling a user defined function,
+// with a copy of the variables and the same methods and imports.
+func (s *Scope) child(depth int) *Scope {
+	c := &Scope{
+		variables:      make(map[string]interface{}, len(s.variables)),
+		dynamicMethods: s.dynamicMethods,
+		importer:       s.importer,
+		imports:        s.imports,
+		depth:          depth,
 	}
+	for k, v := range s.variables {
+		c.variables[k] = v
+	}
+	return c
 }
 
 // Set defines a name -> value pairing in the scope.
@@ -46,3 +75,11 @@ func (s *Scope) SetDynamicMethod(name string, m DynamicMethod) {
 func (s *Scope) DynamicMethod(name string) DynamicMethod {
 	return s.dynamicMethods[name]
 }
+
+func (s *Scope) SetImporter(i ImportFunc) {
+	s.importer = i
+}
+
+func (s *Scope) Importer() ImportFunc {
+	return s.importer
+}
@@ -0,0 +1,250 @@
+package stateful
+
+import (
+	"github.com/influxdata/kapacitor/tick"
+)
+
+// The argument and return types of a built-in function.
+type signature struct {
+	// Types of the arguments, nil if arguments of any type are accepted.
+	// InvalidType accepts an argument of any type.
+	args []ValueType
+	// Types of the optional arguments following args.
+	optional []ValueType
+	returns  ValueType
+}
+
+// Signatures of the built-in functions used to check their calls statically.
+var signatures = map[string]signature{
+	// Conversion functions
+	"bool":   {returns: TBool},
+	"int":    {returns: TInt64},
+	"float":  {returns: TFloat64},
+	"string": {returns: TString},
+
+	// Math functions
+	"atan2": {args: []ValueType{TFloat64, TFloat64}, returns: TFloat64},
+	"hypot": {args: []ValueType{TFloat64, TFloat64}, returns: TFloat64},
+	"max":   {args: []ValueType{TFloat64, TFloat64}, returns: TFloat64},
+	"min":   {args: []ValueType{TFloat64, TFloat64}, returns: TFloat64},
+	"mod":   {args: []ValueType{TFloat64, TFloat64}, returns: TFloat64},
+	"pow":   {args: []ValueType{TFloat64, TFloat64}, returns: TFloat64},
+	"jn":    {args: []ValueType{TInt64, TFloat64}, returns: TFloat64},
+	"yn":    {args: []ValueType{TInt64, TFloat64}, returns: TFloat64},
+	"pow10": {args: []ValueType{TInt64}, returns: TFloat64},
+
+	// Stateful functions
+	"sigma":        {args: []ValueType{TFloat64}, returns: TFloat64},
+	"count":        {args: []ValueType{}, returns: TInt64},
+	"ewma":         {args: []ValueType{TFloat64, TFloat64}, returns: TFloat64},
+	"delta":        {args: []ValueType{TFloat64}, returns: TFloat64},
+	"rate":         {args: []ValueType{TFloat64}, returns: TFloat64},
+	"spread":       {args: []ValueType{TFloat64}, returns: TFloat64},
+	"mean":         {args: []ValueType{TFloat64}, returns: TFloat64},
+	"elapsedSince": {args: []ValueType{InvalidType}, returns: TFloat64},
+}
+
+func init() {
+	for _, name := range []string{
+		"abs", "acos", "acosh", "asin", "asinh", "atan", "atanh", "cbrt", "ceil",
+		"cos", "cosh", "erf", "erfc", "exp", "exp2", "expm1", "floor", "gamma",
+		"j0", "j1", "log", "log10", "log1p", "log2", "logb", "sin", "sinh",
+		"sqrt", "tan", "tanh", "trunc", "y0", "y1",
+	} {
+		signatures[name] = signature{args: []ValueType{TFloat64}, returns: TFloat64}
+	}
+	for _, name := range []string{"minute", "hour", "weekday", "day", "month", "year"} {
+		signatures[name] = signature{args: []ValueType{TTime}, optional: []ValueType{TString}, returns: TInt64}
+	}
+}
+
+// Check infers the types of the expression without evaluating it
+// and returns a diagnostic for each type error found.
+//
+// The types of fields and tags are not known until data arrives,
+// fieldTypes contains the types that are known, references to other fields and tags are not checked.
+// If want is not InvalidType the expression must evaluate to that type.
+func Check(n tick.Node, want ValueType, fieldTypes map[string]ValueType) tick.Diagnostics {
+	c := &checker{
+		fieldTypes: fieldTypes,
+	}
+	if l, ok := n.(*tick.LambdaNode); ok {
+		n = l.Node
+	}
+	got := c.infer(n)
+	if len(c.diagnostics) == 0 && want != InvalidType && got != InvalidType && got != want {
+		c.errorf(n, "expression must evaluate to %s, got %s", want, got)
+	}
+	return c.diagnostics
+}
+
+// Infer the type of the expression, see Check.
+// InvalidType is returned when the type can only be known while evaluating.
+func InferType(n tick.Node, fieldTypes map[string]ValueType) ValueType {
+	c := &checker{
+		fieldTypes: fieldTypes,
+	}
+	if l, ok := n.(*tick.LambdaNode); ok {
+		n = l.Node
+	}
+	return c.infer(n)
+}
+
+type checker struct {
+	fieldTypes  map[string]ValueType
+	diagnostics tick.Diagnostics
+}
+
+func (c *checker) errorf(p tick.Position, fmtStr string, args ...interface{}) {
+	c.diagnostics = append(c.diagnostics, tick.NewDiagnostic(p, fmtStr, args...))
+}
+
+// infer returns the type of the node or InvalidType if it is not known.
+func (c *checker) infer(n tick.Node) ValueType {
+	switch node := n.(type) {
+	case *tick.NumberNode:
+		if node.IsInt {
+			return TInt64
+		}
+		return TFloat64
+	case *tick.StringNode:
+		return TString
+	case *tick.BoolNode:
+		return TBool
+	case *tick.RegexNode:
+		return TRegex
+	case *tick.ReferenceNode:
+		if node.Reference == "time" {
+			return TTime
+		}
+		return c.fieldTypes[node.Reference]
+	case *tick.UnaryNode:
+		return c.inferUnary(node)
+	case *tick.BinaryNode:
+		return c.inferBinary(node)
+	case *tick.FunctionNode:
+		return c.inferFunction(node)
+	}
+	return InvalidType
+}
+
+func (c *checker) inferUnary(node *tick.UnaryNode) ValueType {
+	t := c.infer(node.Node)
+	switch node.Operator {
+	case tick.TokenNot:
+		if t != InvalidType && t != TBool {
+			c.errorf(node, "invalid unary operator %v for type %s", node.Operator, t)
+		}
+		return TBool
+	case tick.TokenMinus:
+		if t != InvalidType && t != TInt64 && t != TFloat64 {
+			c.errorf(node, "invalid unary operator %v for type %s", node.Operator, t)
+			return InvalidType
+		}
+		return t
+	}
+	return InvalidType
+}
+
+func (c *checker) inferBinary(node *tick.BinaryNode) ValueType {
+	switch node.Operator {
+	case tick.TokenDefault:
+		return c.branchesType(c.infer(node.Left), c.infer(node.Right))
+	case tick.TokenIn, tick.TokenNotIn:
+		// The elements are checked when the list is compiled.
+		left := c.infer(node.Left)
+		if list, ok := node.Right.(*tick.ListLiteralNode); ok && left != InvalidType {
+			types := make(map[ValueType]bool, len(list.Elements))
+			for _, element := range list.Elements {
+				types[listElementType(element)] = true
+			}
+			if !inListAccepts(types, left) {
+				c.errorf(node, "%v", mismatchedInListError(node.Operator, left))
+				return InvalidType
+			}
+		}
+		return TBool
+	}
+
+	left := c.infer(node.Left)
+	right := c.infer(node.Right)
+	var result ValueType
+	switch {
+	case tick.IsCompOperator(node.Operator), tick.IsLogicalOperator(node.Operator):
+		result = TBool
+	case left == right:
+		result = left
+	case left != InvalidType && right != InvalidType:
+		// Only mixed numeric types have evaluation funcs.
+		result = TFloat64
+	}
+
+	switch {
+	case left != InvalidType && right != InvalidType:
+		if evaluationFuncs[operationKey{operator: node.Operator, leftType: left, rightType: right}] == nil {
+			c.errorf(node, "mismatched type to binary operator. got %s %v %s. see bool(), int(), float(), string()", left, node.Operator, right)
+			return InvalidType
+		}
+	case left != InvalidType:
+		if !typeToBinaryOperators[left][node.Operator] {
+			c.errorf(node, "invalid %s operator %v for type %s", operatorKind(node.Operator), node.Operator, left)
+			return InvalidType
+		}
+	case right != InvalidType:
+		if !typeToBinaryOperators[right][node.Operator] {
+			c.errorf(node, "invalid %s operator %v for type %s", operatorKind(node.Operator), node.Operator, right)
+			return InvalidType
+		}
+	}
+	return result
+}
+
+func (c *checker) inferFunction(node *tick.FunctionNode) ValueType {
+	args := make([]ValueType, len(node.Args))
+	for i, arg := range node.Args {
+		args[i] = c.infer(arg)
+	}
+	switch node.Func {
+	case "if":
+		if len(args) != 3 {
+			c.errorf(node, "if expects exactly three arguments, got %d", len(args))
+			return InvalidType
+		}
+		if args[0] != InvalidType && args[0] != TBool {
+			c.errorf(node, "if condition must be a boolean, got %s", args[0])
+		}
+		return c.branchesType(args[1], args[2])
+	case "isPresent":
+		return TBool
+	}
+
+	sig, ok := signatures[node.Func]
+	if !ok {
+		return InvalidType
+	}
+	if sig.args != nil {
+		want := append(sig.args[:len(sig.args):len(sig.args)], sig.optional...)
+		switch {
+		case len(sig.optional) == 0 && len(args) != len(sig.args):
+			c.errorf(node, "%s expects %d arguments, got %d", node.Func, len(sig.args), len(args))
+			return sig.returns
+		case len(args) < len(sig.args) || len(args) > len(want):
+			c.errorf(node, "%s expects %d to %d arguments, got %d", node.Func, len(sig.args), len(want), len(args))
+			return sig.returns
+		}
+		for i, t := range args {
+			if t != InvalidType && want[i] != InvalidType && t != want[i] {
+				c.errorf(node.Args[i], "cannot pass %s to %s, must be %s", t, node.Func, want[i])
+			}
+		}
+	}
+	return sig.returns
+}
+
+// branchesType returns the type of an expression that evaluates only one of two branches.
+func (c *checker) branchesType(a, b ValueType) ValueType {
+	if a == b {
+		return a
+	}
+	return InvalidType
+}
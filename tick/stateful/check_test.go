@@ -0,0 +1,131 @@
+package stateful_test
+
+import (
+	"testing"
+
+	"github.com/influxdata/kapacitor/tick"
+	"github.com/influxdata/kapacitor/tick/stateful"
+)
+
+// Parse a lambda expression from a TICKscript so that the nodes have positions.
+// The positions include the 8 characters of the "var l = " prefix.
+func parseLambda(t *testing.T, lambda string) tick.Node {
+	scope := tick.NewScope()
+	if err := tick.Evaluate("var l = "+lambda, scope); err != nil {
+		t.Fatal(err)
+	}
+	l, err := scope.Get("l")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return l.(tick.Node)
+}
+
+func TestCheck(t *testing.T) {
+	fieldTypes := map[string]stateful.ValueType{
+		"host":  stateful.TString,
+		"value": stateful.TFloat64,
+		"count": stateful.TInt64,
+	}
+	testCases := []struct {
+		lambda string
+		want   stateful.ValueType
+		exp    string
+	}{
+		{
+			lambda: `lambda: "value" > 10.0 AND "host" =~ /^a/`,
+			want:   stateful.TBool,
+		},
+		{
+			lambda: `lambda: "unknown" > 10 OR "count" != 0`,
+			want:   stateful.TBool,
+		},
+		{
+			lambda: `lambda: "host" > 10`,
+			want:   stateful.TBool,
+			exp:    "line 1 char 24: mismatched type to binary operator. got string > int64. see bool(), int(), float(), string()",
+		},
+		{
+			lambda: `lambda: 'a' > 10 OR "value" AND sqrt("count") > 1.0`,
+			want:   stateful.TBool,
+			exp: "line 1 char 21: mismatched type to binary operator. got string > int64. see bool(), int(), float(), string()\n" +
+				"line 1 char 46: cannot pass int64 to sqrt, must be float64\n" +
+				"line 1 char 37: mismatched type to binary operator. got float64 AND boolean. see bool(), int(), float(), string()",
+		},
+		{
+			lambda: `lambda: "value" * 2.0`,
+			want:   stateful.TBool,
+			exp:    "line 1 char 25: expression must evaluate to boolean, got float64",
+		},
+		{
+			lambda: `lambda: if("host", 1, 2)`,
+			exp:    "line 1 char 17: if condition must be a boolean, got string",
+		},
+		{
+			lambda: `lambda: -"host" + hour("value")`,
+			exp: "line 1 char 17: invalid unary operator - for type string\n" +
+				"line 1 char 32: cannot pass float64 to hour, must be time",
+		},
+		{
+			lambda: `lambda: "host" ?? 'unknown'`,
+			want:   stateful.TString,
+		},
+		{
+			lambda: `lambda: hour("time", 'Europe/Berlin') >= 9`,
+			want:   stateful.TBool,
+		},
+		{
+			lambda: `lambda: hour("time", 1) + day("time", 'UTC', 'UTC')`,
+			exp: "line 1 char 30: cannot pass int64 to hour, must be string\n" +
+				"line 1 char 35: day expects 1 to 2 arguments, got 3",
+		},
+		{
+			lambda: `lambda: elapsedSince("host") > 60.0 AND ewma("value", 0.5) > rate("value")`,
+			want:   stateful.TBool,
+		},
+		{
+			lambda: `lambda: "host" in [1, 2.5]`,
+			want:   stateful.TBool,
+			exp:    "line 1 char 24: mismatched type to in operator. the list has no elements matching type string",
+		},
+		{
+			lambda: `lambda: delta("count") + ewma("value")`,
+			exp: "line 1 char 23: cannot pass int64 to delta, must be float64\n" +
+				"line 1 char 34: ewma expects 2 arguments, got 1",
+		},
+	}
+	for _, tc := range testCases {
+		got := stateful.Check(parseLambda(t, tc.lambda), tc.want, fieldTypes)
+		if tc.exp == "" {
+			if len(got) != 0 {
+				t.Errorf("unexpected diagnostics for %s: %v", tc.lambda, got)
+			}
+			continue
+		}
+		if got.Error() != tc.exp {
+			t.Errorf("unexpected diagnostics for %s:\ngot:\n%s\nexp:\n%s", tc.lambda, got.Error(), tc.exp)
+		}
+	}
+}
+
+func TestInferType(t *testing.T) {
+	fieldTypes := map[string]stateful.ValueType{
+		"value": stateful.TFloat64,
+	}
+	testCases := []struct {
+		lambda string
+		exp    stateful.ValueType
+	}{
+		{lambda: `lambda: "value" * 2.0`, exp: stateful.TFloat64},
+		{lambda: `lambda: int("value")`, exp: stateful.TInt64},
+		{lambda: `lambda: "other" + 1`, exp: stateful.InvalidType},
+		{lambda: `lambda: string("value") + 'x'`, exp: stateful.TString},
+		{lambda: `lambda: "value" ?? 1.0`, exp: stateful.TFloat64},
+		{lambda: `lambda: "host" in ['a', 'b']`, exp: stateful.TBool},
+	}
+	for _, tc := range testCases {
+		if got := stateful.InferType(parseLambda(t, tc.lambda), fieldTypes); got != tc.exp {
+			t.Errorf("unexpected type for %s: got %s exp %s", tc.lambda, got, tc.exp)
+		}
+	}
+}
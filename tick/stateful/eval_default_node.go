@@ -0,0 +1,76 @@
+package stateful
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/influxdata/kapacitor/tick"
+)
+
+// EvalDefaultNode evaluates the '??' operator,
+// which returns the referenced value or the default expression when the reference is missing.
+type EvalDefaultNode struct {
+	reference        string
+	leftEvaluator    NodeEvaluator
+	defaultEvaluator NodeEvaluator
+}
+
+func NewEvalDefaultNode(node *tick.BinaryNode) (*EvalDefaultNode, error) {
+	if node.Operator != tick.TokenDefault {
+		return nil, fmt.Errorf("unknown default operator %v", node.Operator)
+	}
+	ref, ok := node.Left.(*tick.ReferenceNode)
+	if !ok {
+		return nil, fmt.Errorf("left operand of %v operator must be a field or tag reference, got %T", node.Operator, node.Left)
+	}
+	leftEvaluator, err := createNodeEvaluator(ref)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to handle left node: %v", err)
+	}
+	defaultEvaluator, err := createNodeEvaluator(node.Right)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to handle right node: %v", err)
+	}
+	return &EvalDefaultNode{
+		reference:        ref.Reference,
+		leftEvaluator:    leftEvaluator,
+		defaultEvaluator: defaultEvaluator,
+	}, nil
+}
+
+// operand returns the evaluator for the reference if present, otherwise the default.
+func (n *EvalDefaultNode) operand(scope ReadOnlyScope) NodeEvaluator {
+	if isPresent(scope, n.reference) {
+		return n.leftEvaluator
+	}
+	return n.defaultEvaluator
+}
+
+func (n *EvalDefaultNode) Type(scope ReadOnlyScope, executionState ExecutionState) (ValueType, error) {
+	return n.operand(scope).Type(scope, executionState)
+}
+
+func (n *EvalDefaultNode) EvalRegex(scope *tick.Scope, executionState ExecutionState) (*regexp.Regexp, error) {
+	return n.operand(scope).EvalRegex(scope, executionState)
+}
+
+func (n *EvalDefaultNode) EvalTime(scope *tick.Scope, executionState ExecutionState) (time.Time, error) {
+	return n.operand(scope).EvalTime(scope, executionState)
+}
+
+func (n *EvalDefaultNode) EvalString(scope *tick.Scope, executionState ExecutionState) (string, error) {
+	return n.operand(scope).EvalString(scope, executionState)
+}
+
+func (n *EvalDefaultNode) EvalFloat(scope *tick.Scope, executionState ExecutionState) (float64, error) {
+	return n.operand(scope).EvalFloat(scope, executionState)
+}
+
+func (n *EvalDefaultNode) EvalInt(scope *tick.Scope, executionState ExecutionState) (int64, error) {
+	return n.operand(scope).EvalInt(scope, executionState)
+}
+
+func (n *EvalDefaultNode) EvalBool(scope *tick.Scope, executionState ExecutionState) (bool, error) {
+	return n.operand(scope).EvalBool(scope, executionState)
+}
@@ -9,13 +9,16 @@ import (
 )
 
 type EvalFunctionNode struct {
-	funcName       string
+	funcName string
+	// The call site, identifying the state of stateful functions.
+	call           *tick.FunctionNode
 	argsEvaluators []NodeEvaluator
 }
 
 func NewEvalFunctionNode(funcNode *tick.FunctionNode) (*EvalFunctionNode, error) {
 	evalFuncNode := &EvalFunctionNode{
 		funcName: funcNode.Func,
+		call:     funcNode,
 	}
 
 	evalFuncNode.argsEvaluators = make([]NodeEvaluator, 0, len(funcNode.Args))
@@ -55,13 +58,23 @@ func (n *EvalFunctionNode) callFunction(scope *tick.Scope, executionState Execut
 		args = append(args, value)
 	}
 
-	f := executionState.Funcs[n.funcName]
+	f := executionState.callFunc(n.call)
 
 	if f == nil {
 		return nil, fmt.Errorf("undefined function: %q", n.funcName)
 	}
 
-	ret, err := f.Call(args...)
+	var ret interface{}
+	var err error
+	if tf, ok := f.(tick.TimeFunc); ok {
+		t, terr := scopeTime(scope)
+		if terr != nil {
+			return nil, fmt.Errorf("error calling %q: %s", n.funcName, terr)
+		}
+		ret, err = tf.CallAt(t, args...)
+	} else {
+		ret, err = f.Call(args...)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("error calling %q: %s", n.funcName, err)
 	}
@@ -69,6 +82,19 @@ func (n *EvalFunctionNode) callFunction(scope *tick.Scope, executionState Execut
 	return ret, nil
 }
 
+// The time of the point the expression is evaluated for.
+func scopeTime(scope *tick.Scope) (time.Time, error) {
+	v, err := scope.Get("time")
+	if err != nil {
+		return time.Time{}, err
+	}
+	t, ok := v.(time.Time)
+	if !ok {
+		return time.Time{}, fmt.Errorf("time is %T, not time.Time", v)
+	}
+	return t, nil
+}
+
 func (n *EvalFunctionNode) EvalRegex(scope *tick.Scope, executionState ExecutionState) (*regexp.Regexp, error) {
 	refValue, err := n.callFunction(scope, executionState)
 	if err != nil {
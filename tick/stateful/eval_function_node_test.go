@@ -198,10 +198,8 @@ func TestEvalFunctionNode_EvalInt64_Reset(t *testing.T) {
 		t.Errorf("first evaluation: unexpected result: got: %T(%v), expected: int64(1)", result, result)
 	}
 
-	// reset (we don't call ResetAll on ExecutionState in order to isolate the scope of this test)
-	for _, fnc := range executionState.Funcs {
-		fnc.Reset()
-	}
+	// reset, the stateful functions have an instance for each call site
+	executionState.ResetAll()
 
 	// second evaluation
 	result, err = evaluator.EvalInt(tick.NewScope(), executionState)
@@ -0,0 +1,108 @@
+package stateful
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/influxdata/kapacitor/tick"
+)
+
+// EvalIfNode evaluates the if(condition, true expression, false expression) function.
+// Only the branch selected by the condition is evaluated,
+// so the other branch may reference missing fields or return a different type.
+type EvalIfNode struct {
+	conditionEvaluator NodeEvaluator
+	trueEvaluator      NodeEvaluator
+	falseEvaluator     NodeEvaluator
+}
+
+func NewEvalIfNode(funcNode *tick.FunctionNode) (*EvalIfNode, error) {
+	if len(funcNode.Args) != 3 {
+		return nil, fmt.Errorf("if expects exactly three arguments, got %d", len(funcNode.Args))
+	}
+	evaluators := make([]NodeEvaluator, len(funcNode.Args))
+	for i, argNode := range funcNode.Args {
+		argEvaluator, err := createNodeEvaluator(argNode)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to handle %v argument: %v", i+1, err)
+		}
+		evaluators[i] = argEvaluator
+	}
+	return &EvalIfNode{
+		conditionEvaluator: evaluators[0],
+		trueEvaluator:      evaluators[1],
+		falseEvaluator:     evaluators[2],
+	}, nil
+}
+
+// branch evaluates the condition and returns the selected branch.
+func (n *EvalIfNode) branch(scope *tick.Scope, executionState ExecutionState) (NodeEvaluator, error) {
+	condition, err := n.conditionEvaluator.EvalBool(scope, executionState)
+	if err != nil {
+		if typeGuardErr, ok := err.(ErrTypeGuardFailed); ok {
+			return nil, fmt.Errorf("if condition must be a boolean, got %s", typeGuardErr.ActualType)
+		}
+		return nil, err
+	}
+	if condition {
+		return n.trueEvaluator, nil
+	}
+	return n.falseEvaluator, nil
+}
+
+func (n *EvalIfNode) Type(scope ReadOnlyScope, executionState ExecutionState) (ValueType, error) {
+	branch, err := n.branch(scope.(*tick.Scope), executionState)
+	if err != nil {
+		return InvalidType, err
+	}
+	return branch.Type(scope, executionState)
+}
+
+func (n *EvalIfNode) EvalRegex(scope *tick.Scope, executionState ExecutionState) (*regexp.Regexp, error) {
+	branch, err := n.branch(scope, executionState)
+	if err != nil {
+		return nil, err
+	}
+	return branch.EvalRegex(scope, executionState)
+}
+
+func (n *EvalIfNode) EvalTime(scope *tick.Scope, executionState ExecutionState) (time.Time, error) {
+	branch, err := n.branch(scope, executionState)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return branch.EvalTime(scope, executionState)
+}
+
+func (n *EvalIfNode) EvalString(scope *tick.Scope, executionState ExecutionState) (string, error) {
+	branch, err := n.branch(scope, executionState)
+	if err != nil {
+		return "", err
+	}
+	return branch.EvalString(scope, executionState)
+}
+
+func (n *EvalIfNode) EvalFloat(scope *tick.Scope, executionState ExecutionState) (float64, error) {
+	branch, err := n.branch(scope, executionState)
+	if err != nil {
+		return float64(0), err
+	}
+	return branch.EvalFloat(scope, executionState)
+}
+
+func (n *EvalIfNode) EvalInt(scope *tick.Scope, executionState ExecutionState) (int64, error) {
+	branch, err := n.branch(scope, executionState)
+	if err != nil {
+		return int64(0), err
+	}
+	return branch.EvalInt(scope, executionState)
+}
+
+func (n *EvalIfNode) EvalBool(scope *tick.Scope, executionState ExecutionState) (bool, error) {
+	branch, err := n.branch(scope, executionState)
+	if err != nil {
+		return false, err
+	}
+	return branch.EvalBool(scope, executionState)
+}
@@ -0,0 +1,226 @@
+package stateful
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/influxdata/kapacitor/tick"
+)
+
+// EvalInNode evaluates the 'in' and 'not in' operators
+// by looking up the left value in a set built from the list literal once.
+type EvalInNode struct {
+	negate bool
+
+	leftEvaluator NodeEvaluator
+	// Cached type of the left value, fixed up using the type guard errors.
+	leftType ValueType
+
+	strings map[string]bool
+	ints    map[int64]bool
+	floats  map[float64]bool
+	bools   map[bool]bool
+	regexes []*regexp.Regexp
+	// The types of the elements of the list.
+	types map[ValueType]bool
+}
+
+func NewEvalInNode(node *tick.BinaryNode) (*EvalInNode, error) {
+	if node.Operator != tick.TokenIn && node.Operator != tick.TokenNotIn {
+		return nil, fmt.Errorf("unknown membership operator %v", node.Operator)
+	}
+	list, ok := node.Right.(*tick.ListLiteralNode)
+	if !ok {
+		return nil, fmt.Errorf("right operand of %v operator must be a list literal, got %T", node.Operator, node.Right)
+	}
+
+	leftEvaluator, err := createNodeEvaluator(node.Left)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to handle left node: %v", err)
+	}
+
+	n := &EvalInNode{
+		negate:        node.Operator == tick.TokenNotIn,
+		leftEvaluator: leftEvaluator,
+		strings:       make(map[string]bool),
+		ints:          make(map[int64]bool),
+		floats:        make(map[float64]bool),
+		bools:         make(map[bool]bool),
+		types:         make(map[ValueType]bool),
+	}
+	for _, element := range list.Elements {
+		if err := n.add(element); err != nil {
+			return nil, err
+		}
+		n.types[listElementType(element)] = true
+	}
+	return n, nil
+}
+
+// listElementType returns the type of a literal element of a list,
+// or InvalidType if the element is not a literal.
+func listElementType(element tick.Node) ValueType {
+	switch e := element.(type) {
+	case *tick.StringNode:
+		return TString
+	case *tick.RegexNode:
+		return TRegex
+	case *tick.BoolNode:
+		return TBool
+	case *tick.NumberNode:
+		if e.IsInt {
+			return TInt64
+		}
+		return TFloat64
+	case *tick.UnaryNode:
+		return listElementType(e.Node)
+	}
+	return InvalidType
+}
+
+// inListAccepts reports whether a value of the type can be a member of a list with the element types.
+// Strings match string and regex elements, numbers match int and float elements.
+func inListAccepts(types map[ValueType]bool, t ValueType) bool {
+	switch t {
+	case TString:
+		return types[TString] || types[TRegex]
+	case TInt64, TFloat64:
+		return types[TInt64] || types[TFloat64]
+	case TBool:
+		return types[TBool]
+	}
+	return false
+}
+
+func mismatchedInListError(operator tick.TokenType, t ValueType) error {
+	return fmt.Errorf("mismatched type to %v operator. the list has no elements matching type %s", operator, t)
+}
+
+// add a literal element of the list to the sets.
+func (n *EvalInNode) add(element tick.Node) error {
+	switch e := element.(type) {
+	case *tick.StringNode:
+		n.strings[e.Literal] = true
+	case *tick.RegexNode:
+		n.regexes = append(n.regexes, e.Regex)
+	case *tick.BoolNode:
+		n.bools[e.Bool] = true
+	case *tick.NumberNode:
+		if e.IsInt {
+			n.ints[e.Int64] = true
+		} else {
+			n.floats[e.Float64] = true
+		}
+	case *tick.UnaryNode:
+		num, ok := e.Node.(*tick.NumberNode)
+		if !ok || e.Operator != tick.TokenMinus {
+			return fmt.Errorf("list elements must be literal values, got %s", e.Operator)
+		}
+		if num.IsInt {
+			n.ints[-num.Int64] = true
+		} else {
+			n.floats[-num.Float64] = true
+		}
+	default:
+		return fmt.Errorf("list elements must be literal values, got %T", element)
+	}
+	return nil
+}
+
+func (n *EvalInNode) Type(scope ReadOnlyScope, executionState ExecutionState) (ValueType, error) {
+	return TBool, nil
+}
+
+func (n *EvalInNode) EvalRegex(scope *tick.Scope, executionState ExecutionState) (*regexp.Regexp, error) {
+	return nil, ErrTypeGuardFailed{RequestedType: TRegex, ActualType: TBool}
+}
+
+func (n *EvalInNode) EvalTime(scope *tick.Scope, executionState ExecutionState) (time.Time, error) {
+	return time.Time{}, ErrTypeGuardFailed{RequestedType: TTime, ActualType: TBool}
+}
+
+func (n *EvalInNode) EvalString(scope *tick.Scope, executionState ExecutionState) (string, error) {
+	return "", ErrTypeGuardFailed{RequestedType: TString, ActualType: TBool}
+}
+
+func (n *EvalInNode) EvalFloat(scope *tick.Scope, executionState ExecutionState) (float64, error) {
+	return float64(0), ErrTypeGuardFailed{RequestedType: TFloat64, ActualType: TBool}
+}
+
+func (n *EvalInNode) EvalInt(scope *tick.Scope, executionState ExecutionState) (int64, error) {
+	return int64(0), ErrTypeGuardFailed{RequestedType: TInt64, ActualType: TBool}
+}
+
+func (n *EvalInNode) EvalBool(scope *tick.Scope, executionState ExecutionState) (bool, error) {
+	if n.leftType == InvalidType {
+		leftType, err := n.leftEvaluator.Type(scope, CreateExecutionState())
+		if err != nil {
+			return false, err
+		}
+		n.leftType = leftType
+	}
+	found, err := n.contains(scope, executionState)
+	if typeGuardErr, ok := err.(ErrTypeGuardFailed); ok {
+		// The type of the left value changed, try again with the new type.
+		n.leftType = typeGuardErr.ActualType
+		found, err = n.contains(scope, executionState)
+	}
+	if err != nil {
+		return false, err
+	}
+	return found != n.negate, nil
+}
+
+// contains reports whether the left value is a member of the list.
+func (n *EvalInNode) contains(scope *tick.Scope, executionState ExecutionState) (bool, error) {
+	if !inListAccepts(n.types, n.leftType) {
+		switch n.leftType {
+		case TString, TInt64, TFloat64, TBool:
+			return false, mismatchedInListError(n.operator(), n.leftType)
+		}
+	}
+	switch n.leftType {
+	case TString:
+		s, err := n.leftEvaluator.EvalString(scope, executionState)
+		if err != nil {
+			return false, err
+		}
+		if n.strings[s] {
+			return true, nil
+		}
+		for _, r := range n.regexes {
+			if r.MatchString(s) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case TInt64:
+		i, err := n.leftEvaluator.EvalInt(scope, executionState)
+		if err != nil {
+			return false, err
+		}
+		return n.ints[i] || n.floats[float64(i)], nil
+	case TFloat64:
+		f, err := n.leftEvaluator.EvalFloat(scope, executionState)
+		if err != nil {
+			return false, err
+		}
+		return n.floats[f] || (f == float64(int64(f)) && n.ints[int64(f)]), nil
+	case TBool:
+		b, err := n.leftEvaluator.EvalBool(scope, executionState)
+		if err != nil {
+			return false, err
+		}
+		return n.bools[b], nil
+	default:
+		return false, fmt.Errorf("invalid left operand of type %s to %s operator", n.leftType, n.operator())
+	}
+}
+
+func (n *EvalInNode) operator() tick.TokenType {
+	if n.negate {
+		return tick.TokenNotIn
+	}
+	return tick.TokenIn
+}
@@ -0,0 +1,62 @@
+package stateful
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/influxdata/kapacitor/tick"
+)
+
+// EvalIsPresentNode evaluates the isPresent("field") function,
+// which reports whether the referenced field or tag exists.
+type EvalIsPresentNode struct {
+	reference string
+}
+
+func NewEvalIsPresentNode(funcNode *tick.FunctionNode) (*EvalIsPresentNode, error) {
+	if len(funcNode.Args) != 1 {
+		return nil, fmt.Errorf("isPresent expects exactly one argument, got %d", len(funcNode.Args))
+	}
+	ref, ok := funcNode.Args[0].(*tick.ReferenceNode)
+	if !ok {
+		return nil, fmt.Errorf("isPresent expects a field or tag reference, got %T", funcNode.Args[0])
+	}
+	return &EvalIsPresentNode{
+		reference: ref.Reference,
+	}, nil
+}
+
+// isPresent reports whether the reference has a value in the scope.
+func isPresent(scope ReadOnlyScope, reference string) bool {
+	value, err := scope.Get(reference)
+	return err == nil && value != nil
+}
+
+func (n *EvalIsPresentNode) Type(scope ReadOnlyScope, executionState ExecutionState) (ValueType, error) {
+	return TBool, nil
+}
+
+func (n *EvalIsPresentNode) EvalRegex(scope *tick.Scope, executionState ExecutionState) (*regexp.Regexp, error) {
+	return nil, ErrTypeGuardFailed{RequestedType: TRegex, ActualType: TBool}
+}
+
+func (n *EvalIsPresentNode) EvalTime(scope *tick.Scope, executionState ExecutionState) (time.Time, error) {
+	return time.Time{}, ErrTypeGuardFailed{RequestedType: TTime, ActualType: TBool}
+}
+
+func (n *EvalIsPresentNode) EvalString(scope *tick.Scope, executionState ExecutionState) (string, error) {
+	return "", ErrTypeGuardFailed{RequestedType: TString, ActualType: TBool}
+}
+
+func (n *EvalIsPresentNode) EvalFloat(scope *tick.Scope, executionState ExecutionState) (float64, error) {
+	return float64(0), ErrTypeGuardFailed{RequestedType: TFloat64, ActualType: TBool}
+}
+
+func (n *EvalIsPresentNode) EvalInt(scope *tick.Scope, executionState ExecutionState) (int64, error) {
+	return int64(0), ErrTypeGuardFailed{RequestedType: TInt64, ActualType: TBool}
+}
+
+func (n *EvalIsPresentNode) EvalBool(scope *tick.Scope, executionState ExecutionState) (bool, error) {
+	return isPresent(scope, n.reference), nil
+}
@@ -1,16 +1,25 @@
 package stateful
 
-import "github.com/influxdata/kapacitor/tick"
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/influxdata/kapacitor/tick"
+)
 
 // ExecutionState is auxiliary struct for data/context that needs to be passed
 // to evaluation functions
 type ExecutionState struct {
 	Funcs tick.Funcs
+	// Instances of the stateful functions by call site,
+	// so that each call of a function in an expression keeps its own state.
+	calls map[*tick.FunctionNode]tick.Func
 }
 
 func CreateExecutionState() ExecutionState {
 	return ExecutionState{
 		Funcs: tick.NewFunctions(),
+		calls: make(map[*tick.FunctionNode]tick.Func),
 	}
 }
 
@@ -19,4 +28,66 @@ func (ea ExecutionState) ResetAll() {
 	for _, f := range ea.Funcs {
 		f.Reset()
 	}
+	for _, f := range ea.calls {
+		f.Reset()
+	}
+}
+
+// Return the function called at the call site,
+// stateful functions have an instance for each call site.
+func (ea ExecutionState) callFunc(call *tick.FunctionNode) tick.Func {
+	if f, ok := ea.calls[call]; ok {
+		return f
+	}
+	f, ok := tick.NewStatefulFunc(call.Func)
+	if !ok {
+		return ea.Funcs[call.Func]
+	}
+	ea.calls[call] = f
+	return f
+}
+
+// The key of the state of the function called at the call site with the index in snapshots.
+func callKey(call *tick.FunctionNode, index int) string {
+	return fmt.Sprintf("%s:%d", call.Func, index)
+}
+
+// Snapshot the state of the stateful functions called at the call sites.
+func (ea ExecutionState) Snapshot(calls []*tick.FunctionNode) ([]byte, error) {
+	states := make(map[string]json.RawMessage, len(calls))
+	for i, call := range calls {
+		sf, ok := ea.callFunc(call).(tick.SnapshotFunc)
+		if !ok {
+			continue
+		}
+		data, err := sf.Snapshot()
+		if err != nil {
+			return nil, err
+		}
+		states[callKey(call, i)] = data
+	}
+	return json.Marshal(states)
+}
+
+// Restore the state of the stateful functions called at the call sites from a snapshot.
+// Functions missing from the snapshot keep their state.
+func (ea ExecutionState) Restore(data []byte, calls []*tick.FunctionNode) error {
+	var states map[string]json.RawMessage
+	if err := json.Unmarshal(data, &states); err != nil {
+		return err
+	}
+	for i, call := range calls {
+		state, ok := states[callKey(call, i)]
+		if !ok {
+			continue
+		}
+		sf, ok := ea.callFunc(call).(tick.SnapshotFunc)
+		if !ok {
+			continue
+		}
+		if err := sf.Restore(state); err != nil {
+			return fmt.Errorf("failed to restore %s: %v", call.Func, err)
+		}
+	}
+	return nil
 }
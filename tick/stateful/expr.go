@@ -20,11 +20,17 @@ type Expression interface {
 
 	// Return a copy of the expression but with a Reset state.
 	CopyReset() Expression
+
+	// Snapshot and restore the state of the stateful functions.
+	Snapshot() ([]byte, error)
+	Restore(data []byte) error
 }
 
 type expression struct {
 	nodeEvaluator  NodeEvaluator
 	executionState ExecutionState
+	// Calls of the functions whose state is snapshotted.
+	snapshotCalls []*tick.FunctionNode
 }
 
 // NewExpression accept a node and try to "compile"/ "specialise" it
@@ -42,6 +48,7 @@ func NewExpression(node tick.Node) (Expression, error) {
 	return &expression{
 		nodeEvaluator:  nodeEvaluator,
 		executionState: CreateExecutionState(),
+		snapshotCalls:  findSnapshotCalls(node),
 	}, nil
 }
 
@@ -49,6 +56,7 @@ func (se *expression) CopyReset() Expression {
 	return &expression{
 		nodeEvaluator:  se.nodeEvaluator,
 		executionState: CreateExecutionState(),
+		snapshotCalls:  se.snapshotCalls,
 	}
 }
 
@@ -56,6 +64,18 @@ func (se *expression) Reset() {
 	se.executionState.ResetAll()
 }
 
+// Snapshot returns nil if the expression does not call any stateful functions.
+func (se *expression) Snapshot() ([]byte, error) {
+	if len(se.snapshotCalls) == 0 {
+		return nil, nil
+	}
+	return se.executionState.Snapshot(se.snapshotCalls)
+}
+
+func (se *expression) Restore(data []byte) error {
+	return se.executionState.Restore(data, se.snapshotCalls)
+}
+
 func (se *expression) EvalBool(scope *tick.Scope) (bool, error) {
 	return se.nodeEvaluator.EvalBool(scope, se.executionState)
 }
@@ -137,8 +157,37 @@ func buildReferenceVariablesSet(n tick.Node, itemsSet map[string]bool) {
 		buildReferenceVariablesSet(node.Left, itemsSet)
 		buildReferenceVariablesSet(node.Right, itemsSet)
 	case *tick.FunctionNode:
+		if tick.IsTimeFunc(node.Func) {
+			itemsSet["time"] = true
+		}
 		for _, arg := range node.Args {
 			buildReferenceVariablesSet(arg, itemsSet)
 		}
 	}
 }
+
+// Find the calls of the functions whose state can be snapshotted in the order they appear in the expression.
+func findSnapshotCalls(n tick.Node) []*tick.FunctionNode {
+	var calls []*tick.FunctionNode
+	var find func(n tick.Node)
+	find = func(n tick.Node) {
+		switch node := n.(type) {
+		case *tick.UnaryNode:
+			find(node.Node)
+		case *tick.BinaryNode:
+			find(node.Left)
+			find(node.Right)
+		case *tick.FunctionNode:
+			if f, ok := tick.NewStatefulFunc(node.Func); ok {
+				if _, ok := f.(tick.SnapshotFunc); ok {
+					calls = append(calls, node)
+				}
+			}
+			for _, arg := range node.Args {
+				find(arg)
+			}
+		}
+	}
+	find(n)
+	return calls
+}
@@ -2,6 +2,7 @@ package stateful_test
 
 import (
 	"errors"
+	"regexp"
 	"testing"
 
 	"github.com/influxdata/kapacitor/tick"
@@ -251,3 +252,129 @@ func TestExpression_UnaryNode_DyanmicTestCases(t *testing.T) {
 		},
 	})
 }
+
+func TestExpression_InNode_DynamicTestCases(t *testing.T) {
+
+	runDynamicTestCase(t, testCase{
+		Title: "InNode - EvalBool supports type changes",
+
+		Node: &tick.BinaryNode{
+			Operator: tick.TokenIn,
+			Left: &tick.ReferenceNode{
+				Reference: "value",
+			},
+			Right: &tick.ListLiteralNode{
+				Elements: []tick.Node{
+					&tick.StringNode{Literal: "a"},
+					&tick.RegexNode{Regex: regexp.MustCompile("^b")},
+					&tick.NumberNode{IsInt: true, Int64: 1},
+					&tick.UnaryNode{
+						Operator: tick.TokenMinus,
+						Node:     &tick.NumberNode{IsFloat: true, Float64: 2.5},
+					},
+				},
+			},
+		},
+
+		Expectations: []valueExpectation{
+			{IsEvalBool: true, Value: "a", ExpectedResult: true},
+			{IsEvalBool: true, Value: "bc", ExpectedResult: true},
+			{IsEvalBool: true, Value: "c", ExpectedResult: false},
+			{IsEvalBool: true, Value: int64(1), ExpectedResult: true},
+			{IsEvalBool: true, Value: float64(1), ExpectedResult: true},
+			{IsEvalBool: true, Value: float64(-2.5), ExpectedResult: true},
+			{IsEvalBool: true, Value: int64(2), ExpectedResult: false},
+			{IsEvalBool: true, Value: true, ExpectedResult: false, ExpectedError: errors.New("mismatched type to in operator. the list has no elements matching type boolean")},
+		},
+	})
+
+	runDynamicTestCase(t, testCase{
+		Title: "InNode - not in",
+
+		Node: &tick.BinaryNode{
+			Operator: tick.TokenNotIn,
+			Left: &tick.ReferenceNode{
+				Reference: "value",
+			},
+			Right: &tick.ListLiteralNode{
+				Elements: []tick.Node{
+					&tick.StringNode{Literal: "a"},
+					&tick.StringNode{Literal: "b"},
+				},
+			},
+		},
+
+		Expectations: []valueExpectation{
+			{IsEvalBool: true, Value: "a", ExpectedResult: false},
+			{IsEvalBool: true, Value: "c", ExpectedResult: true},
+			{IsEvalBool: true, Value: int64(1), ExpectedResult: false, ExpectedError: errors.New("mismatched type to not in operator. the list has no elements matching type int64")},
+		},
+	})
+}
+
+func TestExpression_IfNode_DynamicTestCases(t *testing.T) {
+
+	runDynamicTestCase(t, testCase{
+		Title: "IfNode - branches with mismatched types",
+
+		Node: &tick.FunctionNode{
+			Func: "if",
+			Args: []tick.Node{
+				&tick.FunctionNode{
+					Func: "isPresent",
+					Args: []tick.Node{&tick.ReferenceNode{Reference: "value"}},
+				},
+				&tick.ReferenceNode{Reference: "value"},
+				&tick.StringNode{Literal: "missing"},
+			},
+		},
+
+		Expectations: []valueExpectation{
+			{IsEvalNum: true, Value: float64(20), ExpectedResult: float64(20)},
+			{IsEvalNum: true, Value: int64(5), ExpectedResult: int64(5)},
+			{IsEvalNum: true, Value: nil, ExpectedResult: "missing"},
+		},
+	})
+
+	runDynamicTestCase(t, testCase{
+		Title: "IfNode - condition must be a boolean",
+
+		Node: &tick.FunctionNode{
+			Func: "if",
+			Args: []tick.Node{
+				&tick.ReferenceNode{Reference: "value"},
+				&tick.NumberNode{IsInt: true, Int64: 1},
+				&tick.NumberNode{IsInt: true, Int64: 2},
+			},
+		},
+
+		Expectations: []valueExpectation{
+			{IsEvalNum: true, Value: true, ExpectedResult: int64(1)},
+			{IsEvalNum: true, Value: false, ExpectedResult: int64(2)},
+			{IsEvalNum: true, Value: "true", ExpectedError: errors.New("if condition must be a boolean, got string")},
+		},
+	})
+}
+
+func TestExpression_DefaultNode_DynamicTestCases(t *testing.T) {
+
+	runDynamicTestCase(t, testCase{
+		Title: "DefaultNode - default on missing reference",
+
+		Node: &tick.BinaryNode{
+			Operator: tick.TokenGreater,
+			Left: &tick.BinaryNode{
+				Operator: tick.TokenDefault,
+				Left:     &tick.ReferenceNode{Reference: "value"},
+				Right:    &tick.NumberNode{IsFloat: true, Float64: 0},
+			},
+			Right: &tick.NumberNode{IsFloat: true, Float64: 10},
+		},
+
+		Expectations: []valueExpectation{
+			{IsEvalBool: true, Value: float64(20), ExpectedResult: true},
+			{IsEvalBool: true, Value: nil, ExpectedResult: false},
+			{IsEvalBool: true, Value: float64(5), ExpectedResult: false},
+		},
+	})
+}
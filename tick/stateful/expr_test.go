@@ -3,7 +3,9 @@ package stateful_test
 import (
 	"errors"
 	"fmt"
+	"reflect"
 	"regexp"
+	"sort"
 	"testing"
 	"time"
 
@@ -48,6 +50,225 @@ func TestExpression_EvalNum_KeepsFunctionsState(t *testing.T) {
 
 }
 
+func TestExpression_Eval_TimeFunctionsInTimezone(t *testing.T) {
+	// 2016-03-27 is the day daylight saving time starts in Europe.
+	now := time.Date(2016, 3, 27, 1, 30, 0, 0, time.UTC)
+	testCases := []struct {
+		fn   string
+		tz   string
+		want int64
+	}{
+		{fn: "hour", want: 1},
+		{fn: "hour", tz: "UTC", want: 1},
+		{fn: "hour", tz: "Europe/Berlin", want: 3},
+		{fn: "hour", tz: "America/Los_Angeles", want: 18},
+		{fn: "day", tz: "America/Los_Angeles", want: 26},
+		{fn: "weekday", tz: "Pacific/Auckland", want: int64(time.Sunday)},
+		{fn: "minute", tz: "Asia/Kolkata", want: 0},
+	}
+	for _, tc := range testCases {
+		args := []tick.Node{&tick.ReferenceNode{Reference: "time"}}
+		if tc.tz != "" {
+			args = append(args, &tick.StringNode{Literal: tc.tz})
+		}
+		se := mustCompileExpression(&tick.FunctionNode{
+			Func: tc.fn,
+			Args: args,
+		})
+		scope := tick.NewScope()
+		scope.Set("time", now)
+		result, err := se.Eval(scope)
+		if err != nil {
+			t.Errorf("%s in %q: unexpected error: %v", tc.fn, tc.tz, err)
+			continue
+		}
+		if result != tc.want {
+			t.Errorf("%s in %q: got %v exp %d", tc.fn, tc.tz, result, tc.want)
+		}
+	}
+
+	se := mustCompileExpression(&tick.FunctionNode{
+		Func: "hour",
+		Args: []tick.Node{&tick.ReferenceNode{Reference: "time"}, &tick.StringNode{Literal: "Nowhere/Land"}},
+	})
+	scope := tick.NewScope()
+	scope.Set("time", now)
+	if _, err := se.Eval(scope); err == nil {
+		t.Error("expected error for unknown time zone")
+	}
+}
+
+func TestExpression_Eval_StatefulFunctions(t *testing.T) {
+	start := time.Date(2016, 3, 27, 0, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		fn     string
+		args   []tick.Node
+		values []interface{}
+		want   []float64
+	}{
+		{
+			fn:     "ewma",
+			args:   []tick.Node{&tick.NumberNode{IsFloat: true, Float64: 0.5}},
+			values: []interface{}{10.0, 20.0, 20.0},
+			want:   []float64{10, 15, 17.5},
+		},
+		{
+			fn:     "delta",
+			values: []interface{}{10.0, 25.0, 20.0},
+			want:   []float64{0, 15, -5},
+		},
+		{
+			fn:     "rate",
+			values: []interface{}{10.0, 30.0, 20.0},
+			want:   []float64{0, 2, -1},
+		},
+		{
+			fn:     "spread",
+			values: []interface{}{10.0, 30.0, 20.0, 5.0},
+			want:   []float64{0, 20, 20, 25},
+		},
+		{
+			fn:     "mean",
+			values: []interface{}{10.0, 30.0, 20.0},
+			want:   []float64{10, 20, 20},
+		},
+		{
+			fn:     "elapsedSince",
+			values: []interface{}{"ok", "ok", "crit", "crit"},
+			want:   []float64{0, 10, 0, 10},
+		},
+	}
+	for _, tc := range testCases {
+		se := mustCompileExpression(&tick.FunctionNode{
+			Func: tc.fn,
+			Args: append([]tick.Node{&tick.ReferenceNode{Reference: "value"}}, tc.args...),
+		})
+		scope := tick.NewScope()
+		for i, v := range tc.values {
+			scope.Set("value", v)
+			scope.Set("time", start.Add(time.Duration(i)*10*time.Second))
+			result, err := se.Eval(scope)
+			if err != nil {
+				t.Fatalf("%s: unexpected error: %v", tc.fn, err)
+			}
+			if result != tc.want[i] {
+				t.Errorf("%s: value %d: got %v exp %v", tc.fn, i, result, tc.want[i])
+			}
+		}
+	}
+}
+
+func TestExpression_SnapshotRestore(t *testing.T) {
+	start := time.Date(2016, 3, 27, 0, 0, 0, 0, time.UTC)
+	node := &tick.BinaryNode{
+		Operator: tick.TokenPlus,
+		Left: &tick.FunctionNode{
+			Func: "rate",
+			Args: []tick.Node{&tick.ReferenceNode{Reference: "value"}},
+		},
+		Right: &tick.FunctionNode{
+			Func: "elapsedSince",
+			Args: []tick.Node{&tick.FunctionNode{
+				Func: "int",
+				Args: []tick.Node{&tick.ReferenceNode{Reference: "value"}},
+			}},
+		},
+	}
+	se := mustCompileExpression(node)
+	scope := tick.NewScope()
+	scope.Set("value", 10.0)
+	scope.Set("time", start)
+	if _, err := se.Eval(scope); err != nil {
+		t.Fatal(err)
+	}
+	data, err := se.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	restored := se.CopyReset()
+	if err := restored.Restore(data); err != nil {
+		t.Fatal(err)
+	}
+	scope.Set("value", 10.0)
+	scope.Set("time", start.Add(10*time.Second))
+	result, err := restored.Eval(scope)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The rate is 0 and the value has not changed for 10s.
+	if result != 10.0 {
+		t.Errorf("unexpected result after restore: got %v exp 10", result)
+	}
+
+	// Expressions without stateful functions have no state to snapshot.
+	data, err = mustCompileExpression(&tick.FunctionNode{
+		Func: "abs",
+		Args: []tick.Node{&tick.ReferenceNode{Reference: "value"}},
+	}).Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data != nil {
+		t.Errorf("unexpected snapshot of stateless expression: %s", data)
+	}
+}
+
+// Each call of a function keeps its own state.
+func TestExpression_StatePerCall(t *testing.T) {
+	delta := func(field string) *tick.FunctionNode {
+		return &tick.FunctionNode{
+			Func: "delta",
+			Args: []tick.Node{&tick.ReferenceNode{Reference: field}},
+		}
+	}
+	se := mustCompileExpression(&tick.BinaryNode{
+		Operator: tick.TokenMinus,
+		Left:     delta("a"),
+		Right:    delta("b"),
+	})
+	scope := tick.NewScope()
+	eval := func(se stateful.Expression, a, b float64) interface{} {
+		scope.Set("a", a)
+		scope.Set("b", b)
+		result, err := se.Eval(scope)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+
+	eval(se, 1, 100)
+	// The delta of a is 1 and the delta of b is 10.
+	if got := eval(se, 2, 110); got != -9.0 {
+		t.Errorf("unexpected result: got %v exp -9", got)
+	}
+	data, err := se.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	restored := se.CopyReset()
+	if err := restored.Restore(data); err != nil {
+		t.Fatal(err)
+	}
+	// The delta of a is 2 and the delta of b is 20.
+	if got := eval(restored, 4, 130); got != -18.0 {
+		t.Errorf("unexpected result after restore: got %v exp -18", got)
+	}
+}
+
+func TestFindReferenceVariables_TimeFunctions(t *testing.T) {
+	got := stateful.FindReferenceVariables(&tick.FunctionNode{
+		Func: "rate",
+		Args: []tick.Node{&tick.ReferenceNode{Reference: "value"}},
+	})
+	sort.Strings(got)
+	if exp := []string{"time", "value"}; !reflect.DeepEqual(got, exp) {
+		t.Errorf("unexpected reference variables: got %v exp %v", got, exp)
+	}
+}
+
 func TestExpression_EvalBool_BinaryNodeWithDurationNode(t *testing.T) {
 	se, err := stateful.NewExpression(&tick.BinaryNode{
 		Operator: tick.TokenGreater,
@@ -64,12 +64,24 @@ func createNodeEvaluator(n tick.Node) (NodeEvaluator, error) {
 		return &EvalRegexNode{Node: node}, nil
 
 	case *tick.BinaryNode:
+		switch node.Operator {
+		case tick.TokenIn, tick.TokenNotIn:
+			return NewEvalInNode(node)
+		case tick.TokenDefault:
+			return NewEvalDefaultNode(node)
+		}
 		return NewEvalBinaryNode(node)
 
 	case *tick.ReferenceNode:
 		return &EvalReferenceNode{Node: node}, nil
 
 	case *tick.FunctionNode:
+		switch node.Func {
+		case "if":
+			return NewEvalIfNode(node)
+		case "isPresent":
+			return NewEvalIsPresentNode(node)
+		}
 		return NewEvalFunctionNode(node)
 
 	case *tick.UnaryNode:
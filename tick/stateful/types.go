@@ -104,7 +104,20 @@ func getConstantNodeType(n tick.Node) ValueType {
 			return nodeType
 		}
 
+	case *tick.FunctionNode:
+		switch node.Func {
+		case "if":
+			if len(node.Args) == 3 {
+				return getBranchesType(node.Args[1], node.Args[2])
+			}
+		case "isPresent":
+			return TBool
+		}
+
 	case *tick.BinaryNode:
+		if node.Operator == tick.TokenDefault {
+			return getBranchesType(node.Left, node.Right)
+		}
 		if node.Operator == tick.TokenPlus {
 			leftType := getConstantNodeType(node.Left)
 			rightType := getConstantNodeType(node.Right)
@@ -144,6 +157,21 @@ func getConstantNodeType(n tick.Node) ValueType {
 	return InvalidType
 }
 
+// getBranchesType returns the type of an expression that evaluates only one of two branches.
+// Branches of mismatched types can only be resolved while evaluating,
+// so InvalidType is returned for them, unless both are numeric.
+func getBranchesType(a, b tick.Node) ValueType {
+	aType := getConstantNodeType(a)
+	bType := getConstantNodeType(b)
+	if aType == bType {
+		return aType
+	}
+	if (aType == TInt64 || aType == TFloat64) && (bType == TInt64 || bType == TFloat64) {
+		return TNumeric
+	}
+	return InvalidType
+}
+
 func isDynamicNode(n tick.Node) bool {
 	switch node := n.(type) {
 	case *tick.ReferenceNode:
@@ -107,3 +107,42 @@ func Test_findNodeTypes_TNumericWithEvalError(t *testing.T) {
 		t.Errorf("Got unexpected error:\ngot: %v\nexpected: %v\n", err, expectedError)
 	}
 }
+
+func Test_getConstantNodeType_Branches(t *testing.T) {
+	intNode := &tick.NumberNode{IsInt: true, Int64: 1}
+	floatNode := &tick.NumberNode{IsFloat: true, Float64: 1}
+	stringNode := &tick.StringNode{Literal: "a"}
+	refNode := &tick.ReferenceNode{Reference: "value"}
+	ifNode := func(a, b tick.Node) tick.Node {
+		return &tick.FunctionNode{
+			Func: "if",
+			Args: []tick.Node{&tick.BoolNode{Bool: true}, a, b},
+		}
+	}
+	defaultNode := func(a, b tick.Node) tick.Node {
+		return &tick.BinaryNode{
+			Operator: tick.TokenDefault,
+			Left:     a,
+			Right:    b,
+		}
+	}
+
+	type expectation struct {
+		node      tick.Node
+		valueType ValueType
+	}
+
+	expectations := []expectation{
+		{node: ifNode(stringNode, stringNode), valueType: TString},
+		{node: ifNode(intNode, floatNode), valueType: TNumeric},
+		{node: ifNode(intNode, stringNode), valueType: InvalidType},
+		{node: defaultNode(refNode, stringNode), valueType: InvalidType},
+		{node: &tick.FunctionNode{Func: "isPresent", Args: []tick.Node{refNode}}, valueType: TBool},
+	}
+
+	for i, expect := range expectations {
+		if result := getConstantNodeType(expect.node); result != expect.valueType {
+			t.Errorf("%d: Got unexpected result:\ngot: %s\nexpected: %s\n", i, result, expect.valueType)
+		}
+	}
+}
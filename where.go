@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"sync"
 
 	"github.com/influxdata/kapacitor/models"
 	"github.com/influxdata/kapacitor/pipeline"
@@ -15,6 +16,8 @@ type WhereNode struct {
 	w        *pipeline.WhereNode
 	endpoint string
 
+	// Protects the expressions while they are snapshotted.
+	mu          sync.Mutex
 	expressions map[models.GroupID]stateful.Expression
 	scopePools  map[models.GroupID]stateful.ScopePool
 }
@@ -35,26 +38,24 @@ func newWhereNode(et *ExecutingTask, n *pipeline.WhereNode, l *log.Logger) (wn *
 }
 
 func (w *WhereNode) runWhere(snapshot []byte) error {
+	if snapshot != nil {
+		if err := w.restore(snapshot); err != nil {
+			w.logger.Println("E! failed to restore snapshot:", err)
+		}
+	}
 	switch w.Wants() {
 	case pipeline.StreamEdge:
 		for p, ok := w.ins[0].NextPoint(); ok; p, ok = w.ins[0].NextPoint() {
 			w.timer.Start()
-			expr := w.expressions[p.Group]
-			scopePool := w.scopePools[p.Group]
-
-			if expr == nil {
-				compiledExpr, err := stateful.NewExpression(w.w.Expression)
-				if err != nil {
-					return fmt.Errorf("Failed to compile expression in where clause: %v", err)
-				}
-
-				expr = compiledExpr
-				w.expressions[p.Group] = expr
-
-				scopePool = stateful.NewScopePool(stateful.FindReferenceVariables(w.w.Expression))
-				w.scopePools[p.Group] = scopePool
+			w.mu.Lock()
+			expr, scopePool, err := w.groupExpression(p.Group)
+			if err != nil {
+				w.mu.Unlock()
+				return err
 			}
-			if pass, err := EvalPredicate(expr, scopePool, p.Time, p.Fields, p.Tags); pass {
+			pass, err := EvalPredicate(expr, scopePool, p.Time, w.Location(), p.Fields, p.Tags)
+			w.mu.Unlock()
+			if pass {
 				w.timer.Pause()
 				for _, child := range w.outs {
 					err := child.CollectPoint(p)
@@ -71,24 +72,15 @@ func (w *WhereNode) runWhere(snapshot []byte) error {
 	case pipeline.BatchEdge:
 		for b, ok := w.ins[0].NextBatch(); ok; b, ok = w.ins[0].NextBatch() {
 			w.timer.Start()
-			expr := w.expressions[b.Group]
-			scopePool := w.scopePools[b.Group]
-
-			if expr == nil {
-				compiledExpr, err := stateful.NewExpression(w.w.Expression)
-				if err != nil {
-					return fmt.Errorf("Failed to compile expression in where clause: %v", err)
-				}
-
-				expr = compiledExpr
-				w.expressions[b.Group] = expr
-
-				scopePool = stateful.NewScopePool(stateful.FindReferenceVariables(w.w.Expression))
-				w.scopePools[b.Group] = scopePool
+			w.mu.Lock()
+			expr, scopePool, err := w.groupExpression(b.Group)
+			if err != nil {
+				w.mu.Unlock()
+				return err
 			}
 			for i := 0; i < len(b.Points); {
 				p := b.Points[i]
-				if pass, err := EvalPredicate(expr, scopePool, p.Time, p.Fields, p.Tags); !pass {
+				if pass, err := EvalPredicate(expr, scopePool, p.Time, w.Location(), p.Fields, p.Tags); !pass {
 					if err != nil {
 						w.logger.Println("E! error while evaluating WHERE expression:", err)
 					}
@@ -97,6 +89,7 @@ func (w *WhereNode) runWhere(snapshot []byte) error {
 					i++
 				}
 			}
+			w.mu.Unlock()
 			w.timer.Stop()
 			if len(b.Points) > 0 {
 				for _, child := range w.outs {
@@ -110,3 +103,38 @@ func (w *WhereNode) runWhere(snapshot []byte) error {
 	}
 	return nil
 }
+
+// The expression and scope pool of the group, created for the first point of the group.
+// The caller must hold mu.
+func (w *WhereNode) groupExpression(group models.GroupID) (stateful.Expression, stateful.ScopePool, error) {
+	if expr, ok := w.expressions[group]; ok {
+		return expr, w.scopePools[group], nil
+	}
+	expr, err := stateful.NewExpression(w.w.Expression)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Failed to compile expression in where clause: %v", err)
+	}
+	scopePool := stateful.NewScopePool(stateful.FindReferenceVariables(w.w.Expression))
+	w.expressions[group] = expr
+	w.scopePools[group] = scopePool
+	return expr, scopePool, nil
+}
+
+func (w *WhereNode) snapshot() ([]byte, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	byGroup := make(map[models.GroupID][]stateful.Expression, len(w.expressions))
+	for group, expr := range w.expressions {
+		byGroup[group] = []stateful.Expression{expr}
+	}
+	return snapshotExpressions(byGroup)
+}
+
+func (w *WhereNode) restore(snapshot []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return restoreExpressions(snapshot, func(group models.GroupID) ([]stateful.Expression, error) {
+		expr, _, err := w.groupExpression(group)
+		return []stateful.Expression{expr}, err
+	})
+}
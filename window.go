@@ -3,16 +3,34 @@ package kapacitor
 import (
 	"fmt"
 	"log"
+	"sort"
 	"sync"
 	"time"
 
+	"github.com/influxdata/kapacitor/expvar"
 	"github.com/influxdata/kapacitor/models"
 	"github.com/influxdata/kapacitor/pipeline"
 )
 
+const (
+	statsLatePoints = "late_points"
+)
+
 type WindowNode struct {
 	node
 	w *pipeline.WindowNode
+
+	// Edges to the children receiving the windows and the late points.
+	windowOuts []*Edge
+	lateOuts   []*Edge
+
+	// The InfluxQL child reducing the windows incrementally, if fused.
+	fused *InfluxQLNode
+
+	// The time zone the windows are aligned in, nil if they are aligned in UTC or not aligned.
+	loc *time.Location
+
+	latePoints *expvar.Int
 }
 
 // Create a new  WindowNode, which windows data for a period of time and emits the window.
@@ -22,33 +40,119 @@ func newWindowNode(et *ExecutingTask, n *pipeline.WindowNode, l *log.Logger) (*W
 		node: node{Node: n, et: et, logger: l},
 	}
 	wn.node.runF = wn.runWindow
+	if n.AlignFlag {
+		wn.loc = n.Location()
+	}
 	return wn, nil
 }
 
+// Return the end of the window following the time t,
+// aligned to a multiple of every in the time zone loc if align is set.
+func nextWindowEnd(t time.Time, every time.Duration, align bool, loc *time.Location) time.Time {
+	if !align {
+		return t.Add(every)
+	}
+	return nextIn(t, every, loc)
+}
+
 func (w *WindowNode) runWindow([]byte) error {
+	w.latePoints = &expvar.Int{}
+	w.statMap.Set(statsLatePoints, w.latePoints)
+
+	for i, child := range w.children {
+		if _, ok := child.(*LateNode); ok {
+			w.lateOuts = append(w.lateOuts, w.outs[i])
+		} else {
+			w.windowOuts = append(w.windowOuts, w.outs[i])
+		}
+	}
+
+	switch {
+	case w.w.PeriodCount > 0:
+		return w.runWindowByCount()
+	case w.w.SessionGap > 0:
+		return w.runWindowBySession()
+	case w.w.AllowedLateness > 0:
+		return w.runWindowWithLateness()
+	case w.fused != nil:
+		return w.runWindowFused()
+	default:
+		return w.runWindowByTime()
+	}
+}
+
+// InfluxQL functions that can reduce a window one point at a time.
+var incrementalMethods = map[string]bool{
+	"count":  true,
+	"sum":    true,
+	"mean":   true,
+	"min":    true,
+	"max":    true,
+	"first":  true,
+	"last":   true,
+	"spread": true,
+	"stddev": true,
+}
+
+// Fuse the window with its child if the child is the only child
+// and reduces the window with an incremental InfluxQL function.
+// A fused window keeps only the running state of the function per group
+// instead of all points of the window,
+// and emits the result of the function on behalf of the child.
+func (w *WindowNode) fuse() {
+	if len(w.children) != 1 {
+		return
+	}
+	child, ok := w.children[0].(*InfluxQLNode)
+	if !ok || !incrementalMethods[child.n.Method] {
+		return
+	}
+	tumbling := w.w.Every > 0 && w.w.Period == w.w.Every
+	if !tumbling || w.w.PeriodCount != 0 || w.w.SessionGap != 0 || w.w.AllowedLateness != 0 {
+		return
+	}
+	w.fused = child
+}
+
+// Send a window to all children, except late nodes.
+func (w *WindowNode) emit(b models.Batch) error {
+	w.timer.Pause()
+	defer w.timer.Resume()
+	for _, child := range w.windowOuts {
+		err := child.CollectBatch(b)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Return the tags of the group-by dimensions of the point.
+func dimensionTags(p models.Point) map[string]string {
+	tags := make(map[string]string, len(p.Dimensions))
+	for _, dim := range p.Dimensions {
+		tags[dim] = p.Tags[dim]
+	}
+	return tags
+}
+
+func (w *WindowNode) runWindowByTime() error {
 	windows := make(map[models.GroupID]*window)
 	// Loops through points windowing by group
 	for p, ok := w.ins[0].NextPoint(); ok; p, ok = w.ins[0].NextPoint() {
 		w.timer.Start()
 		wnd := windows[p.Group]
 		if wnd == nil {
-			tags := make(map[string]string, len(p.Dimensions))
-			for _, dim := range p.Dimensions {
-				tags[dim] = p.Tags[dim]
-			}
-			nextEmit := p.Time.Add(w.w.Every)
-			if w.w.AlignFlag {
-				nextEmit = nextEmit.Truncate(w.w.Every)
-			}
 			wnd = &window{
 				buf:      &windowBuffer{logger: w.logger},
 				align:    w.w.AlignFlag,
-				nextEmit: nextEmit,
+				loc:      w.loc,
+				nextEmit: nextWindowEnd(p.Time, w.w.Every, w.w.AlignFlag, w.loc),
 				period:   w.w.Period,
 				every:    w.w.Every,
 				name:     p.Name,
 				group:    p.Group,
-				tags:     tags,
+				tags:     dimensionTags(p),
 				logger:   w.logger,
 			}
 			windows[p.Group] = wnd
@@ -57,7 +161,7 @@ func (w *WindowNode) runWindow([]byte) error {
 			points := wnd.emit(p.Time)
 			// Send window to all children
 			w.timer.Pause()
-			for _, child := range w.outs {
+			for _, child := range w.windowOuts {
 				child.CollectBatch(points)
 			}
 			w.timer.Resume()
@@ -68,9 +172,471 @@ func (w *WindowNode) runWindow([]byte) error {
 	return nil
 }
 
+// Reduce the windows of runWindowByTime incrementally using the fused InfluxQL child.
+// Errors are reported by the child, as if it had reduced the window.
+func (w *WindowNode) runWindowFused() error {
+	n := w.fused
+	windows := make(map[models.GroupID]*reducedWindow)
+	for p, ok := w.ins[0].NextPoint(); ok; p, ok = w.ins[0].NextPoint() {
+		w.timer.Start()
+		wnd := windows[p.Group]
+		if wnd == nil {
+			wnd = &reducedWindow{
+				nextEmit: nextWindowEnd(p.Time, w.w.Every, w.w.AlignFlag, w.loc),
+				name:     p.Name,
+				group:    p.Group,
+				tags:     dimensionTags(p),
+			}
+			windows[p.Group] = wnd
+		}
+		if !p.Time.Before(wnd.nextEmit) {
+			if wnd.context != nil {
+				w.timer.Pause()
+				err := n.emit(wnd.context)
+				w.timer.Resume()
+				if err != nil {
+					n.logger.Println("E! failed to emit batch:", err)
+				}
+			}
+			wnd.context = nil
+			wnd.failed = false
+			wnd.nextEmit = nextWindowEnd(p.Time, w.w.Every, w.w.AlignFlag, w.loc)
+		}
+		// Points older than the window are reduced with it,
+		// as the window buffer only purges points preceding the first point of the window.
+		if wnd.failed {
+			w.timer.Stop()
+			continue
+		}
+		if wnd.context == nil {
+			c := baseReduceContext{
+				as:         n.n.As,
+				field:      n.n.Field,
+				name:       wnd.name,
+				group:      wnd.group,
+				dimensions: models.SortedKeys(wnd.tags),
+				tags:       wnd.tags,
+				time:       wnd.nextEmit,
+				pointTimes: n.n.PointTimes,
+			}
+			createFn, err := n.getCreateFn(p.Fields[c.field])
+			if err != nil {
+				return err
+			}
+			wnd.context = createFn(c)
+		}
+		if err := wnd.context.AggregatePoint(&p); err != nil {
+			// Like reducing a batch, stop reducing the window at the first error.
+			n.logger.Println("E! failed to aggregate batch:", err)
+			wnd.failed = true
+		}
+		w.timer.Stop()
+	}
+	return nil
+}
+
+func (w *WindowNode) runWindowWithLateness() error {
+	windows := make(map[models.GroupID]*lateWindow)
+	for p, ok := w.ins[0].NextPoint(); ok; p, ok = w.ins[0].NextPoint() {
+		w.timer.Start()
+		wnd := windows[p.Group]
+		if wnd == nil {
+			wnd = newLateWindow(p, w.w.Period, w.w.Every, w.w.AllowedLateness, w.w.AlignFlag, w.loc, w.w.ReemitFlag)
+			windows[p.Group] = wnd
+		}
+		batches, late := wnd.insert(p)
+		if late {
+			w.latePoints.Add(1)
+			if err := w.emitLate(p); err != nil {
+				return err
+			}
+		}
+		for _, b := range batches {
+			if err := w.emit(b); err != nil {
+				return err
+			}
+		}
+		w.timer.Stop()
+	}
+	return nil
+}
+
+// Send a late point to the late nodes, as a batch of the single point.
+func (w *WindowNode) emitLate(p models.Point) error {
+	if len(w.lateOuts) == 0 {
+		return nil
+	}
+	b := models.Batch{
+		Name:   p.Name,
+		Group:  p.Group,
+		Tags:   dimensionTags(p),
+		TMax:   p.Time,
+		Points: []models.BatchPoint{models.BatchPointFromPoint(p)},
+	}
+	w.timer.Pause()
+	defer w.timer.Resume()
+	for _, child := range w.lateOuts {
+		err := child.CollectBatch(b)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *WindowNode) runWindowByCount() error {
+	windows := make(map[models.GroupID]*countWindow)
+	for p, ok := w.ins[0].NextPoint(); ok; p, ok = w.ins[0].NextPoint() {
+		w.timer.Start()
+		wnd := windows[p.Group]
+		if wnd == nil {
+			wnd = newCountWindow(p, int(w.w.PeriodCount), int(w.w.EveryCount))
+			windows[p.Group] = wnd
+		}
+		if b, ok := wnd.insert(p); ok {
+			if err := w.emit(b); err != nil {
+				return err
+			}
+		}
+		w.timer.Stop()
+	}
+	return nil
+}
+
+func (w *WindowNode) runWindowBySession() error {
+	sessions := newSessionWindows(w.w.SessionGap)
+	for p, ok := w.ins[0].NextPoint(); ok; p, ok = w.ins[0].NextPoint() {
+		w.timer.Start()
+		for _, b := range sessions.insert(p) {
+			if err := w.emit(b); err != nil {
+				return err
+			}
+		}
+		w.timer.Stop()
+	}
+	return nil
+}
+
+// Window of the last points of a group,
+// emitted once it is full and then every number of points.
+type countWindow struct {
+	name  string
+	group models.GroupID
+	tags  map[string]string
+	every int
+
+	// Ring buffer of the last points.
+	points []models.Point
+	next   int
+	full   bool
+
+	// Number of points to insert until the next emit.
+	untilEmit int
+}
+
+func newCountWindow(p models.Point, period, every int) *countWindow {
+	return &countWindow{
+		name:      p.Name,
+		group:     p.Group,
+		tags:      dimensionTags(p),
+		every:     every,
+		points:    make([]models.Point, period),
+		untilEmit: period,
+	}
+}
+
+// Insert a point returning the window if it should be emitted.
+func (w *countWindow) insert(p models.Point) (models.Batch, bool) {
+	w.points[w.next] = p
+	w.next++
+	if w.next == len(w.points) {
+		w.next = 0
+		w.full = true
+	}
+	w.untilEmit--
+	if w.untilEmit > 0 {
+		return models.Batch{}, false
+	}
+	w.untilEmit = w.every
+
+	batch := models.Batch{
+		Name:   w.name,
+		Group:  w.group,
+		Tags:   w.tags,
+		TMax:   p.Time,
+		Points: make([]models.BatchPoint, 0, len(w.points)),
+	}
+	if w.full {
+		for _, p := range w.points[w.next:] {
+			batch.Points = append(batch.Points, models.BatchPointFromPoint(p))
+		}
+	}
+	for _, p := range w.points[:w.next] {
+		batch.Points = append(batch.Points, models.BatchPointFromPoint(p))
+	}
+	return batch, true
+}
+
+// Session windows of all groups.
+type sessionWindows struct {
+	gap      time.Duration
+	sessions map[models.GroupID]*sessionWindow
+	// The earliest time at which a session expires.
+	nextExpiry time.Time
+}
+
+// The points of a session of a group.
+type sessionWindow struct {
+	name   string
+	group  models.GroupID
+	tags   map[string]string
+	points []models.BatchPoint
+	last   time.Time
+}
+
+func newSessionWindows(gap time.Duration) *sessionWindows {
+	return &sessionWindows{
+		gap:      gap,
+		sessions: make(map[models.GroupID]*sessionWindow),
+	}
+}
+
+// Insert a point returning the windows of the sessions that ended,
+// ordered by the time of their last point.
+func (s *sessionWindows) insert(p models.Point) []models.Batch {
+	var ended []*sessionWindow
+	if !s.nextExpiry.IsZero() && p.Time.After(s.nextExpiry) {
+		s.nextExpiry = time.Time{}
+		for group, session := range s.sessions {
+			expiry := session.last.Add(s.gap)
+			if p.Time.After(expiry) {
+				ended = append(ended, session)
+				delete(s.sessions, group)
+			} else if s.nextExpiry.IsZero() || expiry.Before(s.nextExpiry) {
+				s.nextExpiry = expiry
+			}
+		}
+	}
+
+	session := s.sessions[p.Group]
+	if session == nil {
+		session = &sessionWindow{
+			name:  p.Name,
+			group: p.Group,
+			tags:  dimensionTags(p),
+		}
+		s.sessions[p.Group] = session
+	}
+	session.points = append(session.points, models.BatchPointFromPoint(p))
+	if p.Time.After(session.last) {
+		session.last = p.Time
+	}
+	if expiry := session.last.Add(s.gap); s.nextExpiry.IsZero() || expiry.Before(s.nextExpiry) {
+		s.nextExpiry = expiry
+	}
+
+	if len(ended) == 0 {
+		return nil
+	}
+	sort.Sort(sessionsByLast(ended))
+	batches := make([]models.Batch, len(ended))
+	for i, session := range ended {
+		batches[i] = models.Batch{
+			Name:   session.name,
+			Group:  session.group,
+			Tags:   session.tags,
+			TMax:   session.last,
+			Points: session.points,
+		}
+	}
+	return batches
+}
+
+type sessionsByLast []*sessionWindow
+
+func (s sessionsByLast) Len() int      { return len(s) }
+func (s sessionsByLast) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s sessionsByLast) Less(i, j int) bool {
+	if !s[i].last.Equal(s[j].last) {
+		return s[i].last.Before(s[j].last)
+	}
+	return s[i].group < s[j].group
+}
+
+// Running state of the current window of a group reduced by a fused InfluxQL node.
+type reducedWindow struct {
+	nextEmit time.Time
+	name     string
+	group    models.GroupID
+	tags     map[string]string
+	// The reduce context of the window, nil if no points have been aggregated.
+	context reduceContext
+	// Whether aggregating a point of the window failed.
+	failed bool
+}
+
+// Time window of a group accepting points out of order.
+// Windows are emitted once the watermark, the latest point time of the group,
+// passes the end of the window plus the allowed lateness.
+// In reemit mode windows are emitted at their end instead
+// and emitted again for each point arriving within the allowed lateness.
+type lateWindow struct {
+	name     string
+	group    models.GroupID
+	tags     map[string]string
+	period   time.Duration
+	every    time.Duration
+	lateness time.Duration
+	reemit   bool
+	// The time zone the windows are aligned in, nil if they are not aligned in a time zone.
+	loc *time.Location
+
+	// Points of the open windows sorted by time.
+	points []models.Point
+	// The end of the first window, all window ends are a multiple of every apart.
+	first time.Time
+	// The end of the next window to emit.
+	nextEmit time.Time
+	// The latest point time.
+	watermark time.Time
+}
+
+func newLateWindow(p models.Point, period, every, lateness time.Duration, align bool, loc *time.Location, reemit bool) *lateWindow {
+	first := nextWindowEnd(p.Time, every, align, loc)
+	return &lateWindow{
+		name:      p.Name,
+		group:     p.Group,
+		tags:      dimensionTags(p),
+		period:    period,
+		every:     every,
+		lateness:  lateness,
+		reemit:    reemit,
+		loc:       loc,
+		first:     first,
+		nextEmit:  first,
+		watermark: p.Time,
+	}
+}
+
+// Return the end of the first window ending after t.
+func (w *lateWindow) endAfter(t time.Time) time.Time {
+	if w.loc != nil {
+		return nextWindowEnd(t, w.every, true, w.loc)
+	}
+	d := t.Sub(w.first)
+	n := d / w.every
+	if d < 0 && d%w.every != 0 {
+		n--
+	}
+	return w.first.Add((n + 1) * w.every)
+}
+
+// Return the start of the window ending at end.
+func (w *lateWindow) start(end time.Time) time.Time {
+	return addIn(end, -w.period, w.loc)
+}
+
+// Return the time the watermark needs to reach to emit the window ending at end.
+func (w *lateWindow) ready(end time.Time) time.Time {
+	if w.reemit {
+		return end
+	}
+	return end.Add(w.lateness)
+}
+
+// Return the end of the first window still accepting points,
+// all windows ending before it are closed.
+func (w *lateWindow) openFrom() time.Time {
+	if w.reemit {
+		if end := w.endAfter(w.watermark.Add(-w.lateness)); end.Before(w.nextEmit) {
+			return end
+		}
+	}
+	return w.nextEmit
+}
+
+// Insert a point returning the windows to emit in order,
+// or whether the point is late since all windows containing it are closed.
+func (w *lateWindow) insert(p models.Point) ([]models.Batch, bool) {
+	oldest := w.start(w.openFrom())
+	if p.Time.Before(oldest) {
+		return nil, true
+	}
+	i := sort.Search(len(w.points), func(i int) bool { return w.points[i].Time.After(p.Time) })
+	w.points = append(w.points, models.Point{})
+	copy(w.points[i+1:], w.points[i:])
+	w.points[i] = p
+	if p.Time.After(w.watermark) {
+		w.watermark = p.Time
+	}
+
+	var batches []models.Batch
+	if w.reemit {
+		// Emit the already emitted windows containing the point again.
+		open := w.openFrom()
+		for end := w.endAfter(p.Time); end.Before(w.nextEmit) && !w.start(end).After(p.Time); end = w.endAfter(end) {
+			if !end.Before(open) {
+				batches = append(batches, w.batch(end))
+			}
+		}
+	}
+
+	// Emit the windows passed by the watermark.
+	for !w.watermark.Before(w.ready(w.nextEmit)) {
+		b := w.batch(w.nextEmit)
+		w.nextEmit = w.endAfter(w.nextEmit)
+		if len(b.Points) > 0 {
+			batches = append(batches, b)
+			continue
+		}
+		// Skip the empty windows up to the first window
+		// containing a point or not yet ready.
+		next := w.endAfter(w.watermark)
+		if !w.reemit {
+			next = w.endAfter(w.watermark.Add(-w.lateness))
+		}
+		j := sort.Search(len(w.points), func(j int) bool { return !w.points[j].Time.Before(w.start(w.nextEmit)) })
+		if j < len(w.points) {
+			if end := w.endAfter(w.points[j].Time); end.Before(next) {
+				next = end
+			}
+		}
+		if next.After(w.nextEmit) {
+			w.nextEmit = next
+		}
+	}
+
+	// Purge the points of closed windows.
+	oldest = w.start(w.openFrom())
+	j := sort.Search(len(w.points), func(j int) bool { return !w.points[j].Time.Before(oldest) })
+	if j > 0 {
+		w.points = append(w.points[:0], w.points[j:]...)
+	}
+	return batches, false
+}
+
+// Return the window ending at end.
+func (w *lateWindow) batch(end time.Time) models.Batch {
+	start := w.start(end)
+	lo := sort.Search(len(w.points), func(i int) bool { return !w.points[i].Time.Before(start) })
+	hi := sort.Search(len(w.points), func(i int) bool { return !w.points[i].Time.Before(end) })
+	batch := models.Batch{
+		Name:   w.name,
+		Group:  w.group,
+		Tags:   w.tags,
+		TMax:   end,
+		Points: make([]models.BatchPoint, 0, hi-lo),
+	}
+	for _, p := range w.points[lo:hi] {
+		batch.Points = append(batch.Points, models.BatchPointFromPoint(p))
+	}
+	return batch
+}
+
 type window struct {
 	buf      *windowBuffer
 	align    bool
+	loc      *time.Location
 	nextEmit time.Time
 	period   time.Duration
 	every    time.Duration
@@ -81,7 +647,7 @@ type window struct {
 }
 
 func (w *window) emit(now time.Time) models.Batch {
-	oldest := w.nextEmit.Add(-1 * w.period)
+	oldest := addIn(w.nextEmit, -1*w.period, w.loc)
 	w.buf.purge(oldest)
 
 	batch := w.buf.batch()
@@ -92,10 +658,7 @@ func (w *window) emit(now time.Time) models.Batch {
 
 	// Determine next emit time.
 	// This is dependent on the current time not the last time we emitted.
-	w.nextEmit = now.Add(w.every)
-	if w.align {
-		w.nextEmit = w.nextEmit.Truncate(w.every)
-	}
+	w.nextEmit = nextWindowEnd(now, w.every, w.align, w.loc)
 	return batch
 }
 
@@ -215,3 +778,43 @@ func (b *windowBuffer) batch() models.Batch {
 	}
 	return batch
 }
+
+type LateNode struct {
+	node
+}
+
+// Create a new LateNode, which converts the late points of a window back into a stream.
+func newLateNode(et *ExecutingTask, n *pipeline.LateNode, l *log.Logger) (*LateNode, error) {
+	ln := &LateNode{
+		node: node{Node: n, et: et, logger: l},
+	}
+	ln.node.runF = ln.runLate
+	return ln, nil
+}
+
+func (l *LateNode) runLate([]byte) error {
+	for b, ok := l.ins[0].NextBatch(); ok; b, ok = l.ins[0].NextBatch() {
+		l.timer.Start()
+		dims := b.PointDimensions()
+		for _, bp := range b.Points {
+			p := models.Point{
+				Name:       b.Name,
+				Group:      b.Group,
+				Dimensions: dims,
+				Tags:       bp.Tags,
+				Fields:     bp.Fields,
+				Time:       bp.Time,
+			}
+			l.timer.Pause()
+			for _, child := range l.outs {
+				err := child.CollectPoint(p)
+				if err != nil {
+					return err
+				}
+			}
+			l.timer.Resume()
+		}
+		l.timer.Stop()
+	}
+	return nil
+}
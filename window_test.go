@@ -3,10 +3,12 @@ package kapacitor
 import (
 	"log"
 	"os"
+	"reflect"
 	"testing"
 	"time"
 
 	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -75,3 +77,414 @@ func TestWindowBuffer(t *testing.T) {
 		}
 	}
 }
+
+func TestCountWindow(t *testing.T) {
+	assert := assert.New(t)
+
+	point := func(i int) models.Point {
+		return models.Point{
+			Name:       "cpu",
+			Group:      "host=serverA,",
+			Dimensions: models.Dimensions{"host"},
+			Tags:       models.Tags{"host": "serverA", "type": "idle"},
+			Time:       time.Unix(int64(i), 0),
+		}
+	}
+
+	// Window of the last 4 points emitted every 2 points
+	wnd := newCountWindow(point(0), 4, 2)
+	var emitted [][]int64
+	for i := 1; i <= 9; i++ {
+		b, ok := wnd.insert(point(i))
+		if !ok {
+			continue
+		}
+		assert.Equal("cpu", b.Name)
+		assert.Equal(models.GroupID("host=serverA,"), b.Group)
+		assert.Equal(models.Tags{"host": "serverA"}, b.Tags)
+		assert.Equal(time.Unix(int64(i), 0), b.TMax)
+		times := make([]int64, len(b.Points))
+		for j, p := range b.Points {
+			times[j] = p.Time.Unix()
+		}
+		emitted = append(emitted, times)
+	}
+	assert.Equal([][]int64{
+		{1, 2, 3, 4},
+		{3, 4, 5, 6},
+		{5, 6, 7, 8},
+	}, emitted)
+}
+
+func TestSessionWindows(t *testing.T) {
+	assert := assert.New(t)
+
+	point := func(host string, s int) models.Point {
+		return models.Point{
+			Name:       "requests",
+			Group:      models.GroupID("host=" + host + ","),
+			Dimensions: models.Dimensions{"host"},
+			Tags:       models.Tags{"host": host},
+			Time:       time.Unix(int64(s), 0),
+		}
+	}
+
+	sessions := newSessionWindows(10 * time.Second)
+	assert.Empty(sessions.insert(point("A", 0)))
+	assert.Empty(sessions.insert(point("B", 5)))
+	assert.Empty(sessions.insert(point("A", 10)))
+	// Gap of exactly 10s does not end the session of B.
+	assert.Empty(sessions.insert(point("A", 15)))
+	// Session of B ended at 5s.
+	batches := sessions.insert(point("A", 16))
+	if assert.Len(batches, 1) {
+		b := batches[0]
+		assert.Equal(models.GroupID("host=B,"), b.Group)
+		assert.Equal(models.Tags{"host": "B"}, b.Tags)
+		assert.Equal(time.Unix(5, 0), b.TMax)
+		assert.Len(b.Points, 1)
+	}
+	// New session of B, both sessions end with the next point.
+	assert.Empty(sessions.insert(point("B", 20)))
+	batches = sessions.insert(point("C", 40))
+	if assert.Len(batches, 2) {
+		assert.Equal(models.GroupID("host=A,"), batches[0].Group)
+		assert.Equal(time.Unix(16, 0), batches[0].TMax)
+		assert.Len(batches[0].Points, 4)
+		assert.Equal(models.GroupID("host=B,"), batches[1].Group)
+		assert.Equal(time.Unix(20, 0), batches[1].TMax)
+		assert.Len(batches[1].Points, 1)
+	}
+}
+
+func TestLateWindow(t *testing.T) {
+	assert := assert.New(t)
+
+	point := func(s int) models.Point {
+		return models.Point{
+			Name:   "cpu",
+			Group:  models.NilGroup,
+			Fields: models.Fields{"value": int64(s)},
+			Time:   time.Unix(int64(s), 0),
+		}
+	}
+	values := func(b models.Batch) []int64 {
+		vs := make([]int64, len(b.Points))
+		for i, p := range b.Points {
+			vs[i] = p.Fields["value"].(int64)
+		}
+		return vs
+	}
+
+	// Windows [0s,10s), [10s,20s), ... held open for 5s.
+	wnd := newLateWindow(point(0), 10*time.Second, 10*time.Second, 5*time.Second, true, nil, false)
+	for _, s := range []int{0, 3, 11, 8, 14} {
+		batches, late := wnd.insert(point(s))
+		assert.Empty(batches)
+		assert.False(late)
+	}
+	// Watermark passes 15s, the first window is emitted with the late point.
+	batches, late := wnd.insert(point(15))
+	assert.False(late)
+	if assert.Len(batches, 1) {
+		assert.Equal(time.Unix(10, 0), batches[0].TMax)
+		assert.Equal([]int64{0, 3, 8}, values(batches[0]))
+	}
+	// Too late for the first window.
+	batches, late = wnd.insert(point(9))
+	assert.Empty(batches)
+	assert.True(late)
+	// Skip the empty windows.
+	batches, late = wnd.insert(point(60))
+	assert.False(late)
+	if assert.Len(batches, 1) {
+		assert.Equal(time.Unix(20, 0), batches[0].TMax)
+		assert.Equal([]int64{11, 14, 15}, values(batches[0]))
+	}
+	batches, late = wnd.insert(point(75))
+	assert.False(late)
+	if assert.Len(batches, 1) {
+		assert.Equal(time.Unix(70, 0), batches[0].TMax)
+		assert.Equal([]int64{60}, values(batches[0]))
+	}
+}
+
+func TestLateWindow_Reemit(t *testing.T) {
+	assert := assert.New(t)
+
+	point := func(s int) models.Point {
+		return models.Point{
+			Name:  "cpu",
+			Group: models.NilGroup,
+			Time:  time.Unix(int64(s), 0),
+		}
+	}
+
+	wnd := newLateWindow(point(0), 10*time.Second, 10*time.Second, 5*time.Second, true, nil, true)
+	batches, _ := wnd.insert(point(0))
+	assert.Empty(batches)
+	batches, _ = wnd.insert(point(10))
+	if assert.Len(batches, 1) {
+		assert.Equal(time.Unix(10, 0), batches[0].TMax)
+		assert.Len(batches[0].Points, 1)
+	}
+	// Late point corrects the emitted window.
+	batches, late := wnd.insert(point(5))
+	assert.False(late)
+	if assert.Len(batches, 1) {
+		assert.Equal(time.Unix(10, 0), batches[0].TMax)
+		assert.Len(batches[0].Points, 2)
+	}
+	// The first window is closed once the watermark passes 15s.
+	batches, late = wnd.insert(point(15))
+	assert.Empty(batches)
+	assert.False(late)
+	batches, late = wnd.insert(point(6))
+	assert.Empty(batches)
+	assert.True(late)
+	batches, late = wnd.insert(point(12))
+	assert.Empty(batches)
+	assert.False(late)
+	batches, _ = wnd.insert(point(20))
+	if assert.Len(batches, 1) {
+		assert.Equal(time.Unix(20, 0), batches[0].TMax)
+		assert.Len(batches[0].Points, 3)
+	}
+}
+
+func TestNextIn(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// In 2016 daylight saving time in Berlin starts on March 27 at 01:00 UTC
+	// and ends on October 30 at 01:00 UTC.
+	utc := func(month time.Month, day, hour, min int) time.Time {
+		return time.Date(2016, month, day, hour, min, 0, 0, time.UTC)
+	}
+	testCases := []struct {
+		t    time.Time
+		d    time.Duration
+		loc  *time.Location
+		want time.Time
+	}{
+		{t: utc(3, 27, 8, 0), d: 24 * time.Hour, want: utc(3, 28, 0, 0)},
+		// Midnight in Berlin
+		{t: utc(3, 26, 11, 0), d: 24 * time.Hour, loc: berlin, want: utc(3, 26, 23, 0)},
+		{t: utc(3, 26, 22, 30), d: 24 * time.Hour, loc: berlin, want: utc(3, 26, 23, 0)},
+		{t: utc(3, 26, 23, 0), d: 24 * time.Hour, loc: berlin, want: utc(3, 27, 22, 0)},
+		{t: utc(10, 29, 22, 0), d: 24 * time.Hour, loc: berlin, want: utc(10, 30, 23, 0)},
+		// Hours are not skipped or repeated
+		{t: utc(3, 27, 0, 30), d: time.Hour, loc: berlin, want: utc(3, 27, 1, 0)},
+		{t: utc(3, 27, 1, 0), d: time.Hour, loc: berlin, want: utc(3, 27, 2, 0)},
+		{t: utc(10, 30, 0, 30), d: time.Hour, loc: berlin, want: utc(10, 30, 1, 0)},
+		{t: utc(10, 30, 1, 30), d: time.Hour, loc: berlin, want: utc(10, 30, 2, 0)},
+		// The wall clock time 02:00 does not exist when daylight saving time starts
+		{t: utc(3, 26, 23, 30), d: 2 * time.Hour, loc: berlin, want: utc(3, 27, 1, 0)},
+		{t: utc(3, 27, 1, 0), d: 2 * time.Hour, loc: berlin, want: utc(3, 27, 2, 0)},
+		// The six hours from midnight to 06:00 are seven hours long when daylight saving time ends
+		{t: utc(10, 29, 22, 30), d: 6 * time.Hour, loc: berlin, want: utc(10, 30, 5, 0)},
+	}
+	for _, tc := range testCases {
+		if got := nextIn(tc.t, tc.d, tc.loc); !got.Equal(tc.want) {
+			t.Errorf("next %v after %v in %v: got %v exp %v", tc.d, tc.t, tc.loc, got.UTC(), tc.want)
+		}
+	}
+
+	// Whole days are added to the wall clock time.
+	if got, exp := addIn(utc(10, 30, 23, 0), -24*time.Hour, berlin), utc(10, 29, 22, 0); !got.Equal(exp) {
+		t.Errorf("unexpected start of day got %v exp %v", got.UTC(), exp)
+	}
+	if got, exp := addIn(utc(10, 30, 2, 0), -time.Hour, berlin), utc(10, 30, 1, 0); !got.Equal(exp) {
+		t.Errorf("unexpected start of hour got %v exp %v", got.UTC(), exp)
+	}
+}
+
+func TestLateWindow_Timezone(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	point := func(t time.Time) models.Point {
+		return models.Point{Name: "cpu", Time: t, Fields: models.Fields{"value": 1.0}}
+	}
+	// Daily windows in Berlin across the end of daylight saving time,
+	// the day of October 30 is 25 hours long.
+	start := time.Date(2016, 10, 29, 12, 0, 0, 0, time.UTC)
+	wnd := newLateWindow(point(start), 24*time.Hour, 24*time.Hour, time.Hour, true, berlin, false)
+	var batches []models.Batch
+	for i := 1; i <= 48; i++ {
+		b, late := wnd.insert(point(start.Add(time.Duration(i) * time.Hour)))
+		if late {
+			t.Fatalf("unexpected late point %d", i)
+		}
+		batches = append(batches, b...)
+	}
+	if len(batches) != 2 {
+		t.Fatalf("unexpected number of windows got %d exp 2", len(batches))
+	}
+	if exp := time.Date(2016, 10, 29, 22, 0, 0, 0, time.UTC); !batches[0].TMax.Equal(exp) {
+		t.Errorf("unexpected end of first window got %v exp %v", batches[0].TMax.UTC(), exp)
+	}
+	if exp := time.Date(2016, 10, 30, 23, 0, 0, 0, time.UTC); !batches[1].TMax.Equal(exp) {
+		t.Errorf("unexpected end of second window got %v exp %v", batches[1].TMax.UTC(), exp)
+	}
+	if got, exp := len(batches[1].Points), 25; got != exp {
+		t.Errorf("unexpected number of points in the 25 hour day got %d exp %d", got, exp)
+	}
+}
+
+func TestWindowNode_Fuse(t *testing.T) {
+	newWindow := func(period, every time.Duration) *pipeline.WindowNode {
+		return &pipeline.WindowNode{Period: period, Every: every}
+	}
+	newInfluxQL := func(method string) Node {
+		return &InfluxQLNode{n: &pipeline.InfluxQLNode{Method: method}}
+	}
+	testCases := []struct {
+		window   *pipeline.WindowNode
+		children []Node
+		fused    bool
+	}{
+		{
+			window:   newWindow(time.Minute, time.Minute),
+			children: []Node{newInfluxQL("mean")},
+			fused:    true,
+		},
+		{
+			window:   newWindow(time.Minute, time.Minute),
+			children: []Node{newInfluxQL("stddev")},
+			fused:    true,
+		},
+		// Not incremental
+		{
+			window:   newWindow(time.Minute, time.Minute),
+			children: []Node{newInfluxQL("median")},
+		},
+		// Sliding window
+		{
+			window:   newWindow(time.Minute, 30*time.Second),
+			children: []Node{newInfluxQL("sum")},
+		},
+		// Window used by other children
+		{
+			window: newWindow(time.Minute, time.Minute),
+			children: []Node{
+				newInfluxQL("sum"),
+				newInfluxQL("count"),
+			},
+		},
+	}
+	for i, tc := range testCases {
+		w := &WindowNode{w: tc.window}
+		w.children = tc.children
+		w.fuse()
+		if got := w.fused != nil; got != tc.fused {
+			t.Errorf("%d: unexpected fused got %t exp %t", i, got, tc.fused)
+		}
+	}
+}
+
+func TestWindowNode_FusedEquivalence(t *testing.T) {
+	tm := NewTaskMaster(testLogService{})
+	tm.DeadmanService = testDeadman{}
+	if err := tm.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer tm.Close()
+	dbrps := []DBRP{{Database: "db", RetentionPolicy: "rp"}}
+
+	// Run the points through the task and return the points emitted by the InfluxQL node.
+	run := func(script string, fused bool, points []models.Point) []models.Point {
+		task, err := tm.NewTask("fuse", script, StreamTask, dbrps, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		et, err := NewExecutingTask(tm, task)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var n *InfluxQLNode
+		for _, node := range et.nodes {
+			if q, ok := node.(*InfluxQLNode); ok {
+				n = q
+			}
+			if w, ok := node.(*WindowNode); ok && (w.fused != nil) != fused {
+				t.Fatalf("unexpected fused got %t exp %t", w.fused != nil, fused)
+			}
+		}
+		out := newEdge(task.ID, n.Name(), "test", pipeline.StreamEdge, defaultEdgeBufferSize, tm.LogService)
+		n.outs = append(n.outs, out)
+		in := newEdge(task.ID, "test", "stream", pipeline.StreamEdge, defaultEdgeBufferSize, tm.LogService)
+		if err := et.start([]*Edge{in}, nil); err != nil {
+			t.Fatal(err)
+		}
+		emitted := make(chan []models.Point, 1)
+		go func() {
+			var points []models.Point
+			for p, ok := out.NextPoint(); ok; p, ok = out.NextPoint() {
+				points = append(points, p)
+			}
+			emitted <- points
+		}()
+		for _, p := range points {
+			if err := in.CollectPoint(p); err != nil {
+				t.Fatal(err)
+			}
+		}
+		in.Close()
+		if err := et.Wait(); err != nil {
+			t.Fatal(err)
+		}
+		et.stop()
+		return <-emitted
+	}
+
+	start := time.Date(2016, 3, 27, 0, 0, 3, 0, time.UTC)
+	point := func(host string, offset time.Duration, value float64) models.Point {
+		return models.Point{
+			Database:        "db",
+			RetentionPolicy: "rp",
+			Name:            "cpu",
+			Tags:            models.Tags{"host": host},
+			Time:            start.Add(offset),
+			Fields:          models.Fields{"value": value},
+		}
+	}
+	var points []models.Point
+	for i := 0; i < 40; i++ {
+		// Leave a gap of several windows.
+		if i >= 15 && i < 32 {
+			continue
+		}
+		offset := time.Duration(i) * 2 * time.Second
+		points = append(points,
+			point("serverA", offset, float64(i*i%17)+0.5),
+			point("serverB", offset+time.Second, float64(40-i)/3),
+		)
+		// Points older than the current window.
+		if i == 10 || i == 35 {
+			points = append(points, point("serverA", offset-30*time.Second, 100))
+		}
+	}
+
+	methods := []string{"count", "sum", "mean", "min", "max", "first", "last", "spread", "stddev"}
+	windows := []string{
+		"period(10s).every(10s)",
+		"period(10s).every(10s).align()",
+	}
+	for _, method := range methods {
+		for _, window := range windows {
+			source := `var w = stream|from().measurement('cpu').groupBy('host')|window().` + window + "\n"
+			fused := run(source+`w|`+method+`('value')`, true, points)
+			// A second child of the window keeps it from being fused.
+			unfused := run(source+`w|`+method+`('value')`+"\n"+`w|log()`, false, points)
+			if len(fused) == 0 {
+				t.Fatalf("%s %s: no points emitted", method, window)
+			}
+			if !reflect.DeepEqual(fused, unfused) {
+				t.Errorf("%s %s: unexpected fused points:\ngot %v\nexp %v", method, window, fused, unfused)
+			}
+		}
+	}
+}